@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"network-monitor/internal/config"
+	"network-monitor/internal/database"
+	"network-monitor/internal/models"
+)
+
+// runBackfill rebuilds the heatmap's hourly patterns from every stored ping
+// result, e.g. after importing historical data into an existing database
+func runBackfill(args []string) error {
+	cfg, err := config.ParseBackfillFlags(args)
+	if err != nil {
+		return fmt.Errorf("failed to parse backfill flags: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid backfill configuration: %w", err)
+	}
+
+	db, err := openBackfillDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	start := time.Now()
+	if err := db.BackfillHourlyPatterns(context.Background()); err != nil {
+		return fmt.Errorf("failed to backfill hourly patterns: %w", err)
+	}
+	log.Printf("Backfilled hourly patterns in %v", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// openBackfillDatabase opens and migrates the configured database, with hourly
+// patterns bucketed in the configured zone
+func openBackfillDatabase(cfg config.BackfillConfig) (models.Database, error) {
+	if cfg.DBDriver == database.DriverPostgres {
+		db, err := database.OpenPostgres(cfg.DBDSN, 0, time.Second)
+		if err != nil {
+			return nil, err
+		}
+		db.SetLocation(cfg.Location())
+		return db, nil
+	}
+
+	db, err := database.Open(cfg.DatabasePath, cfg.JournalMode, 0, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	db.SetLocation(cfg.Location())
+	return db, nil
+}
@@ -10,7 +10,7 @@ go mod download
 
 # Build the binary
 echo "Building binary..."
-go build -o network-monitor *.go
+go build -ldflags "-X main.Commit=$(git rev-parse --short HEAD 2>/dev/null || echo unknown)" -o network-monitor *.go
 
 if [ $? -eq 0 ]; then
     echo "✅ Build successful!"
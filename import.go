@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"network-monitor/internal/config"
+	"network-monitor/internal/models"
+)
+
+// importColumns are the CSV columns import reads, in the order assumed when
+// the file has no header row. rtt_ms may be left out or empty for failures.
+var importColumns = []string{"timestamp", "target", "success", "rtt_ms"}
+
+// importTimestampLayouts are the timestamp forms import accepts besides Unix
+// times. Those without an offset are read in the configured zone.
+var importTimestampLayouts = []struct {
+	layout    string
+	hasOffset bool
+}{
+	{time.RFC3339Nano, true},
+	{"2006-01-02 15:04:05Z07:00", true},
+	{"2006-01-02 15:04:05 -0700", true},
+	{"2006-01-02T15:04:05", false},
+	{"2006-01-02 15:04:05", false},
+	{"2006-01-02 15:04", false},
+	{"2006/01/02 15:04:05", false},
+}
+
+// maxSkipReports caps how many skipped rows import logs individually
+const maxSkipReports = 10
+
+// runImport bulk-loads historical ping results from a CSV file, e.g. from a
+// script that pinged before the monitor did, and rebuilds the hourly patterns
+// so they show on the heatmap
+func runImport(args []string) error {
+	cfg, err := config.ParseImportFlags(args)
+	if err != nil {
+		return fmt.Errorf("failed to parse import flags: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid import configuration: %w", err)
+	}
+
+	file, err := os.Open(cfg.File)
+	if err != nil {
+		return fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer file.Close()
+
+	results, skipped, err := readImportCSV(file, cfg.Location())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", cfg.File, err)
+	}
+
+	db, err := openBackfillDatabase(cfg.Backfill())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveResults(context.Background(), results); err != nil {
+		return fmt.Errorf("failed to import results: %w", err)
+	}
+	log.Printf("Imported %d results from %s, skipped %d rows", len(results), cfg.File, skipped)
+	if len(results) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	if err := db.BackfillHourlyPatterns(context.Background()); err != nil {
+		return fmt.Errorf("failed to backfill hourly patterns: %w", err)
+	}
+	log.Printf("Backfilled hourly patterns in %v", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// readImportCSV reads ping results from CSV rows of timestamp, target,
+// success and rtt_ms. A header row naming the columns may put them in any
+// order. Rows that don't parse are logged and counted as skipped.
+func readImportCSV(r io.Reader, loc *time.Location) (results []models.PingResult, skipped int, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+	reader.Comment = '#'
+
+	columns := make(map[string]int, len(importColumns))
+	for i, name := range importColumns {
+		columns[name] = i
+	}
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return results, skipped, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first && isImportHeader(record) {
+			if columns, err = importHeaderColumns(record); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		result, err := parseImportRecord(record, columns, loc)
+		if err != nil {
+			if skipped < maxSkipReports {
+				log.Printf("Skipping line %d: %v", line, err)
+			}
+			skipped++
+			continue
+		}
+		results = append(results, result)
+	}
+}
+
+// isImportHeader reports whether record is a header row rather than a result
+func isImportHeader(record []string) bool {
+	for _, field := range record {
+		if strings.EqualFold(strings.TrimSpace(field), "timestamp") {
+			return true
+		}
+	}
+	return false
+}
+
+// importHeaderColumns maps each column import reads to its index in header.
+// rtt_ms is optional; the other columns are required.
+func importHeaderColumns(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(importColumns))
+	for i, field := range header {
+		columns[strings.ToLower(strings.TrimSpace(field))] = i
+	}
+	for _, name := range importColumns[:3] {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("header has no %s column", name)
+		}
+	}
+	return columns, nil
+}
+
+// parseImportRecord parses one CSV row into a ping result
+func parseImportRecord(record []string, columns map[string]int, loc *time.Location) (models.PingResult, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	timestamp, err := parseImportTimestamp(field("timestamp"), loc)
+	if err != nil {
+		return models.PingResult{}, err
+	}
+
+	target := field("target")
+	if _, err := models.ParseTarget(target); err != nil {
+		return models.PingResult{}, err
+	}
+
+	success, err := parseImportSuccess(field("success"))
+	if err != nil {
+		return models.PingResult{}, err
+	}
+
+	var rtt float64
+	if value := field("rtt_ms"); value != "" {
+		rtt, err = strconv.ParseFloat(value, 64)
+		if err != nil || rtt < 0 {
+			return models.PingResult{}, fmt.Errorf("invalid rtt_ms %q", value)
+		}
+	}
+	if !success {
+		rtt = 0
+	}
+
+	return models.PingResult{Timestamp: timestamp, Target: target, Success: success, RTT: rtt, Attempts: 1}, nil
+}
+
+// parseImportTimestamp parses a timestamp in one of importTimestampLayouts or
+// as Unix seconds, or milliseconds when the number is too large for seconds
+func parseImportTimestamp(value string, loc *time.Location) (time.Time, error) {
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if unix > 1e11 {
+			return time.UnixMilli(unix), nil
+		}
+		return time.Unix(unix, 0), nil
+	}
+	for _, l := range importTimestampLayouts {
+		var t time.Time
+		var err error
+		if l.hasOffset {
+			t, err = time.Parse(l.layout, value)
+		} else {
+			t, err = time.ParseInLocation(l.layout, value, loc)
+		}
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
+
+// parseImportSuccess parses a success column: true/false, 1/0, yes/no or up/down
+func parseImportSuccess(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "up", "ok":
+		return true, nil
+	case "no", "down", "fail", "failed":
+		return false, nil
+	}
+	success, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid success %q", value)
+	}
+	return success, nil
+}
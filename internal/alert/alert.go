@@ -0,0 +1,148 @@
+package alert
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// flapTransitions is how many up/down transitions within the cooldown mark a
+// target as flapping
+const flapTransitions = 4
+
+// Alerter turns target state changes into notifications. Repeated down alerts
+// for a target are suppressed within the cooldown, and rapid transitions are
+// collapsed into a single flapping alert so an unstable network can't flood
+// the notifier.
+type Alerter struct {
+	cooldown time.Duration
+	notifier models.Notifier
+
+	mu            sync.Mutex
+	targets       map[string]*targetState
+	quality       QualityThresholds
+	qualityStates map[string]*qualityState
+}
+
+// targetState is the alerting history of a single target
+type targetState struct {
+	lastDown    time.Time   // when the last down alert was sent
+	downAlerted bool        // a down alert was sent and its recovery hasn't been
+	transitions []time.Time // state changes within the cooldown window
+	flapping    bool
+}
+
+// New creates an Alerter; a zero cooldown disables suppression
+func New(cooldown time.Duration, notifier models.Notifier) *Alerter {
+	return &Alerter{
+		cooldown:      cooldown,
+		notifier:      notifier,
+		targets:       make(map[string]*targetState),
+		qualityStates: make(map[string]*qualityState),
+	}
+}
+
+// Down reports that target entered an outage at the given time
+func (a *Alerter) Down(target string, at time.Time) {
+	a.transition(target, at, true)
+}
+
+// Up reports that target recovered at the given time
+func (a *Alerter) Up(target string, at time.Time) {
+	a.transition(target, at, false)
+}
+
+func (a *Alerter) transition(target string, at time.Time, down bool) {
+	alert, ok := a.evaluate(target, at, down)
+	if !ok {
+		return
+	}
+	// Deliver outside the lock so a slow notifier doesn't hold up other targets
+	if err := a.notifier.Notify(alert); err != nil {
+		log.Printf("Failed to send %s alert for %s: %v", alert.Kind, target, err)
+	}
+}
+
+// evaluate records the transition and returns the alert to send, if any
+func (a *Alerter) evaluate(target string, at time.Time, down bool) (models.Alert, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	state, ok := a.targets[target]
+	if !ok {
+		state = &targetState{}
+		a.targets[target] = state
+	}
+
+	recent := state.transitions[:0]
+	for _, t := range state.transitions {
+		if at.Sub(t) < a.cooldown {
+			recent = append(recent, t)
+		}
+	}
+	state.transitions = append(recent, at)
+
+	if len(state.transitions) >= flapTransitions {
+		if state.flapping {
+			return models.Alert{}, false
+		}
+		state.flapping = true
+		return models.Alert{
+			Target:    target,
+			Kind:      models.AlertFlapping,
+			Timestamp: at,
+			Message:   fmt.Sprintf("%s is flapping: %d state changes within %s", target, len(state.transitions), a.cooldown),
+		}, true
+	}
+	state.flapping = false
+
+	if down {
+		if state.downAlerted || (!state.lastDown.IsZero() && at.Sub(state.lastDown) < a.cooldown) {
+			return models.Alert{}, false
+		}
+		state.lastDown = at
+		state.downAlerted = true
+		return models.Alert{
+			Target:    target,
+			Kind:      models.AlertDown,
+			Timestamp: at,
+			Message:   fmt.Sprintf("%s is down", target),
+		}, true
+	}
+
+	// Only announce recoveries for outages that were announced
+	if !state.downAlerted {
+		return models.Alert{}, false
+	}
+	state.downAlerted = false
+	return models.Alert{
+		Target:    target,
+		Kind:      models.AlertUp,
+		Timestamp: at,
+		Message:   fmt.Sprintf("%s is back up after %s", target, at.Sub(state.lastDown).Round(time.Second)),
+	}, true
+}
+
+// Test sends a synthetic alert, marked as a test, straight to the notifier
+// and returns the delivery error so a misconfigured notifier shows up right
+// away. It bypasses suppression and doesn't affect any target's state.
+func (a *Alerter) Test(at time.Time) error {
+	return a.notifier.Notify(models.Alert{
+		Kind:      models.AlertTest,
+		Timestamp: at,
+		Message:   "TEST alert from network-monitor: alert delivery works, no action needed",
+		Test:      true,
+	})
+}
+
+// LogNotifier delivers alerts to the standard logger
+type LogNotifier struct{}
+
+// Notify logs the alert
+func (LogNotifier) Notify(alert models.Alert) error {
+	log.Printf("ALERT [%s] %s", alert.Kind, alert.Message)
+	return nil
+}
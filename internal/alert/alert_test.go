@@ -0,0 +1,135 @@
+package alert
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// recordingNotifier captures delivered alerts
+type recordingNotifier struct {
+	alerts []models.Alert
+}
+
+func (n *recordingNotifier) Notify(alert models.Alert) error {
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func (n *recordingNotifier) kinds() []string {
+	kinds := []string{}
+	for _, a := range n.alerts {
+		kinds = append(kinds, a.Kind)
+	}
+	return kinds
+}
+
+type transition struct {
+	at   time.Duration
+	down bool
+}
+
+// flap alternates down/up transitions every step for the given duration
+func flap(start, duration, step time.Duration) []transition {
+	var transitions []transition
+	down := true
+	for at := start; at < start+duration; at += step {
+		transitions = append(transitions, transition{at: at, down: down})
+		down = !down
+	}
+	return transitions
+}
+
+func TestAlerterSuppression(t *testing.T) {
+	tests := []struct {
+		name        string
+		cooldown    time.Duration
+		transitions []transition
+		want        []string
+	}{
+		{
+			name:        "single outage",
+			cooldown:    5 * time.Minute,
+			transitions: []transition{{0, true}, {2 * time.Minute, false}},
+			want:        []string{models.AlertDown, models.AlertUp},
+		},
+		{
+			name:     "second outage within cooldown is suppressed",
+			cooldown: 5 * time.Minute,
+			transitions: []transition{
+				{0, true}, {time.Minute, false},
+				{2 * time.Minute, true},
+			},
+			want: []string{models.AlertDown, models.AlertUp},
+		},
+		{
+			name:     "outages further apart than the cooldown both alert",
+			cooldown: 5 * time.Minute,
+			transitions: []transition{
+				{0, true}, {time.Minute, false},
+				{10 * time.Minute, true}, {11 * time.Minute, false},
+			},
+			want: []string{models.AlertDown, models.AlertUp, models.AlertDown, models.AlertUp},
+		},
+		{
+			name:        "an hour of flapping collapses into one flapping alert",
+			cooldown:    5 * time.Minute,
+			transitions: flap(0, time.Hour, 10*time.Second),
+			want:        []string{models.AlertDown, models.AlertUp, models.AlertFlapping},
+		},
+		{
+			name:     "alerts resume once flapping settles",
+			cooldown: 5 * time.Minute,
+			transitions: append(flap(0, 10*time.Minute, 10*time.Second),
+				transition{30 * time.Minute, true}, transition{32 * time.Minute, false}),
+			want: []string{models.AlertDown, models.AlertUp, models.AlertFlapping, models.AlertDown, models.AlertUp},
+		},
+		{
+			name:        "zero cooldown disables suppression",
+			cooldown:    0,
+			transitions: flap(0, time.Minute, 10*time.Second),
+			want: []string{
+				models.AlertDown, models.AlertUp, models.AlertDown,
+				models.AlertUp, models.AlertDown, models.AlertUp,
+			},
+		},
+	}
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &recordingNotifier{}
+			alerter := New(tt.cooldown, notifier)
+
+			for _, tr := range tt.transitions {
+				if tr.down {
+					alerter.Down("8.8.8.8", base.Add(tr.at))
+				} else {
+					alerter.Up("8.8.8.8", base.Add(tr.at))
+				}
+			}
+
+			if got := notifier.kinds(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("alerts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlerterTargetsAreIndependent(t *testing.T) {
+	notifier := &recordingNotifier{}
+	alerter := New(5*time.Minute, notifier)
+
+	now := time.Now()
+	alerter.Down("8.8.8.8", now)
+	alerter.Down("1.1.1.1", now)
+
+	if len(notifier.alerts) != 2 {
+		t.Fatalf("expected a down alert per target, got %v", notifier.kinds())
+	}
+	if notifier.alerts[0].Target != "8.8.8.8" || notifier.alerts[1].Target != "1.1.1.1" {
+		t.Errorf("unexpected alert targets: %+v", notifier.alerts)
+	}
+}
@@ -0,0 +1,168 @@
+package alert
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// QualityThresholds configures latency and jitter alerts. Each metric is a
+// rolling value over the last Window of successful pings and must stay above
+// its threshold for a full Window before alerting; a zero threshold disables
+// that alert.
+type QualityThresholds struct {
+	RTT    float64 // average round-trip time in ms
+	Jitter float64 // mean difference between consecutive round-trip times in ms
+	Window time.Duration
+}
+
+// enabled reports whether any quality alert is configured
+func (q QualityThresholds) enabled() bool {
+	return q.Window > 0 && (q.RTT > 0 || q.Jitter > 0)
+}
+
+// rttSample is one successful ping's round-trip time
+type rttSample struct {
+	at  time.Time
+	rtt float64
+}
+
+// breach tracks one metric of one target against its threshold
+type breach struct {
+	since   time.Time // when the metric went above the threshold; zero while below
+	alerted bool
+}
+
+// qualityState is the rolling RTT window of a single target
+type qualityState struct {
+	samples []rttSample
+	latency breach
+	jitter  breach
+}
+
+// SetQualityThresholds enables latency and jitter alerts, independently of
+// up/down alerts
+func (a *Alerter) SetQualityThresholds(thresholds QualityThresholds) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.quality = thresholds
+}
+
+// Sample feeds a successful ping's round-trip time into the target's rolling
+// window, sending latency and jitter alerts as thresholds are crossed
+func (a *Alerter) Sample(target string, at time.Time, rtt float64) {
+	for _, alert := range a.evaluateSample(target, at, rtt) {
+		if err := a.notifier.Notify(alert); err != nil {
+			log.Printf("Failed to send %s alert for %s: %v", alert.Kind, target, err)
+		}
+	}
+}
+
+func (a *Alerter) evaluateSample(target string, at time.Time, rtt float64) []models.Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.quality.enabled() {
+		return nil
+	}
+
+	state, ok := a.qualityStates[target]
+	if !ok {
+		state = &qualityState{}
+		a.qualityStates[target] = state
+	}
+
+	recent := state.samples[:0]
+	for _, s := range state.samples {
+		if at.Sub(s.at) < a.quality.Window {
+			recent = append(recent, s)
+		}
+	}
+	state.samples = append(recent, rttSample{at: at, rtt: rtt})
+
+	var alerts []models.Alert
+	if a.quality.RTT > 0 {
+		avg := averageRTT(state.samples)
+		fire, recovered := state.latency.update(at, avg > a.quality.RTT, a.quality.Window)
+		switch {
+		case fire:
+			alerts = append(alerts, models.Alert{
+				Target:    target,
+				Kind:      models.AlertHighLatency,
+				Timestamp: at,
+				Message:   fmt.Sprintf("%s average latency is %.1fms over the last %s (threshold %.1fms)", target, avg, a.quality.Window, a.quality.RTT),
+			})
+		case recovered:
+			alerts = append(alerts, models.Alert{
+				Target:    target,
+				Kind:      models.AlertLatencyRecovered,
+				Timestamp: at,
+				Message:   fmt.Sprintf("%s average latency is back to %.1fms", target, avg),
+			})
+		}
+	}
+	if a.quality.Jitter > 0 {
+		jitter := meanJitter(state.samples)
+		fire, recovered := state.jitter.update(at, jitter > a.quality.Jitter, a.quality.Window)
+		switch {
+		case fire:
+			alerts = append(alerts, models.Alert{
+				Target:    target,
+				Kind:      models.AlertHighJitter,
+				Timestamp: at,
+				Message:   fmt.Sprintf("%s jitter is %.1fms over the last %s (threshold %.1fms)", target, jitter, a.quality.Window, a.quality.Jitter),
+			})
+		case recovered:
+			alerts = append(alerts, models.Alert{
+				Target:    target,
+				Kind:      models.AlertJitterRecovered,
+				Timestamp: at,
+				Message:   fmt.Sprintf("%s jitter is back to %.1fms", target, jitter),
+			})
+		}
+	}
+	return alerts
+}
+
+// update records whether the metric is over its threshold and reports whether
+// the high alert should fire (over for a full window) or its recovery be sent
+func (b *breach) update(at time.Time, over bool, window time.Duration) (fire, recovered bool) {
+	if !over {
+		b.since = time.Time{}
+		recovered = b.alerted
+		b.alerted = false
+		return false, recovered
+	}
+
+	if b.since.IsZero() {
+		b.since = at
+	}
+	if b.alerted || at.Sub(b.since) < window {
+		return false, false
+	}
+	b.alerted = true
+	return true, false
+}
+
+func averageRTT(samples []rttSample) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += s.rtt
+	}
+	return sum / float64(len(samples))
+}
+
+// meanJitter is the mean absolute difference between consecutive samples
+func meanJitter(samples []rttSample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	var sum float64
+	for i := 1; i < len(samples); i++ {
+		sum += math.Abs(samples[i].rtt - samples[i-1].rtt)
+	}
+	return sum / float64(len(samples)-1)
+}
@@ -0,0 +1,139 @@
+package alert
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// rttStep feeds a ping every interval for a duration, with RTTs from rtt
+type rttStep struct {
+	duration time.Duration
+	rtt      func(i int) float64
+}
+
+// steady returns the same RTT for every ping
+func steady(rtt float64) func(int) float64 { return func(int) float64 { return rtt } }
+
+// alternating swings between low and high on every ping
+func alternating(low, high float64) func(int) float64 {
+	return func(i int) float64 {
+		if i%2 == 0 {
+			return low
+		}
+		return high
+	}
+}
+
+type firedAlert struct {
+	kind string
+	at   time.Duration
+}
+
+func TestAlerterQualityThresholds(t *testing.T) {
+	const interval = 10 * time.Second
+
+	tests := []struct {
+		name       string
+		thresholds QualityThresholds
+		steps      []rttStep
+		want       []firedAlert
+	}{
+		{
+			name:       "healthy target never alerts",
+			thresholds: QualityThresholds{RTT: 100, Jitter: 20, Window: time.Minute},
+			steps:      []rttStep{{10 * time.Minute, steady(20)}},
+			want:       nil,
+		},
+		{
+			name:       "latency alert fires after a sustained window and clears",
+			thresholds: QualityThresholds{RTT: 100, Window: time.Minute},
+			steps: []rttStep{
+				{2 * time.Minute, steady(20)},
+				{3 * time.Minute, steady(400)},
+				{2 * time.Minute, steady(20)},
+			},
+			// The one-minute average first exceeds 100ms at 2m10s (two slow pings
+			// of six) and has to stay over for a full minute; it drops back once
+			// five of the six pings in the window are fast again
+			want: []firedAlert{
+				{models.AlertHighLatency, 3*time.Minute + 10*time.Second},
+				{models.AlertLatencyRecovered, 5*time.Minute + 40*time.Second},
+			},
+		},
+		{
+			name:       "brief spike shorter than the window does not alert",
+			thresholds: QualityThresholds{RTT: 100, Window: time.Minute},
+			steps: []rttStep{
+				{2 * time.Minute, steady(20)},
+				{20 * time.Second, steady(400)},
+				{2 * time.Minute, steady(20)},
+			},
+			want: nil,
+		},
+		{
+			name:       "jitter alert is independent of latency",
+			thresholds: QualityThresholds{Jitter: 50, Window: time.Minute},
+			steps: []rttStep{
+				{2 * time.Minute, steady(20)},
+				{3 * time.Minute, alternating(20, 200)},
+				{2 * time.Minute, steady(20)},
+			},
+			want: []firedAlert{
+				{models.AlertHighJitter, 3*time.Minute + 20*time.Second},
+				{models.AlertJitterRecovered, 5*time.Minute + 40*time.Second},
+			},
+		},
+		{
+			name:       "disabled thresholds never alert",
+			thresholds: QualityThresholds{Window: time.Minute},
+			steps:      []rttStep{{5 * time.Minute, alternating(300, 900)}},
+			want:       nil,
+		},
+	}
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &recordingNotifier{}
+			alerter := New(5*time.Minute, notifier)
+			alerter.SetQualityThresholds(tt.thresholds)
+
+			var at time.Duration
+			i := 0
+			for _, step := range tt.steps {
+				for end := at + step.duration; at < end; at += interval {
+					alerter.Sample("8.8.8.8", base.Add(at), step.rtt(i))
+					i++
+				}
+			}
+
+			var got []firedAlert
+			for _, a := range notifier.alerts {
+				got = append(got, firedAlert{a.Kind, a.Timestamp.Sub(base)})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("alerts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlerterQualityDoesNotAffectUpDown(t *testing.T) {
+	notifier := &recordingNotifier{}
+	alerter := New(5*time.Minute, notifier)
+	alerter.SetQualityThresholds(QualityThresholds{RTT: 100, Window: time.Minute})
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for at := time.Duration(0); at <= 2*time.Minute; at += 10 * time.Second {
+		alerter.Sample("8.8.8.8", base.Add(at), 400)
+	}
+	alerter.Down("8.8.8.8", base.Add(3*time.Minute))
+
+	want := []string{models.AlertHighLatency, models.AlertDown}
+	if got := notifier.kinds(); !reflect.DeepEqual(got, want) {
+		t.Errorf("alerts = %v, want %v", got, want)
+	}
+}
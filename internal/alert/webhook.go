@@ -0,0 +1,140 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// webhookTimeout bounds a single webhook delivery, so a hanging endpoint
+// can't hold up the alerts behind it
+const webhookTimeout = 10 * time.Second
+
+// WebhookNotifier POSTs each alert as JSON to a URL, e.g. a chat service's
+// incoming webhook or an automation endpoint
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Notify posts the alert. Any response other than 2xx is an error.
+func (n *WebhookNotifier) Notify(alert models.Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("encode alert: %w", err)
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s returned %s", n.url, resp.Status)
+	}
+	return nil
+}
+
+// Notifiers delivers each alert to every notifier in turn
+type Notifiers []models.Notifier
+
+// Notify delivers the alert to all notifiers, even if some fail, and returns
+// their errors joined
+func (ns Notifiers) Notify(alert models.Alert) error {
+	var errs []error
+	for _, n := range ns {
+		if err := n.Notify(alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Queue delivers alerts to a slow notifier, such as a webhook, in the
+// background, so a hanging endpoint can't hold up the results being
+// processed while a network is down. When the queue is full further alerts
+// are dropped and logged. Test alerts are delivered right away instead, so
+// their delivery error can be reported.
+type Queue struct {
+	notifier models.Notifier
+	alerts   chan models.Alert
+	done     chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewQueue creates a Queue holding up to size alerts and starts delivering
+// them to notifier
+func NewQueue(notifier models.Notifier, size int) *Queue {
+	q := &Queue{
+		notifier: notifier,
+		alerts:   make(chan models.Alert, size),
+		done:     make(chan struct{}),
+	}
+	go q.deliver()
+	return q
+}
+
+// Notify queues the alert without blocking; a test alert is delivered before
+// returning and its error returned
+func (q *Queue) Notify(alert models.Alert) error {
+	if alert.Test {
+		return q.notifier.Notify(alert)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		log.Printf("Dropped %s alert for %s: alert queue closed", alert.Kind, alert.Target)
+		return nil
+	}
+	select {
+	case q.alerts <- alert:
+	default:
+		log.Printf("Dropped %s alert for %s: alert queue full", alert.Kind, alert.Target)
+	}
+	return nil
+}
+
+// Close stops accepting alerts and waits for the queued ones to be
+// delivered, giving up after webhookTimeout so an endpoint that hangs can't
+// hold up shutdown
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.alerts)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+	case <-time.After(webhookTimeout):
+		log.Printf("Gave up delivering %d queued alerts", len(q.alerts))
+	}
+	return nil
+}
+
+// deliver sends queued alerts to the notifier in order until the queue closes
+func (q *Queue) deliver() {
+	defer close(q.done)
+	for alert := range q.alerts {
+		if err := q.notifier.Notify(alert); err != nil {
+			log.Printf("Failed to send %s alert for %s: %v", alert.Kind, alert.Target, err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// mockWebhook records the alerts posted to it and answers with status
+type mockWebhook struct {
+	*httptest.Server
+	alerts []models.Alert
+}
+
+func newMockWebhook(t *testing.T, status int) *mockWebhook {
+	t.Helper()
+
+	hook := &mockWebhook{}
+	hook.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("webhook got %s with Content-Type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		var alert models.Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("decode webhook body: %v", err)
+		}
+		hook.alerts = append(hook.alerts, alert)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(hook.Close)
+	return hook
+}
+
+func TestAlerterTestReachesWebhook(t *testing.T) {
+	hook := newMockWebhook(t, http.StatusNoContent)
+	alerter := New(5*time.Minute, Notifiers{LogNotifier{}, NewWebhookNotifier(hook.URL)})
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := alerter.Test(at); err != nil {
+		t.Fatalf("Test() error = %v", err)
+	}
+
+	if len(hook.alerts) != 1 {
+		t.Fatalf("webhook received %d alerts, want 1", len(hook.alerts))
+	}
+	got := hook.alerts[0]
+	if !got.Test || got.Kind != models.AlertTest || !got.Timestamp.Equal(at) || !strings.Contains(got.Message, "TEST") {
+		t.Errorf("webhook received %+v, want a test alert marked TEST at %v", got, at)
+	}
+
+	// A test alert doesn't count as a target's down alert
+	alerter.Down("8.8.8.8", at)
+	if len(hook.alerts) != 2 || hook.alerts[1].Kind != models.AlertDown || hook.alerts[1].Test {
+		t.Errorf("down alert after a test = %+v, want a real down alert", hook.alerts[1:])
+	}
+}
+
+func TestWebhookNotifierErrors(t *testing.T) {
+	hook := newMockWebhook(t, http.StatusUnauthorized)
+	err := NewWebhookNotifier(hook.URL).Notify(models.Alert{Kind: models.AlertTest, Test: true})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Notify() error = %v, want the 401 status", err)
+	}
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	if err := NewWebhookNotifier(closed.URL).Notify(models.Alert{}); err == nil {
+		t.Error("Notify() to an unreachable webhook succeeded")
+	}
+}
+
+// failingNotifier fails every delivery with err
+type failingNotifier struct {
+	err error
+}
+
+func (n failingNotifier) Notify(models.Alert) error {
+	return n.err
+}
+
+func TestNotifiersDeliverToAll(t *testing.T) {
+	errDown := errors.New("delivery failed")
+	recorder := &recordingNotifier{}
+
+	err := Notifiers{failingNotifier{errDown}, recorder}.Notify(models.Alert{Kind: models.AlertTest})
+	if !errors.Is(err, errDown) {
+		t.Errorf("Notify() error = %v, want the failing notifier's error", err)
+	}
+	if len(recorder.alerts) != 1 {
+		t.Errorf("notifier after a failing one got %d alerts, want 1", len(recorder.alerts))
+	}
+}
+
+// blockingNotifier holds every delivery until released
+type blockingNotifier struct {
+	release   chan struct{}
+	delivered chan models.Alert
+}
+
+func (n *blockingNotifier) Notify(alert models.Alert) error {
+	<-n.release
+	n.delivered <- alert
+	return nil
+}
+
+func TestQueueDeliversInBackground(t *testing.T) {
+	notifier := &blockingNotifier{release: make(chan struct{}), delivered: make(chan models.Alert, 10)}
+	queue := NewQueue(notifier, 2)
+
+	// The first alert is taken for delivery and blocks; two more fill the
+	// queue and the last is dropped, all without waiting on the notifier
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, target := range []string{"a", "b", "c", "d"} {
+			queue.Notify(models.Alert{Target: target, Kind: models.AlertDown})
+			if target == "a" {
+				// Let the deliverer pick up the first alert before queueing more
+				for len(queue.alerts) > 0 {
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Notify blocked on a slow notifier")
+	}
+
+	close(notifier.release)
+	queue.Close()
+	close(notifier.delivered)
+	var got []string
+	for alert := range notifier.delivered {
+		got = append(got, alert.Target)
+	}
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Errorf("delivered %v, want a, b and c in order with d dropped", got)
+	}
+}
+
+func TestQueueDeliversTestAlertsRightAway(t *testing.T) {
+	hook := newMockWebhook(t, http.StatusUnauthorized)
+	queue := NewQueue(NewWebhookNotifier(hook.URL), 10)
+	defer queue.Close()
+
+	err := queue.Notify(models.Alert{Kind: models.AlertTest, Test: true})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Notify() error = %v, want the 401 status of the test alert", err)
+	}
+	if len(hook.alerts) != 1 {
+		t.Errorf("webhook received %d alerts, want the test alert", len(hook.alerts))
+	}
+}
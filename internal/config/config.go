@@ -2,35 +2,420 @@ package config
 
 import (
 	"fmt"
+	"math"
+	"net/netip"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"network-monitor/internal/models"
 )
 
 // Config holds all configuration for the network monitor
 type Config struct {
-	Targets      []string
-	Interval     time.Duration
-	Timeout      time.Duration
-	DatabasePath string
-	Port         int
-	DevMode      bool // Enable development mode for live static file editing
+	Targets        []string
+	TargetMeta     []models.TargetMeta // Dashboard names and colors of targets, from the config file
+	Interval       time.Duration
+	Adaptive       bool          // Lengthen the interval for targets that keep answering
+	AdaptiveMax    time.Duration // Longest interval adaptive mode backs off to
+	Timeout        time.Duration
+	DBDriver       string // sqlite (default) or postgres
+	DBDSN          string // Postgres connection string; the sqlite driver uses DatabasePath
+	DatabasePath   string
+	JournalMode    string // SQLite journal mode (WAL, DELETE, TRUNCATE or PERSIST)
+	DBOpenRetries  int    // Extra attempts to open the database at startup, e.g. while its volume mounts
+	VacuumOnStart  bool   // Rebuild the SQLite file with VACUUM before monitoring starts
+	RecoverCorrupt bool   // Move a corrupt SQLite file aside and start a fresh one instead of refusing to start
+	Bind           string // Address the web and gRPC servers listen on, e.g. 0.0.0.0 for all interfaces
+	Port           int
+	GRPCPort       int           // gRPC API port; 0 disables the gRPC server
+	BasePath       string        // URL prefix the web server is mounted under behind a proxy, e.g. /netmon
+	CORSOrigins    []string      // Origins allowed to call the API cross-site, or "*"; empty means same-origin only
+	RateLimit      float64       // API requests per second allowed per client IP; 0 disables
+	AccessLog      bool          // Log every web request with its status, size and duration
+	QueryTimeout   time.Duration // Cancel the database queries behind an API request after this long; 0 disables
+	AnomalySigma   float64       // Flag RTTs this many standard deviations above a target's rolling mean in /api/recent; 0 disables
+	Theme          string        // Dashboard theme: light, dark or auto (follow the browser)
+	DefaultHours   int           // Dashboard time range on load
+	Refresh        time.Duration // Dashboard auto-refresh interval; 0 disables
+	PingRetries    int           // Extra attempts before a ping is recorded as failed
+	PingWorkers    int           // Pings run at once across all targets; 0 means one per target
+	PingBinary     string        // Ping executable, e.g. a wrapper script; empty means "ping"
+	PingArgs       []string      // Extra arguments placed before the platform's ping arguments
+	NoStagger      bool          // Ping every target at once instead of spreading first pings across the interval
+	Traceroute     bool          // Run a traceroute when a target enters an outage
+	Loopback       bool          // Also monitor loopback and the default gateway as reference targets
+	OutageWindow   int           // Number of recent pings considered for outage detection
+	OutageFailures int           // Failed pings within the window that constitute an outage
+	OutageRecovery int           // Consecutive successful pings that end an outage; 0 uses the default
+	PreservePad    time.Duration // Keep raw results this close to a recorded outage past the 7-day retention; 0 disables
+	Maintenance    time.Duration // How often hourly patterns are aggregated; old data is archived daily
+	MaxDBSize      int64         // Evict the oldest raw results while the SQLite database holds more bytes; 0 disables
+	AlertCooldown  time.Duration // Suppress repeated alerts for a target within this period
+	Timezone       string        // IANA zone for hour-of-day buckets and reports; empty means system local
+	InstanceName   string        // Stored with every result to tell monitors sharing a database apart, e.g. "home"
+	DevMode        bool          // Enable development mode for live static file editing
+	DebugOutput    bool          // Store raw output of failed or unparseable pings in ping_debug
+
+	// Latency and jitter alerts over a rolling window; thresholds in ms, 0 disables
+	AlertRTTThreshold    float64
+	AlertJitterThreshold float64
+	AlertWindow          time.Duration
+
+	AlertWebhook  string // URL alerts are POSTed to as JSON, in addition to the log; empty disables
+	AlertSelfTest bool   // Send a test alert at startup to check delivery
+
+	// Heatmap cells with an average RTT (ms) or failure rate (%) above these
+	// are graded as warnings or bad; 0 disables
+	WarnRTT  float64
+	BadRTT   float64
+	WarnLoss float64
+	BadLoss  float64
+
+	RTTBuckets []float64 // Upper bounds in ms of the /metrics RTT histogram buckets, ascending; empty uses the defaults
+
+	// Optional InfluxDB sink; results are pushed there in addition to SQLite
+	InfluxURL      string
+	InfluxToken    string
+	InfluxOrg      string // v2 organization
+	InfluxBucket   string // v2 bucket
+	InfluxDatabase string // v1 database
+
+	// Optional MQTT publishing of results and outage transitions
+	MQTTBroker      string
+	MQTTTopicPrefix string
+	MQTTUsername    string
+	MQTTPassword    string
 }
 
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
-	if len(c.Targets) == 0 {
+	if len(cleanTargets(c.Targets)) == 0 {
 		return fmt.Errorf("at least one target must be specified")
 	}
+	seen := make(map[string]bool, len(c.Targets))
+	for _, target := range c.Targets {
+		if _, err := models.ParseTarget(target); err != nil {
+			return err
+		}
+		// Each target's results are stored under its name, so a repeat would
+		// ping twice into one series
+		if seen[target] {
+			return fmt.Errorf("target %q is listed more than once", target)
+		}
+		seen[target] = true
+		if c.Loopback && models.IsReferenceTarget(target) {
+			return fmt.Errorf("target %q is reserved for the reference targets -include-loopback adds", target)
+		}
+	}
+	if err := validateTargetMeta(c.TargetMeta, seen); err != nil {
+		return err
+	}
 	if c.Interval <= 0 {
 		return fmt.Errorf("interval must be positive")
 	}
 	if c.Timeout <= 0 {
 		return fmt.Errorf("timeout must be positive")
 	}
-	if c.DatabasePath == "" {
-		return fmt.Errorf("database path cannot be empty")
+	if c.Adaptive && c.AdaptiveMax < c.Interval {
+		return fmt.Errorf("adaptive max interval (%v) cannot be shorter than the interval (%v)", c.AdaptiveMax, c.Interval)
+	}
+	if c.PingRetries < 0 {
+		return fmt.Errorf("ping retries cannot be negative")
+	}
+	if c.PingWorkers < 0 {
+		return fmt.Errorf("ping concurrency cannot be negative")
+	}
+	if err := validateOutageThreshold(c.OutageWindow, c.OutageFailures); err != nil {
+		return err
+	}
+	if c.OutageRecovery < 0 {
+		return fmt.Errorf("outage recovery cannot be negative")
+	}
+	if c.AlertCooldown < 0 {
+		return fmt.Errorf("alert cooldown cannot be negative")
+	}
+	if c.AlertRTTThreshold < 0 || c.AlertJitterThreshold < 0 {
+		return fmt.Errorf("alert thresholds cannot be negative")
+	}
+	if (c.AlertRTTThreshold > 0 || c.AlertJitterThreshold > 0) && c.AlertWindow <= 0 {
+		return fmt.Errorf("alert window must be positive when latency or jitter alerts are enabled")
+	}
+	if err := validateWebhook(c.AlertWebhook); err != nil {
+		return err
+	}
+	if err := validateBind(c.Bind); err != nil {
+		return err
+	}
+	if err := validateTimezone(c.Timezone); err != nil {
+		return err
+	}
+	if err := validateDatabase(c.DBDriver, c.DatabasePath, c.DBDSN); err != nil {
+		return err
+	}
+	if c.DBOpenRetries < 0 {
+		return fmt.Errorf("database open retries cannot be negative")
+	}
+	if c.PreservePad < 0 {
+		return fmt.Errorf("outage preservation padding cannot be negative")
+	}
+	if c.Maintenance <= 0 {
+		return fmt.Errorf("maintenance interval must be positive")
+	}
+	if c.MaxDBSize < 0 {
+		return fmt.Errorf("maximum database size cannot be negative")
+	}
+	switch strings.ToUpper(c.JournalMode) {
+	case "WAL", "DELETE", "TRUNCATE", "PERSIST":
+	default:
+		return fmt.Errorf("journal mode must be one of WAL, DELETE, TRUNCATE or PERSIST")
 	}
 	if c.Port <= 0 || c.Port > 65535 {
 		return fmt.Errorf("port must be between 1 and 65535")
 	}
+	if c.GRPCPort < 0 || c.GRPCPort > 65535 {
+		return fmt.Errorf("gRPC port must be between 0 and 65535")
+	}
+	if c.GRPCPort != 0 && c.GRPCPort == c.Port {
+		return fmt.Errorf("gRPC port cannot be the same as the web server port")
+	}
+	if err := validateBasePath(c.BasePath); err != nil {
+		return err
+	}
+	for _, origin := range c.CORSOrigins {
+		if err := validateOrigin(origin); err != nil {
+			return err
+		}
+	}
+	if c.RateLimit < 0 {
+		return fmt.Errorf("rate limit cannot be negative")
+	}
+	if c.QueryTimeout < 0 {
+		return fmt.Errorf("query timeout cannot be negative")
+	}
+	if c.AnomalySigma < 0 {
+		return fmt.Errorf("anomaly sigma cannot be negative")
+	}
+	if err := validateSeverity("RTT", c.WarnRTT, c.BadRTT); err != nil {
+		return err
+	}
+	if err := validateSeverity("loss", c.WarnLoss, c.BadLoss); err != nil {
+		return err
+	}
+	if err := validateBuckets(c.RTTBuckets); err != nil {
+		return err
+	}
+	if err := validateDisplay(c.Theme, c.DefaultHours, c.Refresh); err != nil {
+		return err
+	}
+	if c.InfluxURL != "" {
+		if (c.InfluxBucket == "") == (c.InfluxDatabase == "") {
+			return fmt.Errorf("InfluxDB output needs exactly one of a bucket (v2) or database (v1)")
+		}
+		if c.InfluxBucket != "" && c.InfluxOrg == "" {
+			return fmt.Errorf("InfluxDB bucket requires an organization")
+		}
+	}
+	return nil
+}
+
+// validateBasePath checks a URL prefix is a plain absolute path such as
+// /netmon; a trailing slash is allowed. Empty or "/" means the root.
+func validateBasePath(prefix string) error {
+	if prefix == "" || prefix == "/" {
+		return nil
+	}
+	trimmed := strings.TrimSuffix(prefix, "/")
+	if !strings.HasPrefix(prefix, "/") || path.Clean(trimmed) != trimmed || strings.ContainsAny(prefix, "?#% ") {
+		return fmt.Errorf("base path %q must be an absolute URL path such as /netmon", prefix)
+	}
+	return nil
+}
+
+// validateDisplay checks the dashboard preferences. An empty theme means auto
+// and a zero default range leaves the dashboard's own default.
+func validateDisplay(theme string, hours int, refresh time.Duration) error {
+	switch theme {
+	case "", "light", "dark", "auto":
+	default:
+		return fmt.Errorf("theme must be light, dark or auto, got %q", theme)
+	}
+	if hours < 0 {
+		return fmt.Errorf("default hours cannot be negative")
+	}
+	if refresh < 0 {
+		return fmt.Errorf("refresh interval cannot be negative")
+	}
+	return nil
+}
+
+// OutageThreshold returns the configured outage definition, or the default when
+// the window is unset
+func (c *Config) OutageThreshold() models.OutageThreshold {
+	return outageThreshold(c.OutageWindow, c.OutageFailures)
+}
+
+func outageThreshold(window, failures int) models.OutageThreshold {
+	if window == 0 {
+		return models.DefaultOutageThreshold
+	}
+	return models.OutageThreshold{Window: window, Failures: failures}
+}
+
+// OutageRecoveryChecks returns how many consecutive successful pings end an
+// outage, or the default when unset
+func (c *Config) OutageRecoveryChecks() int {
+	if c.OutageRecovery == 0 {
+		return models.DefaultOutageRecovery
+	}
+	return c.OutageRecovery
+}
+
+// Location returns the configured display time zone, or the system local zone
+// when unset. Validate must have accepted the configuration.
+func (c *Config) Location() *time.Location {
+	return location(c.Timezone)
+}
+
+func location(name string) *time.Location {
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// targetColorPattern matches the CSS hex colors a target may be drawn in
+var targetColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// maxTargetNameLength keeps display names short enough for the dashboard's
+// cards and chart legend
+const maxTargetNameLength = 64
+
+// validateTargetMeta checks the display names and colors given for the
+// monitored targets. Names end up in the dashboard's HTML, so markup
+// characters are refused.
+func validateTargetMeta(meta []models.TargetMeta, monitored map[string]bool) error {
+	for _, m := range meta {
+		if !monitored[m.Target] {
+			return fmt.Errorf("target %q has a name or color but isn't monitored", m.Target)
+		}
+		if utf8.RuneCountInString(m.Name) > maxTargetNameLength {
+			return fmt.Errorf("target %q name is longer than %d characters", m.Target, maxTargetNameLength)
+		}
+		if strings.ContainsAny(m.Name, "<>") || strings.IndexFunc(m.Name, unicode.IsControl) >= 0 {
+			return fmt.Errorf("target %q name %q can't contain <, > or control characters", m.Target, m.Name)
+		}
+		if m.Color != "" && !targetColorPattern.MatchString(m.Color) {
+			return fmt.Errorf("target %q color %q must be a hex color such as #1f77b4", m.Target, m.Color)
+		}
+	}
+	return nil
+}
+
+// validateSeverity checks a pair of heatmap thresholds: neither may be
+// negative, and a warning can't start above bad when both are set
+func validateSeverity(name string, warn, bad float64) error {
+	if warn < 0 || bad < 0 {
+		return fmt.Errorf("warn and bad %s thresholds cannot be negative", name)
+	}
+	if warn > 0 && bad > 0 && warn > bad {
+		return fmt.Errorf("warn %s threshold %v is above the bad one %v", name, warn, bad)
+	}
+	return nil
+}
+
+// validateBuckets checks histogram bucket bounds are positive and ascending
+func validateBuckets(buckets []float64) error {
+	for i, bound := range buckets {
+		if bound <= 0 || math.IsInf(bound, 0) || math.IsNaN(bound) {
+			return fmt.Errorf("RTT bucket %v must be a positive number of milliseconds", bound)
+		}
+		if i > 0 && bound <= buckets[i-1] {
+			return fmt.Errorf("RTT buckets must be in ascending order, %v follows %v", bound, buckets[i-1])
+		}
+	}
+	return nil
+}
+
+func validateTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", name, err)
+	}
+	return nil
+}
+
+// validateDatabase checks that the selected driver has what it connects with
+func validateDatabase(driver, path, dsn string) error {
+	switch driver {
+	case "", "sqlite":
+		if path == "" {
+			return fmt.Errorf("database path cannot be empty")
+		}
+	case "postgres":
+		if dsn == "" {
+			return fmt.Errorf("the postgres database driver requires a DSN")
+		}
+	default:
+		return fmt.Errorf("database driver must be sqlite or postgres")
+	}
+	return nil
+}
+
+// validateOrigin checks a CORS origin is "*" or a bare scheme://host[:port],
+// which is how browsers send the Origin header
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Errorf("invalid CORS origin %q: use scheme://host[:port], e.g. https://dash.example.com", origin)
+	}
+	return nil
+}
+
+// validateBind checks the listen address is an IP address or a hostname such
+// as localhost
+func validateBind(bind string) error {
+	if _, err := netip.ParseAddr(bind); err == nil {
+		return nil
+	}
+	if target, err := models.ParseTarget(bind); err == nil && target.Host == bind {
+		return nil
+	}
+	return fmt.Errorf("bind address %q must be an IP address or hostname, e.g. 127.0.0.1 or 0.0.0.0", bind)
+}
+
+// validateWebhook checks an alert webhook is empty or an http(s) URL with a host
+func validateWebhook(webhook string) error {
+	if webhook == "" {
+		return nil
+	}
+	u, err := url.Parse(webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid alert webhook %q: use an http or https URL", webhook)
+	}
+	return nil
+}
+
+func validateOutageThreshold(window, failures int) error {
+	if window < 1 {
+		return fmt.Errorf("outage window must be at least 1")
+	}
+	if failures < 1 || failures > window {
+		return fmt.Errorf("outage failures must be between 1 and the outage window (%d)", window)
+	}
 	return nil
 }
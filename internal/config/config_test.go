@@ -0,0 +1,709 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+	"network-monitor/internal/report"
+)
+
+func validConfig() Config {
+	return Config{
+		Targets:        []string{"8.8.8.8"},
+		Interval:       time.Second,
+		Timeout:        time.Second,
+		DatabasePath:   "network_monitor.db",
+		JournalMode:    "WAL",
+		Bind:           "127.0.0.1",
+		Port:           8080,
+		OutageWindow:   3,
+		OutageFailures: 3,
+		OutageRecovery: 2,
+		Maintenance:    5 * time.Minute,
+	}
+}
+
+func TestValidateJournalMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		journalMode string
+		wantErr     bool
+	}{
+		{name: "WAL", journalMode: "WAL"},
+		{name: "lowercase delete", journalMode: "delete"},
+		{name: "truncate", journalMode: "TRUNCATE"},
+		{name: "memory is rejected", journalMode: "MEMORY", wantErr: true},
+		{name: "empty is rejected", journalMode: "", wantErr: true},
+		{name: "garbage is rejected", journalMode: "fast", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.JournalMode = tt.journalMode
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateOutageThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   int
+		failures int
+		wantErr  bool
+	}{
+		{name: "consecutive failures", window: 3, failures: 3},
+		{name: "failures within window", window: 10, failures: 5},
+		{name: "single failure", window: 1, failures: 1},
+		{name: "zero window", window: 0, failures: 0, wantErr: true},
+		{name: "zero failures", window: 10, failures: 0, wantErr: true},
+		{name: "failures exceed window", window: 3, failures: 4, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.OutageWindow = tt.window
+			cfg.OutageFailures = tt.failures
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateOutageRecovery(t *testing.T) {
+	tests := []struct {
+		name     string
+		recovery int
+		want     int
+		wantErr  bool
+	}{
+		{name: "unset uses the default", recovery: 0, want: models.DefaultOutageRecovery},
+		{name: "single success", recovery: 1, want: 1},
+		{name: "several successes", recovery: 5, want: 5},
+		{name: "negative", recovery: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.OutageRecovery = tt.recovery
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.OutageRecoveryChecks() != tt.want {
+				t.Errorf("OutageRecoveryChecks() = %d, want %d", cfg.OutageRecoveryChecks(), tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSeverityThresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		warn    float64
+		bad     float64
+		wantErr bool
+	}{
+		{name: "defaults", warn: 50, bad: 100},
+		{name: "both disabled", warn: 0, bad: 0},
+		{name: "only bad", warn: 0, bad: 100},
+		{name: "only warn", warn: 50, bad: 0},
+		{name: "warn above bad", warn: 150, bad: 100, wantErr: true},
+		{name: "negative", warn: -1, bad: 100, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.WarnRTT, cfg.BadRTT = tt.warn, tt.bad
+			cfg.WarnLoss, cfg.BadLoss = tt.warn/100, tt.bad/100
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTimezone(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		wantErr  bool
+	}{
+		{name: "system local", timezone: ""},
+		{name: "UTC", timezone: "UTC"},
+		{name: "IANA zone", timezone: "Europe/Helsinki"},
+		{name: "unknown zone", timezone: "Mars/Olympus_Mons", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Timezone = tt.timezone
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.timezone != "" && cfg.Location().String() != tt.timezone {
+				t.Errorf("Location() = %v, want %s", cfg.Location(), tt.timezone)
+			}
+		})
+	}
+}
+
+func TestValidateAdaptiveInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		adaptive    bool
+		adaptiveMax time.Duration
+		wantErr     bool
+	}{
+		{name: "disabled ignores max", adaptive: false, adaptiveMax: 0},
+		{name: "max above interval", adaptive: true, adaptiveMax: time.Minute},
+		{name: "max equal to interval", adaptive: true, adaptiveMax: time.Second},
+		{name: "max below interval", adaptive: true, adaptiveMax: 500 * time.Millisecond, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Interval = time.Second
+			cfg.Adaptive = tt.adaptive
+			cfg.AdaptiveMax = tt.adaptiveMax
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateGRPCPort(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    int
+		wantErr bool
+	}{
+		{name: "disabled", port: 0},
+		{name: "separate port", port: 9090},
+		{name: "same as web port", port: 8080, wantErr: true},
+		{name: "negative", port: -1, wantErr: true},
+		{name: "out of range", port: 70000, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.GRPCPort = tt.port
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []string
+		wantErr bool
+	}{
+		{name: "plain hosts", targets: []string{"8.8.8.8", "example.com"}},
+		{name: "no targets", targets: nil, wantErr: true},
+		{name: "only blank targets", targets: []string{"", "  "}, wantErr: true},
+		{name: "duplicate target", targets: []string{"8.8.8.8", "1.1.1.1", "8.8.8.8"}, wantErr: true},
+		{name: "same host via two interfaces", targets: []string{"8.8.8.8 via eth0", "8.8.8.8 via wwan0"}},
+		{name: "via source address", targets: []string{"8.8.8.8 via 192.168.1.10"}},
+		{name: "missing interface", targets: []string{"8.8.8.8 via"}, wantErr: true},
+		{name: "unknown keyword", targets: []string{"8.8.8.8 over eth0"}, wantErr: true},
+		{name: "DNS probe", targets: []string{"dns://1.1.1.1/example.com", "dns://[2606:4700:4700::1111]:53/example.com"}},
+		{name: "DNS probe without name", targets: []string{"dns://1.1.1.1"}, wantErr: true},
+		{name: "DNS probe without resolver", targets: []string{"dns:///example.com"}, wantErr: true},
+		{name: "DNS probe via interface", targets: []string{"dns://1.1.1.1/example.com via eth0"}, wantErr: true},
+		{name: "unknown probe type", targets: []string{"http://example.com/"}, wantErr: true},
+		{name: "IPv6 and hostnames", targets: []string{"2001:4860:4860::8888", "fe80::1%eth0", "dns.google.", "_gateway.lan"}},
+		{name: "flag as target", targets: []string{"-f"}, wantErr: true},
+		{name: "long flag as target", targets: []string{"--flood"}, wantErr: true},
+		{name: "flag as source", targets: []string{"8.8.8.8 via -f"}, wantErr: true},
+		{name: "flag as DNS resolver", targets: []string{"dns://-f/example.com"}, wantErr: true},
+		{name: "shell metacharacters", targets: []string{"8.8.8.8;reboot"}, wantErr: true},
+		{name: "command substitution", targets: []string{"$(reboot)"}, wantErr: true},
+		{name: "hyphen-edged label", targets: []string{"bad-.example.com"}, wantErr: true},
+		{name: "empty label", targets: []string{"example..com"}, wantErr: true},
+		{name: "overlong label", targets: []string{strings.Repeat("a", 64) + ".com"}, wantErr: true},
+		{name: "interface name too long", targets: []string{"8.8.8.8 via averyveryverylongif0"}, wantErr: true},
+		{name: "DNS port out of range", targets: []string{"dns://1.1.1.1:70000/example.com"}, wantErr: true},
+		{name: "invalid DNS name", targets: []string{"dns://1.1.1.1/exa$mple.com"}, wantErr: true},
+		{name: "DNS credentials", targets: []string{"dns://user@1.1.1.1/example.com"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Targets = tt.targets
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReferenceTargetNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		loopback bool
+		targets  []string
+		wantErr  bool
+	}{
+		{name: "reserved names without reference targets", targets: []string{"gateway", "loopback"}},
+		{name: "other targets with reference targets", loopback: true, targets: []string{"8.8.8.8", "gateway.lan"}},
+		{name: "gateway with reference targets", loopback: true, targets: []string{"8.8.8.8", "gateway"}, wantErr: true},
+		{name: "loopback with reference targets", loopback: true, targets: []string{"loopback"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Loopback = tt.loopback
+			cfg.Targets = tt.targets
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDatabaseDriver(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		path    string
+		dsn     string
+		wantErr bool
+	}{
+		{name: "sqlite", driver: "sqlite", path: "network_monitor.db"},
+		{name: "default driver", driver: "", path: "network_monitor.db"},
+		{name: "sqlite without path", driver: "sqlite", path: "", wantErr: true},
+		{name: "postgres", driver: "postgres", dsn: "postgres://monitor@db/monitor"},
+		{name: "postgres without dsn", driver: "postgres", path: "network_monitor.db", wantErr: true},
+		{name: "unknown driver", driver: "mysql", path: "network_monitor.db", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.DBDriver = tt.driver
+			cfg.DatabasePath = tt.path
+			cfg.DBDSN = tt.dsn
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCORSOrigins(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		wantErr bool
+	}{
+		{name: "none", origins: nil},
+		{name: "any", origins: []string{"*"}},
+		{name: "allowlist", origins: []string{"https://dash.example.com", "http://localhost:3000"}},
+		{name: "missing scheme", origins: []string{"dash.example.com"}, wantErr: true},
+		{name: "with path", origins: []string{"https://dash.example.com/app"}, wantErr: true},
+		{name: "other scheme", origins: []string{"ftp://dash.example.com"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.CORSOrigins = tt.origins
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAlertWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook string
+		wantErr bool
+	}{
+		{name: "none", webhook: ""},
+		{name: "https", webhook: "https://hooks.example.com/services/T000/B000/XXXX"},
+		{name: "http with port", webhook: "http://192.168.1.10:8123/api/webhook/netmon"},
+		{name: "missing scheme", webhook: "hooks.example.com/netmon", wantErr: true},
+		{name: "other scheme", webhook: "mailto:admin@example.com", wantErr: true},
+		{name: "missing host", webhook: "https:///netmon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.AlertWebhook = tt.webhook
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateBind(t *testing.T) {
+	tests := []struct {
+		name    string
+		bind    string
+		wantErr bool
+	}{
+		{name: "loopback", bind: "127.0.0.1"},
+		{name: "all interfaces", bind: "0.0.0.0"},
+		{name: "IPv6 all interfaces", bind: "::"},
+		{name: "hostname", bind: "localhost"},
+		{name: "empty", bind: "", wantErr: true},
+		{name: "with port", bind: "127.0.0.1:8080", wantErr: true},
+		{name: "URL", bind: "http://127.0.0.1", wantErr: true},
+		{name: "spaces", bind: "127.0.0.1 via eth0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Bind = tt.bind
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateBasePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+		wantErr  bool
+	}{
+		{name: "root", basePath: ""},
+		{name: "slash", basePath: "/"},
+		{name: "prefix", basePath: "/netmon"},
+		{name: "trailing slash", basePath: "/tools/netmon/"},
+		{name: "relative", basePath: "netmon", wantErr: true},
+		{name: "dot segments", basePath: "/netmon/../admin", wantErr: true},
+		{name: "double slash", basePath: "//netmon", wantErr: true},
+		{name: "query", basePath: "/netmon?x=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.BasePath = tt.basePath
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDisplay(t *testing.T) {
+	tests := []struct {
+		name    string
+		theme   string
+		hours   int
+		refresh time.Duration
+		wantErr bool
+	}{
+		{name: "defaults", theme: "auto", hours: 24, refresh: 30 * time.Second},
+		{name: "unset", theme: ""},
+		{name: "dark without refresh", theme: "dark", hours: 1, refresh: 0},
+		{name: "unknown theme", theme: "solarized", wantErr: true},
+		{name: "negative hours", theme: "light", hours: -1, wantErr: true},
+		{name: "negative refresh", theme: "light", refresh: -time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Theme = tt.theme
+			cfg.DefaultHours = tt.hours
+			cfg.Refresh = tt.refresh
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFlagsDisplay(t *testing.T) {
+	cfg, err := ParseFlags([]string{
+		"-config", filepath.Join(t.TempDir(), "missing.yml"),
+		"-theme", "dark", "-default-hours", "6", "-refresh-interval", "1m",
+	})
+	if err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if cfg.Theme != "dark" || cfg.DefaultHours != 6 || cfg.Refresh != time.Minute {
+		t.Errorf("display = %q, %d, %v; want dark, 6, 1m", cfg.Theme, cfg.DefaultHours, cfg.Refresh)
+	}
+}
+
+func TestParseFlagsRTTBuckets(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.yml")
+	cfg, err := ParseFlags([]string{"-config", missing})
+	if err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.RTTBuckets, models.DefaultRTTBuckets) {
+		t.Errorf("default RTTBuckets = %v, want %v", cfg.RTTBuckets, models.DefaultRTTBuckets)
+	}
+
+	cfg, err = ParseFlags([]string{"-config", missing, "-rtt-buckets", "2, 20,200.5"})
+	if err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if want := []float64{2, 20, 200.5}; !reflect.DeepEqual(cfg.RTTBuckets, want) {
+		t.Errorf("RTTBuckets = %v, want %v", cfg.RTTBuckets, want)
+	}
+
+	for _, buckets := range [][]float64{{10, 5}, {5, 5}, {0, 5}, {-1}} {
+		cfg := validConfig()
+		cfg.RTTBuckets = buckets
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate() with RTT buckets %v succeeded, want an error", buckets)
+		}
+	}
+}
+
+func TestParseReportFlagsTimeout(t *testing.T) {
+	cfg, err := ParseReportFlags(nil)
+	if err != nil {
+		t.Fatalf("ParseReportFlags() error = %v", err)
+	}
+	if cfg.QueryTimeout != report.DefaultQueryTimeout {
+		t.Errorf("default QueryTimeout = %v, want the generator's %v", cfg.QueryTimeout, report.DefaultQueryTimeout)
+	}
+
+	cfg, err = ParseReportFlags([]string{"-report-timeout", "0"})
+	if err != nil {
+		t.Fatalf("ParseReportFlags() error = %v", err)
+	}
+	if cfg.QueryTimeout != 0 {
+		t.Errorf("QueryTimeout = %v, want 0 for no limit", cfg.QueryTimeout)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "0", want: 0},
+		{value: "1048576", want: 1 << 20},
+		{value: "512KB", want: 512 << 10},
+		{value: "500mb", want: 500 << 20},
+		{value: "1.5 GB", want: 3 << 29},
+		{value: "2G", want: 2 << 30},
+		{value: "100B", want: 100},
+		{value: "", wantErr: true},
+		{value: "MB", wantErr: true},
+		{value: "-1MB", wantErr: true},
+		{value: "10PB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseByteSize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseByteSize(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseByteSize(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitOrigins(t *testing.T) {
+	got := splitOrigins(" https://dash.example.com/ ,, http://localhost:3000")
+	want := []string{"https://dash.example.com", "http://localhost:3000"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitOrigins() = %q, want %q", got, want)
+	}
+}
+
+func TestSplitTargetsNormalizesVia(t *testing.T) {
+	got := splitTargets(" 8.8.8.8  via   eth0 ,8.8.8.8 via wwan0,, 1.1.1.1")
+	want := []string{"8.8.8.8 via eth0", "8.8.8.8 via wwan0", "1.1.1.1"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitTargets() = %q, want %q", got, want)
+	}
+}
+
+func TestSplitTargets(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{name: "spaces after commas", raw: "8.8.8.8, 1.1.1.1 ,\t9.9.9.9", want: []string{"8.8.8.8", "1.1.1.1", "9.9.9.9"}},
+		{name: "empty entries", raw: ",8.8.8.8,,  ,1.1.1.1,", want: []string{"8.8.8.8", "1.1.1.1"}},
+		{name: "duplicates keep first position", raw: "1.1.1.1,8.8.8.8, 1.1.1.1,8.8.8.8", want: []string{"1.1.1.1", "8.8.8.8"}},
+		{name: "duplicates after normalizing", raw: "8.8.8.8 via eth0,8.8.8.8  via eth0", want: []string{"8.8.8.8 via eth0"}},
+		{name: "only separators", raw: " , ,", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitTargets(tt.raw); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitTargets(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlagsTargets(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "default", args: nil, want: splitTargets(defaultTargets)},
+		{name: "comma list", args: []string{"-targets", "8.8.8.8, 1.1.1.1"}, want: []string{"8.8.8.8", "1.1.1.1"}},
+		{
+			name: "repeated flag keeps commas",
+			args: []string{"-target", "8.8.8.8", "-target", "https://example.com/health?a=1,2"},
+			want: []string{"8.8.8.8", "https://example.com/health?a=1,2"},
+		},
+		{
+			name: "both forms",
+			args: []string{"-target", "9.9.9.9", "-targets", "8.8.8.8,1.1.1.1", "-target", "8.8.8.8"},
+			want: []string{"8.8.8.8", "1.1.1.1", "9.9.9.9"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-config", filepath.Join(t.TempDir(), "missing.yml")}, tt.args...)
+			cfg, err := ParseFlags(args)
+			if err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Targets, tt.want) {
+				t.Errorf("Targets = %q, want %q", cfg.Targets, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFileTargetsCleaned(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	yaml := "targets:\n  - \" 8.8.8.8\"\n  - \"\"\n  - 1.1.1.1\n  - \"8.8.8.8 \"\n"
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := ParseFlags([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if want := []string{"8.8.8.8", "1.1.1.1"}; !reflect.DeepEqual(cfg.Targets, want) {
+		t.Errorf("Targets = %q, want %q", cfg.Targets, want)
+	}
+}
+
+func TestConfigFileTargetMeta(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	yaml := `targets:
+  - 9.9.9.9
+  - target: " 8.8.8.8"
+    name: Comcast WAN
+    color: "#1f77b4"
+  - target: 1.1.1.1 via wwan0
+    name: Backup LTE
+  - target: 192.0.2.1
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := ParseFlags([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if want := []string{"9.9.9.9", "8.8.8.8", "1.1.1.1 via wwan0", "192.0.2.1"}; !reflect.DeepEqual(cfg.Targets, want) {
+		t.Errorf("Targets = %q, want %q", cfg.Targets, want)
+	}
+	want := []models.TargetMeta{
+		{Target: "8.8.8.8", Name: "Comcast WAN", Color: "#1f77b4"},
+		{Target: "1.1.1.1 via wwan0", Name: "Backup LTE"},
+	}
+	if !reflect.DeepEqual(cfg.TargetMeta, want) {
+		t.Errorf("TargetMeta = %+v, want %+v", cfg.TargetMeta, want)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestValidateTargetMeta(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    models.TargetMeta
+		wantErr bool
+	}{
+		{name: "name and color", meta: models.TargetMeta{Target: "8.8.8.8", Name: "AT&T Fiber", Color: "#1F77B4"}},
+		{name: "short color", meta: models.TargetMeta{Target: "8.8.8.8", Color: "#abc"}},
+		{name: "unmonitored target", meta: models.TargetMeta{Target: "1.1.1.1", Name: "Cloudflare"}, wantErr: true},
+		{name: "color name", meta: models.TargetMeta{Target: "8.8.8.8", Color: "red"}, wantErr: true},
+		{name: "CSS injection", meta: models.TargetMeta{Target: "8.8.8.8", Color: "#fff;background:url(x)"}, wantErr: true},
+		{name: "markup in name", meta: models.TargetMeta{Target: "8.8.8.8", Name: "<img src=x onerror=alert(1)>"}, wantErr: true},
+		{name: "control character in name", meta: models.TargetMeta{Target: "8.8.8.8", Name: "WAN\n1"}, wantErr: true},
+		{name: "overlong name", meta: models.TargetMeta{Target: "8.8.8.8", Name: strings.Repeat("x", 65)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.TargetMeta = []models.TargetMeta{tt.meta}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFlagsRejectsEmptyTargets(t *testing.T) {
+	cfg, err := ParseFlags([]string{"-config", filepath.Join(t.TempDir(), "missing.yml"), "-targets", ","})
+	if err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "at least one target") {
+		t.Errorf("Validate() error = %v, want one about missing targets", err)
+	}
+}
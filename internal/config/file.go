@@ -8,18 +8,114 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"network-monitor/internal/models"
 )
 
 const defaultConfigPath = "config/config.yml"
 
 // fileConfig represents the YAML configuration structure.
 type fileConfig struct {
-	Targets      []string `yaml:"targets"`
-	Interval     string   `yaml:"interval"`
-	Timeout      string   `yaml:"timeout"`
-	DatabasePath string   `yaml:"database_path"`
-	Port         *int     `yaml:"port"`
-	DevMode      *bool    `yaml:"dev_mode"`
+	Targets []fileTarget `yaml:"targets"` // target specs, or mappings that also name and color them
+
+	Interval        string    `yaml:"interval"`
+	Timeout         string    `yaml:"timeout"`
+	Adaptive        *bool     `yaml:"adaptive"`
+	AdaptiveMax     string    `yaml:"adaptive_max_interval"`
+	PingRetries     *int      `yaml:"ping_retries"`
+	PingConcurrency *int      `yaml:"ping_concurrency"`
+	PingBinary      string    `yaml:"ping_binary"`
+	PingArgs        []string  `yaml:"ping_args"`
+	NoStagger       *bool     `yaml:"no_stagger"`
+	Traceroute      *bool     `yaml:"traceroute_on_failure"`
+	IncludeLoopback *bool     `yaml:"include_loopback"`
+	OutageWindow    *int      `yaml:"outage_window"`
+	OutageFailures  *int      `yaml:"outage_failures"`
+	OutageRecovery  *int      `yaml:"outage_recovery"`
+	PreservePad     string    `yaml:"preserve_outage_padding"`
+	Maintenance     string    `yaml:"maintenance_interval"`
+	MaxDBSize       string    `yaml:"max_db_size"`
+	AlertCooldown   string    `yaml:"alert_cooldown"`
+	AlertRTT        *float64  `yaml:"alert_rtt_threshold"`
+	AlertJitter     *float64  `yaml:"alert_jitter_threshold"`
+	AlertWindow     string    `yaml:"alert_window"`
+	AlertWebhook    string    `yaml:"alert_webhook"`
+	AlertSelfTest   *bool     `yaml:"alert_selftest_on_start"`
+	Timezone        string    `yaml:"timezone"`
+	InstanceName    string    `yaml:"instance_name"`
+	DBDriver        string    `yaml:"db_driver"`
+	DBDSN           string    `yaml:"db_dsn"`
+	DatabasePath    string    `yaml:"database_path"`
+	JournalMode     string    `yaml:"journal_mode"`
+	DBOpenRetries   *int      `yaml:"db_open_retries"`
+	VacuumOnStart   *bool     `yaml:"vacuum_on_start"`
+	RecoverCorrupt  *bool     `yaml:"recover_corrupt"`
+	Bind            string    `yaml:"bind"`
+	Port            *int      `yaml:"port"`
+	GRPCPort        *int      `yaml:"grpc_port"`
+	BasePath        string    `yaml:"base_path"`
+	CORSOrigins     []string  `yaml:"cors_origins"`
+	RateLimit       *float64  `yaml:"rate_limit"`
+	AccessLog       *bool     `yaml:"access_log"`
+	QueryTimeout    string    `yaml:"query_timeout"`
+	AnomalySigma    *float64  `yaml:"anomaly_sigma"`
+	WarnRTT         *float64  `yaml:"warn_rtt"`
+	BadRTT          *float64  `yaml:"bad_rtt"`
+	WarnLoss        *float64  `yaml:"warn_loss"`
+	BadLoss         *float64  `yaml:"bad_loss"`
+	RTTBuckets      []float64 `yaml:"rtt_buckets"`
+	Theme           string    `yaml:"theme"`
+	DefaultHours    *int      `yaml:"default_hours"`
+	RefreshInterval string    `yaml:"refresh_interval"`
+	DevMode         *bool     `yaml:"dev_mode"`
+	DebugOutput     *bool     `yaml:"debug_store_output"`
+	InfluxURL       string    `yaml:"influx_url"`
+	InfluxToken     string    `yaml:"influx_token"`
+	InfluxOrg       string    `yaml:"influx_org"`
+	InfluxBucket    string    `yaml:"influx_bucket"`
+	InfluxDatabase  string    `yaml:"influx_db"`
+	MQTTBroker      string    `yaml:"mqtt_broker"`
+	MQTTTopicPrefix string    `yaml:"mqtt_topic_prefix"`
+	MQTTUsername    string    `yaml:"mqtt_username"`
+	MQTTPassword    string    `yaml:"mqtt_password"`
+}
+
+// fileTarget is an entry of the config file's targets list: the target spec
+// alone, or a mapping that also gives the dashboard a name and color for it
+//
+//	targets:
+//	  - 8.8.8.8
+//	  - target: 1.1.1.1
+//	    name: Comcast WAN
+//	    color: "#e4572e"
+type fileTarget struct {
+	Target string `yaml:"target"`
+	Name   string `yaml:"name"`
+	Color  string `yaml:"color"`
+}
+
+// UnmarshalYAML accepts a plain target spec as well as the mapping form
+func (t *fileTarget) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&t.Target)
+	}
+	type mapping fileTarget // without this method, so decoding doesn't recurse
+	return node.Decode((*mapping)(t))
+}
+
+// fileTargetMeta returns the names and colors the targets list gives, keyed
+// by the cleaned target spec
+func fileTargetMeta(targets []fileTarget) []models.TargetMeta {
+	var meta []models.TargetMeta
+	for _, t := range targets {
+		spec := cleanTargets([]string{t.Target})
+		name, color := strings.TrimSpace(t.Name), strings.TrimSpace(t.Color)
+		if len(spec) == 0 || (name == "" && color == "") {
+			continue
+		}
+		meta = append(meta, models.TargetMeta{Target: spec[0], Name: name, Color: color})
+	}
+	return meta
 }
 
 func mergeConfigFile(base Config, path string) (Config, error) {
@@ -40,17 +136,13 @@ func mergeConfigFile(base Config, path string) (Config, error) {
 		return Config{}, fmt.Errorf("parse config file %q: %w", path, err)
 	}
 
-	if len(cfg.Targets) > 0 {
-		cleanedTargets := make([]string, 0, len(cfg.Targets))
-		for _, target := range cfg.Targets {
-			trimmed := strings.TrimSpace(target)
-			if trimmed != "" {
-				cleanedTargets = append(cleanedTargets, trimmed)
-			}
-		}
-		if len(cleanedTargets) > 0 {
-			base.Targets = cleanedTargets
-		}
+	specs := make([]string, len(cfg.Targets))
+	for i, t := range cfg.Targets {
+		specs[i] = t.Target
+	}
+	if cleanedTargets := cleanTargets(specs); len(cleanedTargets) > 0 {
+		base.Targets = cleanedTargets
+		base.TargetMeta = fileTargetMeta(cfg.Targets)
 	}
 
 	if cfg.Interval != "" {
@@ -69,17 +161,268 @@ func mergeConfigFile(base Config, path string) (Config, error) {
 		base.Timeout = duration
 	}
 
+	if cfg.Adaptive != nil {
+		base.Adaptive = *cfg.Adaptive
+	}
+
+	if cfg.AdaptiveMax != "" {
+		duration, err := time.ParseDuration(cfg.AdaptiveMax)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid adaptive max interval duration %q: %w", cfg.AdaptiveMax, err)
+		}
+		base.AdaptiveMax = duration
+	}
+
+	if cfg.PingRetries != nil {
+		base.PingRetries = *cfg.PingRetries
+	}
+
+	if cfg.PingConcurrency != nil {
+		base.PingWorkers = *cfg.PingConcurrency
+	}
+
+	if cfg.PingBinary != "" {
+		base.PingBinary = cfg.PingBinary
+	}
+
+	if len(cfg.PingArgs) > 0 {
+		base.PingArgs = cfg.PingArgs
+	}
+
+	if cfg.NoStagger != nil {
+		base.NoStagger = *cfg.NoStagger
+	}
+
+	if cfg.Traceroute != nil {
+		base.Traceroute = *cfg.Traceroute
+	}
+
+	if cfg.IncludeLoopback != nil {
+		base.Loopback = *cfg.IncludeLoopback
+	}
+
+	if cfg.OutageWindow != nil {
+		base.OutageWindow = *cfg.OutageWindow
+	}
+
+	if cfg.OutageFailures != nil {
+		base.OutageFailures = *cfg.OutageFailures
+	}
+
+	if cfg.OutageRecovery != nil {
+		base.OutageRecovery = *cfg.OutageRecovery
+	}
+
+	if cfg.PreservePad != "" {
+		duration, err := time.ParseDuration(cfg.PreservePad)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid outage preservation padding %q: %w", cfg.PreservePad, err)
+		}
+		base.PreservePad = duration
+	}
+
+	if cfg.Maintenance != "" {
+		duration, err := time.ParseDuration(cfg.Maintenance)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid maintenance interval %q: %w", cfg.Maintenance, err)
+		}
+		base.Maintenance = duration
+	}
+
+	if cfg.MaxDBSize != "" {
+		size, err := parseByteSize(cfg.MaxDBSize)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid maximum database size: %w", err)
+		}
+		base.MaxDBSize = size
+	}
+
+	if cfg.AlertCooldown != "" {
+		duration, err := time.ParseDuration(cfg.AlertCooldown)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid alert cooldown duration %q: %w", cfg.AlertCooldown, err)
+		}
+		base.AlertCooldown = duration
+	}
+
+	if cfg.AlertRTT != nil {
+		base.AlertRTTThreshold = *cfg.AlertRTT
+	}
+
+	if cfg.AlertJitter != nil {
+		base.AlertJitterThreshold = *cfg.AlertJitter
+	}
+
+	if cfg.AlertWindow != "" {
+		duration, err := time.ParseDuration(cfg.AlertWindow)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid alert window duration %q: %w", cfg.AlertWindow, err)
+		}
+		base.AlertWindow = duration
+	}
+
+	if cfg.AlertWebhook != "" {
+		base.AlertWebhook = cfg.AlertWebhook
+	}
+
+	if cfg.AlertSelfTest != nil {
+		base.AlertSelfTest = *cfg.AlertSelfTest
+	}
+
+	if cfg.Timezone != "" {
+		base.Timezone = cfg.Timezone
+	}
+
+	if cfg.InstanceName != "" {
+		base.InstanceName = cfg.InstanceName
+	}
+
+	if cfg.DBDriver != "" {
+		base.DBDriver = cfg.DBDriver
+	}
+
+	if cfg.DBDSN != "" {
+		base.DBDSN = cfg.DBDSN
+	}
+
 	if cfg.DatabasePath != "" {
 		base.DatabasePath = cfg.DatabasePath
 	}
 
+	if cfg.JournalMode != "" {
+		base.JournalMode = cfg.JournalMode
+	}
+
+	if cfg.DBOpenRetries != nil {
+		base.DBOpenRetries = *cfg.DBOpenRetries
+	}
+
+	if cfg.VacuumOnStart != nil {
+		base.VacuumOnStart = *cfg.VacuumOnStart
+	}
+
+	if cfg.RecoverCorrupt != nil {
+		base.RecoverCorrupt = *cfg.RecoverCorrupt
+	}
+
+	if cfg.Bind != "" {
+		base.Bind = cfg.Bind
+	}
+
 	if cfg.Port != nil {
 		base.Port = *cfg.Port
 	}
 
+	if cfg.GRPCPort != nil {
+		base.GRPCPort = *cfg.GRPCPort
+	}
+
+	if cfg.BasePath != "" {
+		base.BasePath = cfg.BasePath
+	}
+
+	if len(cfg.CORSOrigins) > 0 {
+		base.CORSOrigins = cleanOrigins(cfg.CORSOrigins)
+	}
+
+	if cfg.RateLimit != nil {
+		base.RateLimit = *cfg.RateLimit
+	}
+
+	if cfg.AccessLog != nil {
+		base.AccessLog = *cfg.AccessLog
+	}
+
+	if cfg.QueryTimeout != "" {
+		duration, err := time.ParseDuration(cfg.QueryTimeout)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid query timeout duration %q: %w", cfg.QueryTimeout, err)
+		}
+		base.QueryTimeout = duration
+	}
+
+	if cfg.AnomalySigma != nil {
+		base.AnomalySigma = *cfg.AnomalySigma
+	}
+
+	if cfg.WarnRTT != nil {
+		base.WarnRTT = *cfg.WarnRTT
+	}
+
+	if cfg.BadRTT != nil {
+		base.BadRTT = *cfg.BadRTT
+	}
+
+	if cfg.WarnLoss != nil {
+		base.WarnLoss = *cfg.WarnLoss
+	}
+
+	if cfg.BadLoss != nil {
+		base.BadLoss = *cfg.BadLoss
+	}
+	if len(cfg.RTTBuckets) > 0 {
+		base.RTTBuckets = cfg.RTTBuckets
+	}
+
+	if cfg.Theme != "" {
+		base.Theme = cfg.Theme
+	}
+
+	if cfg.DefaultHours != nil {
+		base.DefaultHours = *cfg.DefaultHours
+	}
+
+	if cfg.RefreshInterval != "" {
+		duration, err := time.ParseDuration(cfg.RefreshInterval)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid refresh interval duration %q: %w", cfg.RefreshInterval, err)
+		}
+		base.Refresh = duration
+	}
+
 	if cfg.DevMode != nil {
 		base.DevMode = *cfg.DevMode
 	}
 
+	if cfg.DebugOutput != nil {
+		base.DebugOutput = *cfg.DebugOutput
+	}
+
+	if cfg.InfluxURL != "" {
+		base.InfluxURL = cfg.InfluxURL
+	}
+
+	if cfg.InfluxToken != "" {
+		base.InfluxToken = cfg.InfluxToken
+	}
+
+	if cfg.InfluxOrg != "" {
+		base.InfluxOrg = cfg.InfluxOrg
+	}
+
+	if cfg.InfluxBucket != "" {
+		base.InfluxBucket = cfg.InfluxBucket
+	}
+
+	if cfg.InfluxDatabase != "" {
+		base.InfluxDatabase = cfg.InfluxDatabase
+	}
+
+	if cfg.MQTTBroker != "" {
+		base.MQTTBroker = cfg.MQTTBroker
+	}
+
+	if cfg.MQTTTopicPrefix != "" {
+		base.MQTTTopicPrefix = cfg.MQTTTopicPrefix
+	}
+
+	if cfg.MQTTUsername != "" {
+		base.MQTTUsername = cfg.MQTTUsername
+	}
+
+	if cfg.MQTTPassword != "" {
+		base.MQTTPassword = cfg.MQTTPassword
+	}
+
 	return base, nil
 }
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// GetBaselineComparison compares the target's latency over the last hour with
+// the hourly patterns recorded at the same hour on the same weekday in earlier
+// weeks
+func (db *DB) GetBaselineComparison(ctx context.Context, target string) (models.BaselineComparison, error) {
+	currentQuery := `
+        SELECT success, rtt_ms
+        FROM ping_results
+        WHERE target = ? AND timestamp > ?
+    `
+	baselineQuery := `
+        SELECT date, total_pings - failed_pings, avg_rtt_ms, p95_rtt_ms
+        FROM hourly_patterns
+        WHERE target = ? AND hour = ? AND date >= ? AND date < ?
+    `
+	return compareToBaseline(ctx, db.DB, currentQuery, baselineQuery, target, time.Now().In(db.location))
+}
+
+// compareToBaseline builds a baseline comparison for target as of now, whose
+// location is the one hourly patterns are bucketed in. currentQuery selects the
+// success and rtt_ms of the target's results after a time; baselineQuery selects
+// the date, successful ping count, avg_rtt_ms and p95_rtt_ms of the target's
+// hourly patterns for an hour of day between two dates.
+func compareToBaseline(ctx context.Context, db *sql.DB, currentQuery, baselineQuery, target string, now time.Time) (models.BaselineComparison, error) {
+	comparison := models.BaselineComparison{Target: target, Weekday: now.Weekday().String(), Hour: now.Hour()}
+
+	rows, err := db.QueryContext(ctx, currentQuery, target, now.Add(-time.Hour).UTC())
+	if err != nil {
+		return comparison, fmt.Errorf("query last hour: %w", err)
+	}
+	var current patternStats
+	for rows.Next() {
+		var success bool
+		var rtt float64
+		if err := rows.Scan(&success, &rtt); err != nil {
+			continue
+		}
+		current.add(success, rtt)
+		if success && rtt > 0 {
+			current.rttValues = append(current.rttValues, rtt)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return comparison, fmt.Errorf("query last hour: %w", err)
+	}
+	comparison.CurrentPings = current.total
+	if current.timed > 0 {
+		comparison.CurrentAvgRTT = current.rttSum / float64(current.timed)
+		comparison.CurrentP95RTT = percentile(current.rttValues, 95)
+	}
+
+	// Hourly patterns are kept for 90 days
+	today := now.Format("2006-01-02")
+	from := now.AddDate(0, 0, -90).Format("2006-01-02")
+	rows, err = db.QueryContext(ctx, baselineQuery, target, now.Hour(), from, today)
+	if err != nil {
+		return comparison, fmt.Errorf("query baseline: %w", err)
+	}
+	if err := addBaseline(&comparison, rows, now.Weekday()); err != nil {
+		return comparison, fmt.Errorf("query baseline: %w", err)
+	}
+
+	comparison.AvgDeviation = deviation(comparison.CurrentAvgRTT, comparison.BaselineAvgRTT)
+	comparison.P95Deviation = deviation(comparison.CurrentP95RTT, comparison.BaselineP95RTT)
+	return comparison, nil
+}
+
+// addBaseline reads date, successful ping count, avg_rtt_ms and p95_rtt_ms
+// hourly pattern rows into the comparison's baseline, skipping dates on other
+// weekdays, and closes the rows. The average is weighted by successful pings;
+// the 95th percentile is the mean of the weeks that have one, since patterns
+// aggregated before it was recorded lack it.
+func addBaseline(comparison *models.BaselineComparison, rows *sql.Rows, weekday time.Weekday) error {
+	defer rows.Close()
+
+	var weighted, p95Sum float64
+	var successful, p95Weeks int
+	for rows.Next() {
+		var date time.Time
+		var count int
+		var avgRTT, p95RTT sql.NullFloat64
+		if err := rows.Scan(&date, &count, &avgRTT, &p95RTT); err != nil {
+			continue
+		}
+		if date.Weekday() != weekday || !avgRTT.Valid || count == 0 {
+			continue
+		}
+		comparison.BaselineWeeks++
+		weighted += avgRTT.Float64 * float64(count)
+		successful += count
+		if p95RTT.Valid {
+			p95Sum += p95RTT.Float64
+			p95Weeks++
+		}
+	}
+	if successful > 0 {
+		comparison.BaselineAvgRTT = weighted / float64(successful)
+	}
+	if p95Weeks > 0 {
+		comparison.BaselineP95RTT = p95Sum / float64(p95Weeks)
+	}
+	return rows.Err()
+}
+
+// deviation returns how far current is above baseline as a percentage of it,
+// rounded to two decimals; 0 when either is missing
+func deviation(current, baseline float64) float64 {
+	if current == 0 || baseline == 0 {
+		return 0
+	}
+	return math.Round((current-baseline)/baseline*100*100) / 100
+}
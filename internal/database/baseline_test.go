@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestGetBaselineComparison(t *testing.T) {
+	db := newTestDB(t)
+	db.SetLocation(time.UTC)
+
+	now := time.Now().UTC()
+	hour := now.Hour()
+
+	// Two earlier weeks at this hour of the week, plus rows that don't belong
+	// in the baseline: another hour, another weekday and another target
+	patterns := []struct {
+		daysAgo  int
+		hour     int
+		target   string
+		total    int
+		avgRTT   float64
+		p95RTT   any
+		baseline bool
+	}{
+		{7, hour, "8.8.8.8", 60, 20, 30.0, true},
+		{14, hour, "8.8.8.8", 30, 26, nil, true}, // aggregated before p95 was recorded
+		{7, (hour + 1) % 24, "8.8.8.8", 60, 500, 900.0, false},
+		{3, hour, "8.8.8.8", 60, 500, 900.0, false},
+		{7, hour, "1.1.1.1", 60, 500, 900.0, false},
+	}
+	for _, p := range patterns {
+		date := now.AddDate(0, 0, -p.daysAgo).Format("2006-01-02")
+		if _, err := db.Exec(`
+            INSERT INTO hourly_patterns (date, hour, target, total_pings, failed_pings, avg_rtt_ms, max_rtt_ms, p95_rtt_ms, failure_rate)
+            VALUES (?, ?, ?, ?, 0, ?, ?, ?, 0)
+        `, date, p.hour, p.target, p.total, p.avgRTT, p.avgRTT, p.p95RTT); err != nil {
+			t.Fatalf("insert hourly pattern: %v", err)
+		}
+	}
+
+	// A degraded last hour: 20 pings at 60ms with one 174ms spike and one loss
+	for i := 0; i < 20; i++ {
+		result := models.PingResult{Timestamp: now.Add(-time.Duration(i+1) * time.Minute), Target: "8.8.8.8", Success: true, RTT: 60}
+		switch i {
+		case 0:
+			result.RTT = 174
+		case 1:
+			result.Success, result.RTT = false, 0
+		}
+		if err := db.SaveResult(context.Background(), result); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	got, err := db.GetBaselineComparison(context.Background(), "8.8.8.8")
+	if err != nil {
+		t.Fatalf("GetBaselineComparison() error = %v", err)
+	}
+
+	want := models.BaselineComparison{
+		Target:         "8.8.8.8",
+		Weekday:        now.Weekday().String(),
+		Hour:           hour,
+		CurrentPings:   20,
+		CurrentAvgRTT:  66,
+		CurrentP95RTT:  174,
+		BaselineWeeks:  2,
+		BaselineAvgRTT: 22,  // (60*20 + 30*26) / 90
+		BaselineP95RTT: 30,  // only the week that recorded it
+		AvgDeviation:   200, // three times slower than usual
+		P95Deviation:   480,
+	}
+	if got != want {
+		t.Errorf("GetBaselineComparison() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	none, err := db.GetBaselineComparison(context.Background(), "9.9.9.9")
+	if err != nil {
+		t.Fatalf("GetBaselineComparison(unknown) error = %v", err)
+	}
+	if none.CurrentPings != 0 || none.BaselineWeeks != 0 || none.AvgDeviation != 0 || none.P95Deviation != 0 {
+		t.Errorf("GetBaselineComparison(unknown) = %+v, want no data and no deviation", none)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{7}, 7},
+		{[]float64{5, 1, 4, 2, 3}, 5},
+		{[]float64{20, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, 19},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.values, 95); got != tt.want {
+			t.Errorf("percentile(%v, 95) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// compareBucketSizes are the bucket widths a target comparison picks from:
+// the smallest that keeps the period within maxCompareBuckets
+var compareBucketSizes = []time.Duration{5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour}
+
+// maxCompareBuckets is the most buckets a target comparison returns, short of
+// periods too long for even the widest bucket size
+const maxCompareBuckets = 100
+
+// comparisonLossMargin is how many percentage points lower one target's packet
+// loss must be for it to win a comparison on loss alone; within the margin the
+// lower average RTT wins
+const comparisonLossMargin = 1.0
+
+// CompareTargets compares the latency and packet loss of targets a and b over
+// the last hours, in aligned buckets
+func (db *DB) CompareTargets(ctx context.Context, a, b string, hours int) (models.TargetComparison, error) {
+	query := `
+        SELECT target, timestamp, success, rtt_ms
+        FROM ping_results
+        WHERE (target = ? OR target = ?) AND timestamp > ?
+    `
+	return compareTargets(ctx, db.DB, query, a, b, hours, time.Now())
+}
+
+// compareTargets builds the comparison of targets a and b over the hours up to
+// now. query selects the target, timestamp, success and rtt_ms of the results
+// of either of two targets after a time.
+func compareTargets(ctx context.Context, db *sql.DB, query, a, b string, hours int, now time.Time) (models.TargetComparison, error) {
+	span := time.Duration(hours) * time.Hour
+	width := compareBucketSize(span)
+	comparison := models.TargetComparison{
+		A:             a,
+		B:             b,
+		Since:         now.Add(-span),
+		Until:         now,
+		BucketSeconds: int(width.Seconds()),
+		Buckets:       []models.ComparisonBucket{},
+	}
+
+	rows, err := db.QueryContext(ctx, query, a, b, comparison.Since.UTC())
+	if err != nil {
+		return comparison, fmt.Errorf("query results: %w", err)
+	}
+	defer rows.Close()
+
+	type bucketStats struct{ a, b patternStats }
+	buckets := make(map[time.Time]*bucketStats)
+	var totalA, totalB patternStats
+	for rows.Next() {
+		var target string
+		var timestamp time.Time
+		var success bool
+		var rtt float64
+		if err := rows.Scan(&target, &timestamp, &success, &rtt); err != nil {
+			continue
+		}
+		start := timestamp.UTC().Truncate(width)
+		bucket := buckets[start]
+		if bucket == nil {
+			bucket = &bucketStats{}
+			buckets[start] = bucket
+		}
+		switch target {
+		case a:
+			bucket.a.add(success, rtt)
+			totalA.add(success, rtt)
+		case b:
+			bucket.b.add(success, rtt)
+			totalB.add(success, rtt)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return comparison, fmt.Errorf("query results: %w", err)
+	}
+
+	for start := comparison.Since.UTC().Truncate(width); !start.After(now); start = start.Add(width) {
+		bucket := buckets[start]
+		if bucket == nil {
+			continue
+		}
+		comparison.Buckets = append(comparison.Buckets, models.ComparisonBucket{
+			Start:   start,
+			APings:  bucket.a.total,
+			BPings:  bucket.b.total,
+			AAvgRTT: bucket.a.avgRTT(),
+			BAvgRTT: bucket.b.avgRTT(),
+			ALoss:   bucket.a.loss(),
+			BLoss:   bucket.b.loss(),
+		})
+	}
+
+	comparison.AAvgRTT, comparison.BAvgRTT = totalA.avgRTT(), totalB.avgRTT()
+	comparison.ALoss, comparison.BLoss = totalA.loss(), totalB.loss()
+	comparison.RTTDelta = math.Round((comparison.BAvgRTT-comparison.AAvgRTT)*100) / 100
+	comparison.LossDelta = math.Round((comparison.BLoss-comparison.ALoss)*100) / 100
+	if totalA.total > 0 && totalB.total > 0 {
+		comparison.Winner = comparisonWinner(comparison)
+	}
+	return comparison, nil
+}
+
+// compareBucketSize returns the narrowest bucket width that splits span into
+// at most maxCompareBuckets buckets, or the widest there is
+func compareBucketSize(span time.Duration) time.Duration {
+	for _, size := range compareBucketSizes {
+		if span <= size*maxCompareBuckets {
+			return size
+		}
+	}
+	return compareBucketSizes[len(compareBucketSizes)-1]
+}
+
+// comparisonWinner returns which of the compared targets is better: the one
+// with clearly less packet loss, otherwise the one with the lower average RTT,
+// or "" on a tie
+func comparisonWinner(comparison models.TargetComparison) string {
+	switch {
+	case comparison.LossDelta <= -comparisonLossMargin:
+		return comparison.B
+	case comparison.LossDelta >= comparisonLossMargin:
+		return comparison.A
+	case comparison.AAvgRTT == 0 || comparison.BAvgRTT == 0:
+		return ""
+	case comparison.RTTDelta < 0:
+		return comparison.B
+	case comparison.RTTDelta > 0:
+		return comparison.A
+	}
+	return ""
+}
+
+// avgRTT returns the average RTT of the successful pings that have one,
+// rounded to two decimals; 0 when there are none
+func (p *patternStats) avgRTT() float64 {
+	if p.timed == 0 {
+		return 0
+	}
+	return math.Round(p.rttSum/float64(p.timed)*100) / 100
+}
+
+// loss returns the percentage of failed pings, 0 when there are none
+func (p *patternStats) loss() float64 {
+	if p.total == 0 {
+		return 0
+	}
+	return p.failureRate()
+}
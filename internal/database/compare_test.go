@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestCompareTargets(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC()
+
+	// 8.8.8.8 is slower but never drops a ping; 1.1.1.1 is faster but loses
+	// one ping in ten. Both are pinged at the same times, plus a result before
+	// the period and one of another target that don't belong.
+	save := func(result models.PingResult) {
+		t.Helper()
+		if err := db.SaveResult(context.Background(), result); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		at := now.Add(-time.Duration(i*5+1) * time.Minute)
+		save(models.PingResult{Timestamp: at, Target: "8.8.8.8", Success: true, RTT: 30})
+		if i < 2 {
+			save(models.PingResult{Timestamp: at, Target: "1.1.1.1"})
+		} else {
+			save(models.PingResult{Timestamp: at, Target: "1.1.1.1", Success: true, RTT: 12})
+		}
+	}
+	save(models.PingResult{Timestamp: now.Add(-3 * time.Hour), Target: "8.8.8.8", Success: true, RTT: 900})
+	save(models.PingResult{Timestamp: now.Add(-time.Minute), Target: "9.9.9.9", Success: true, RTT: 900})
+
+	got, err := db.CompareTargets(context.Background(), "8.8.8.8", "1.1.1.1", 2)
+	if err != nil {
+		t.Fatalf("CompareTargets() error = %v", err)
+	}
+	if got.AAvgRTT != 30 || got.BAvgRTT != 12 || got.ALoss != 0 || got.BLoss != 10 {
+		t.Errorf("averages = %v/%v ms, loss = %v/%v%%, want 30/12 ms and 0/10%%", got.AAvgRTT, got.BAvgRTT, got.ALoss, got.BLoss)
+	}
+	if got.RTTDelta != -18 || got.LossDelta != 10 {
+		t.Errorf("RTTDelta = %v, LossDelta = %v, want -18 and 10", got.RTTDelta, got.LossDelta)
+	}
+	if got.Winner != "8.8.8.8" {
+		t.Errorf("Winner = %q, want 8.8.8.8 for losing no pings", got.Winner)
+	}
+	if got.BucketSeconds != 300 {
+		t.Errorf("BucketSeconds = %d, want 300 for a 2 hour period", got.BucketSeconds)
+	}
+
+	var aPings, bPings int
+	for _, bucket := range got.Buckets {
+		if bucket.APings != bucket.BPings {
+			t.Errorf("bucket %s has %d and %d pings, want them aligned", bucket.Start, bucket.APings, bucket.BPings)
+		}
+		aPings += bucket.APings
+		bPings += bucket.BPings
+	}
+	if aPings != 20 || bPings != 20 {
+		t.Errorf("buckets hold %d and %d pings, want 20 each", aPings, bPings)
+	}
+
+	swapped, err := db.CompareTargets(context.Background(), "1.1.1.1", "8.8.8.8", 2)
+	if err != nil {
+		t.Fatalf("CompareTargets(swapped) error = %v", err)
+	}
+	if swapped.Winner != "8.8.8.8" || swapped.RTTDelta != 18 || swapped.LossDelta != -10 {
+		t.Errorf("swapped comparison winner = %q, deltas %v/%v, want 8.8.8.8, 18 and -10", swapped.Winner, swapped.RTTDelta, swapped.LossDelta)
+	}
+
+	unknown, err := db.CompareTargets(context.Background(), "8.8.8.8", "192.0.2.1", 2)
+	if err != nil {
+		t.Fatalf("CompareTargets(unknown) error = %v", err)
+	}
+	if unknown.Winner != "" {
+		t.Errorf("Winner = %q against a target with no pings, want none", unknown.Winner)
+	}
+}
+
+func TestComparisonWinner(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparison models.TargetComparison
+		want       string
+	}{
+		{"less loss wins", models.TargetComparison{AAvgRTT: 10, BAvgRTT: 30, LossDelta: -5, RTTDelta: 20}, "b"},
+		{"lower RTT within the loss margin", models.TargetComparison{AAvgRTT: 10, BAvgRTT: 30, LossDelta: -0.5, RTTDelta: 20}, "a"},
+		{"equal", models.TargetComparison{AAvgRTT: 10, BAvgRTT: 10}, ""},
+		{"no RTT to compare", models.TargetComparison{BAvgRTT: 10, ALoss: 100, BLoss: 99.5, LossDelta: -0.5}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.comparison.A, tt.comparison.B = "a", "b"
+			if got := comparisonWinner(tt.comparison); got != tt.want {
+				t.Errorf("comparisonWinner() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
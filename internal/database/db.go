@@ -2,20 +2,56 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
+
+	"network-monitor/internal/models"
 )
 
-// DB wraps sql.DB with additional methods
+// DB wraps sql.DB with additional methods.
+//
+// Concurrency model: SQLite permits a single writer at a time, and WAL mode only
+// lets readers proceed alongside that writer. Rather than juggling separate read
+// and write pools, all access goes through one pooled connection so statements are
+// serialized in Go, and busy_timeout covers any remaining contention with other
+// processes (e.g. the sqlite3 CLI or a backup job) holding the file lock.
 type DB struct {
 	*sql.DB
+	outageThreshold models.OutageThreshold
+	location        *time.Location // zone hourly patterns are bucketed in
+	preservePadding time.Duration  // raw results this close to an outage survive archival; 0 disables
 }
 
-// New creates a new database connection
-func New(path string) (*DB, error) {
-	// Use DSN with embedded pragmas to ensure all connections get proper settings
-	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(15000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)", path)
+// busyTimeoutMillis is how long SQLite waits on a locked database before
+// returning SQLITE_BUSY ("database is locked")
+const busyTimeoutMillis = 15000
+
+// DefaultJournalMode is the SQLite journal mode used unless overridden
+const DefaultJournalMode = "WAL"
+
+// ErrJournalMode is returned when SQLite refuses to switch to the requested journal mode
+var ErrJournalMode = errors.New("journal mode not applied")
+
+// New creates a new database connection using the given SQLite journal mode
+// (WAL unless the filesystem can't support it, e.g. some network mounts)
+func New(path, journalMode string) (*DB, error) {
+	if journalMode == "" {
+		journalMode = DefaultJournalMode
+	}
+	journalMode = strings.ToUpper(journalMode)
+
+	// Use DSN with embedded pragmas to ensure all connections get proper settings.
+	// _time_format=sqlite writes times as "2006-01-02 15:04:05.999999999-07:00",
+	// which SQLite's date functions understand, unlike the driver's default time.String() format.
+	// auto_vacuum only takes effect on a new file (or at the next VACUUM), so it comes first.
+	dsn := fmt.Sprintf("file:%s?_pragma=auto_vacuum(INCREMENTAL)&_pragma=busy_timeout(%d)&_pragma=journal_mode(%s)&_pragma=synchronous(NORMAL)&_time_format=sqlite",
+		path, busyTimeoutMillis, journalMode)
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("database open failed: %w", err)
@@ -24,72 +60,124 @@ func New(path string) (*DB, error) {
 	// Serialize all database access to eliminate SQLITE_BUSY errors
 	db.SetMaxOpenConns(1) // Only one connection at a time
 	db.SetMaxIdleConns(1) // Keep connection alive for reuse
+	db.SetConnMaxLifetime(0)
 
-	return &DB{db}, nil
+	// sql.Open is lazy; connect now so pragma failures surface here instead of on the first write
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database connect failed: %w", err)
+	}
+
+	if err := verifyPragmas(db, journalMode); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &DB{DB: db, outageThreshold: models.DefaultOutageThreshold, location: time.Local}, nil
 }
 
-// InitSchema creates all necessary tables
-func (db *DB) InitSchema() error {
-	schema := `
-    CREATE TABLE IF NOT EXISTS ping_results (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        timestamp DATETIME NOT NULL,
-        target TEXT NOT NULL,
-        success BOOLEAN NOT NULL,
-        rtt_ms REAL,
-        error_message TEXT,
-        created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-    );
-
-    CREATE INDEX IF NOT EXISTS idx_timestamp ON ping_results(timestamp);
-    CREATE INDEX IF NOT EXISTS idx_target_timestamp ON ping_results(target, timestamp);
-
-    CREATE TABLE IF NOT EXISTS outages (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        target TEXT NOT NULL,
-        start_time DATETIME NOT NULL,
-        end_time DATETIME,
-        duration_seconds INTEGER,
-        checks_failed INTEGER
-    );
-
-    CREATE TABLE IF NOT EXISTS hourly_stats (
-        hour DATETIME NOT NULL,
-        target TEXT NOT NULL,
-        total_pings INTEGER,
-        successful_pings INTEGER,
-        avg_rtt_ms REAL,
-        max_rtt_ms REAL,
-        min_rtt_ms REAL,
-        p95_rtt_ms REAL,
-        p99_rtt_ms REAL,
-        packet_loss_percent REAL,
-        PRIMARY KEY (hour, target)
-    );
-
-    -- New table for heatmap data (aggregated by hour of day)
-    CREATE TABLE IF NOT EXISTS hourly_patterns (
-        date DATE NOT NULL,
-        hour INTEGER NOT NULL, -- 0-23
-        target TEXT NOT NULL,
-        total_pings INTEGER,
-        failed_pings INTEGER,
-        avg_rtt_ms REAL,
-        max_rtt_ms REAL,
-        failure_rate REAL,
-        PRIMARY KEY (date, hour, target)
-    );
-
-    CREATE INDEX IF NOT EXISTS idx_hourly_patterns ON hourly_patterns(hour, target);
-    CREATE INDEX IF NOT EXISTS idx_hourly_patterns_date ON hourly_patterns(date);
-    CREATE INDEX IF NOT EXISTS idx_hourly_patterns_hour_date ON hourly_patterns(hour, date);
-    CREATE INDEX IF NOT EXISTS idx_ping_success_timestamp ON ping_results(success, timestamp);
-    CREATE INDEX IF NOT EXISTS idx_outages_start_time ON outages(start_time);
-    `
-
-	if _, err := db.Exec(schema); err != nil {
-		return fmt.Errorf("schema creation failed: %w", err)
+// OpenReadOnly opens an existing database without write access, for tools that
+// inspect data while the monitor may be writing to it
+func OpenReadOnly(path string) (*DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("database %s: %w", path, err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?mode=ro&_pragma=busy_timeout(%d)", path, busyTimeoutMillis)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("database open failed: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database connect failed: %w", err)
+	}
+
+	return &DB{DB: db, outageThreshold: models.DefaultOutageThreshold, location: time.Local}, nil
+}
+
+// NewMemory creates a migrated database held entirely in memory, for tests.
+// The data lives as long as the single pooled connection, so it is gone once
+// the database is closed.
+func NewMemory() (*DB, error) {
+	dsn := fmt.Sprintf("file::memory:?_pragma=busy_timeout(%d)&_time_format=sqlite", busyTimeoutMillis)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("database open failed: %w", err)
 	}
 
+	// Every new connection would open a separate, empty database
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	memory := &DB{DB: db, outageThreshold: models.DefaultOutageThreshold, location: time.Local}
+	if err := memory.InitSchema(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return memory, nil
+}
+
+// SetOutageThreshold sets how GetOutages detects outages in raw ping data
+func (db *DB) SetOutageThreshold(threshold models.OutageThreshold) {
+	db.outageThreshold = threshold
+}
+
+// SetLocation sets the time zone whose hour of day the heatmap patterns use.
+// Timestamps are always stored in UTC.
+func (db *DB) SetLocation(loc *time.Location) {
+	db.location = loc
+}
+
+// SetPreservePadding keeps raw results within padding of a recorded outage,
+// copied into preserved_results, when ArchiveOldData deletes old data; zero
+// disables it
+func (db *DB) SetPreservePadding(padding time.Duration) {
+	db.preservePadding = padding
+}
+
+// verifyPragmas reads back the connection pragmas to confirm SQLite applied them
+func verifyPragmas(db *sql.DB, journalMode string) error {
+	var timeout int
+	if err := db.QueryRow("PRAGMA busy_timeout").Scan(&timeout); err != nil {
+		return fmt.Errorf("read busy_timeout: %w", err)
+	}
+	if timeout != busyTimeoutMillis {
+		return fmt.Errorf("busy_timeout is %dms, expected %dms", timeout, busyTimeoutMillis)
+	}
+
+	var mode string
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		return fmt.Errorf("read journal_mode: %w", err)
+	}
+	if !strings.EqualFold(mode, journalMode) {
+		return fmt.Errorf("%w: requested %s, database is using %s", ErrJournalMode, journalMode, strings.ToUpper(mode))
+	}
+	if journalMode != DefaultJournalMode {
+		log.Printf("Warning: SQLite journal mode is %s; readers and the writer will block each other", journalMode)
+	}
+
+	// synchronous=NORMAL reads back as 1
+	var synchronous int
+	if err := db.QueryRow("PRAGMA synchronous").Scan(&synchronous); err != nil {
+		return fmt.Errorf("read synchronous: %w", err)
+	}
+	if synchronous != 1 {
+		return fmt.Errorf("synchronous is %d, expected 1 (NORMAL)", synchronous)
+	}
+
+	return nil
+}
+
+// InitSchema creates all necessary tables and applies any pending migrations
+func (db *DB) InitSchema() error {
+	if err := db.migrate(len(migrations)); err != nil {
+		return fmt.Errorf("schema migration failed: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,654 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// newTestDB opens a fresh database with the schema applied in a temp directory
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	db, err := New(filepath.Join(t.TempDir(), "test.db"), DefaultJournalMode)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.InitSchema(); err != nil {
+		t.Fatalf("InitSchema() error = %v", err)
+	}
+	return db
+}
+
+// lastHours returns the period of the given number of hours ending now
+func lastHours(hours int) (from, to time.Time) {
+	to = time.Now()
+	return to.Add(-time.Duration(hours) * time.Hour), to
+}
+
+func TestNewSetsBusyTimeout(t *testing.T) {
+	db := newTestDB(t)
+
+	var timeout int
+	if err := db.QueryRow("PRAGMA busy_timeout").Scan(&timeout); err != nil {
+		t.Fatalf("read busy_timeout: %v", err)
+	}
+	if timeout != busyTimeoutMillis {
+		t.Errorf("busy_timeout = %d, want %d", timeout, busyTimeoutMillis)
+	}
+}
+
+func TestNewJournalMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		journalMode string
+		want        string
+	}{
+		{name: "default is WAL", journalMode: "", want: "wal"},
+		{name: "explicit WAL", journalMode: "WAL", want: "wal"},
+		{name: "DELETE for network mounts", journalMode: "delete", want: "delete"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := New(filepath.Join(t.TempDir(), "test.db"), tt.journalMode)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer db.Close()
+
+			var mode string
+			if err := db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+				t.Fatalf("read journal_mode: %v", err)
+			}
+			if mode != tt.want {
+				t.Errorf("journal_mode = %q, want %q", mode, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewInMemoryRejectsWAL(t *testing.T) {
+	// In-memory databases cannot use WAL, so the mode silently stays "memory"
+	_, err := New(":memory:", "WAL")
+	if !errors.Is(err, ErrJournalMode) {
+		t.Fatalf("expected ErrJournalMode, got %v", err)
+	}
+}
+
+func TestConcurrentSaveResult(t *testing.T) {
+	db := newTestDB(t)
+
+	const (
+		workers = 20
+		writes  = 25
+	)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*writes)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				err := db.SaveResult(context.Background(), models.PingResult{
+					Timestamp: time.Now(),
+					Target:    fmt.Sprintf("10.0.0.%d", worker),
+					Success:   i%2 == 0,
+					RTT:       float64(i),
+				})
+				if err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("SaveResult() error = %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM ping_results").Scan(&count); err != nil {
+		t.Fatalf("count rows: %v", err)
+	}
+	if count != workers*writes {
+		t.Errorf("saved %d rows, want %d", count, workers*writes)
+	}
+}
+
+func TestStatsKeepSourceInterfacesSeparate(t *testing.T) {
+	db := newTestDB(t)
+
+	// The same host over two WAN links: eth0 answers, wwan0 drops every other ping
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		at := now.Add(time.Duration(-i) * time.Minute)
+		for _, r := range []models.PingResult{
+			{Timestamp: at, Target: "8.8.8.8 via eth0", Success: true, RTT: 10},
+			{Timestamp: at, Target: "8.8.8.8 via wwan0", Success: i%2 == 0, RTT: 40},
+		} {
+			if err := db.SaveResult(context.Background(), r); err != nil {
+				t.Fatalf("SaveResult() error = %v", err)
+			}
+		}
+	}
+
+	targets, err := db.GetTargets(context.Background())
+	if err != nil {
+		t.Fatalf("GetTargets() error = %v", err)
+	}
+	if len(targets) != 2 || targets[0] != "8.8.8.8 via eth0" || targets[1] != "8.8.8.8 via wwan0" {
+		t.Fatalf("GetTargets() = %q, want both interfaces as separate targets", targets)
+	}
+
+	from, to := lastHours(1)
+	stats, err := db.GetStats(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+	loss := make(map[string]float64)
+	for _, s := range stats {
+		loss[s.Target] = s.PacketLoss
+	}
+	if len(loss) != 2 || loss["8.8.8.8 via eth0"] != 0 || loss["8.8.8.8 via wwan0"] != 50 {
+		t.Errorf("packet loss per target = %v, want eth0 0%% and wwan0 50%%", loss)
+	}
+}
+
+// assertFinite fails for NaN or infinite values, which encoding/json rejects
+func assertFinite(t *testing.T, name string, values ...float64) {
+	t.Helper()
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			t.Errorf("%s contains non-finite value %v", name, v)
+		}
+	}
+}
+
+func TestStatsWithoutSuccessfulPings(t *testing.T) {
+	t.Run("empty window", func(t *testing.T) {
+		db := newTestDB(t)
+
+		from, to := lastHours(24)
+		stats, err := db.GetStats(context.Background(), from, to)
+		if err != nil {
+			t.Fatalf("GetStats() error = %v", err)
+		}
+		encoded, err := json.Marshal(stats)
+		if err != nil || string(encoded) != "[]" {
+			t.Errorf("GetStats() encodes as %s (%v), want []", encoded, err)
+		}
+
+		summary, err := db.GetSummary(context.Background())
+		if err != nil {
+			t.Fatalf("GetSummary() error = %v", err)
+		}
+		assertFinite(t, "summary", summary.Availability, summary.WorstPacketLoss, summary.BestRTT, summary.WorstRTT)
+
+		from, to = lastHours(30 * 24)
+		sla, err := db.GetSLA(context.Background(), "", from, to)
+		if err != nil {
+			t.Fatalf("GetSLA() error = %v", err)
+		}
+		assertFinite(t, "SLA", sla.Availability, sla.DowntimeSeconds)
+		if _, err := json.Marshal([]interface{}{summary, sla}); err != nil {
+			t.Errorf("json.Marshal() error = %v", err)
+		}
+	})
+
+	t.Run("all pings failed", func(t *testing.T) {
+		db := newTestDB(t)
+		now := time.Now()
+		for i := 0; i < 5; i++ {
+			if err := db.SaveResult(context.Background(), models.PingResult{Timestamp: now.Add(-time.Duration(i) * time.Minute), Target: "192.0.2.1"}); err != nil {
+				t.Fatalf("SaveResult() error = %v", err)
+			}
+		}
+
+		from, to := lastHours(24)
+		stats, err := db.GetStats(context.Background(), from, to)
+		if err != nil {
+			t.Fatalf("GetStats() error = %v", err)
+		}
+		if len(stats) != 1 {
+			t.Fatalf("GetStats() returned %d targets, want the failing target", len(stats))
+		}
+		s := stats[0]
+		assertFinite(t, "stats", s.AvgRTT, s.MaxRTT, s.MinRTT, s.PacketLoss)
+		if s.PacketLoss != 100 || s.AvgRTT != 0 {
+			t.Errorf("PacketLoss = %v, AvgRTT = %v, want 100 and 0", s.PacketLoss, s.AvgRTT)
+		}
+
+		summary, err := db.GetSummary(context.Background())
+		if err != nil {
+			t.Fatalf("GetSummary() error = %v", err)
+		}
+		assertFinite(t, "summary", summary.Availability, summary.WorstPacketLoss, summary.BestRTT, summary.WorstRTT)
+		if summary.WorstTarget != "192.0.2.1" || summary.TargetsDown != 1 {
+			t.Errorf("summary = %+v, want 192.0.2.1 as the worst and only target, down", summary)
+		}
+		if _, err := json.Marshal([]interface{}{stats, summary}); err != nil {
+			t.Errorf("json.Marshal() error = %v", err)
+		}
+	})
+}
+
+func TestSummaryFaultDomain(t *testing.T) {
+	tests := []struct {
+		name string
+		up   map[string]bool
+		want string
+	}{
+		{"no reference targets", map[string]bool{"8.8.8.8": false}, ""},
+		{"all up", map[string]bool{models.TargetLoopback: true, models.TargetGateway: true, "8.8.8.8": true}, ""},
+		{"loopback down", map[string]bool{models.TargetLoopback: false, models.TargetGateway: false, "8.8.8.8": false}, models.FaultLocal},
+		{"gateway down", map[string]bool{models.TargetLoopback: true, models.TargetGateway: false, "8.8.8.8": false}, models.FaultLAN},
+		{"remote down", map[string]bool{models.TargetLoopback: true, models.TargetGateway: true, "8.8.8.8": false}, models.FaultInternet},
+		{"remote down without a gateway", map[string]bool{models.TargetLoopback: true, "8.8.8.8": false}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var latest []models.PingResult
+			for target, success := range tt.up {
+				latest = append(latest, models.PingResult{Target: target, Success: success})
+			}
+			if got := summarize(nil, latest, 0).FaultDomain; got != tt.want {
+				t.Errorf("FaultDomain = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCancelledContextStopsQueries(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		if err := db.SaveResult(context.Background(), models.PingResult{Timestamp: now.Add(-time.Duration(i) * time.Minute), Target: "8.8.8.8", Success: true, RTT: 10}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	from, to := lastHours(24)
+
+	tests := []struct {
+		name  string
+		query func() error
+	}{
+		{"GetStats", func() error { _, err := db.GetStats(ctx, from, to); return err }},
+		{"GetSummary", func() error { _, err := db.GetSummary(ctx); return err }},
+		{"DetectOutages", func() error { _, err := db.DetectOutages(ctx, from, to, models.DefaultOutageThreshold); return err }},
+		{"SaveResult", func() error { return db.SaveResult(ctx, models.PingResult{Timestamp: now, Target: "8.8.8.8"}) }},
+		{"ArchiveOldData", func() error { return db.ArchiveOldData(ctx) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := tt.query()
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("%s() error = %v, want context.Canceled", tt.name, err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("%s() took %v with a cancelled context", tt.name, elapsed)
+			}
+		})
+	}
+
+	// Nothing was written under the cancelled context
+	stats, err := db.GetStats(context.Background(), from, to)
+	if err != nil || len(stats) != 1 || stats[0].TotalPings != 10 {
+		t.Errorf("GetStats() = %+v, %v, want the 10 saved pings", stats, err)
+	}
+}
+
+func TestSetTargetMetaReplaces(t *testing.T) {
+	db := newTestDB(t)
+
+	if meta, err := db.GetTargetMeta(context.Background()); err != nil || meta == nil || len(meta) != 0 {
+		t.Fatalf("GetTargetMeta() on an empty database = %v, %v; want an empty list", meta, err)
+	}
+
+	first := []models.TargetMeta{{Target: "8.8.8.8", Name: "Comcast WAN", Color: "#1f77b4"}, {Target: "1.1.1.1", Name: "Backup"}}
+	second := []models.TargetMeta{{Target: "1.1.1.1", Color: "#e4572e"}}
+	for _, meta := range [][]models.TargetMeta{first, second} {
+		if err := db.SetTargetMeta(context.Background(), meta); err != nil {
+			t.Fatalf("SetTargetMeta() error = %v", err)
+		}
+	}
+
+	got, err := db.GetTargetMeta(context.Background())
+	if err != nil {
+		t.Fatalf("GetTargetMeta() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, second) {
+		t.Errorf("GetTargetMeta() = %+v, want only the latest %+v", got, second)
+	}
+}
+
+func TestGetLastTimestamp(t *testing.T) {
+	db := newTestDB(t)
+
+	if last, err := db.GetLastTimestamp(context.Background()); err != nil || !last.IsZero() {
+		t.Fatalf("GetLastTimestamp() on an empty database = %v, %v; want the zero time", last, err)
+	}
+
+	latest := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	for _, stamp := range []time.Time{latest.Add(-time.Minute), latest, latest.Add(-2 * time.Minute)} {
+		if err := db.SaveResult(context.Background(), models.PingResult{Timestamp: stamp, Target: "8.8.8.8", Success: true, RTT: 10}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	last, err := db.GetLastTimestamp(context.Background())
+	if err != nil {
+		t.Fatalf("GetLastTimestamp() error = %v", err)
+	}
+	if !last.Equal(latest) {
+		t.Errorf("GetLastTimestamp() = %v, want %v", last, latest)
+	}
+}
+
+func TestGetCurrent(t *testing.T) {
+	db := newTestDB(t)
+
+	if current, err := db.GetCurrent(context.Background()); err != nil || len(current) != 0 {
+		t.Fatalf("GetCurrent() on an empty database = %v, %v; want none", current, err)
+	}
+
+	// Five pings per target a minute apart, saved out of order; index 0 is the
+	// most recent
+	now := time.Now().Truncate(time.Second)
+	for _, i := range []int{2, 0, 4, 1, 3} {
+		stamp := now.Add(-time.Duration(i) * time.Minute)
+		for _, result := range []models.PingResult{
+			{Timestamp: stamp, Target: "8.8.8.8", Success: true, RTT: 10 + float64(i)},
+			{Timestamp: stamp.Add(-time.Hour), Target: "1.1.1.1", Success: i != 0, RTT: 20},
+		} {
+			if err := db.SaveResult(context.Background(), result); err != nil {
+				t.Fatalf("SaveResult() error = %v", err)
+			}
+		}
+	}
+
+	current, err := db.GetCurrent(context.Background())
+	if err != nil {
+		t.Fatalf("GetCurrent() error = %v", err)
+	}
+	if len(current) != 2 {
+		t.Fatalf("GetCurrent() returned %d rows, want one per target: %+v", len(current), current)
+	}
+
+	down, up := current[0], current[1]
+	if down.Target != "1.1.1.1" || down.Success || !down.LastCheck.Equal(now.Add(-time.Hour)) {
+		t.Errorf("current[0] = %+v, want 1.1.1.1's failed ping an hour ago", down)
+	}
+	if down.SecondsSinceCheck < 3600 || down.SecondsSinceCheck > 3660 {
+		t.Errorf("SecondsSinceCheck = %v, want about 3600", down.SecondsSinceCheck)
+	}
+	if up.Target != "8.8.8.8" || !up.Success || up.RTT != 10 || !up.LastCheck.Equal(now) {
+		t.Errorf("current[1] = %+v, want 8.8.8.8's latest ping at 10ms", up)
+	}
+}
+
+func TestRecentIncludesReplyDetails(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+
+	results := []models.PingResult{
+		{Timestamp: now.Add(-2 * time.Minute), Target: "dns.google", Success: true, RTT: 10, ResolvedIP: "8.8.8.8", TTL: 117},
+		{Timestamp: now.Add(-time.Minute), Target: "dns.google", Success: true, RTT: 25, ResolvedIP: "8.8.4.4", TTL: 109},
+		{Timestamp: now, Target: "dns.google", ErrorMessage: "unknown host", FailureReason: models.FailureDNS},
+	}
+	for _, r := range results {
+		if err := db.SaveResult(context.Background(), r); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	from, to := lastHours(1)
+	recent, err := db.GetRecentFiltered(context.Background(), from, to, "dns.google", "", 10, 0)
+	if err != nil {
+		t.Fatalf("GetRecentFiltered() error = %v", err)
+	}
+	if len(recent) != len(results) {
+		t.Fatalf("got %d results, want %d", len(recent), len(results))
+	}
+	for i, got := range recent {
+		want := results[len(results)-1-i] // newest first
+		if got.ResolvedIP != want.ResolvedIP || got.TTL != want.TTL {
+			t.Errorf("result %d: resolved IP %q, TTL %d; want %q, %d", i, got.ResolvedIP, got.TTL, want.ResolvedIP, want.TTL)
+		}
+		if got.FailureReason != want.FailureReason {
+			t.Errorf("result %d: failure reason %q, want %q", i, got.FailureReason, want.FailureReason)
+		}
+	}
+
+	// A result without either is stored as NULL rather than "" and 0
+	var nulls int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ping_results WHERE resolved_ip IS NULL AND ttl IS NULL`).Scan(&nulls); err != nil {
+		t.Fatalf("count NULL columns: %v", err)
+	}
+	if nulls != 1 {
+		t.Errorf("%d results with NULL resolved_ip and ttl, want 1", nulls)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ping_results WHERE failure_reason IS NULL`).Scan(&nulls); err != nil {
+		t.Fatalf("count NULL failure reasons: %v", err)
+	}
+	if nulls != 2 {
+		t.Errorf("%d results with NULL failure_reason, want 2", nulls)
+	}
+}
+
+func TestRecentFilteredByInstance(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+
+	results := []models.PingResult{
+		{Timestamp: now.Add(-2 * time.Minute), Target: "8.8.8.8", Success: true, RTT: 10, Instance: "home"},
+		{Timestamp: now.Add(-time.Minute), Target: "8.8.8.8", Success: true, RTT: 30, Instance: "office"},
+		{Timestamp: now, Target: "8.8.8.8", Success: true, RTT: 12}, // imported, from no particular monitor
+	}
+	for _, r := range results {
+		if err := db.SaveResult(context.Background(), r); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		instance string
+		want     []string
+	}{
+		{"", []string{"", "office", "home"}},
+		{"home", []string{"home"}},
+		{"office", []string{"office"}},
+		{"lab", nil},
+	}
+	from, to := lastHours(1)
+	for _, tt := range tests {
+		recent, err := db.GetRecentFiltered(context.Background(), from, to, "8.8.8.8", tt.instance, 10, 0)
+		if err != nil {
+			t.Fatalf("GetRecentFiltered(%q) error = %v", tt.instance, err)
+		}
+		var got []string
+		for _, r := range recent {
+			got = append(got, r.Instance)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("GetRecentFiltered(%q) instances = %q, want %q", tt.instance, got, tt.want)
+		}
+	}
+}
+
+func TestNewMemory(t *testing.T) {
+	db, err := NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory() error = %v", err)
+	}
+	defer db.Close()
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion() error = %v", err)
+	}
+	if version != len(migrations) {
+		t.Errorf("schema version = %d, want %d", version, len(migrations))
+	}
+
+	// Data must survive across statements, i.e. stay on the one connection
+	if err := db.SaveResult(context.Background(), models.PingResult{Timestamp: time.Now(), Target: "8.8.8.8", Success: true, RTT: 10}); err != nil {
+		t.Fatalf("SaveResult() error = %v", err)
+	}
+	targets, err := db.GetTargets(context.Background())
+	if err != nil {
+		t.Fatalf("GetTargets() error = %v", err)
+	}
+	if len(targets) != 1 || targets[0] != "8.8.8.8" {
+		t.Errorf("targets = %v, want [8.8.8.8]", targets)
+	}
+}
+
+func TestGetRecentSampled(t *testing.T) {
+	db := newTestDB(t)
+
+	// Two targets pinged every 30s for the last 10 hours: 2400 results
+	now := time.Now().Truncate(time.Second)
+	const perTarget = 1200
+	for i := 0; i < perTarget; i++ {
+		for _, target := range []string{"8.8.8.8", "1.1.1.1"} {
+			result := models.PingResult{Timestamp: now.Add(-time.Duration(i) * 30 * time.Second), Target: target, Success: true, RTT: float64(i)}
+			if err := db.SaveResult(context.Background(), result); err != nil {
+				t.Fatalf("SaveResult() error = %v", err)
+			}
+		}
+	}
+
+	const maxPoints = 100
+	from, to := lastHours(24)
+	sampled, err := db.GetRecentSampled(context.Background(), from, to, maxPoints)
+	if err != nil {
+		t.Fatalf("GetRecentSampled() error = %v", err)
+	}
+	if len(sampled) == 0 || len(sampled) > maxPoints {
+		t.Fatalf("GetRecentSampled() returned %d results, want 1 to %d", len(sampled), maxPoints)
+	}
+
+	// Every 24th result per target: from the newest back to within one step of
+	// the oldest, both targets alike
+	perTargetCount := map[string]int{}
+	for i, r := range sampled {
+		perTargetCount[r.Target]++
+		if i > 0 && r.Timestamp.After(sampled[i-1].Timestamp) {
+			t.Fatalf("results not newest first at %d", i)
+		}
+	}
+	if perTargetCount["8.8.8.8"] != perTargetCount["1.1.1.1"] || perTargetCount["8.8.8.8"] < maxPoints/2-1 {
+		t.Errorf("results per target = %v, want an even split of about %d", perTargetCount, maxPoints)
+	}
+	if newest := sampled[0]; !newest.Timestamp.Equal(now) {
+		t.Errorf("newest sampled result at %v, want %v", newest.Timestamp, now)
+	}
+	step := time.Duration(perTarget/(maxPoints/2)) * 30 * time.Second
+	oldest := now.Add(-(perTarget - 1) * 30 * time.Second)
+	if got := sampled[len(sampled)-1].Timestamp; got.After(oldest.Add(step)) {
+		t.Errorf("oldest sampled result at %v, want within %v of %v", got, step, oldest)
+	}
+
+	// Under the limit everything is returned, as with GetRecent
+	all, err := db.GetRecentSampled(context.Background(), from, to, 2*perTarget)
+	if err != nil {
+		t.Fatalf("GetRecentSampled() error = %v", err)
+	}
+	if len(all) != 2*perTarget {
+		t.Errorf("GetRecentSampled() under the limit returned %d results, want %d", len(all), 2*perTarget)
+	}
+}
+
+func TestSampleStep(t *testing.T) {
+	tests := []struct {
+		name      string
+		counts    []int
+		maxPoints int
+		want      int
+	}{
+		{"no results", nil, 10, 1},
+		{"under the limit", []int{3, 4}, 10, 1},
+		{"exactly the limit", []int{5, 5}, 10, 1},
+		{"even split", []int{1200, 1200}, 100, 24},
+		{"rounding per target", []int{5, 5}, 4, 3},
+		{"uneven targets", []int{100, 1}, 10, 12},
+		{"more targets than points", []int{3, 3, 3}, 2, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sampleStep(tt.counts, tt.maxPoints); got != tt.want {
+				t.Errorf("sampleStep(%v, %d) = %d, want %d", tt.counts, tt.maxPoints, got, tt.want)
+			}
+		})
+	}
+}
+
+// A success whose RTT couldn't be parsed is stored with an RTT of 0; it counts
+// towards availability but mustn't drag the RTT figures down
+func TestRTTAggregatesSkipMissingRTTs(t *testing.T) {
+	db := newTestDB(t)
+	// Mid-hour, so all three land in one hourly pattern
+	now := time.Now().Add(-time.Hour).Truncate(time.Hour).Add(30 * time.Minute)
+	results := []models.PingResult{
+		{Timestamp: now, Target: "8.8.8.8", Success: true, RTT: 10},
+		{Timestamp: now.Add(time.Second), Target: "8.8.8.8", Success: true, RTT: 0},
+		{Timestamp: now.Add(2 * time.Second), Target: "8.8.8.8", Success: true, RTT: 20},
+	}
+	for _, r := range results {
+		if err := db.SaveResult(context.Background(), r); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	from, to := lastHours(2)
+	stats, err := db.GetStats(context.Background(), from, to)
+	if err != nil || len(stats) != 1 {
+		t.Fatalf("GetStats() = %v, %v; want one target", stats, err)
+	}
+	if s := stats[0]; s.Successful != 3 || s.MinRTT != 10 || s.AvgRTT != 15 {
+		t.Errorf("stats = %d successful, min %v, avg %v; want 3, 10 and 15", s.Successful, s.MinRTT, s.AvgRTT)
+	}
+
+	comparison, err := db.CompareTargets(context.Background(), "8.8.8.8", "1.1.1.1", 2)
+	if err != nil {
+		t.Fatalf("CompareTargets() error = %v", err)
+	}
+	if comparison.AAvgRTT != 15 || comparison.ALoss != 0 {
+		t.Errorf("comparison avg RTT %v, loss %v; want 15 and 0", comparison.AAvgRTT, comparison.ALoss)
+	}
+
+	if err := db.BackfillHourlyPatterns(context.Background()); err != nil {
+		t.Fatalf("BackfillHourlyPatterns() error = %v", err)
+	}
+	var avg, p95 float64
+	var failed int
+	if err := db.QueryRow(`SELECT avg_rtt_ms, p95_rtt_ms, failed_pings FROM hourly_patterns WHERE target = '8.8.8.8'`).Scan(&avg, &p95, &failed); err != nil {
+		t.Fatalf("read hourly pattern: %v", err)
+	}
+	if avg != 15 || p95 != 20 || failed != 0 {
+		t.Errorf("hourly pattern avg %v, p95 %v, failed %d; want 15, 20 and 0", avg, p95, failed)
+	}
+}
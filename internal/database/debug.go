@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"network-monitor/internal/models"
+)
+
+// MaxPingDebugRows bounds the ping_debug table; saving an entry drops the
+// oldest ones past it, so leaving output capture on can't fill the disk
+const MaxPingDebugRows = 1000
+
+// SavePingDebug stores the raw output of a ping and prunes the table to the
+// newest MaxPingDebugRows entries
+func (db *DB) SavePingDebug(ctx context.Context, entry models.PingDebug) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO ping_debug (timestamp, target, success, output) VALUES (?, ?, ?, ?)`,
+		entry.Timestamp.UTC(), entry.Target, entry.Success, entry.Output); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ping_debug WHERE id <= (SELECT MAX(id) FROM ping_debug) - ?`,
+		MaxPingDebugRows); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetPingDebug retrieves the newest stored ping outputs, newest first. An
+// empty target matches all targets; limit is clamped to MaxPingDebugRows.
+func (db *DB) GetPingDebug(ctx context.Context, target string, limit int) ([]models.PingDebug, error) {
+	if limit <= 0 || limit > MaxPingDebugRows {
+		limit = MaxPingDebugRows
+	}
+	query := `
+        SELECT timestamp, target, success, output
+        FROM ping_debug
+        WHERE (? = '' OR target = ?)
+        ORDER BY timestamp DESC, id DESC
+        LIMIT ?
+    `
+
+	rows, err := db.QueryContext(ctx, query, target, target, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanPingDebug(rows)
+}
+
+// scanPingDebug reads timestamp, target, success, output rows and closes them
+func scanPingDebug(rows *sql.Rows) ([]models.PingDebug, error) {
+	defer rows.Close()
+
+	entries := []models.PingDebug{}
+	for rows.Next() {
+		var e models.PingDebug
+		if err := rows.Scan(&e.Timestamp, &e.Target, &e.Success, &e.Output); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestPingDebug(t *testing.T) {
+	db := newTestDB(t)
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i := 0; i < MaxPingDebugRows+5; i++ {
+		target := "8.8.8.8"
+		if i%2 == 1 {
+			target = "1.1.1.1"
+		}
+		entry := models.PingDebug{
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Target:    target,
+			Success:   i%4 == 0,
+			Output:    fmt.Sprintf("output %d", i),
+		}
+		if err := db.SavePingDebug(context.Background(), entry); err != nil {
+			t.Fatalf("SavePingDebug() error = %v", err)
+		}
+	}
+
+	// The five oldest entries were pruned
+	all, err := db.GetPingDebug(context.Background(), "", 0)
+	if err != nil {
+		t.Fatalf("GetPingDebug() error = %v", err)
+	}
+	if len(all) != MaxPingDebugRows {
+		t.Fatalf("GetPingDebug() returned %d entries, want %d", len(all), MaxPingDebugRows)
+	}
+	newest := fmt.Sprintf("output %d", MaxPingDebugRows+4)
+	if all[0].Output != newest || all[len(all)-1].Output != "output 5" {
+		t.Errorf("GetPingDebug() runs from %q to %q, want %q to %q", all[0].Output, all[len(all)-1].Output, newest, "output 5")
+	}
+
+	limited, err := db.GetPingDebug(context.Background(), "8.8.8.8", 3)
+	if err != nil {
+		t.Fatalf("GetPingDebug(8.8.8.8) error = %v", err)
+	}
+	if len(limited) != 3 {
+		t.Fatalf("GetPingDebug(8.8.8.8, 3) returned %d entries, want 3", len(limited))
+	}
+	for _, e := range limited {
+		if e.Target != "8.8.8.8" {
+			t.Errorf("GetPingDebug(8.8.8.8) returned an entry for %s", e.Target)
+		}
+	}
+	if first := limited[0]; !first.Success || !first.Timestamp.Equal(base.Add(time.Duration(MaxPingDebugRows+4)*time.Second)) {
+		t.Errorf("newest 8.8.8.8 entry = %+v", first)
+	}
+}
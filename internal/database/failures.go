@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// GetErrorBreakdown counts each target's failed pings between from and to by
+// failure reason and error message, most frequent first
+func (db *DB) GetErrorBreakdown(ctx context.Context, from, to time.Time) ([]models.ErrorCount, error) {
+	query := `
+        SELECT target, COALESCE(failure_reason, ''), COALESCE(error_message, ''), COUNT(*)
+        FROM ping_results
+        WHERE NOT success AND timestamp >= ? AND timestamp <= ?
+        GROUP BY 1, 2, 3
+        ORDER BY 1, 4 DESC, 2, 3
+    `
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return scanErrorCounts(rows)
+}
+
+// scanErrorCounts reads target, failure_reason, error_message and count rows
+// and closes them
+func scanErrorCounts(rows *sql.Rows) ([]models.ErrorCount, error) {
+	defer rows.Close()
+
+	counts := []models.ErrorCount{}
+	for rows.Next() {
+		var c models.ErrorCount
+		if err := rows.Scan(&c.Target, &c.FailureReason, &c.ErrorMessage, &c.Count); err != nil {
+			continue
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
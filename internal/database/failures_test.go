@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestGetErrorBreakdown(t *testing.T) {
+	db := newTestDB(t)
+
+	now := time.Now()
+	timeout := models.PingResult{Target: "8.8.8.8", ErrorMessage: "ping timed out after 5s", FailureReason: models.FailureTimeout}
+	unreachable := models.PingResult{Target: "8.8.8.8", ErrorMessage: "Destination Host Unreachable", FailureReason: models.FailureUnreachable}
+	unknownHost := models.PingResult{Target: "example.invalid", ErrorMessage: "unknown host", FailureReason: models.FailureDNS}
+	seed := []struct {
+		result models.PingResult
+		count  int
+		age    time.Duration
+	}{
+		{timeout, 3, time.Minute},
+		{unreachable, 2, time.Minute},
+		{unknownHost, 4, time.Minute},
+		{timeout, 5, 48 * time.Hour}, // outside the window
+		{models.PingResult{Target: "8.8.8.8", Success: true, RTT: 10}, 6, time.Minute}, // successes aren't errors
+		{models.PingResult{Target: "1.1.1.1", ErrorMessage: "exit status 1"}, 1, time.Minute},
+	}
+	for _, s := range seed {
+		for i := 0; i < s.count; i++ {
+			r := s.result
+			r.Timestamp = now.Add(-s.age - time.Duration(i)*time.Second)
+			if err := db.SaveResult(context.Background(), r); err != nil {
+				t.Fatalf("SaveResult() error = %v", err)
+			}
+		}
+	}
+
+	from, to := lastHours(24)
+	breakdown, err := db.GetErrorBreakdown(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetErrorBreakdown() error = %v", err)
+	}
+	want := []models.ErrorCount{
+		{Target: "1.1.1.1", ErrorMessage: "exit status 1", Count: 1},
+		{Target: "8.8.8.8", FailureReason: models.FailureTimeout, ErrorMessage: "ping timed out after 5s", Count: 3},
+		{Target: "8.8.8.8", FailureReason: models.FailureUnreachable, ErrorMessage: "Destination Host Unreachable", Count: 2},
+		{Target: "example.invalid", FailureReason: models.FailureDNS, ErrorMessage: "unknown host", Count: 4},
+	}
+	if !reflect.DeepEqual(breakdown, want) {
+		t.Errorf("GetErrorBreakdown() = %+v, want %+v", breakdown, want)
+	}
+
+	// A week back also counts the older timeouts
+	from, to = lastHours(24 * 7)
+	breakdown, err = db.GetErrorBreakdown(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetErrorBreakdown() error = %v", err)
+	}
+	if len(breakdown) != 4 || breakdown[1].Count != 8 {
+		t.Errorf("GetErrorBreakdown(168) = %+v, want 8 timeouts for 8.8.8.8", breakdown)
+	}
+}
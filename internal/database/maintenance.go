@@ -1,102 +1,340 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
 	"time"
 )
 
-// AggregateHourlyPatterns aggregates hourly patterns for heatmap
-func (db *DB) AggregateHourlyPatterns() error {
-	query := `
-        INSERT OR REPLACE INTO hourly_patterns (date, hour, target, total_pings, failed_pings, avg_rtt_ms, max_rtt_ms, failure_rate)
-        SELECT
-            substr(timestamp, 1, 10) as date,
-            CAST(substr(timestamp, 12, 2) AS INTEGER) as hour,
-            target,
-            COUNT(*) as total_pings,
-            SUM(CASE WHEN NOT success THEN 1 ELSE 0 END) as failed_pings,
-            AVG(CASE WHEN success THEN rtt_ms ELSE NULL END) as avg_rtt_ms,
-            MAX(CASE WHEN success THEN rtt_ms ELSE NULL END) as max_rtt_ms,
-            ROUND((SUM(CASE WHEN NOT success THEN 1 ELSE 0 END) * 100.0 / COUNT(*)), 2) as failure_rate
-        FROM ping_results
-        WHERE timestamp > datetime('now', '-7 days')
-        AND length(timestamp) > 19
-        GROUP BY date, hour, target
-    `
-	_, err := db.Exec(query)
-	return err
+// AggregateHourlyPatterns aggregates results into hourly patterns for the
+// heatmap, picking up from the hour the last run reached, or covering the last
+// week the first time
+func (db *DB) AggregateHourlyPatterns(ctx context.Context) error {
+	row := db.QueryRowContext(ctx, `SELECT watermark FROM aggregation_state WHERE name = ?`, patternsAggregation)
+	since, err := aggregationStart(row, db.location)
+	if err != nil {
+		return fmt.Errorf("read aggregation watermark: %w", err)
+	}
+	return db.aggregateHourlyPatterns(ctx, since)
 }
 
-// ArchiveOldData archives old data and cleans up
-func (db *DB) ArchiveOldData() error {
-	// First, ensure hourly stats are captured for old data
-	archiveQuery := `
+// patternsAggregation names the hourly patterns row in aggregation_state
+const patternsAggregation = "hourly_patterns"
+
+// lateResultSlack is how far behind the time it is saved a result's timestamp
+// can be, covering a ping's timeout and retries. Aggregation watermarks stay
+// this far behind the run so late results still land in their hour.
+const lateResultSlack = 5 * time.Minute
+
+// aggregationStart returns where an incremental aggregation resumes: the start,
+// in loc, of the hour holding the watermark scanned from row, since that hour's
+// bucket is rebuilt from all of its results. Without a watermark it covers the
+// last week.
+func aggregationStart(row *sql.Row, loc *time.Location) (time.Time, error) {
+	var watermark time.Time
+	if err := row.Scan(&watermark); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Now().AddDate(0, 0, -7), nil
+		}
+		return time.Time{}, err
+	}
+	local := watermark.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, loc), nil
+}
+
+// rawRetention is how long raw ping results are kept before ArchiveOldData
+// deletes them, leaving hourly_stats (and preserved_results around outages)
+const rawRetention = 7 * 24 * time.Hour
+
+// archiveCutoff is the time before which ArchiveOldData rolls up and deletes
+// raw results. It falls on a whole hour, so every hour is archived once and
+// complete: INSERT OR IGNORE would otherwise keep the first, partial count of
+// an hour split across two runs.
+func archiveCutoff(now time.Time) time.Time {
+	return now.Add(-rawRetention).UTC().Truncate(time.Hour)
+}
+
+// archiveHourlyStatsQuery rolls the raw results older than its first parameter
+// and newer than its second up into hourly_stats before they are deleted
+const archiveHourlyStatsQuery = `
         INSERT OR IGNORE INTO hourly_stats (hour, target, total_pings, successful_pings, avg_rtt_ms, max_rtt_ms, min_rtt_ms, packet_loss_percent)
         SELECT
             strftime('%Y-%m-%d %H:00:00', timestamp) as hour,
             target,
             COUNT(*) as total_pings,
             SUM(CASE WHEN success THEN 1 ELSE 0 END) as successful_pings,
-            AVG(CASE WHEN success THEN rtt_ms ELSE NULL END) as avg_rtt_ms,
-            MAX(CASE WHEN success THEN rtt_ms ELSE NULL END) as max_rtt_ms,
-            MIN(CASE WHEN success THEN rtt_ms ELSE NULL END) as min_rtt_ms,
+            AVG(CASE WHEN success AND rtt_ms > 0 THEN rtt_ms ELSE NULL END) as avg_rtt_ms,
+            MAX(CASE WHEN success AND rtt_ms > 0 THEN rtt_ms ELSE NULL END) as max_rtt_ms,
+            MIN(CASE WHEN success AND rtt_ms > 0 THEN rtt_ms ELSE NULL END) as min_rtt_ms,
             ROUND((1.0 - (CAST(SUM(CASE WHEN success THEN 1 ELSE 0 END) AS REAL) / COUNT(*))) * 100, 2) as packet_loss_percent
         FROM ping_results
-        WHERE timestamp < datetime('now', '-7 days')
-        AND timestamp > datetime('now', '-90 days')
+        WHERE timestamp < ? AND timestamp > ?
         GROUP BY hour, target
     `
 
-	if _, err := db.Exec(archiveQuery); err != nil {
+// ArchiveOldData archives old data and cleans up
+func (db *DB) ArchiveOldData(ctx context.Context) error {
+	now := time.Now()
+	cutoff := archiveCutoff(now)
+
+	// First, ensure hourly stats are captured for old data
+	if _, err := db.ExecContext(ctx, archiveHourlyStatsQuery, cutoff, now.AddDate(0, 0, -90).UTC()); err != nil {
 		return err
 	}
 
-	// Delete raw ping results older than 7 days (we keep aggregated data)
-	deleteQuery := `DELETE FROM ping_results WHERE timestamp < datetime('now', '-7 days')`
-	if _, err := db.Exec(deleteQuery); err != nil {
+	// Delete the archived raw ping results (we keep aggregated data),
+	// first copying any near a recorded outage when preservation is enabled
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if db.preservePadding > 0 {
+		copyQuery := `
+            INSERT OR IGNORE INTO preserved_results (id, timestamp, target, success, rtt_ms, error_message, attempts, resolved_ip, ttl, failure_reason, instance)
+            SELECT id, timestamp, target, success, rtt_ms, error_message, attempts, resolved_ip, ttl, failure_reason, instance
+            FROM ping_results
+            WHERE timestamp >= ? AND timestamp <= ? AND timestamp < ?
+        `
+		outageQuery := `SELECT id, target, start_time, end_time, COALESCE(checks_failed, 0),
+            COALESCE(rtt_before_ms, 0), COALESCE(rtt_after_ms, 0) FROM outages WHERE start_time < ?`
+		if err := preserveOutageRows(ctx, tx, outageQuery, copyQuery, cutoff, db.preservePadding); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ping_results WHERE timestamp < ?`, cutoff.UTC()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
 		return err
 	}
 
 	// Delete hourly patterns older than 90 days
-	deletePatternQuery := `DELETE FROM hourly_patterns WHERE date < date('now', '-90 days')`
-	if _, err := db.Exec(deletePatternQuery); err != nil {
+	deletePatternQuery := `DELETE FROM hourly_patterns WHERE date < ?`
+	if _, err := db.ExecContext(ctx, deletePatternQuery, db.localDate(-90)); err != nil {
 		return err
 	}
 
-	// Vacuum to reclaim space (run occasionally)
-	if time.Now().Day() == 1 { // Run on first day of month
-		_, err := db.Exec("VACUUM")
+	return db.incrementalVacuum(ctx)
+}
+
+// incrementalVacuum returns the pages freed by deletes to the filesystem.
+// Unlike VACUUM it doesn't rewrite the whole file, so writes only wait while
+// the freed pages are released. It does nothing unless the database uses
+// auto_vacuum=INCREMENTAL, which new databases do; Vacuum converts older ones.
+func (db *DB) incrementalVacuum(ctx context.Context) error {
+	// Each step frees one page, so the statement has to be run to completion
+	rows, err := db.QueryContext(ctx, "PRAGMA incremental_vacuum")
+	if err != nil {
 		return err
 	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
 
+// Vacuum rebuilds the database file, reclaiming all free space and switching
+// databases created before incremental vacuuming to auto_vacuum=INCREMENTAL.
+// It locks the database for the whole rewrite, so run it while nothing else
+// writes, e.g. at startup.
+func (db *DB) Vacuum() error {
+	_, err := db.Exec("VACUUM")
+	return err
+}
+
+// preserveOutageRows copies the raw results older than cutoff that fall within
+// padding of a recorded outage into preserved_results, before archival deletes
+// them. Every target's results in the window are kept, since comparing targets
+// is what shows where the problem was. outageQuery selects outage rows started
+// before its one parameter; copyQuery copies the results between its first two
+// parameters that are older than its third.
+func preserveOutageRows(ctx context.Context, tx *sql.Tx, outageQuery, copyQuery string, cutoff time.Time, padding time.Duration) error {
+	rows, err := tx.QueryContext(ctx, outageQuery, cutoff.Add(padding).UTC())
+	if err != nil {
+		return fmt.Errorf("load outages to preserve: %w", err)
+	}
+	outages, err := scanRecordedOutages(rows)
+	if err != nil {
+		return fmt.Errorf("load outages to preserve: %w", err)
+	}
+
+	for _, o := range outages {
+		from, to := o.StartTime.Add(-padding).UTC(), o.EndTime.Add(padding).UTC()
+		if _, err := tx.ExecContext(ctx, copyQuery, from, to, cutoff.UTC()); err != nil {
+			return fmt.Errorf("preserve results around outage %d: %w", o.ID, err)
+		}
+	}
 	return nil
 }
 
-// BackfillHourlyPatterns backfills hourly patterns from all available ping_results data
-// This is useful for initial population or when the hourly_patterns table is empty
-func (db *DB) BackfillHourlyPatterns() error {
-	query := `
-        INSERT OR REPLACE INTO hourly_patterns (date, hour, target, total_pings, failed_pings, avg_rtt_ms, max_rtt_ms, failure_rate)
-        SELECT
-            substr(timestamp, 1, 10) as date,
-            CAST(substr(timestamp, 12, 2) AS INTEGER) as hour,
-            target,
-            COUNT(*) as total_pings,
-            SUM(CASE WHEN NOT success THEN 1 ELSE 0 END) as failed_pings,
-            AVG(CASE WHEN success THEN rtt_ms ELSE NULL END) as avg_rtt_ms,
-            MAX(CASE WHEN success THEN rtt_ms ELSE NULL END) as max_rtt_ms,
-            ROUND((SUM(CASE WHEN NOT success THEN 1 ELSE 0 END) * 100.0 / COUNT(*)), 2) as failure_rate
+// BackfillHourlyPatterns rebuilds hourly patterns from all available ping_results data,
+// and the daily and monthly rollups from them, since the maintenance pass only
+// rolls up from the latest day on.
+// This is useful for initial population, or after importing historical results.
+func (db *DB) BackfillHourlyPatterns(ctx context.Context) error {
+	if err := db.aggregateHourlyPatterns(ctx, time.Time{}); err != nil {
+		return err
+	}
+	return rebuildRollups(ctx, db.DB, dailyRollup, monthlyRollup, db.location)
+}
+
+// patternKey identifies one hourly_patterns row
+type patternKey struct {
+	date   string
+	hour   int
+	target string
+}
+
+// patternStats accumulates the pings for one hourly_patterns row
+type patternStats struct {
+	total      int
+	failed     int
+	rttSum     float64
+	successful int
+	timed      int // successful pings with an RTT, which the RTT figures cover
+	maxRTT     float64
+	rttValues  []float64 // successful RTTs, for the 95th percentile
+}
+
+// add counts one ping result. A success without an RTT counts towards
+// availability but not the RTT figures.
+func (p *patternStats) add(success bool, rtt float64) {
+	p.total++
+	if !success {
+		p.failed++
+		return
+	}
+	p.successful++
+	if rtt <= 0 {
+		return
+	}
+	p.timed++
+	p.rttSum += rtt
+	p.maxRTT = max(p.maxRTT, rtt)
+}
+
+// aggregateHourlyPatterns buckets results recorded from since on by date and
+// hour of day in the configured location, and moves the watermark up to now.
+// SQLite has no time zone database, so the conversion happens here rather than
+// in SQL.
+func (db *DB) aggregateHourlyPatterns(ctx context.Context, since time.Time) error {
+	watermark := time.Now().Add(-lateResultSlack)
+	rows, err := db.QueryContext(ctx, `
+        SELECT timestamp, target, success, rtt_ms
         FROM ping_results
-        WHERE length(timestamp) > 19
-        GROUP BY date, hour, target
-    `
-	_, err := db.Exec(query)
-	return err
+        WHERE timestamp >= ?
+    `, since.UTC())
+	if err != nil {
+		return err
+	}
+
+	keys, patterns, err := bucketPatterns(rows, db.location)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT OR REPLACE INTO hourly_patterns (date, hour, target, total_pings, failed_pings, avg_rtt_ms, max_rtt_ms, p95_rtt_ms, failure_rate)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, key := range keys {
+		p := patterns[key]
+		avgRTT, maxRTT, p95RTT := p.rtts()
+		if _, err := stmt.ExecContext(ctx, key.date, key.hour, key.target, p.total, p.failed, avgRTT, maxRTT, p95RTT, p.failureRate()); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO aggregation_state (name, watermark) VALUES (?, ?)`,
+		patternsAggregation, watermark.UTC()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// bucketPatterns reads timestamp, target, success and rtt_ms rows into hourly
+// pattern buckets by date and hour of day in loc, and closes the rows. Keys are
+// returned in the order they were first seen.
+func bucketPatterns(rows *sql.Rows, loc *time.Location) ([]patternKey, map[patternKey]*patternStats, error) {
+	defer rows.Close()
+
+	var keys []patternKey
+	patterns := make(map[patternKey]*patternStats)
+	for rows.Next() {
+		var timestamp time.Time
+		var target string
+		var success bool
+		var rtt float64
+		if err := rows.Scan(&timestamp, &target, &success, &rtt); err != nil {
+			continue
+		}
+
+		local := timestamp.In(loc)
+		key := patternKey{date: local.Format("2006-01-02"), hour: local.Hour(), target: target}
+		p, ok := patterns[key]
+		if !ok {
+			p = &patternStats{}
+			patterns[key] = p
+			keys = append(keys, key)
+		}
+		p.add(success, rtt)
+		if success && rtt > 0 {
+			p.rttValues = append(p.rttValues, rtt)
+		}
+	}
+	return keys, patterns, rows.Err()
+}
+
+// rtts returns the average, maximum and 95th percentile RTT, NULL without
+// successful pings that have one
+func (p *patternStats) rtts() (avg, maximum, p95 sql.NullFloat64) {
+	if p.timed == 0 {
+		return avg, maximum, p95
+	}
+	return sql.NullFloat64{Float64: p.rttSum / float64(p.timed), Valid: true},
+		sql.NullFloat64{Float64: p.maxRTT, Valid: true},
+		sql.NullFloat64{Float64: percentile(p.rttValues, 95), Valid: true}
+}
+
+// percentile returns the nearest-rank pth percentile of values, sorting them
+// in place; 0 for no values
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	slices.Sort(values)
+	rank := int(math.Ceil(p / 100 * float64(len(values))))
+	return values[max(rank, 1)-1]
+}
+
+// failureRate returns the percentage of failed pings, rounded to two decimals
+func (p *patternStats) failureRate() float64 {
+	return math.Round(float64(p.failed)*100/float64(p.total)*100) / 100
 }
 
 // IsHourlyPatternsEmpty checks if the hourly_patterns table is empty
-func (db *DB) IsHourlyPatternsEmpty() (bool, error) {
+func (db *DB) IsHourlyPatternsEmpty(ctx context.Context) (bool, error) {
 	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM hourly_patterns").Scan(&count)
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM hourly_patterns").Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,386 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestAggregateHourlyPatternsUsesLocation(t *testing.T) {
+	db := newTestDB(t)
+	zone := time.FixedZone("UTC+3", 3*60*60)
+	db.SetLocation(zone)
+
+	// 22:30 UTC two days ago is 01:30 the following day in UTC+3
+	day := time.Now().UTC().AddDate(0, 0, -2)
+	instant := time.Date(day.Year(), day.Month(), day.Day(), 22, 30, 0, 0, time.UTC)
+	results := []models.PingResult{
+		{Timestamp: instant.In(zone), Target: "8.8.8.8", Success: true, RTT: 10},
+		{Timestamp: instant.Add(time.Minute), Target: "8.8.8.8", Success: false},
+	}
+	for _, r := range results {
+		if err := db.SaveResult(context.Background(), r); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	// Stored in UTC regardless of the result's zone
+	var storedHour string
+	if err := db.QueryRow(`SELECT substr(timestamp, 12, 2) FROM ping_results LIMIT 1`).Scan(&storedHour); err != nil {
+		t.Fatalf("read stored timestamp: %v", err)
+	}
+	if storedHour != "22" {
+		t.Errorf("stored hour = %s, want 22 (UTC)", storedHour)
+	}
+
+	if err := db.AggregateHourlyPatterns(context.Background()); err != nil {
+		t.Fatalf("AggregateHourlyPatterns() error = %v", err)
+	}
+
+	var date time.Time
+	var hour, total, failed int
+	var failureRate float64
+	err := db.QueryRow(`SELECT date, hour, total_pings, failed_pings, failure_rate FROM hourly_patterns WHERE target = '8.8.8.8'`).
+		Scan(&date, &hour, &total, &failed, &failureRate)
+	if err != nil {
+		t.Fatalf("read hourly pattern: %v", err)
+	}
+
+	wantDate := instant.In(zone).Format("2006-01-02")
+	if date.Format("2006-01-02") != wantDate || hour != 1 {
+		t.Errorf("bucket = %s hour %d, want %s hour 1", date.Format("2006-01-02"), hour, wantDate)
+	}
+	if total != 2 || failed != 1 || failureRate != 50 {
+		t.Errorf("total=%d failed=%d failure_rate=%v, want 2, 1, 50", total, failed, failureRate)
+	}
+}
+
+func TestAggregateHourlyPatternsIncremental(t *testing.T) {
+	db := newTestDB(t)
+	db.SetLocation(time.UTC)
+
+	save := func(stamps ...time.Time) {
+		t.Helper()
+		for _, stamp := range stamps {
+			if err := db.SaveResult(context.Background(), models.PingResult{Timestamp: stamp, Target: "8.8.8.8", Success: true, RTT: 10}); err != nil {
+				t.Fatalf("SaveResult() error = %v", err)
+			}
+		}
+	}
+	patternTotals := func() map[int]int {
+		t.Helper()
+		rows, err := db.Query(`SELECT hour, total_pings FROM hourly_patterns`)
+		if err != nil {
+			t.Fatalf("read hourly patterns: %v", err)
+		}
+		defer rows.Close()
+		totals := make(map[int]int)
+		for rows.Next() {
+			var hour, total int
+			if err := rows.Scan(&hour, &total); err != nil {
+				t.Fatalf("scan hourly pattern: %v", err)
+			}
+			totals[hour] = total
+		}
+		return totals
+	}
+
+	now := time.Now().UTC()
+	old := now.Add(-3 * time.Hour).Truncate(time.Hour).Add(10 * time.Minute)
+	save(old, old.Add(time.Minute))
+
+	// The first run has no watermark and covers the last week
+	if err := db.AggregateHourlyPatterns(context.Background()); err != nil {
+		t.Fatalf("AggregateHourlyPatterns() error = %v", err)
+	}
+	if totals := patternTotals(); len(totals) != 1 || totals[old.Hour()] != 2 {
+		t.Fatalf("hourly patterns after the first run = %v, want 2 pings at hour %d", totals, old.Hour())
+	}
+
+	// Mark the old row so a rewrite would show, then add pings in a new hour
+	if _, err := db.Exec(`UPDATE hourly_patterns SET total_pings = 99`); err != nil {
+		t.Fatalf("mark hourly pattern: %v", err)
+	}
+	recent := now.Add(-time.Minute)
+	save(recent, recent.Add(time.Second), recent.Add(2*time.Second))
+
+	if err := db.AggregateHourlyPatterns(context.Background()); err != nil {
+		t.Fatalf("AggregateHourlyPatterns() error = %v", err)
+	}
+	totals := patternTotals()
+	if totals[old.Hour()] != 99 {
+		t.Errorf("hour %d has %d pings, want the old row left untouched", old.Hour(), totals[old.Hour()])
+	}
+	if totals[recent.Hour()] != 3 {
+		t.Errorf("hour %d has %d pings, want the new hour's 3", recent.Hour(), totals[recent.Hour()])
+	}
+
+	// Backfill still rebuilds everything
+	if err := db.BackfillHourlyPatterns(context.Background()); err != nil {
+		t.Fatalf("BackfillHourlyPatterns() error = %v", err)
+	}
+	if totals := patternTotals(); totals[old.Hour()] != 2 {
+		t.Errorf("hour %d has %d pings after backfill, want 2", old.Hour(), totals[old.Hour()])
+	}
+}
+
+func TestHeatmapHourInLocation(t *testing.T) {
+	db := newTestDB(t)
+
+	// A zone far enough west that it is still yesterday there, so the local
+	// and UTC dates of the pings differ
+	now := time.Now().UTC()
+	zone := time.FixedZone("west", -(now.Hour()+1)*60*60)
+	db.SetLocation(zone)
+
+	instant := now.Add(-10 * time.Minute)
+	for i := 0; i < 4; i++ {
+		if err := db.SaveResult(context.Background(), models.PingResult{
+			Timestamp: instant.Add(time.Duration(i) * time.Second),
+			Target:    "8.8.8.8",
+			Success:   i != 0,
+			RTT:       20,
+		}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	if err := db.BackfillHourlyPatterns(context.Background()); err != nil {
+		t.Fatalf("BackfillHourlyPatterns() error = %v", err)
+	}
+
+	from, to := lastHours(24)
+	heatmap, err := db.GetHeatmapData(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetHeatmapData() error = %v", err)
+	}
+	if len(heatmap) != 1 {
+		t.Fatalf("got %d heatmap points, want 1: %+v", len(heatmap), heatmap)
+	}
+
+	wantHour := instant.In(zone).Hour()
+	if got := heatmap[0]; got.Hour != wantHour || got.TotalPings != 4 || got.TotalFailures != 1 {
+		t.Errorf("heatmap point = %+v, want hour %d with 4 pings and 1 failure", got, wantHour)
+	}
+
+	from, to = lastHours(24)
+	patterns, err := db.GetPatterns(context.Background(), fmt.Sprint(wantHour), from, to)
+	if err != nil {
+		t.Fatalf("GetPatterns() error = %v", err)
+	}
+	if len(patterns) != 1 {
+		t.Errorf("got %d patterns for hour %d, want 1", len(patterns), wantHour)
+	}
+}
+
+func TestArchivePreservesOutageWindows(t *testing.T) {
+	tests := []struct {
+		name          string
+		padding       time.Duration
+		wantPreserved int
+	}{
+		// 8.8.8.8 once a minute from 10 minutes before to 10 minutes after the
+		// 5-minute outage, plus the gateway's ping during it
+		{name: "padded", padding: 10 * time.Minute, wantPreserved: 26 + 1},
+		{name: "disabled", padding: 0, wantPreserved: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			db.SetPreservePadding(tt.padding)
+
+			start := time.Now().AddDate(0, 0, -10).Truncate(time.Minute)
+			id, err := db.RecordOutageStart(context.Background(), "8.8.8.8", start, 0)
+			if err != nil {
+				t.Fatalf("RecordOutageStart() error = %v", err)
+			}
+			if err := db.RecordOutageEnd(context.Background(), id, start.Add(5*time.Minute), 5, 0); err != nil {
+				t.Fatalf("RecordOutageEnd() error = %v", err)
+			}
+
+			results := []models.PingResult{
+				{Timestamp: start.Add(2 * time.Minute), Target: "192.168.1.1", Success: true, RTT: 1},
+				{Timestamp: time.Now().Add(-time.Hour), Target: "8.8.8.8", Success: true, RTT: 12},
+			}
+			for m := -60; m <= 65; m++ {
+				stamp := start.Add(time.Duration(m) * time.Minute)
+				inOutage := m >= 0 && m <= 5
+				results = append(results, models.PingResult{Timestamp: stamp, Target: "8.8.8.8", Success: !inOutage, RTT: 15})
+			}
+			for _, r := range results {
+				if err := db.SaveResult(context.Background(), r); err != nil {
+					t.Fatalf("SaveResult() error = %v", err)
+				}
+			}
+
+			// Archiving again must not copy anything twice
+			for i := 0; i < 2; i++ {
+				if err := db.ArchiveOldData(context.Background()); err != nil {
+					t.Fatalf("ArchiveOldData() error = %v", err)
+				}
+			}
+
+			var remaining int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM ping_results`).Scan(&remaining); err != nil {
+				t.Fatalf("count ping_results: %v", err)
+			}
+			if remaining != 1 {
+				t.Errorf("%d raw results left, want only the recent one", remaining)
+			}
+
+			rows, err := db.Query(`SELECT timestamp FROM preserved_results ORDER BY timestamp`)
+			if err != nil {
+				t.Fatalf("query preserved_results: %v", err)
+			}
+			defer rows.Close()
+			var preserved []time.Time
+			for rows.Next() {
+				var stamp time.Time
+				if err := rows.Scan(&stamp); err != nil {
+					t.Fatalf("scan preserved result: %v", err)
+				}
+				preserved = append(preserved, stamp)
+			}
+
+			if len(preserved) != tt.wantPreserved {
+				t.Fatalf("%d results preserved, want %d", len(preserved), tt.wantPreserved)
+			}
+			if len(preserved) > 0 {
+				first, last := preserved[0], preserved[len(preserved)-1]
+				if !first.Equal(start.Add(-tt.padding)) || !last.Equal(start.Add(5*time.Minute+tt.padding)) {
+					t.Errorf("preserved %v to %v, want the outage padded by %v", first, last, tt.padding)
+				}
+			}
+		})
+	}
+}
+
+// pageCounts returns the database's page count and how many of them are free
+func pageCounts(t *testing.T, db *DB) (pages, free int) {
+	t.Helper()
+	if err := db.QueryRow("PRAGMA page_count").Scan(&pages); err != nil {
+		t.Fatalf("read page_count: %v", err)
+	}
+	if err := db.QueryRow("PRAGMA freelist_count").Scan(&free); err != nil {
+		t.Fatalf("read freelist_count: %v", err)
+	}
+	return pages, free
+}
+
+func TestArchiveReclaimsPages(t *testing.T) {
+	db := newTestDB(t)
+
+	var autoVacuum int
+	if err := db.QueryRow("PRAGMA auto_vacuum").Scan(&autoVacuum); err != nil || autoVacuum != 2 {
+		t.Fatalf("auto_vacuum = %d, %v; want 2 (INCREMENTAL) on a new database", autoVacuum, err)
+	}
+
+	old := time.Now().AddDate(0, 0, -10)
+	message := strings.Repeat("request timed out ", 20)
+	for i := 0; i < 2000; i++ {
+		result := models.PingResult{Timestamp: old.Add(time.Duration(i) * time.Second), Target: "8.8.8.8", ErrorMessage: message}
+		if err := db.SaveResult(context.Background(), result); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+	before, _ := pageCounts(t, db)
+
+	if err := db.ArchiveOldData(context.Background()); err != nil {
+		t.Fatalf("ArchiveOldData() error = %v", err)
+	}
+
+	after, free := pageCounts(t, db)
+	if free != 0 || after >= before/2 {
+		t.Errorf("after archival %d pages with %d free, want well under the %d before and none free", after, free, before)
+	}
+}
+
+func TestVacuumConvertsToIncremental(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+
+	// A database created before incremental vacuuming was enabled
+	legacy, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatalf("open legacy database: %v", err)
+	}
+	if _, err := legacy.Exec("CREATE TABLE legacy (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+	legacy.Close()
+
+	db, err := New(path, DefaultJournalMode)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer db.Close()
+
+	var autoVacuum int
+	if err := db.QueryRow("PRAGMA auto_vacuum").Scan(&autoVacuum); err != nil || autoVacuum != 0 {
+		t.Fatalf("auto_vacuum = %d, %v; want 0 (NONE) until the database is vacuumed", autoVacuum, err)
+	}
+	if err := db.Vacuum(); err != nil {
+		t.Fatalf("Vacuum() error = %v", err)
+	}
+	if err := db.QueryRow("PRAGMA auto_vacuum").Scan(&autoVacuum); err != nil || autoVacuum != 2 {
+		t.Errorf("auto_vacuum = %d, %v; want 2 (INCREMENTAL) after Vacuum", autoVacuum, err)
+	}
+}
+
+func TestArchiveCountsWholeHours(t *testing.T) {
+	db := newTestDB(t)
+
+	// One result a minute from two hours before the cutoff to 30 minutes
+	// after, saved in a zone other than UTC
+	zone := time.FixedZone("UTC-5", -5*60*60)
+	cutoff := archiveCutoff(time.Now())
+	for m := -120; m < 30; m++ {
+		r := models.PingResult{Timestamp: cutoff.Add(time.Duration(m) * time.Minute).In(zone), Target: "8.8.8.8", Success: m%10 != 0, RTT: 10}
+		if err := db.SaveResult(context.Background(), r); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	// A second run after the next hour has passed must not lose any pings
+	for i := 0; i < 2; i++ {
+		if err := db.ArchiveOldData(context.Background()); err != nil {
+			t.Fatalf("ArchiveOldData() error = %v", err)
+		}
+	}
+
+	rows, err := db.Query(`SELECT hour, total_pings, successful_pings FROM hourly_stats ORDER BY hour`)
+	if err != nil {
+		t.Fatalf("query hourly_stats: %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var hour time.Time
+		var total, successful int
+		if err := rows.Scan(&hour, &total, &successful); err != nil {
+			t.Fatalf("scan hourly stat: %v", err)
+		}
+		got = append(got, fmt.Sprintf("%s %d/%d", hour.UTC().Format(time.DateTime), successful, total))
+	}
+	want := []string{
+		fmt.Sprintf("%s 54/60", cutoff.Add(-2*time.Hour).Format(time.DateTime)),
+		fmt.Sprintf("%s 54/60", cutoff.Add(-time.Hour).Format(time.DateTime)),
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("hourly_stats = %v, want %v (UTC hours before the cutoff)", got, want)
+	}
+
+	var remaining int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ping_results`).Scan(&remaining); err != nil {
+		t.Fatalf("count ping_results: %v", err)
+	}
+	if remaining != 30 {
+		t.Errorf("%d raw results left, want the 30 after the cutoff", remaining)
+	}
+}
@@ -0,0 +1,447 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// migration is a single, ordered schema change. Versions start at 1 and must be
+// contiguous; a migration is applied only when its version is greater than the
+// database's current schema version.
+type migration struct {
+	version     int
+	description string
+	apply       func(tx *sql.Tx) error
+}
+
+// migrations lists every schema change in order. Never edit or reorder an entry
+// that has shipped; append a new one instead.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "initial schema",
+		apply:       execMigration(schemaV1),
+	},
+	{
+		version:     2,
+		description: "ping attempts and monitoring events",
+		apply: func(tx *sql.Tx) error {
+			if err := addColumnIfMissing(tx, "ping_results", "attempts", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+				return err
+			}
+			return execMigration(`
+    -- Intentional gaps in monitoring (pause/resume markers)
+    CREATE TABLE IF NOT EXISTS monitoring_events (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        timestamp DATETIME NOT NULL,
+        event TEXT NOT NULL
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_monitoring_events_timestamp ON monitoring_events(timestamp);
+    `)(tx)
+		},
+	},
+	{
+		version:     3,
+		description: "traceroutes captured on outage",
+		apply: execMigration(`
+    CREATE TABLE IF NOT EXISTS traceroutes (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        outage_id INTEGER NOT NULL REFERENCES outages(id),
+        target TEXT NOT NULL,
+        timestamp DATETIME NOT NULL,
+        hop INTEGER NOT NULL,
+        address TEXT,
+        avg_rtt_ms REAL,
+        loss_percent REAL
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_traceroutes_outage ON traceroutes(outage_id, hop);
+    `),
+	},
+	{
+		version:     4,
+		description: "resolved IP and reply TTL per ping",
+		apply: func(tx *sql.Tx) error {
+			if err := addColumnIfMissing(tx, "ping_results", "resolved_ip", "TEXT"); err != nil {
+				return err
+			}
+			return addColumnIfMissing(tx, "ping_results", "ttl", "INTEGER")
+		},
+	},
+	{
+		version:     5,
+		description: "failure reason per ping",
+		apply: func(tx *sql.Tx) error {
+			return addColumnIfMissing(tx, "ping_results", "failure_reason", "TEXT")
+		},
+	},
+	{
+		version:     6,
+		description: "raw results preserved around outages",
+		apply: execMigration(`
+    -- Copies of ping_results rows near outages, kept past raw retention;
+    -- id is the id the row had in ping_results
+    CREATE TABLE IF NOT EXISTS preserved_results (
+        id INTEGER PRIMARY KEY,
+        timestamp DATETIME NOT NULL,
+        target TEXT NOT NULL,
+        success BOOLEAN NOT NULL,
+        rtt_ms REAL,
+        error_message TEXT,
+        attempts INTEGER NOT NULL DEFAULT 1,
+        resolved_ip TEXT,
+        ttl INTEGER,
+        failure_reason TEXT
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_preserved_target_timestamp ON preserved_results(target, timestamp);
+    `),
+	},
+	{
+		version:     7,
+		description: "95th percentile RTT per hourly pattern",
+		apply: func(tx *sql.Tx) error {
+			return addColumnIfMissing(tx, "hourly_patterns", "p95_rtt_ms", "REAL")
+		},
+	},
+	{
+		version:     8,
+		description: "incremental aggregation watermarks",
+		apply: execMigration(`
+    -- How far each aggregation has processed raw results, by aggregation name
+    CREATE TABLE IF NOT EXISTS aggregation_state (
+        name TEXT PRIMARY KEY,
+        watermark DATETIME NOT NULL
+    );
+    `),
+	},
+	{
+		version:     9,
+		description: "index failed results by target for the error breakdown",
+		apply: execMigration(`
+    CREATE INDEX IF NOT EXISTS idx_target_success ON ping_results(target, success);
+    `),
+	},
+	{
+		version:     10,
+		description: "raw ping output kept for debugging",
+		apply: execMigration(`
+    -- Output of failed or unparseable pings, only written with -debug-store-output
+    CREATE TABLE IF NOT EXISTS ping_debug (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        timestamp DATETIME NOT NULL,
+        target TEXT NOT NULL,
+        success BOOLEAN NOT NULL,
+        output TEXT NOT NULL
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_ping_debug_target_timestamp ON ping_debug(target, timestamp);
+    `),
+	},
+	{
+		version:     11,
+		description: "rewrite timestamps stored before UTC storage",
+		apply:       normalizeTimestamps,
+	},
+	{
+		version:     12,
+		description: "daily and monthly trend rollups",
+		apply: execMigration(`
+    -- Long-term rollups of hourly_patterns, kept indefinitely. Days and months
+    -- are in the configured time zone; a month is stored as its first day.
+    CREATE TABLE IF NOT EXISTS daily_stats (
+        day DATE NOT NULL,
+        target TEXT NOT NULL,
+        total_pings INTEGER NOT NULL,
+        successful_pings INTEGER NOT NULL,
+        avg_rtt_ms REAL,
+        p95_rtt_ms REAL,
+        outages INTEGER NOT NULL,
+        PRIMARY KEY (day, target)
+    );
+
+    CREATE TABLE IF NOT EXISTS monthly_stats (
+        month DATE NOT NULL,
+        target TEXT NOT NULL,
+        total_pings INTEGER NOT NULL,
+        successful_pings INTEGER NOT NULL,
+        avg_rtt_ms REAL,
+        p95_rtt_ms REAL,
+        outages INTEGER NOT NULL,
+        PRIMARY KEY (month, target)
+    );
+    `),
+	},
+	{
+		version:     13,
+		description: "target display names and colors",
+		apply: execMigration(`
+    -- How the dashboard shows a target; results stay keyed by the target
+    CREATE TABLE IF NOT EXISTS target_meta (
+        target TEXT PRIMARY KEY,
+        name TEXT NOT NULL DEFAULT '',
+        color TEXT NOT NULL DEFAULT ''
+    );
+    `),
+	},
+	{
+		version:     14,
+		description: "RTTs around recorded outages",
+		apply: func(tx *sql.Tx) error {
+			if err := addColumnIfMissing(tx, "outages", "rtt_before_ms", "REAL"); err != nil {
+				return err
+			}
+			return addColumnIfMissing(tx, "outages", "rtt_after_ms", "REAL")
+		},
+	},
+	{
+		version:     15,
+		description: "monitor instance per ping",
+		apply: func(tx *sql.Tx) error {
+			if err := addColumnIfMissing(tx, "ping_results", "instance", "TEXT"); err != nil {
+				return err
+			}
+			return addColumnIfMissing(tx, "preserved_results", "instance", "TEXT")
+		},
+	},
+}
+
+// legacyTimestampColumns are the columns that held times before they were
+// stored in UTC, by table
+var legacyTimestampColumns = []struct{ table, column string }{
+	{"ping_results", "timestamp"},
+	{"outages", "start_time"},
+	{"outages", "end_time"},
+	{"monitoring_events", "timestamp"},
+	{"traceroutes", "timestamp"},
+}
+
+// normalizeTimestamps rewrites times stored in local time or in the driver's
+// old time.String() layout to the UTC layout written since. SQLite's date
+// functions can't parse the old layout, and local times don't compare
+// correctly with UTC ones, so archival would bucket those rows wrongly.
+func normalizeTimestamps(tx *sql.Tx) error {
+	for _, c := range legacyTimestampColumns {
+		if err := normalizeColumn(tx, c.table, c.column); err != nil {
+			return fmt.Errorf("%s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+// normalizeColumn rewrites one column's non-UTC timestamps. Values that
+// don't parse are left alone.
+func normalizeColumn(tx *sql.Tx, table, column string) error {
+	type row struct {
+		id int64
+		ts time.Time
+	}
+
+	rows, err := tx.Query(fmt.Sprintf(`SELECT rowid, CAST(%[1]s AS TEXT) FROM %[2]s WHERE %[1]s IS NOT NULL AND %[1]s NOT LIKE '%%+00:00'`, column, table))
+	if err != nil {
+		return err
+	}
+	var pending []row
+	for rows.Next() {
+		var id int64
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			continue
+		}
+		ts, err := ParseTimestamp(value)
+		if err != nil {
+			continue
+		}
+		pending = append(pending, row{id, ts})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(fmt.Sprintf(`UPDATE %s SET %s = ? WHERE rowid = ?`, table, column))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range pending {
+		if _, err := stmt.Exec(r.ts.UTC(), r.id); err != nil {
+			return err
+		}
+	}
+	if len(pending) > 0 {
+		log.Printf("Rewrote %d legacy timestamps in %s.%s as UTC", len(pending), table, column)
+	}
+	return nil
+}
+
+// schemaV1 is the schema as it existed before versioning was introduced. It uses
+// IF NOT EXISTS so unversioned databases from older releases migrate cleanly.
+const schemaV1 = `
+    CREATE TABLE IF NOT EXISTS ping_results (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        timestamp DATETIME NOT NULL,
+        target TEXT NOT NULL,
+        success BOOLEAN NOT NULL,
+        rtt_ms REAL,
+        error_message TEXT,
+        created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_timestamp ON ping_results(timestamp);
+    CREATE INDEX IF NOT EXISTS idx_target_timestamp ON ping_results(target, timestamp);
+
+    CREATE TABLE IF NOT EXISTS outages (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        target TEXT NOT NULL,
+        start_time DATETIME NOT NULL,
+        end_time DATETIME,
+        duration_seconds INTEGER,
+        checks_failed INTEGER
+    );
+
+    CREATE TABLE IF NOT EXISTS hourly_stats (
+        hour DATETIME NOT NULL,
+        target TEXT NOT NULL,
+        total_pings INTEGER,
+        successful_pings INTEGER,
+        avg_rtt_ms REAL,
+        max_rtt_ms REAL,
+        min_rtt_ms REAL,
+        p95_rtt_ms REAL,
+        p99_rtt_ms REAL,
+        packet_loss_percent REAL,
+        PRIMARY KEY (hour, target)
+    );
+
+    -- New table for heatmap data (aggregated by hour of day)
+    CREATE TABLE IF NOT EXISTS hourly_patterns (
+        date DATE NOT NULL,
+        hour INTEGER NOT NULL, -- 0-23
+        target TEXT NOT NULL,
+        total_pings INTEGER,
+        failed_pings INTEGER,
+        avg_rtt_ms REAL,
+        max_rtt_ms REAL,
+        failure_rate REAL,
+        PRIMARY KEY (date, hour, target)
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_hourly_patterns ON hourly_patterns(hour, target);
+    CREATE INDEX IF NOT EXISTS idx_hourly_patterns_date ON hourly_patterns(date);
+    CREATE INDEX IF NOT EXISTS idx_hourly_patterns_hour_date ON hourly_patterns(hour, date);
+    CREATE INDEX IF NOT EXISTS idx_ping_success_timestamp ON ping_results(success, timestamp);
+    CREATE INDEX IF NOT EXISTS idx_outages_start_time ON outages(start_time);
+    `
+
+// execMigration returns a migration step that executes the given SQL
+func execMigration(query string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// SchemaVersion returns the highest migration version applied to the database
+func (db *DB) SchemaVersion() (int, error) {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+        version INTEGER PRIMARY KEY,
+        applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    )`); err != nil {
+		return 0, err
+	}
+
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// migrate applies pending migrations up to and including the target version,
+// each in its own transaction together with its schema_version row
+func (db *DB) migrate(target int) error {
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d)", current, len(migrations))
+	}
+
+	for _, m := range migrations {
+		if m.version <= current || m.version > target {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := m.apply(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.description, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_version (version) VALUES (?)", m.version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("record migration %d: %w", m.version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit migration %d: %w", m.version, err)
+		}
+
+		log.Printf("Applied database migration %d: %s", m.version, m.description)
+	}
+
+	return nil
+}
+
+// addColumnIfMissing adds a column to a table unless it already exists, which
+// covers databases that picked the column up before it had a migration
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// columnExists reports whether the table has a column with the given name
+func columnExists(q queryer, table, column string) (bool, error) {
+	rows, err := q.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrationsAreContiguous(t *testing.T) {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Fatalf("migration at index %d has version %d, want %d", i, m.version, i+1)
+		}
+	}
+}
+
+func TestMigrateV1DatabaseForward(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"), DefaultJournalMode)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer db.Close()
+
+	if err := db.migrate(1); err != nil {
+		t.Fatalf("migrate(1) error = %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO ping_results (timestamp, target, success, rtt_ms) VALUES (datetime('now'), '8.8.8.8', 1, 10.5)`); err != nil {
+		t.Fatalf("insert v1 row: %v", err)
+	}
+	if exists, err := columnExists(db, "ping_results", "attempts"); err != nil || exists {
+		t.Fatalf("v1 schema should not have attempts column (exists=%v, err=%v)", exists, err)
+	}
+
+	if err := db.InitSchema(); err != nil {
+		t.Fatalf("InitSchema() error = %v", err)
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion() error = %v", err)
+	}
+	if version != len(migrations) {
+		t.Errorf("schema version = %d, want %d", version, len(migrations))
+	}
+
+	if exists, err := columnExists(db, "ping_results", "attempts"); err != nil || !exists {
+		t.Fatalf("expected attempts column after migration (exists=%v, err=%v)", exists, err)
+	}
+
+	var attempts int
+	if err := db.QueryRow("SELECT attempts FROM ping_results").Scan(&attempts); err != nil {
+		t.Fatalf("read migrated row: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("migrated row attempts = %d, want 1", attempts)
+	}
+
+	// Running again is a no-op
+	if err := db.InitSchema(); err != nil {
+		t.Fatalf("second InitSchema() error = %v", err)
+	}
+}
+
+func TestMigrateRewritesLegacyTimestamps(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"), DefaultJournalMode)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer db.Close()
+
+	if err := db.migrate(10); err != nil {
+		t.Fatalf("migrate(10) error = %v", err)
+	}
+
+	// 12:30 UTC as written by older releases: in local time by the driver's
+	// default time.String() layout, and by SQLite itself
+	legacy := []string{
+		"2024-05-01 15:30:00.5 +0300 EEST m=+12.000000001",
+		"2024-05-01 08:30:00.5 -0400 EDT",
+		"2024-05-01 12:30:00.5+00:00",
+	}
+	for _, value := range legacy {
+		if _, err := db.Exec(`INSERT INTO ping_results (timestamp, target, success, rtt_ms) VALUES (?, '8.8.8.8', 1, 10)`, value); err != nil {
+			t.Fatalf("insert legacy row: %v", err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO outages (target, start_time, end_time) VALUES ('8.8.8.8', ?, NULL)`, legacy[0]); err != nil {
+		t.Fatalf("insert legacy outage: %v", err)
+	}
+
+	if err := db.InitSchema(); err != nil {
+		t.Fatalf("InitSchema() error = %v", err)
+	}
+
+	rows, err := db.Query(`SELECT CAST(timestamp AS TEXT), strftime('%Y-%m-%d %H:00:00', timestamp) FROM ping_results
+        UNION ALL SELECT CAST(start_time AS TEXT), strftime('%Y-%m-%d %H:00:00', start_time) FROM outages`)
+	if err != nil {
+		t.Fatalf("query timestamps: %v", err)
+	}
+	defer rows.Close()
+	var n int
+	for rows.Next() {
+		var stored string
+		var hour sql.NullString
+		if err := rows.Scan(&stored, &hour); err != nil {
+			t.Fatalf("scan timestamp: %v", err)
+		}
+		if stored != "2024-05-01 12:30:00.5+00:00" || hour.String != "2024-05-01 12:00:00" {
+			t.Errorf("stored %q in hour %q, want 2024-05-01 12:30:00.5+00:00 in hour 2024-05-01 12:00:00", stored, hour.String)
+		}
+		n++
+	}
+	if n != len(legacy)+1 {
+		t.Errorf("read %d timestamps, want %d", n, len(legacy)+1)
+	}
+}
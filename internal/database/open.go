@@ -0,0 +1,172 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Reasons a database can't be opened that may clear up on their own, e.g. while
+// a volume is still being mounted or another process finishes with the file
+var (
+	ErrDirectoryMissing = errors.New("database directory does not exist")
+	ErrPermission       = errors.New("permission denied")
+	ErrLocked           = errors.New("database is locked by another process")
+)
+
+// ErrCorrupt is returned when the database file fails SQLite's integrity check
+// or isn't a database at all, e.g. after power loss in the middle of a write
+var ErrCorrupt = errors.New("database is corrupt")
+
+// maxOpenBackoff caps the wait between open attempts
+const maxOpenBackoff = 30 * time.Second
+
+// Open opens the database and applies pending migrations. Failures that may be
+// transient (see ErrDirectoryMissing, ErrPermission and ErrLocked) are retried
+// up to retries more times, waiting backoff before the first retry and twice as
+// long before each one after that.
+func Open(path, journalMode string, retries int, backoff time.Duration) (*DB, error) {
+	for attempt := 0; ; attempt++ {
+		db, err := openAndMigrate(path, journalMode)
+		if err == nil {
+			return db, nil
+		}
+
+		err = diagnoseOpenError(path, err)
+		if attempt >= retries || !isTransientOpenError(err) {
+			return nil, err
+		}
+
+		log.Printf("Database not ready (attempt %d of %d): %v; retrying in %v", attempt+1, retries+1, err, backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxOpenBackoff)
+	}
+}
+
+// OpenOrRecover opens the database like Open, but when it is corrupt, moves
+// it aside with SetAsideCorrupt and starts a fresh one in its place, so
+// monitoring resumes at the cost of the history, which stays in the moved file
+func OpenOrRecover(path, journalMode string, retries int, backoff time.Duration) (*DB, error) {
+	db, err := Open(path, journalMode, retries, backoff)
+	if !errors.Is(err, ErrCorrupt) {
+		return db, err
+	}
+
+	aside, moveErr := SetAsideCorrupt(path, time.Now())
+	if moveErr != nil {
+		return nil, fmt.Errorf("%w; moving it aside failed: %v", err, moveErr)
+	}
+	log.Printf("WARNING: %v", err)
+	log.Printf("WARNING: Moved the corrupt database to %s and started a fresh one at %s; earlier history is only in the moved file", aside, path)
+	return Open(path, journalMode, retries, backoff)
+}
+
+// SetAsideCorrupt renames a corrupt database, along with its WAL and shared
+// memory files, to the same name with a .corrupt-<time> suffix, and returns
+// the new name of the database file
+func SetAsideCorrupt(path string, now time.Time) (string, error) {
+	aside := path + ".corrupt-" + now.Format("20060102-150405")
+	if err := os.Rename(path, aside); err != nil {
+		return "", err
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Rename(path+suffix, aside+suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return aside, err
+		}
+	}
+	return aside, nil
+}
+
+func openAndMigrate(path, journalMode string) (*DB, error) {
+	db, err := New(path, journalMode)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.checkIntegrity(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := db.InitSchema(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// checkIntegrity runs SQLite's integrity check, which reads the whole file, and
+// returns ErrCorrupt with the first problems it reports
+func (db *DB) checkIntegrity() error {
+	rows, err := db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return fmt.Errorf("integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return fmt.Errorf("integrity check: %w", err)
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("integrity check: %w", err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrCorrupt, strings.Join(problems[:min(len(problems), 3)], "; "))
+	}
+	return nil
+}
+
+// diagnoseOpenError wraps err with the likely cause, since SQLite reports most
+// of them as a bare "unable to open database file"
+func diagnoseOpenError(path string, err error) error {
+	dir := filepath.Dir(path)
+	if _, statErr := os.Stat(dir); errors.Is(statErr, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s (is the volume mounted?): %v", ErrDirectoryMissing, dir, err)
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case errors.Is(err, ErrCorrupt):
+		return err
+	case strings.Contains(msg, "malformed") || strings.Contains(msg, "not a database") || strings.Contains(msg, "sqlite_corrupt"):
+		return fmt.Errorf("%w: %s: %v", ErrCorrupt, path, err)
+	case strings.Contains(msg, "locked") || strings.Contains(msg, "sqlite_busy"):
+		return fmt.Errorf("%w: %s: %v", ErrLocked, path, err)
+	case errors.Is(err, os.ErrPermission) || strings.Contains(msg, "readonly") ||
+		strings.Contains(msg, "permission denied") || !writable(path):
+		return fmt.Errorf("%w: %s must be writable by this user: %v", ErrPermission, path, err)
+	}
+	return err
+}
+
+// writable reports whether the database file, or its directory when the file
+// doesn't exist yet, can be written
+func writable(path string) bool {
+	if f, err := os.OpenFile(path, os.O_RDWR, 0); err == nil {
+		f.Close()
+		return true
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".network-monitor-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
+func isTransientOpenError(err error) bool {
+	return errors.Is(err, ErrDirectoryMissing) || errors.Is(err, ErrPermission) || errors.Is(err, ErrLocked)
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestOpenWaitsForMissingDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "not-mounted-yet")
+	path := filepath.Join(dir, "test.db")
+
+	// The "volume" appears after the first attempt has failed
+	created := make(chan error, 1)
+	time.AfterFunc(50*time.Millisecond, func() { created <- os.Mkdir(dir, 0o755) })
+
+	db, err := Open(path, DefaultJournalMode, 10, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Open() error = %v, want success once the directory exists", err)
+	}
+	defer db.Close()
+	if err := <-created; err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil || version != len(migrations) {
+		t.Errorf("SchemaVersion() = %d, %v; want %d", version, err, len(migrations))
+	}
+}
+
+func TestOpenReportsMissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "test.db")
+
+	start := time.Now()
+	_, err := Open(path, DefaultJournalMode, 2, 10*time.Millisecond)
+	if !errors.Is(err, ErrDirectoryMissing) {
+		t.Fatalf("Open() error = %v, want ErrDirectoryMissing", err)
+	}
+	// Two retries wait 10ms then 20ms
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Open() gave up after %v, want it to retry with backoff", elapsed)
+	}
+}
+
+func TestDiagnoseOpenError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.db")
+	cause := errors.New("database connect failed")
+
+	tests := []struct {
+		name string
+		path string
+		err  error
+		want error
+	}{
+		{"missing directory", filepath.Join(dir, "missing", "test.db"), cause, ErrDirectoryMissing},
+		{"locked", path, errors.New("database is locked (5) (SQLITE_BUSY)"), ErrLocked},
+		{"read-only", path, errors.New("attempt to write a readonly database (8)"), ErrPermission},
+		{"permission error", path, os.ErrPermission, ErrPermission},
+		{"corrupt", path, errors.New("database disk image is malformed (11)"), ErrCorrupt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := diagnoseOpenError(tt.path, tt.err); !errors.Is(err, tt.want) {
+				t.Errorf("diagnoseOpenError() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("other errors are not transient", func(t *testing.T) {
+		err := diagnoseOpenError(path, ErrJournalMode)
+		if !errors.Is(err, ErrJournalMode) || isTransientOpenError(err) {
+			t.Errorf("diagnoseOpenError() = %v, want ErrJournalMode left as a permanent error", err)
+		}
+	})
+}
+
+// corruptDatabase creates a database with some results at path, then
+// overwrites its last page, which holds results, with garbage as a torn write
+// might. The schema still reads, so only the integrity check notices.
+func corruptDatabase(t *testing.T, path string) {
+	t.Helper()
+
+	db, err := Open(path, DefaultJournalMode, 0, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	now := time.Now()
+	for i := 0; i < 500; i++ {
+		result := models.PingResult{Timestamp: now.Add(-time.Duration(i) * time.Second), Target: "8.8.8.8", Success: true, RTT: 10}
+		if err := db.SaveResult(context.Background(), result); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+	db.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read database: %v", err)
+	}
+	for i := len(data) - 4096 + 100; i < len(data); i++ {
+		data[i] = byte(i * 7)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write database: %v", err)
+	}
+}
+
+func TestOpenDetectsCorruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, path string)
+	}{
+		{"damaged pages", corruptDatabase},
+		{"not a database", func(t *testing.T, path string) {
+			if err := os.WriteFile(path, bytes.Repeat([]byte("garbage!"), 1024), 0o644); err != nil {
+				t.Fatalf("write database: %v", err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.db")
+			tt.corrupt(t, path)
+
+			if _, err := Open(path, DefaultJournalMode, 2, time.Millisecond); !errors.Is(err, ErrCorrupt) {
+				t.Fatalf("Open() error = %v, want ErrCorrupt", err)
+			}
+
+			db, err := OpenOrRecover(path, DefaultJournalMode, 0, time.Millisecond)
+			if err != nil {
+				t.Fatalf("OpenOrRecover() error = %v, want a fresh database", err)
+			}
+			defer db.Close()
+			if version, err := db.SchemaVersion(); err != nil || version != len(migrations) {
+				t.Errorf("SchemaVersion() = %d, %v; want %d", version, err, len(migrations))
+			}
+			if targets, err := db.GetTargets(context.Background()); err != nil || len(targets) != 0 {
+				t.Errorf("GetTargets() = %v, %v; want an empty database", targets, err)
+			}
+
+			aside, err := filepath.Glob(path + ".corrupt-*")
+			if err != nil || len(aside) != 1 {
+				t.Fatalf("corrupt files set aside = %v, %v; want one", aside, err)
+			}
+			if _, err := Open(aside[0], DefaultJournalMode, 0, time.Millisecond); !errors.Is(err, ErrCorrupt) {
+				t.Errorf("Open(set-aside file) error = %v, want the corrupt database kept as it was", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// maxOutages caps the number of outages returned by GetOutages
+const maxOutages = 100
+
+// ErrTracerouteNotFound is returned when no traceroute exists for an outage
+var ErrTracerouteNotFound = errors.New("traceroute not found")
+
+// RecordOutageStart opens an outage row for the target and returns its ID.
+// rttBefore is the RTT of the last successful ping before it, 0 if unknown.
+func (db *DB) RecordOutageStart(ctx context.Context, target string, start time.Time, rttBefore float64) (int64, error) {
+	res, err := db.ExecContext(ctx, `INSERT INTO outages (target, start_time, rtt_before_ms) VALUES (?, ?, ?)`,
+		target, start.UTC(), knownRTT(rttBefore))
+	if err != nil {
+		return 0, fmt.Errorf("record outage start: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// RecordOutageEnd closes an open outage at the time connectivity recovered.
+// rttAfter is the RTT of the ping that recovered, 0 if unknown.
+func (db *DB) RecordOutageEnd(ctx context.Context, id int64, end time.Time, checksFailed int, rttAfter float64) error {
+	var start time.Time
+	if err := db.QueryRowContext(ctx, `SELECT start_time FROM outages WHERE id = ?`, id).Scan(&start); err != nil {
+		return fmt.Errorf("record outage end: load outage %d: %w", id, err)
+	}
+
+	query := `
+        UPDATE outages
+        SET end_time = ?, duration_seconds = ?, checks_failed = ?, rtt_after_ms = ?
+        WHERE id = ?
+    `
+	durationSeconds := int64(end.Sub(start).Round(time.Second) / time.Second)
+	if _, err := db.ExecContext(ctx, query, end.UTC(), durationSeconds, checksFailed, knownRTT(rttAfter), id); err != nil {
+		return fmt.Errorf("record outage end: %w", err)
+	}
+	return nil
+}
+
+// getRecordedOutages retrieves outages persisted by the monitor that started
+// between from and to and lasted at least minDuration, newest first. Outages
+// still in progress report the current time as their end.
+func (db *DB) getRecordedOutages(ctx context.Context, from, to time.Time, minDuration time.Duration) ([]models.Outage, error) {
+	query := `
+        SELECT id, target, start_time, end_time, COALESCE(checks_failed, 0),
+            COALESCE(rtt_before_ms, 0), COALESCE(rtt_after_ms, 0)
+        FROM outages
+        WHERE start_time >= ? AND start_time <= ?
+        AND (? = 0 OR duration_seconds >= ? OR (end_time IS NULL AND start_time <= ?))
+        ORDER BY start_time DESC
+        LIMIT ?
+    `
+
+	minSeconds := int64(minDuration / time.Second)
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC(),
+		minSeconds, minSeconds, time.Now().Add(-minDuration).UTC(), maxOutages)
+	if err != nil {
+		return nil, err
+	}
+	return scanRecordedOutages(rows)
+}
+
+// scanRecordedOutages reads outage table rows, selected as id, target,
+// start_time, end_time, checks_failed, rtt_before_ms and rtt_after_ms, and
+// closes them
+func scanRecordedOutages(rows *sql.Rows) ([]models.Outage, error) {
+	defer rows.Close()
+
+	var outages []models.Outage
+	for rows.Next() {
+		var o models.Outage
+		var end sql.NullTime
+		if err := rows.Scan(&o.ID, &o.Target, &o.StartTime, &end, &o.FailedChecks, &o.RTTBefore, &o.RTTAfter); err != nil {
+			continue
+		}
+		if end.Valid {
+			o.EndTime = end.Time
+		} else {
+			o.EndTime = time.Now()
+			o.Ongoing = true
+		}
+		o.Duration = o.EndTime.Sub(o.StartTime).String()
+		outages = append(outages, o)
+	}
+
+	return outages, rows.Err()
+}
+
+// knownRTT stores an RTT of 0, meaning unknown, as NULL
+func knownRTT(rtt float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: rtt, Valid: rtt > 0}
+}
+
+// mergeOutages combines recorded outages with ones detected from raw pings,
+// dropping detected outages that overlap a recorded one for the same target.
+// The result is sorted newest first and capped at limit.
+func mergeOutages(recorded, detected []models.Outage, limit int) []models.Outage {
+	merged := make([]models.Outage, 0, len(recorded)+len(detected))
+	merged = append(merged, recorded...)
+
+	for _, d := range detected {
+		overlaps := false
+		for _, r := range recorded {
+			if r.Target == d.Target && !d.StartTime.After(r.EndTime) && !r.StartTime.After(d.EndTime) {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			merged = append(merged, d)
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].StartTime.After(merged[j].StartTime)
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
+
+// outagesLasting returns the outages that lasted at least minDuration
+func outagesLasting(outages []models.Outage, minDuration time.Duration) []models.Outage {
+	if minDuration <= 0 {
+		return outages
+	}
+	var lasting []models.Outage
+	for _, o := range outages {
+		if o.EndTime.Sub(o.StartTime) >= minDuration {
+			lasting = append(lasting, o)
+		}
+	}
+	return lasting
+}
+
+// SaveTraceroute stores each hop of a traceroute against its outage
+func (db *DB) SaveTraceroute(ctx context.Context, trace models.Traceroute) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO traceroutes (outage_id, target, timestamp, hop, address, avg_rtt_ms, loss_percent)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, hop := range trace.Hops {
+		if _, err := stmt.ExecContext(ctx, trace.OutageID, trace.Target, trace.Timestamp.UTC(),
+			hop.Hop, hop.Address, hop.AvgRTT, hop.Loss); err != nil {
+			return fmt.Errorf("save traceroute hop %d: %w", hop.Hop, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetTraceroute retrieves the traceroute captured for an outage
+func (db *DB) GetTraceroute(ctx context.Context, outageID int64) (models.Traceroute, error) {
+	query := `
+        SELECT target, timestamp, hop, address, avg_rtt_ms, loss_percent
+        FROM traceroutes
+        WHERE outage_id = ?
+        ORDER BY hop
+    `
+
+	rows, err := db.QueryContext(ctx, query, outageID)
+	if err != nil {
+		return models.Traceroute{}, err
+	}
+	return scanTraceroute(rows, outageID)
+}
+
+// scanTraceroute reads the hop rows of an outage's traceroute and closes them
+func scanTraceroute(rows *sql.Rows, outageID int64) (models.Traceroute, error) {
+	defer rows.Close()
+
+	trace := models.Traceroute{OutageID: outageID}
+	for rows.Next() {
+		var hop models.TracerouteHop
+		var address sql.NullString
+		if err := rows.Scan(&trace.Target, &trace.Timestamp, &hop.Hop, &address, &hop.AvgRTT, &hop.Loss); err != nil {
+			continue
+		}
+		hop.Address = address.String
+		trace.Hops = append(trace.Hops, hop)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Traceroute{}, err
+	}
+
+	if len(trace.Hops) == 0 {
+		return models.Traceroute{}, ErrTracerouteNotFound
+	}
+	return trace, nil
+}
@@ -0,0 +1,255 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestRecordOutageStartAndEnd(t *testing.T) {
+	db := newTestDB(t)
+
+	start := time.Now().Add(-2 * time.Minute).Truncate(time.Second)
+	id, err := db.RecordOutageStart(context.Background(), "8.8.8.8", start, 0)
+	if err != nil {
+		t.Fatalf("RecordOutageStart() error = %v", err)
+	}
+
+	if err := db.RecordOutageEnd(context.Background(), id, start.Add(90*time.Second), 12, 0); err != nil {
+		t.Fatalf("RecordOutageEnd() error = %v", err)
+	}
+
+	var durationSeconds, checksFailed int
+	if err := db.QueryRow(`SELECT duration_seconds, checks_failed FROM outages WHERE id = ?`, id).
+		Scan(&durationSeconds, &checksFailed); err != nil {
+		t.Fatalf("read outage: %v", err)
+	}
+	if durationSeconds != 90 {
+		t.Errorf("duration_seconds = %d, want 90", durationSeconds)
+	}
+	if checksFailed != 12 {
+		t.Errorf("checks_failed = %d, want 12", checksFailed)
+	}
+
+	if err := db.RecordOutageEnd(context.Background(), id+1, time.Now(), 1, 0); err == nil {
+		t.Error("expected error closing a non-existent outage")
+	}
+}
+
+func TestOutageSurroundingRTTs(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	// good, good (spiking), six failures, good: detected from raw pings
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, rtt := range []float64{12, 180, 0, 0, 0, 0, 0, 0, 14, 13} {
+		if err := db.SaveResult(ctx, models.PingResult{
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Target:    "1.1.1.1",
+			Success:   rtt > 0,
+			RTT:       rtt,
+		}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	// Recorded by the monitor, with the RTTs it saw
+	id, err := db.RecordOutageStart(ctx, "8.8.8.8", base, 95.5)
+	if err != nil {
+		t.Fatalf("RecordOutageStart() error = %v", err)
+	}
+	if err := db.RecordOutageEnd(ctx, id, base.Add(time.Minute), 6, 21.25); err != nil {
+		t.Fatalf("RecordOutageEnd() error = %v", err)
+	}
+
+	from, to := lastHours(2)
+	outages, err := db.GetOutages(ctx, from, to)
+	if err != nil {
+		t.Fatalf("GetOutages() error = %v", err)
+	}
+	want := map[string][2]float64{"1.1.1.1": {180, 14}, "8.8.8.8": {95.5, 21.25}}
+	if len(outages) != len(want) {
+		t.Fatalf("got %d outages, want %d: %+v", len(outages), len(want), outages)
+	}
+	for _, o := range outages {
+		if got := [2]float64{o.RTTBefore, o.RTTAfter}; got != want[o.Target] {
+			t.Errorf("%s RTTs before and after = %v, want %v", o.Target, got, want[o.Target])
+		}
+	}
+}
+
+func TestGetOutagesIncludesArchivedOutages(t *testing.T) {
+	db := newTestDB(t)
+
+	// An outage from three weeks ago whose raw pings have already been archived
+	archivedStart := time.Now().Add(-21 * 24 * time.Hour)
+	id, err := db.RecordOutageStart(context.Background(), "8.8.8.8", archivedStart, 0)
+	if err != nil {
+		t.Fatalf("RecordOutageStart() error = %v", err)
+	}
+	if err := db.RecordOutageEnd(context.Background(), id, archivedStart.Add(5*time.Minute), 300, 0); err != nil {
+		t.Fatalf("RecordOutageEnd() error = %v", err)
+	}
+
+	// A recent run of failures that only exists in raw ping data
+	recentStart := time.Now().Add(-time.Hour)
+	for i := 0; i < 10; i++ {
+		if err := db.SaveResult(context.Background(), models.PingResult{
+			Timestamp: recentStart.Add(time.Duration(i) * time.Second),
+			Target:    "1.1.1.1",
+			Success:   false,
+		}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	from, to := lastHours(30 * 24)
+	outages, err := db.GetOutages(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetOutages() error = %v", err)
+	}
+
+	var foundArchived, foundRecent bool
+	for _, o := range outages {
+		switch o.Target {
+		case "8.8.8.8":
+			foundArchived = o.ID == id
+		case "1.1.1.1":
+			foundRecent = true
+		}
+	}
+	if !foundArchived {
+		t.Errorf("expected archived outage %d in 30-day results: %+v", id, outages)
+	}
+	if !foundRecent {
+		t.Errorf("expected recent detected outage in 30-day results: %+v", outages)
+	}
+
+	from, to = lastHours(7 * 24)
+	week, err := db.GetOutages(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetOutages() error = %v", err)
+	}
+	for _, o := range week {
+		if o.Target == "8.8.8.8" {
+			t.Errorf("archived outage should not appear in a 7-day query")
+		}
+	}
+}
+
+func TestMergeOutagesDropsOverlappingDetections(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	recorded := []models.Outage{
+		{ID: 1, Target: "8.8.8.8", StartTime: base, EndTime: base.Add(time.Minute)},
+	}
+	detected := []models.Outage{
+		{Target: "8.8.8.8", StartTime: base.Add(10 * time.Second), EndTime: base.Add(50 * time.Second)},
+		{Target: "1.1.1.1", StartTime: base.Add(10 * time.Second), EndTime: base.Add(50 * time.Second)},
+		{Target: "8.8.8.8", StartTime: base.Add(time.Hour), EndTime: base.Add(time.Hour + time.Minute)},
+	}
+
+	merged := mergeOutages(recorded, detected, 100)
+	if len(merged) != 3 {
+		t.Fatalf("expected 3 outages after merge, got %d: %+v", len(merged), merged)
+	}
+	if !merged[0].StartTime.Equal(base.Add(time.Hour)) {
+		t.Errorf("expected newest outage first, got %v", merged[0].StartTime)
+	}
+
+	if got := mergeOutages(recorded, detected, 2); len(got) != 2 {
+		t.Errorf("expected limit to cap results at 2, got %d", len(got))
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"go string", "2024-05-01 12:30:15 +0000 UTC"},
+		{"go string with monotonic clock", "2024-05-01 12:30:15 +0000 UTC m=+0.001234"},
+		{"sqlite with offset", "2024-05-01 12:30:15-00:00"},
+		{"rfc3339", "2024-05-01T12:30:15Z"},
+		{"sqlite datetime", "2024-05-01 12:30:15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimestamp(tt.value)
+			if err != nil {
+				t.Fatalf("ParseTimestamp(%q) error = %v", tt.value, err)
+			}
+			if !got.Equal(want) {
+				t.Errorf("ParseTimestamp(%q) = %v, want %v", tt.value, got, want)
+			}
+		})
+	}
+
+	if _, err := ParseTimestamp("yesterday"); err == nil {
+		t.Error("expected error for unparseable timestamp")
+	}
+}
+
+func TestDetectOutagesThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold models.OutageThreshold
+		pattern   string // one ping per character, X = failed
+		wantFirst int    // index of the first failed ping in the outage, -1 for none
+		wantLast  int
+		wantCount int
+	}{
+		{name: "consecutive below threshold", threshold: models.OutageThreshold{Window: 3, Failures: 3}, pattern: "..XX..", wantFirst: -1},
+		{name: "consecutive at threshold", threshold: models.OutageThreshold{Window: 3, Failures: 3}, pattern: "..XXX..", wantFirst: 2, wantLast: 4, wantCount: 3},
+		{name: "consecutive interrupted", threshold: models.OutageThreshold{Window: 3, Failures: 3}, pattern: "..XX.XX..", wantFirst: -1},
+		{name: "window below threshold", threshold: models.OutageThreshold{Window: 10, Failures: 5}, pattern: ".....X.X.X.X......", wantFirst: -1},
+		{name: "window at threshold", threshold: models.OutageThreshold{Window: 10, Failures: 5}, pattern: ".....X.X.X.X.X.....", wantFirst: 5, wantLast: 13, wantCount: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			base := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+			for i, c := range tt.pattern {
+				if err := db.SaveResult(context.Background(), models.PingResult{
+					Timestamp: base.Add(time.Duration(i) * time.Second),
+					Target:    "8.8.8.8",
+					Success:   c != 'X',
+					RTT:       10,
+				}); err != nil {
+					t.Fatalf("SaveResult() error = %v", err)
+				}
+			}
+
+			from, to := lastHours(24)
+			outages, err := db.DetectOutages(context.Background(), from, to, tt.threshold)
+			if err != nil {
+				t.Fatalf("DetectOutages() error = %v", err)
+			}
+
+			if tt.wantFirst < 0 {
+				if len(outages) != 0 {
+					t.Fatalf("expected no outages, got %+v", outages)
+				}
+				return
+			}
+			if len(outages) != 1 {
+				t.Fatalf("expected 1 outage, got %+v", outages)
+			}
+			o := outages[0]
+			if want := base.Add(time.Duration(tt.wantFirst) * time.Second); !o.StartTime.Equal(want) {
+				t.Errorf("start = %v, want %v", o.StartTime, want)
+			}
+			if want := base.Add(time.Duration(tt.wantLast) * time.Second); !o.EndTime.Equal(want) {
+				t.Errorf("end = %v, want %v", o.EndTime, want)
+			}
+			if o.FailedChecks != tt.wantCount {
+				t.Errorf("failed checks = %d, want %d", o.FailedChecks, tt.wantCount)
+			}
+		})
+	}
+}
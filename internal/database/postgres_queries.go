@@ -0,0 +1,747 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// The queries below mirror the SQLite ones in queries.go, outages.go and sla.go,
+// translated where SQLite's dialect differs: datetime('now', ...) becomes
+// now() - make_interval(...), strftime becomes date_trunc or to_char, booleans
+// are compared with NOT rather than 0, and parameters are numbered.
+
+// postgresInsertResultQuery inserts a ping result with resultArgs
+const postgresInsertResultQuery = `
+        INSERT INTO ping_results (timestamp, target, success, rtt_ms, error_message, attempts, resolved_ip, ttl, failure_reason, instance)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+    `
+
+// SaveResult saves a ping result to the database
+func (db *PostgresDB) SaveResult(ctx context.Context, result models.PingResult) error {
+	_, err := db.ExecContext(ctx, postgresInsertResultQuery, resultArgs(result)...)
+	return err
+}
+
+// SaveResults saves many ping results in one transaction
+func (db *PostgresDB) SaveResults(ctx context.Context, results []models.PingResult) error {
+	return saveResults(ctx, db.DB, postgresInsertResultQuery, results)
+}
+
+// GetRecent retrieves recent ping results for all targets, newest first
+func (db *PostgresDB) GetRecent(ctx context.Context, hours int) ([]models.PingResult, error) {
+	now := time.Now()
+	return db.GetRecentFiltered(ctx, now.Add(-time.Duration(hours)*time.Hour), now, "", "", MaxRecentLimit, 0)
+}
+
+// GetRecentFiltered retrieves one page of the ping results between from and
+// to, newest first. An empty target or instance matches all of them; limit is
+// clamped to MaxRecentLimit.
+func (db *PostgresDB) GetRecentFiltered(ctx context.Context, from, to time.Time, target, instance string, limit, offset int) ([]models.PingResult, error) {
+	if limit <= 0 || limit > MaxRecentLimit {
+		limit = MaxRecentLimit
+	}
+	query := `
+        SELECT timestamp, target, success, rtt_ms, error_message, attempts, resolved_ip, ttl, failure_reason, instance
+        FROM ping_results
+        WHERE timestamp >= $1 AND timestamp <= $2
+        AND ($3::text = '' OR target = $3::text)
+        AND ($4::text = '' OR instance = $4::text)
+        ORDER BY timestamp DESC, id DESC
+        LIMIT $5 OFFSET $6
+    `
+
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC(), target, instance, limit, max(offset, 0))
+	if err != nil {
+		return nil, err
+	}
+	return scanResults(rows)
+}
+
+// GetRecentSampled retrieves the ping results between from and to, newest
+// first, keeping every Nth result of each target when there are more than
+// maxPoints
+func (db *PostgresDB) GetRecentSampled(ctx context.Context, from, to time.Time, maxPoints int) ([]models.PingResult, error) {
+	if maxPoints <= 0 || maxPoints > MaxRecentLimit {
+		maxPoints = MaxRecentLimit
+	}
+	rows, err := db.QueryContext(ctx, `
+        SELECT COUNT(*)
+        FROM ping_results
+        WHERE timestamp >= $1 AND timestamp <= $2
+        GROUP BY target
+    `, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	counts, err := scanCounts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+        SELECT timestamp, target, success, rtt_ms, error_message, attempts, resolved_ip, ttl, failure_reason, instance
+        FROM (
+            SELECT *, ROW_NUMBER() OVER (PARTITION BY target ORDER BY timestamp DESC, id DESC) AS n
+            FROM ping_results
+            WHERE timestamp >= $1 AND timestamp <= $2
+        ) sampled
+        WHERE (n - 1) % $3 = 0
+        ORDER BY timestamp DESC, id DESC
+        LIMIT $4
+    `
+	rows, err = db.QueryContext(ctx, query, from.UTC(), to.UTC(), sampleStep(counts, maxPoints), maxPoints)
+	if err != nil {
+		return nil, err
+	}
+	return scanResults(rows)
+}
+
+// GetRange retrieves all ping results between from and to, oldest first
+func (db *PostgresDB) GetRange(ctx context.Context, from, to time.Time) ([]models.PingResult, error) {
+	query := `
+        SELECT timestamp, target, success, rtt_ms, error_message, attempts, resolved_ip, ttl, failure_reason, instance
+        FROM ping_results
+        WHERE timestamp >= $1 AND timestamp <= $2
+        ORDER BY timestamp
+    `
+
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return scanResults(rows)
+}
+
+// GetTargets lists every target with recorded ping results
+func (db *PostgresDB) GetTargets(ctx context.Context) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT DISTINCT target FROM ping_results ORDER BY target`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []string
+	for rows.Next() {
+		var target string
+		if err := rows.Scan(&target); err != nil {
+			continue
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// GetLastTimestamp returns the time of the most recent stored result, or the
+// zero time if there are none
+func (db *PostgresDB) GetLastTimestamp(ctx context.Context) (time.Time, error) {
+	return lastTimestamp(db.QueryRowContext(ctx, `SELECT timestamp FROM ping_results ORDER BY timestamp DESC LIMIT 1`))
+}
+
+// GetStats retrieves aggregated statistics for the pings between from and to.
+// RTT fields are 0 for a target without successful pings.
+func (db *PostgresDB) GetStats(ctx context.Context, from, to time.Time) ([]models.Stats, error) {
+	query := `
+        SELECT
+            target,
+            COUNT(*) as total_pings,
+            SUM(CASE WHEN success THEN 1 ELSE 0 END) as successful_pings,
+            AVG(CASE WHEN success AND rtt_ms > 0 THEN rtt_ms ELSE NULL END) as avg_rtt,
+            MAX(CASE WHEN success AND rtt_ms > 0 THEN rtt_ms ELSE NULL END) as max_rtt,
+            MIN(CASE WHEN success AND rtt_ms > 0 THEN rtt_ms ELSE NULL END) as min_rtt,
+            ROUND((1 - SUM(CASE WHEN success THEN 1 ELSE 0 END)::numeric / COUNT(*)) * 100, 2)::double precision as packet_loss
+        FROM ping_results
+        WHERE timestamp >= $1 AND timestamp <= $2
+        GROUP BY target
+    `
+
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return scanStats(rows)
+}
+
+// GetSummary aggregates the last 24 hours across all targets. Without any data
+// every field is zero.
+func (db *PostgresDB) GetSummary(ctx context.Context) (models.Summary, error) {
+	now := time.Now()
+	stats, err := db.GetStats(ctx, now.Add(-24*time.Hour), now)
+	if err != nil {
+		return models.Summary{}, err
+	}
+
+	// Each target's most recent ping
+	query := `
+        SELECT target, success, rtt_ms
+        FROM ping_results p
+        WHERE timestamp = (SELECT MAX(timestamp) FROM ping_results WHERE target = p.target)
+        AND timestamp > now() - INTERVAL '24 hours'
+    `
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return models.Summary{}, err
+	}
+	latest, err := scanLatest(rows)
+	if err != nil {
+		return models.Summary{}, err
+	}
+
+	outages, err := db.GetOutages(ctx, now.AddDate(0, 0, -1), now)
+	if err != nil {
+		return models.Summary{}, err
+	}
+
+	return summarize(stats, latest, len(outages)), nil
+}
+
+// GetCurrent returns each target's most recent ping, one row per target off
+// the (target, timestamp) index
+func (db *PostgresDB) GetCurrent(ctx context.Context) ([]models.CurrentStatus, error) {
+	query := `
+        SELECT DISTINCT ON (target) target, timestamp, success, rtt_ms
+        FROM ping_results
+        ORDER BY target, timestamp DESC
+    `
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return scanCurrent(rows, time.Now())
+}
+
+// GetStreaks returns each target's current run of consecutive successful or
+// failed pings: the pings since the last one with the opposite outcome
+func (db *PostgresDB) GetStreaks(ctx context.Context) ([]models.Streak, error) {
+	query := `
+        WITH latest AS (
+            SELECT DISTINCT ON (target) target, success, timestamp
+            FROM ping_results
+            ORDER BY target, timestamp DESC
+        )
+        SELECT l.target, l.success, COUNT(*), MIN(p.timestamp)
+        FROM latest l
+        JOIN ping_results p ON p.target = l.target AND p.success = l.success AND p.timestamp <= l.timestamp
+        WHERE p.timestamp > COALESCE(
+            (SELECT MAX(o.timestamp) FROM ping_results o WHERE o.target = l.target AND o.success != l.success),
+            '-infinity')
+        GROUP BY l.target, l.success
+        ORDER BY l.target
+    `
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return scanStreaks(rows, time.Now())
+}
+
+// GetErrorBreakdown counts each target's failed pings between from and to by
+// failure reason and error message, most frequent first
+func (db *PostgresDB) GetErrorBreakdown(ctx context.Context, from, to time.Time) ([]models.ErrorCount, error) {
+	query := `
+        SELECT target, COALESCE(failure_reason, ''), COALESCE(error_message, ''), COUNT(*)
+        FROM ping_results
+        WHERE NOT success AND timestamp >= $1 AND timestamp <= $2
+        GROUP BY 1, 2, 3
+        ORDER BY 1, 4 DESC, 2, 3
+    `
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return scanErrorCounts(rows)
+}
+
+// GetOutages retrieves recorded outages that started between from and to, plus
+// outages detected in the raw data that weren't recorded
+func (db *PostgresDB) GetOutages(ctx context.Context, from, to time.Time) ([]models.Outage, error) {
+	return db.GetOutagesFiltered(ctx, from, to, 0)
+}
+
+// GetOutagesFiltered retrieves outages like GetOutages, keeping only those that
+// lasted at least minDuration, the same way as the SQLite backend
+func (db *PostgresDB) GetOutagesFiltered(ctx context.Context, from, to time.Time, minDuration time.Duration) ([]models.Outage, error) {
+	query := `
+        SELECT id, target, start_time, end_time, COALESCE(checks_failed, 0),
+            COALESCE(rtt_before_ms, 0), COALESCE(rtt_after_ms, 0)
+        FROM outages
+        WHERE start_time >= $1 AND start_time <= $2
+        AND ($3 = 0 OR duration_seconds >= $3 OR (end_time IS NULL AND start_time <= $4))
+        ORDER BY start_time DESC
+        LIMIT $5
+    `
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC(),
+		int64(minDuration/time.Second), time.Now().Add(-minDuration).UTC(), maxOutages)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := scanRecordedOutages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	detected, err := db.DetectOutages(ctx, from, to, db.outageThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeOutages(recorded, outagesLasting(detected, minDuration), maxOutages), nil
+}
+
+// DetectOutages finds outages in the raw ping data between from and to, with the
+// same windowed rule as the SQLite backend
+func (db *PostgresDB) DetectOutages(ctx context.Context, from, to time.Time, threshold models.OutageThreshold) ([]models.Outage, error) {
+	// Window bounds can't be bound parameters; the threshold is validated config
+	query := fmt.Sprintf(`
+        WITH windowed_pings AS (
+            SELECT
+                target,
+                timestamp,
+                success,
+                COUNT(*) OVER w as window_size,
+                SUM(CASE WHEN NOT success THEN 1 ELSE 0 END) OVER w as failure_count,
+                MIN(CASE WHEN NOT success THEN timestamp END) OVER w as first_failure
+            FROM ping_results
+            WHERE timestamp >= $1 AND timestamp <= $2
+            WINDOW w AS (PARTITION BY target ORDER BY timestamp ROWS %d PRECEDING)
+        ),
+        flagged_pings AS (
+            SELECT
+                target,
+                timestamp,
+                success,
+                first_failure,
+                CASE WHEN window_size = %d AND failure_count >= %d THEN 1 ELSE 0 END as is_outage
+            FROM windowed_pings
+        ),
+        outage_periods AS (
+            SELECT
+                target,
+                timestamp,
+                success,
+                first_failure,
+                is_outage,
+                ROW_NUMBER() OVER (PARTITION BY target ORDER BY timestamp) -
+                ROW_NUMBER() OVER (PARTITION BY target, is_outage ORDER BY timestamp) as outage_grp
+            FROM flagged_pings
+        ),
+        detected AS (
+            SELECT
+                target,
+                MIN(first_failure) as start_time,
+                COALESCE(MAX(CASE WHEN NOT success THEN timestamp END), MAX(timestamp)) as end_time
+            FROM outage_periods
+            WHERE is_outage = 1
+            GROUP BY target, outage_grp
+        )
+        SELECT
+            target,
+            start_time,
+            end_time,
+            (
+                SELECT COUNT(*) FROM ping_results p
+                WHERE p.target = detected.target
+                AND NOT p.success
+                AND p.timestamp BETWEEN detected.start_time AND detected.end_time
+            ) as failed_checks,
+            (
+                SELECT rtt_ms FROM ping_results p
+                WHERE p.target = detected.target AND p.success AND p.rtt_ms > 0 AND p.timestamp < detected.start_time
+                ORDER BY p.timestamp DESC LIMIT 1
+            ) as rtt_before,
+            (
+                SELECT rtt_ms FROM ping_results p
+                WHERE p.target = detected.target AND p.success AND p.rtt_ms > 0 AND p.timestamp > detected.end_time
+                ORDER BY p.timestamp LIMIT 1
+            ) as rtt_after
+        FROM detected
+        ORDER BY start_time DESC
+    `, threshold.Window-1, threshold.Window, threshold.Failures)
+
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outages []models.Outage
+	for rows.Next() {
+		var o models.Outage
+		var before, after sql.NullFloat64
+		if err := rows.Scan(&o.Target, &o.StartTime, &o.EndTime, &o.FailedChecks, &before, &after); err != nil {
+			continue
+		}
+		o.RTTBefore, o.RTTAfter = before.Float64, after.Float64
+		o.Duration = o.EndTime.Sub(o.StartTime).String()
+		outages = append(outages, o)
+	}
+
+	return outages, nil
+}
+
+// GetSLA computes a target's availability between from and to, counting checks
+// and downtime the same way as the SQLite backend; an empty target covers all
+// targets
+func (db *PostgresDB) GetSLA(ctx context.Context, target string, from, to time.Time) (models.SLA, error) {
+	sla := models.SLA{Target: target, Since: from, Until: to}
+
+	var rawTotal, rawSuccessful int
+	rawQuery := `
+        SELECT COUNT(*), COALESCE(SUM(CASE WHEN success THEN 1 ELSE 0 END), 0)
+        FROM ping_results
+        WHERE timestamp >= $1 AND timestamp <= $2 AND ($3::text = '' OR target = $3::text)
+    `
+	if err := db.QueryRowContext(ctx, rawQuery, from.UTC(), to.UTC(), target).Scan(&rawTotal, &rawSuccessful); err != nil {
+		return sla, fmt.Errorf("count checks: %w", err)
+	}
+
+	// Only hours before a target's oldest raw ping, so nothing is counted twice
+	var archivedTotal, archivedSuccessful int
+	archivedQuery := `
+        SELECT COALESCE(SUM(total_pings), 0), COALESCE(SUM(successful_pings), 0)
+        FROM hourly_stats h
+        WHERE hour >= $1 AND hour < $2 AND ($3::text = '' OR target = $3::text)
+        AND hour < COALESCE(
+            (SELECT date_trunc('hour', MIN(timestamp), 'UTC') FROM ping_results p WHERE p.target = h.target),
+            'infinity')
+    `
+	if err := db.QueryRowContext(ctx, archivedQuery, from.UTC().Truncate(time.Hour), to.UTC(), target).Scan(&archivedTotal, &archivedSuccessful); err != nil {
+		return sla, fmt.Errorf("count archived checks: %w", err)
+	}
+
+	sla.TotalChecks = rawTotal + archivedTotal
+	sla.SuccessfulChecks = rawSuccessful + archivedSuccessful
+	if sla.TotalChecks > 0 {
+		sla.Availability = float64(sla.SuccessfulChecks) / float64(sla.TotalChecks) * 100
+	}
+
+	outageQuery := `
+        SELECT start_time, end_time
+        FROM outages
+        WHERE (end_time IS NULL OR end_time > $1) AND start_time < $2 AND ($3::text = '' OR target = $3::text)
+    `
+	rows, err := db.QueryContext(ctx, outageQuery, from.UTC(), to.UTC(), target)
+	if err != nil {
+		return sla, fmt.Errorf("query outages: %w", err)
+	}
+
+	downtime, count, err := sumDowntime(rows, from, to)
+	if err != nil {
+		return sla, fmt.Errorf("query outages: %w", err)
+	}
+	sla.Outages = count
+
+	sla.DowntimeSeconds = downtime.Seconds()
+	sla.Downtime = downtime.Round(time.Second).String()
+	return sla, nil
+}
+
+// GetHeatmapData retrieves heatmap data for the days between from and to, counted in the
+// configured location like the hourly_patterns dates themselves
+func (db *PostgresDB) GetHeatmapData(ctx context.Context, from, to time.Time) ([]models.HeatmapPoint, error) {
+	query := `
+        SELECT
+            hour,
+            target,
+            COALESCE(AVG(failure_rate), 0) as avg_failure_rate,
+            AVG(avg_rtt_ms) as avg_latency,
+            MAX(max_rtt_ms) as max_latency,
+            SUM(failed_pings) as total_failures,
+            SUM(total_pings) as total_pings,
+            COUNT(DISTINCT date) as days_with_data
+        FROM hourly_patterns
+        WHERE date >= $1 AND date <= $2
+        GROUP BY hour, target
+        ORDER BY hour, target
+    `
+
+	rows, err := db.QueryContext(ctx, query, db.localDay(from), db.localDay(to))
+	if err != nil {
+		return nil, err
+	}
+	return scanHeatmap(rows)
+}
+
+// GetPatterns retrieves pattern data for a specific hour on the days between
+// from and to
+func (db *PostgresDB) GetPatterns(ctx context.Context, hour string, from, to time.Time) ([]models.PatternDetail, error) {
+	query := `
+        SELECT
+            to_char(date, 'YYYY-MM-DD'),
+            target,
+            total_pings,
+            failed_pings,
+            avg_rtt_ms,
+            max_rtt_ms,
+            failure_rate
+        FROM hourly_patterns
+        WHERE hour = $1
+        AND date >= $2 AND date <= $3
+        ORDER BY date DESC, target
+    `
+
+	rows, err := db.QueryContext(ctx, query, hour, db.localDay(from), db.localDay(to))
+	if err != nil {
+		return nil, err
+	}
+	return scanPatterns(rows)
+}
+
+// RecordMonitoringEvent saves a monitoring state change such as a pause or resume
+func (db *PostgresDB) RecordMonitoringEvent(ctx context.Context, event models.MonitoringEvent) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO monitoring_events (timestamp, event) VALUES ($1, $2)`,
+		event.Timestamp.UTC(), event.Event)
+	return err
+}
+
+// GetMonitoringEvents retrieves monitoring state changes between from and to
+func (db *PostgresDB) GetMonitoringEvents(ctx context.Context, from, to time.Time) ([]models.MonitoringEvent, error) {
+	query := `
+        SELECT timestamp, event
+        FROM monitoring_events
+        WHERE timestamp >= $1 AND timestamp <= $2
+        ORDER BY timestamp DESC
+    `
+
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return scanEvents(rows)
+}
+
+// RecordOutageStart opens an outage row for the target and returns its ID.
+// rttBefore is the RTT of the last successful ping before it, 0 if unknown.
+func (db *PostgresDB) RecordOutageStart(ctx context.Context, target string, start time.Time, rttBefore float64) (int64, error) {
+	var id int64
+	err := db.QueryRowContext(ctx, `INSERT INTO outages (target, start_time, rtt_before_ms) VALUES ($1, $2, $3) RETURNING id`,
+		target, start.UTC(), knownRTT(rttBefore)).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("record outage start: %w", err)
+	}
+	return id, nil
+}
+
+// RecordOutageEnd closes an open outage at the time connectivity recovered.
+// rttAfter is the RTT of the ping that recovered, 0 if unknown.
+func (db *PostgresDB) RecordOutageEnd(ctx context.Context, id int64, end time.Time, checksFailed int, rttAfter float64) error {
+	var start time.Time
+	if err := db.QueryRowContext(ctx, `SELECT start_time FROM outages WHERE id = $1`, id).Scan(&start); err != nil {
+		return fmt.Errorf("record outage end: load outage %d: %w", id, err)
+	}
+
+	query := `
+        UPDATE outages
+        SET end_time = $1, duration_seconds = $2, checks_failed = $3, rtt_after_ms = $4
+        WHERE id = $5
+    `
+	durationSeconds := int64(end.Sub(start).Round(time.Second) / time.Second)
+	if _, err := db.ExecContext(ctx, query, end.UTC(), durationSeconds, checksFailed, knownRTT(rttAfter), id); err != nil {
+		return fmt.Errorf("record outage end: %w", err)
+	}
+	return nil
+}
+
+// SaveTraceroute stores each hop of a traceroute against its outage
+func (db *PostgresDB) SaveTraceroute(ctx context.Context, trace models.Traceroute) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO traceroutes (outage_id, target, timestamp, hop, address, avg_rtt_ms, loss_percent)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, hop := range trace.Hops {
+		if _, err := stmt.ExecContext(ctx, trace.OutageID, trace.Target, trace.Timestamp.UTC(),
+			hop.Hop, hop.Address, hop.AvgRTT, hop.Loss); err != nil {
+			return fmt.Errorf("save traceroute hop %d: %w", hop.Hop, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetTraceroute retrieves the traceroute captured for an outage
+func (db *PostgresDB) GetTraceroute(ctx context.Context, outageID int64) (models.Traceroute, error) {
+	query := `
+        SELECT target, timestamp, hop, address, avg_rtt_ms, loss_percent
+        FROM traceroutes
+        WHERE outage_id = $1
+        ORDER BY hop
+    `
+
+	rows, err := db.QueryContext(ctx, query, outageID)
+	if err != nil {
+		return models.Traceroute{}, err
+	}
+	return scanTraceroute(rows, outageID)
+}
+
+// SavePingDebug stores the raw output of a ping and prunes the table to the
+// newest MaxPingDebugRows entries
+func (db *PostgresDB) SavePingDebug(ctx context.Context, entry models.PingDebug) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO ping_debug (timestamp, target, success, output) VALUES ($1, $2, $3, $4)`,
+		entry.Timestamp.UTC(), entry.Target, entry.Success, entry.Output); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ping_debug WHERE id <= (SELECT MAX(id) FROM ping_debug) - $1`,
+		MaxPingDebugRows); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetPingDebug retrieves the newest stored ping outputs, newest first. An
+// empty target matches all targets; limit is clamped to MaxPingDebugRows.
+func (db *PostgresDB) GetPingDebug(ctx context.Context, target string, limit int) ([]models.PingDebug, error) {
+	if limit <= 0 || limit > MaxPingDebugRows {
+		limit = MaxPingDebugRows
+	}
+	query := `
+        SELECT timestamp, target, success, output
+        FROM ping_debug
+        WHERE ($1::text = '' OR target = $1::text)
+        ORDER BY timestamp DESC, id DESC
+        LIMIT $2
+    `
+
+	rows, err := db.QueryContext(ctx, query, target, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanPingDebug(rows)
+}
+
+// GetBaselineComparison compares the target's latency over the last hour with
+// its usual latency at this hour of the week, the same way as the SQLite backend
+func (db *PostgresDB) GetBaselineComparison(ctx context.Context, target string) (models.BaselineComparison, error) {
+	currentQuery := `
+        SELECT success, rtt_ms
+        FROM ping_results
+        WHERE target = $1 AND timestamp > $2
+    `
+	baselineQuery := `
+        SELECT date, total_pings - failed_pings, avg_rtt_ms, p95_rtt_ms
+        FROM hourly_patterns
+        WHERE target = $1 AND hour = $2 AND date >= $3 AND date < $4
+    `
+	return compareToBaseline(ctx, db.DB, currentQuery, baselineQuery, target, time.Now().In(db.location))
+}
+
+// CompareTargets compares the latency and packet loss of targets a and b over
+// the last hours, the same way as the SQLite backend
+func (db *PostgresDB) CompareTargets(ctx context.Context, a, b string, hours int) (models.TargetComparison, error) {
+	query := `
+        SELECT target, timestamp, success, rtt_ms
+        FROM ping_results
+        WHERE (target = $1 OR target = $2) AND timestamp > $3
+    `
+	return compareTargets(ctx, db.DB, query, a, b, hours, time.Now())
+}
+
+// AggregateDaily rolls hourly patterns up into daily_stats, the same way as
+// the SQLite backend
+func (db *PostgresDB) AggregateDaily(ctx context.Context) error {
+	return aggregateRollup(ctx, db.DB, postgresDailyRollup, dayPeriod, db.location)
+}
+
+// postgresDailyRollup rolls hourly_patterns up into daily_stats
+var postgresDailyRollup = rollupQueries{
+	latest: `SELECT day FROM daily_stats ORDER BY day DESC LIMIT 1`,
+	source: `
+            SELECT date, target, total_pings, total_pings - failed_pings, avg_rtt_ms, p95_rtt_ms, 0
+            FROM hourly_patterns
+            WHERE date >= $1
+        `,
+	outages: `SELECT target, start_time FROM outages WHERE start_time >= $1`,
+	upsert: `
+            INSERT INTO daily_stats (day, target, total_pings, successful_pings, avg_rtt_ms, p95_rtt_ms, outages)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)
+            ON CONFLICT (day, target) DO UPDATE SET
+                total_pings = EXCLUDED.total_pings,
+                successful_pings = EXCLUDED.successful_pings,
+                avg_rtt_ms = EXCLUDED.avg_rtt_ms,
+                p95_rtt_ms = EXCLUDED.p95_rtt_ms,
+                outages = EXCLUDED.outages
+        `,
+}
+
+// AggregateMonthly rolls daily_stats up into monthly_stats, the same way as
+// the SQLite backend
+func (db *PostgresDB) AggregateMonthly(ctx context.Context) error {
+	return aggregateRollup(ctx, db.DB, postgresMonthlyRollup, monthPeriod, db.location)
+}
+
+// postgresMonthlyRollup rolls daily_stats up into monthly_stats
+var postgresMonthlyRollup = rollupQueries{
+	latest: `SELECT month FROM monthly_stats ORDER BY month DESC LIMIT 1`,
+	source: `
+            SELECT day, target, total_pings, successful_pings, avg_rtt_ms, p95_rtt_ms, outages
+            FROM daily_stats
+            WHERE day >= $1
+        `,
+	upsert: `
+            INSERT INTO monthly_stats (month, target, total_pings, successful_pings, avg_rtt_ms, p95_rtt_ms, outages)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)
+            ON CONFLICT (month, target) DO UPDATE SET
+                total_pings = EXCLUDED.total_pings,
+                successful_pings = EXCLUDED.successful_pings,
+                avg_rtt_ms = EXCLUDED.avg_rtt_ms,
+                p95_rtt_ms = EXCLUDED.p95_rtt_ms,
+                outages = EXCLUDED.outages
+        `,
+}
+
+// GetTrends retrieves the daily or monthly rollups for the days or months
+// overlapping from to to, oldest first. An empty target matches all targets.
+func (db *PostgresDB) GetTrends(ctx context.Context, target, granularity string, from, to time.Time) ([]models.Trend, error) {
+	table, column, err := trendTable(granularity)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+        SELECT %[1]s, target, total_pings, successful_pings, avg_rtt_ms, p95_rtt_ms, outages
+        FROM %[2]s
+        WHERE %[1]s >= $1 AND %[1]s <= $2 AND ($3::text = '' OR target = $3::text)
+        ORDER BY %[1]s, target
+    `, column, table)
+
+	start, end := trendBounds(granularity, from.In(db.location), to.In(db.location))
+	rows, err := db.QueryContext(ctx, query, start, end, target)
+	if err != nil {
+		return nil, err
+	}
+	return scanTrends(rows, granularity)
+}
+
+// SetTargetMeta replaces the stored display names and colors with meta
+func (db *PostgresDB) SetTargetMeta(ctx context.Context, meta []models.TargetMeta) error {
+	return replaceTargetMeta(ctx, db.DB, `INSERT INTO target_meta (target, name, color) VALUES ($1, $2, $3)`, meta)
+}
+
+// GetTargetMeta retrieves the stored display names and colors, by target
+func (db *PostgresDB) GetTargetMeta(ctx context.Context) ([]models.TargetMeta, error) {
+	rows, err := db.QueryContext(ctx, selectTargetMetaQuery)
+	if err != nil {
+		return nil, err
+	}
+	return scanTargetMeta(rows)
+}
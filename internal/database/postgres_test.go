@@ -0,0 +1,375 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// newTestPostgres returns a migrated PostgresDB in a fresh schema of the
+// database at POSTGRES_DSN, dropped again when the test ends. Tests using it
+// are skipped unless POSTGRES_DSN is set.
+func newTestPostgres(t *testing.T) *PostgresDB {
+	t.Helper()
+
+	dsn := os.Getenv("POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_DSN not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("network_monitor_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+	})
+
+	db, err := NewPostgres(withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("NewPostgres() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.InitSchema(); err != nil {
+		t.Fatalf("InitSchema() error = %v", err)
+	}
+	return db
+}
+
+// withSearchPath adds a search_path connection parameter to a URL or
+// key=value DSN
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
+
+func TestPostgresRoundTrip(t *testing.T) {
+	db := newTestPostgres(t)
+
+	var _ models.Database = db
+
+	// Ten pings a second apart with the fourth failing
+	base := time.Now().Add(-30 * time.Minute).Truncate(time.Second)
+	for i := 0; i < 10; i++ {
+		result := models.PingResult{
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Target:    "8.8.8.8",
+			Success:   i != 3,
+			RTT:       10 + float64(i),
+			Attempts:  1,
+		}
+		if i == 3 {
+			result.RTT = 0
+			result.ErrorMessage = "timeout"
+			result.FailureReason = models.FailureTimeout
+		}
+		if i == 9 {
+			result.ResolvedIP = "8.8.8.8"
+			result.TTL = 117
+		}
+		if err := db.SaveResult(context.Background(), result); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	from, to := lastHours(1)
+	recent, err := db.GetRecentFiltered(context.Background(), from, to, "8.8.8.8", "", 100, 0)
+	if err != nil {
+		t.Fatalf("GetRecentFiltered() error = %v", err)
+	}
+	if len(recent) != 10 {
+		t.Fatalf("GetRecentFiltered() returned %d results, want 10", len(recent))
+	}
+	newest := recent[0]
+	if !newest.Timestamp.Equal(base.Add(9*time.Second)) || newest.RTT != 19 || newest.ResolvedIP != "8.8.8.8" || newest.TTL != 117 {
+		t.Errorf("newest result = %+v", newest)
+	}
+	if failed := recent[6]; failed.Success || failed.ErrorMessage != "timeout" || failed.FailureReason != models.FailureTimeout {
+		t.Errorf("failed result = %+v", failed)
+	}
+
+	if other, err := db.GetRecentFiltered(context.Background(), from, to, "1.1.1.1", "", 100, 0); err != nil || len(other) != 0 {
+		t.Errorf("GetRecentFiltered(other target) = %v, %v; want no results", other, err)
+	}
+
+	inRange, err := db.GetRange(context.Background(), base, base.Add(4*time.Second))
+	if err != nil {
+		t.Fatalf("GetRange() error = %v", err)
+	}
+	if len(inRange) != 5 {
+		t.Errorf("GetRange() returned %d results, want 5", len(inRange))
+	}
+
+	if last, err := db.GetLastTimestamp(context.Background()); err != nil || !last.Equal(base.Add(9*time.Second)) {
+		t.Errorf("GetLastTimestamp() = %v, %v; want the newest result", last, err)
+	}
+
+	targets, err := db.GetTargets(context.Background())
+	if err != nil {
+		t.Fatalf("GetTargets() error = %v", err)
+	}
+	if len(targets) != 1 || targets[0] != "8.8.8.8" {
+		t.Errorf("GetTargets() = %v, want [8.8.8.8]", targets)
+	}
+
+	stats, err := db.GetStats(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+	if len(stats) != 1 || stats[0].TotalPings != 10 || stats[0].Successful != 9 || stats[0].PacketLoss != 10 || stats[0].MaxRTT != 19 {
+		t.Errorf("GetStats() = %+v", stats)
+	}
+
+	sla, err := db.GetSLA(context.Background(), "8.8.8.8", from, to)
+	if err != nil {
+		t.Fatalf("GetSLA() error = %v", err)
+	}
+	if sla.TotalChecks != 10 || sla.SuccessfulChecks != 9 {
+		t.Errorf("GetSLA() = %+v, want 9 of 10 checks", sla)
+	}
+
+	summary, err := db.GetSummary(context.Background())
+	if err != nil {
+		t.Fatalf("GetSummary() error = %v", err)
+	}
+	if summary.Targets != 1 || summary.BestRTTTarget != "8.8.8.8" || summary.BestRTT != 19 {
+		t.Errorf("GetSummary() = %+v", summary)
+	}
+
+	current, err := db.GetCurrent(context.Background())
+	if err != nil {
+		t.Fatalf("GetCurrent() error = %v", err)
+	}
+	if len(current) != 1 || !current[0].LastCheck.Equal(base.Add(9*time.Second)) || current[0].RTT != 19 {
+		t.Errorf("GetCurrent() = %+v, want the newest result", current)
+	}
+
+	sampled, err := db.GetRecentSampled(context.Background(), from, to, 5)
+	if err != nil {
+		t.Fatalf("GetRecentSampled() error = %v", err)
+	}
+	if len(sampled) != 5 || !sampled[0].Timestamp.Equal(base.Add(9*time.Second)) || !sampled[4].Timestamp.Equal(base.Add(time.Second)) {
+		t.Errorf("GetRecentSampled() = %+v, want every other result from the newest", sampled)
+	}
+
+	breakdown, err := db.GetErrorBreakdown(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetErrorBreakdown() error = %v", err)
+	}
+	if len(breakdown) != 1 || breakdown[0].FailureReason != models.FailureTimeout || breakdown[0].Count != 1 {
+		t.Errorf("GetErrorBreakdown() = %+v, want the one timeout", breakdown)
+	}
+
+	streaks, err := db.GetStreaks(context.Background())
+	if err != nil {
+		t.Fatalf("GetStreaks() error = %v", err)
+	}
+	if len(streaks) != 1 || !streaks[0].Success || streaks[0].Checks != 6 || !streaks[0].Since.Equal(base.Add(4*time.Second)) {
+		t.Errorf("GetStreaks() = %+v, want 6 successes since the failed ping", streaks)
+	}
+
+	if err := db.SavePingDebug(context.Background(), models.PingDebug{Timestamp: base, Target: "8.8.8.8", Output: "Zeitüberschreitung"}); err != nil {
+		t.Fatalf("SavePingDebug() error = %v", err)
+	}
+	debug, err := db.GetPingDebug(context.Background(), "8.8.8.8", 10)
+	if err != nil {
+		t.Fatalf("GetPingDebug() error = %v", err)
+	}
+	if len(debug) != 1 || debug[0].Output != "Zeitüberschreitung" || !debug[0].Timestamp.Equal(base) {
+		t.Errorf("GetPingDebug() = %+v, want the saved output", debug)
+	}
+}
+
+func TestPostgresOutages(t *testing.T) {
+	db := newTestPostgres(t)
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, c := range "..XXX.." {
+		if err := db.SaveResult(context.Background(), models.PingResult{
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Target:    "1.1.1.1",
+			Success:   c != 'X',
+			RTT:       10,
+		}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	from, to := lastHours(24)
+	detected, err := db.DetectOutages(context.Background(), from, to, models.OutageThreshold{Window: 3, Failures: 3})
+	if err != nil {
+		t.Fatalf("DetectOutages() error = %v", err)
+	}
+	if len(detected) != 1 || !detected[0].StartTime.Equal(base.Add(2*time.Second)) || detected[0].FailedChecks != 3 {
+		t.Fatalf("DetectOutages() = %+v, want one outage of 3 checks", detected)
+	}
+
+	start := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	id, err := db.RecordOutageStart(context.Background(), "8.8.8.8", start, 0)
+	if err != nil {
+		t.Fatalf("RecordOutageStart() error = %v", err)
+	}
+	if err := db.RecordOutageEnd(context.Background(), id, start.Add(2*time.Minute), 12, 0); err != nil {
+		t.Fatalf("RecordOutageEnd() error = %v", err)
+	}
+
+	outages, err := db.GetOutages(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetOutages() error = %v", err)
+	}
+	if len(outages) != 2 || outages[0].ID != id || outages[0].FailedChecks != 12 || outages[0].Ongoing {
+		t.Fatalf("GetOutages() = %+v, want the recorded outage first", outages)
+	}
+
+	trace := models.Traceroute{
+		OutageID:  id,
+		Target:    "8.8.8.8",
+		Timestamp: start,
+		Hops: []models.TracerouteHop{
+			{Hop: 1, Address: "192.168.1.1", AvgRTT: 1.5},
+			{Hop: 2, Loss: 100},
+		},
+	}
+	if err := db.SaveTraceroute(context.Background(), trace); err != nil {
+		t.Fatalf("SaveTraceroute() error = %v", err)
+	}
+	got, err := db.GetTraceroute(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetTraceroute() error = %v", err)
+	}
+	if len(got.Hops) != 2 || got.Hops[0].Address != "192.168.1.1" || got.Hops[1].Loss != 100 {
+		t.Errorf("GetTraceroute() = %+v", got)
+	}
+	if _, err := db.GetTraceroute(context.Background(), id+1); err != ErrTracerouteNotFound {
+		t.Errorf("GetTraceroute(missing) error = %v, want ErrTracerouteNotFound", err)
+	}
+}
+
+func TestPostgresPatternsAndMaintenance(t *testing.T) {
+	db := newTestPostgres(t)
+	db.SetLocation(time.UTC)
+
+	stamp := time.Now().Add(-2 * time.Hour).UTC().Truncate(time.Hour)
+	for i := 0; i < 4; i++ {
+		if err := db.SaveResult(context.Background(), models.PingResult{
+			Timestamp: stamp.Add(time.Duration(i) * time.Minute),
+			Target:    "8.8.8.8",
+			Success:   i != 0,
+			RTT:       20,
+		}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+	if err := db.RecordMonitoringEvent(context.Background(), models.MonitoringEvent{Timestamp: stamp, Event: models.EventPaused}); err != nil {
+		t.Fatalf("RecordMonitoringEvent() error = %v", err)
+	}
+
+	if empty, err := db.IsHourlyPatternsEmpty(context.Background()); err != nil || !empty {
+		t.Fatalf("IsHourlyPatternsEmpty() = %v, %v; want true", empty, err)
+	}
+	if err := db.BackfillHourlyPatterns(context.Background()); err != nil {
+		t.Fatalf("BackfillHourlyPatterns() error = %v", err)
+	}
+	// Rebuilding again must update the rows rather than conflict, and so must
+	// the watermark of an incremental run
+	if err := db.BackfillHourlyPatterns(context.Background()); err != nil {
+		t.Fatalf("BackfillHourlyPatterns() error = %v", err)
+	}
+	if err := db.AggregateHourlyPatterns(context.Background()); err != nil {
+		t.Fatalf("AggregateHourlyPatterns() error = %v", err)
+	}
+
+	from, to := lastHours(7 * 24)
+	heatmap, err := db.GetHeatmapData(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetHeatmapData() error = %v", err)
+	}
+	if len(heatmap) != 1 || heatmap[0].Hour != stamp.Hour() || heatmap[0].TotalPings != 4 || heatmap[0].FailureRate != 25 {
+		t.Errorf("GetHeatmapData() = %+v", heatmap)
+	}
+
+	patterns, err := db.GetPatterns(context.Background(), strconv.Itoa(stamp.Hour()), from, to)
+	if err != nil {
+		t.Fatalf("GetPatterns() error = %v", err)
+	}
+	if len(patterns) != 1 || patterns[0].Date != stamp.Format("2006-01-02") || patterns[0].FailedPings != 1 {
+		t.Errorf("GetPatterns() = %+v", patterns)
+	}
+
+	if err := db.AggregateDaily(context.Background()); err != nil {
+		t.Fatalf("AggregateDaily() error = %v", err)
+	}
+	if err := db.AggregateMonthly(context.Background()); err != nil {
+		t.Fatalf("AggregateMonthly() error = %v", err)
+	}
+	for _, granularity := range []string{models.TrendDay, models.TrendMonth} {
+		trends, err := db.GetTrends(context.Background(), "8.8.8.8", granularity, from, to)
+		if err != nil {
+			t.Fatalf("GetTrends(%s) error = %v", granularity, err)
+		}
+		if len(trends) != 1 || trends[0].TotalChecks != 4 || trends[0].SuccessfulChecks != 3 || trends[0].AvgRTT != 20 {
+			t.Errorf("GetTrends(%s) = %+v", granularity, trends)
+		}
+	}
+
+	from, to = lastHours(24)
+	events, err := db.GetMonitoringEvents(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetMonitoringEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Event != models.EventPaused {
+		t.Errorf("GetMonitoringEvents() = %+v", events)
+	}
+
+	// An outage past raw retention keeps its pings, padded, through archival
+	db.SetPreservePadding(time.Minute)
+	old := time.Now().AddDate(0, 0, -10).Truncate(time.Second)
+	id, err := db.RecordOutageStart(context.Background(), "8.8.8.8", old, 0)
+	if err != nil {
+		t.Fatalf("RecordOutageStart() error = %v", err)
+	}
+	if err := db.RecordOutageEnd(context.Background(), id, old.Add(time.Minute), 2, 0); err != nil {
+		t.Fatalf("RecordOutageEnd() error = %v", err)
+	}
+	for _, offset := range []time.Duration{-time.Hour, -time.Minute, 30 * time.Second, 2 * time.Minute, time.Hour} {
+		if err := db.SaveResult(context.Background(), models.PingResult{Timestamp: old.Add(offset), Target: "8.8.8.8"}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	if err := db.ArchiveOldData(context.Background()); err != nil {
+		t.Fatalf("ArchiveOldData() error = %v", err)
+	}
+	var preserved int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM preserved_results`).Scan(&preserved); err != nil {
+		t.Fatalf("count preserved_results: %v", err)
+	}
+	if preserved != 3 {
+		t.Errorf("%d results preserved, want the 3 within a minute of the outage", preserved)
+	}
+}
@@ -1,140 +1,570 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"network-monitor/internal/models"
 )
 
-// SaveResult saves a ping result to the database
-func (db *DB) SaveResult(result models.PingResult) error {
-	query := `
-        INSERT INTO ping_results (timestamp, target, success, rtt_ms, error_message)
-        VALUES (?, ?, ?, ?, ?)
+// insertResultQuery inserts a ping result with resultArgs
+const insertResultQuery = `
+        INSERT INTO ping_results (timestamp, target, success, rtt_ms, error_message, attempts, resolved_ip, ttl, failure_reason, instance)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
     `
-	_, err := db.Exec(query,
-		result.Timestamp,
+
+// SaveResult saves a ping result to the database. Timestamps are stored in UTC
+// so they compare correctly with SQLite's datetime('now').
+func (db *DB) SaveResult(ctx context.Context, result models.PingResult) error {
+	_, err := db.ExecContext(ctx, insertResultQuery, resultArgs(result)...)
+	return err
+}
+
+// SaveResults saves many ping results in one transaction, e.g. imported
+// history; either all of them are saved or none are
+func (db *DB) SaveResults(ctx context.Context, results []models.PingResult) error {
+	return saveResults(ctx, db.DB, insertResultQuery, results)
+}
+
+// resultArgs are the ping_results column values for result, in the order the
+// insert queries list them
+func resultArgs(result models.PingResult) []any {
+	return []any{
+		result.Timestamp.UTC(),
 		result.Target,
 		result.Success,
 		result.RTT,
 		result.ErrorMessage,
-	)
-	return err
+		max(result.Attempts, 1),
+		sql.NullString{String: result.ResolvedIP, Valid: result.ResolvedIP != ""},
+		sql.NullInt64{Int64: int64(result.TTL), Valid: result.TTL > 0},
+		sql.NullString{String: string(result.FailureReason), Valid: result.FailureReason != ""},
+		sql.NullString{String: result.Instance, Valid: result.Instance != ""},
+	}
 }
 
-// GetRecent retrieves recent ping results
-func (db *DB) GetRecent(hours int) ([]models.PingResult, error) {
+// saveResults inserts results with one backend's insert query in a single
+// transaction
+func saveResults(ctx context.Context, db *sql.DB, query string, results []models.PingResult) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, result := range results {
+		if _, err := stmt.ExecContext(ctx, resultArgs(result)...); err != nil {
+			return fmt.Errorf("save result for %s at %s: %w", result.Target, result.Timestamp.Format(time.RFC3339), err)
+		}
+	}
+	return tx.Commit()
+}
+
+// MaxRecentLimit caps the number of results a single recent-results query returns
+const MaxRecentLimit = 10000
+
+// GetRecent retrieves recent ping results for all targets, newest first
+func (db *DB) GetRecent(ctx context.Context, hours int) ([]models.PingResult, error) {
+	now := time.Now()
+	return db.GetRecentFiltered(ctx, now.Add(-time.Duration(hours)*time.Hour), now, "", "", MaxRecentLimit, 0)
+}
+
+// GetRecentFiltered retrieves one page of the ping results between from and
+// to, newest first. An empty target or instance matches all of them; limit is
+// clamped to MaxRecentLimit.
+func (db *DB) GetRecentFiltered(ctx context.Context, from, to time.Time, target, instance string, limit, offset int) ([]models.PingResult, error) {
+	if limit <= 0 || limit > MaxRecentLimit {
+		limit = MaxRecentLimit
+	}
 	query := `
-        SELECT timestamp, target, success, rtt_ms, error_message
+        SELECT timestamp, target, success, rtt_ms, error_message, attempts, resolved_ip, ttl, failure_reason, instance
         FROM ping_results
-        WHERE timestamp > datetime('now', '-' || ? || ' hours')
-        ORDER BY timestamp DESC
-        LIMIT 10000
+        WHERE timestamp >= ? AND timestamp <= ?
+        AND (? = '' OR target = ?)
+        AND (? = '' OR instance = ?)
+        ORDER BY timestamp DESC, id DESC
+        LIMIT ? OFFSET ?
     `
 
-	rows, err := db.Query(query, hours)
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC(), target, target, instance, instance, limit, max(offset, 0))
+	if err != nil {
+		return nil, err
+	}
+	return scanResults(rows)
+}
+
+// GetRecentSampled retrieves the ping results between from and to, newest
+// first, like GetRecentFiltered, but when there are more than maxPoints it
+// keeps every Nth result of each target instead of dropping the oldest, so the
+// whole window stays covered. maxPoints is clamped to MaxRecentLimit.
+func (db *DB) GetRecentSampled(ctx context.Context, from, to time.Time, maxPoints int) ([]models.PingResult, error) {
+	if maxPoints <= 0 || maxPoints > MaxRecentLimit {
+		maxPoints = MaxRecentLimit
+	}
+	rows, err := db.QueryContext(ctx, `
+        SELECT COUNT(*)
+        FROM ping_results
+        WHERE timestamp >= ? AND timestamp <= ?
+        GROUP BY target
+    `, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	counts, err := scanCounts(rows)
 	if err != nil {
 		return nil, err
 	}
+
+	query := `
+        SELECT timestamp, target, success, rtt_ms, error_message, attempts, resolved_ip, ttl, failure_reason, instance
+        FROM (
+            SELECT *, ROW_NUMBER() OVER (PARTITION BY target ORDER BY timestamp DESC, id DESC) AS n
+            FROM ping_results
+            WHERE timestamp >= ? AND timestamp <= ?
+        )
+        WHERE (n - 1) % ? = 0
+        ORDER BY timestamp DESC, id DESC
+        LIMIT ?
+    `
+	rows, err = db.QueryContext(ctx, query, from.UTC(), to.UTC(), sampleStep(counts, maxPoints), maxPoints)
+	if err != nil {
+		return nil, err
+	}
+	return scanResults(rows)
+}
+
+// scanCounts reads single-column count rows and closes them
+func scanCounts(rows *sql.Rows) ([]int, error) {
+	defer rows.Close()
+
+	var counts []int
+	for rows.Next() {
+		var count int
+		if err := rows.Scan(&count); err != nil {
+			continue
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// sampleStep returns the smallest N such that keeping every Nth result of each
+// target, starting from its newest, leaves at most maxPoints results. With more
+// targets than maxPoints it keeps only each target's newest.
+func sampleStep(counts []int, maxPoints int) int {
+	total, largest := 0, 1
+	for _, count := range counts {
+		total += count
+		largest = max(largest, count)
+	}
+	if total <= maxPoints {
+		return 1
+	}
+
+	for step := (total + maxPoints - 1) / maxPoints; step < largest; step++ {
+		kept := 0
+		for _, count := range counts {
+			kept += (count + step - 1) / step
+		}
+		if kept <= maxPoints {
+			return step
+		}
+	}
+	return largest
+}
+
+// scanResults reads full ping result rows (timestamp, target, success, rtt_ms,
+// error_message, attempts, resolved_ip, ttl, failure_reason, instance) and
+// closes them. The slice is never nil, so a page past the end encodes as []
+// rather than null.
+func scanResults(rows *sql.Rows) ([]models.PingResult, error) {
 	defer rows.Close()
 
-	var results []models.PingResult
+	results := []models.PingResult{}
 	for rows.Next() {
 		var r models.PingResult
-		var errMsg sql.NullString
-		err := rows.Scan(&r.Timestamp, &r.Target, &r.Success, &r.RTT, &errMsg)
+		var errMsg, resolvedIP, reason, instance sql.NullString
+		var ttl sql.NullInt64
+		err := rows.Scan(&r.Timestamp, &r.Target, &r.Success, &r.RTT, &errMsg, &r.Attempts, &resolvedIP, &ttl, &reason, &instance)
 		if err != nil {
 			continue
 		}
 		if errMsg.Valid {
 			r.ErrorMessage = errMsg.String
 		}
+		r.ResolvedIP = resolvedIP.String
+		r.TTL = int(ttl.Int64)
+		r.FailureReason = models.FailureReason(reason.String)
+		r.Instance = instance.String
 		results = append(results, r)
 	}
 
 	return results, nil
 }
 
-// GetStats retrieves aggregated statistics
-func (db *DB) GetStats(hours int) ([]models.Stats, error) {
+// GetRange retrieves all ping results between from and to, oldest first
+func (db *DB) GetRange(ctx context.Context, from, to time.Time) ([]models.PingResult, error) {
+	query := `
+        SELECT timestamp, target, success, rtt_ms, error_message, attempts, resolved_ip, ttl, failure_reason, instance
+        FROM ping_results
+        WHERE timestamp >= ? AND timestamp <= ?
+        ORDER BY timestamp
+    `
+
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return scanResults(rows)
+}
+
+// GetTargets lists every target with recorded ping results
+func (db *DB) GetTargets(ctx context.Context) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT DISTINCT target FROM ping_results ORDER BY target`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []string
+	for rows.Next() {
+		var target string
+		if err := rows.Scan(&target); err != nil {
+			continue
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// GetLastTimestamp returns the time of the most recent stored result, or the
+// zero time if there are none
+func (db *DB) GetLastTimestamp(ctx context.Context) (time.Time, error) {
+	return lastTimestamp(db.QueryRowContext(ctx, `SELECT timestamp FROM ping_results ORDER BY timestamp DESC LIMIT 1`))
+}
+
+// lastTimestamp scans a single timestamp row, treating no row as the zero time
+func lastTimestamp(row *sql.Row) (time.Time, error) {
+	var last time.Time
+	if err := row.Scan(&last); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, err
+	}
+	return last, nil
+}
+
+// GetStats retrieves aggregated statistics for the pings between from and to.
+// RTT fields are 0 for a target without successful pings.
+func (db *DB) GetStats(ctx context.Context, from, to time.Time) ([]models.Stats, error) {
 	query := `
         SELECT
             target,
             COUNT(*) as total_pings,
             SUM(CASE WHEN success THEN 1 ELSE 0 END) as successful_pings,
-            AVG(CASE WHEN success THEN rtt_ms ELSE NULL END) as avg_rtt,
-            MAX(CASE WHEN success THEN rtt_ms ELSE NULL END) as max_rtt,
-            MIN(CASE WHEN success THEN rtt_ms ELSE NULL END) as min_rtt,
+            AVG(CASE WHEN success AND rtt_ms > 0 THEN rtt_ms ELSE NULL END) as avg_rtt,
+            MAX(CASE WHEN success AND rtt_ms > 0 THEN rtt_ms ELSE NULL END) as max_rtt,
+            MIN(CASE WHEN success AND rtt_ms > 0 THEN rtt_ms ELSE NULL END) as min_rtt,
             ROUND((1.0 - (CAST(SUM(CASE WHEN success THEN 1 ELSE 0 END) AS REAL) / COUNT(*))) * 100, 2) as packet_loss
         FROM ping_results
-        WHERE timestamp > datetime('now', '-' || ? || ' hours')
+        WHERE timestamp >= ? AND timestamp <= ?
         GROUP BY target
     `
 
-	rows, err := db.Query(query, hours)
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC())
 	if err != nil {
 		return nil, err
 	}
+	return scanStats(rows)
+}
+
+// scanStats reads per-target stats rows and closes them. The slice is never
+// nil, so an empty window encodes as [] rather than null.
+func scanStats(rows *sql.Rows) ([]models.Stats, error) {
 	defer rows.Close()
 
-	var stats []models.Stats
+	stats := []models.Stats{}
 	for rows.Next() {
 		var s models.Stats
+		// RTT aggregates are NULL for a target whose pings all failed
+		var avgRTT, maxRTT, minRTT sql.NullFloat64
 		err := rows.Scan(&s.Target, &s.TotalPings, &s.Successful,
-			&s.AvgRTT, &s.MaxRTT, &s.MinRTT, &s.PacketLoss)
+			&avgRTT, &maxRTT, &minRTT, &s.PacketLoss)
 		if err != nil {
 			continue
 		}
+		s.AvgRTT = avgRTT.Float64
+		s.MaxRTT = maxRTT.Float64
+		s.MinRTT = minRTT.Float64
 		stats = append(stats, s)
 	}
 
 	return stats, nil
 }
 
-// GetOutages retrieves detected outages using sliding window approach
-func (db *DB) GetOutages(days int) ([]models.Outage, error) {
+// GetSummary aggregates the last 24 hours across all targets. Without any data
+// every field is zero.
+func (db *DB) GetSummary(ctx context.Context) (models.Summary, error) {
+	now := time.Now()
+	stats, err := db.GetStats(ctx, now.Add(-24*time.Hour), now)
+	if err != nil {
+		return models.Summary{}, err
+	}
+
+	// Each target's most recent ping
 	query := `
+        SELECT target, success, rtt_ms
+        FROM ping_results p
+        WHERE timestamp = (SELECT MAX(timestamp) FROM ping_results WHERE target = p.target)
+        AND timestamp > datetime('now', '-24 hours')
+    `
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return models.Summary{}, err
+	}
+	latest, err := scanLatest(rows)
+	if err != nil {
+		return models.Summary{}, err
+	}
+
+	outages, err := db.GetOutages(ctx, now.AddDate(0, 0, -1), now)
+	if err != nil {
+		return models.Summary{}, err
+	}
+
+	return summarize(stats, latest, len(outages)), nil
+}
+
+// scanLatest reads target, success and rtt_ms rows and closes them
+func scanLatest(rows *sql.Rows) ([]models.PingResult, error) {
+	defer rows.Close()
+
+	var latest []models.PingResult
+	for rows.Next() {
+		var r models.PingResult
+		if err := rows.Scan(&r.Target, &r.Success, &r.RTT); err != nil {
+			continue
+		}
+		latest = append(latest, r)
+	}
+	return latest, rows.Err()
+}
+
+// GetCurrent returns each target's most recent ping. It reads one row per
+// target off the (target, timestamp) index rather than scanning a window of
+// results, so it stays cheap however much history is kept.
+func (db *DB) GetCurrent(ctx context.Context) ([]models.CurrentStatus, error) {
+	query := `
+        SELECT p.target, p.timestamp, p.success, p.rtt_ms
+        FROM ping_results p
+        JOIN (SELECT target, MAX(timestamp) AS latest FROM ping_results GROUP BY target) l
+            ON p.target = l.target AND p.timestamp = l.latest
+        ORDER BY p.target
+    `
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return scanCurrent(rows, time.Now())
+}
+
+// scanCurrent reads target, timestamp, success and rtt_ms rows and closes
+// them, keeping the first row of any target that appears twice
+func scanCurrent(rows *sql.Rows, now time.Time) ([]models.CurrentStatus, error) {
+	defer rows.Close()
+
+	current := []models.CurrentStatus{}
+	for rows.Next() {
+		var c models.CurrentStatus
+		if err := rows.Scan(&c.Target, &c.LastCheck, &c.Success, &c.RTT); err != nil {
+			continue
+		}
+		if n := len(current); n > 0 && current[n-1].Target == c.Target {
+			continue
+		}
+		c.SecondsSinceCheck = now.Sub(c.LastCheck).Seconds()
+		current = append(current, c)
+	}
+	return current, rows.Err()
+}
+
+// summarize builds a summary from per-target stats, each target's most recent
+// ping and the number of outages
+func summarize(stats []models.Stats, latest []models.PingResult, outages int) models.Summary {
+	var summary models.Summary
+
+	var total, successful int
+	for _, s := range stats {
+		total += s.TotalPings
+		successful += s.Successful
+		if summary.WorstTarget == "" || s.PacketLoss > summary.WorstPacketLoss {
+			summary.WorstTarget = s.Target
+			summary.WorstPacketLoss = s.PacketLoss
+		}
+	}
+	summary.Targets = len(stats)
+	if total > 0 {
+		summary.Availability = float64(successful) / float64(total) * 100
+	}
+
+	for _, r := range latest {
+		if !r.Success {
+			summary.TargetsDown++
+			continue
+		}
+		if r.RTT <= 0 {
+			continue
+		}
+		if summary.BestRTTTarget == "" || r.RTT < summary.BestRTT {
+			summary.BestRTTTarget = r.Target
+			summary.BestRTT = r.RTT
+		}
+		if summary.WorstRTTTarget == "" || r.RTT > summary.WorstRTT {
+			summary.WorstRTTTarget = r.Target
+			summary.WorstRTT = r.RTT
+		}
+	}
+
+	summary.Outages = outages
+	summary.FaultDomain = faultDomain(latest)
+	return summary
+}
+
+// faultDomain locates current failures from each target's most recent ping:
+// on this machine if loopback is down, on the local network if the gateway
+// is, and beyond it if another target is down while the gateway answers. It
+// is empty without reference targets or failures.
+func faultDomain(latest []models.PingResult) string {
+	var remoteDown bool
+	up := make(map[string]bool, len(latest))
+	for _, r := range latest {
+		up[r.Target] = r.Success
+		if !r.Success && !models.IsReferenceTarget(r.Target) {
+			remoteDown = true
+		}
+	}
+
+	if success, ok := up[models.TargetLoopback]; ok && !success {
+		return models.FaultLocal
+	}
+	if success, ok := up[models.TargetGateway]; ok && !success {
+		return models.FaultLAN
+	}
+	if up[models.TargetGateway] && remoteDown {
+		return models.FaultInternet
+	}
+	return ""
+}
+
+// GetOutages retrieves outages that started between from and to. Outages recorded
+// in real time are kept permanently, so they cover periods whose raw pings have
+// been archived; raw data is also scanned with the same outage threshold to catch
+// outages from before they were recorded, such as data from older versions.
+func (db *DB) GetOutages(ctx context.Context, from, to time.Time) ([]models.Outage, error) {
+	return db.GetOutagesFiltered(ctx, from, to, 0)
+}
+
+// GetOutagesFiltered retrieves outages like GetOutages, keeping only those that
+// lasted at least minDuration; ongoing outages count their duration so far.
+// Short outages are dropped before the result is capped, so they can't crowd
+// out long ones.
+func (db *DB) GetOutagesFiltered(ctx context.Context, from, to time.Time, minDuration time.Duration) ([]models.Outage, error) {
+	recorded, err := db.getRecordedOutages(ctx, from, to, minDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	detected, err := db.DetectOutages(ctx, from, to, db.outageThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeOutages(recorded, outagesLasting(detected, minDuration), maxOutages), nil
+}
+
+// DetectOutages finds outages in the raw ping data between from and to. A ping is in
+// an outage when at least threshold.Failures of the threshold.Window pings up to
+// and including it failed; consecutive such pings form one outage, spanning from
+// the first to the last failed ping involved. The RTTs around it are those of
+// the target's nearest successful pings either side.
+func (db *DB) DetectOutages(ctx context.Context, from, to time.Time, threshold models.OutageThreshold) ([]models.Outage, error) {
+	// Window bounds can't be bound parameters; the threshold is validated config
+	query := fmt.Sprintf(`
         WITH windowed_pings AS (
             SELECT
                 target,
                 timestamp,
                 success,
-                COUNT(*) OVER (
-                    PARTITION BY target
-                    ORDER BY timestamp
-                    ROWS 9 PRECEDING
-                ) as window_size,
-                SUM(CASE WHEN success = 0 THEN 1 ELSE 0 END) OVER (
-                    PARTITION BY target
-                    ORDER BY timestamp
-                    ROWS 9 PRECEDING
-                ) as failure_count
+                COUNT(*) OVER w as window_size,
+                SUM(CASE WHEN success = 0 THEN 1 ELSE 0 END) OVER w as failure_count,
+                MIN(CASE WHEN success = 0 THEN timestamp END) OVER w as first_failure
             FROM ping_results
-            WHERE timestamp > datetime('now', '-' || ? || ' days')
+            WHERE timestamp >= ? AND timestamp <= ?
+            WINDOW w AS (PARTITION BY target ORDER BY timestamp ROWS %d PRECEDING)
+        ),
+        flagged_pings AS (
+            SELECT
+                target,
+                timestamp,
+                success,
+                first_failure,
+                CASE WHEN window_size = %d AND failure_count >= %d THEN 1 ELSE 0 END as is_outage
+            FROM windowed_pings
         ),
         outage_periods AS (
             SELECT
                 target,
                 timestamp,
                 success,
-                CASE WHEN failure_count >= 5 AND window_size = 10 THEN 1 ELSE 0 END as is_outage,
+                first_failure,
+                is_outage,
                 ROW_NUMBER() OVER (PARTITION BY target ORDER BY timestamp) -
-                ROW_NUMBER() OVER (PARTITION BY target, CASE WHEN failure_count >= 5 AND window_size = 10 THEN 1 ELSE 0 END ORDER BY timestamp) as outage_grp
-            FROM windowed_pings
+                ROW_NUMBER() OVER (PARTITION BY target, is_outage ORDER BY timestamp) as outage_grp
+            FROM flagged_pings
+        ),
+        outages AS (
+            SELECT
+                target,
+                MIN(first_failure) as start_time,
+                COALESCE(MAX(CASE WHEN success = 0 THEN timestamp END), MAX(timestamp)) as end_time
+            FROM outage_periods
+            WHERE is_outage = 1
+            GROUP BY target, outage_grp
         )
         SELECT
             target,
-            MIN(timestamp) as start_time,
-            MAX(timestamp) as end_time,
-            COUNT(*) as failed_checks
-        FROM outage_periods
-        WHERE is_outage = 1
-        GROUP BY target, outage_grp
+            start_time,
+            end_time,
+            (
+                SELECT COUNT(*) FROM ping_results p
+                WHERE p.target = outages.target
+                AND p.success = 0
+                AND p.timestamp BETWEEN outages.start_time AND outages.end_time
+            ) as failed_checks,
+            (
+                SELECT rtt_ms FROM ping_results p
+                WHERE p.target = outages.target AND p.success = 1 AND p.rtt_ms > 0 AND p.timestamp < outages.start_time
+                ORDER BY p.timestamp DESC LIMIT 1
+            ) as rtt_before,
+            (
+                SELECT rtt_ms FROM ping_results p
+                WHERE p.target = outages.target AND p.success = 1 AND p.rtt_ms > 0 AND p.timestamp > outages.end_time
+                ORDER BY p.timestamp LIMIT 1
+            ) as rtt_after
+        FROM outages
         ORDER BY start_time DESC
-        LIMIT 100
-    `
+    `, threshold.Window-1, threshold.Window, threshold.Failures)
 
-	rows, err := db.Query(query, days)
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC())
 	if err != nil {
 		return nil, err
 	}
@@ -143,10 +573,19 @@ func (db *DB) GetOutages(days int) ([]models.Outage, error) {
 	var outages []models.Outage
 	for rows.Next() {
 		var o models.Outage
-		err := rows.Scan(&o.Target, &o.StartTime, &o.EndTime, &o.FailedChecks)
+		var start, end string
+		var before, after sql.NullFloat64
+		err := rows.Scan(&o.Target, &start, &end, &o.FailedChecks, &before, &after)
 		if err != nil {
 			continue
 		}
+		o.RTTBefore, o.RTTAfter = before.Float64, after.Float64
+		if o.StartTime, err = ParseTimestamp(start); err != nil {
+			continue
+		}
+		if o.EndTime, err = ParseTimestamp(end); err != nil {
+			continue
+		}
 		o.Duration = o.EndTime.Sub(o.StartTime).String()
 		outages = append(outages, o)
 	}
@@ -154,28 +593,34 @@ func (db *DB) GetOutages(days int) ([]models.Outage, error) {
 	return outages, nil
 }
 
-// GetHeatmapData retrieves heatmap data
-func (db *DB) GetHeatmapData(days int) ([]models.HeatmapPoint, error) {
+// GetHeatmapData retrieves heatmap data for the days between from and to, counted in the
+// configured location like the hourly_patterns dates themselves
+func (db *DB) GetHeatmapData(ctx context.Context, from, to time.Time) ([]models.HeatmapPoint, error) {
 	query := `
         SELECT
             hour,
             target,
-            AVG(failure_rate) as avg_failure_rate,
+            COALESCE(AVG(failure_rate), 0) as avg_failure_rate,
             AVG(avg_rtt_ms) as avg_latency,
             MAX(max_rtt_ms) as max_latency,
             SUM(failed_pings) as total_failures,
             SUM(total_pings) as total_pings,
             COUNT(DISTINCT date) as days_with_data
         FROM hourly_patterns
-        WHERE date > date('now', '-' || ? || ' days')
+        WHERE date >= ? AND date <= ?
         GROUP BY hour, target
         ORDER BY hour, target
     `
 
-	rows, err := db.Query(query, days)
+	rows, err := db.QueryContext(ctx, query, db.localDay(from), db.localDay(to))
 	if err != nil {
 		return nil, err
 	}
+	return scanHeatmap(rows)
+}
+
+// scanHeatmap reads heatmap rows and closes them
+func scanHeatmap(rows *sql.Rows) ([]models.HeatmapPoint, error) {
 	defer rows.Close()
 
 	var heatmapData []models.HeatmapPoint
@@ -199,8 +644,9 @@ func (db *DB) GetHeatmapData(days int) ([]models.HeatmapPoint, error) {
 	return heatmapData, nil
 }
 
-// GetPatterns retrieves pattern data for a specific hour
-func (db *DB) GetPatterns(hour string) ([]models.PatternDetail, error) {
+// GetPatterns retrieves pattern data for a specific hour on the days between
+// from and to
+func (db *DB) GetPatterns(ctx context.Context, hour string, from, to time.Time) ([]models.PatternDetail, error) {
 	query := `
         SELECT
             date,
@@ -212,14 +658,19 @@ func (db *DB) GetPatterns(hour string) ([]models.PatternDetail, error) {
             failure_rate
         FROM hourly_patterns
         WHERE hour = ?
-        AND date > date('now', '-30 days')
+        AND date >= ? AND date <= ?
         ORDER BY date DESC, target
     `
 
-	rows, err := db.Query(query, hour)
+	rows, err := db.QueryContext(ctx, query, hour, db.localDay(from), db.localDay(to))
 	if err != nil {
 		return nil, err
 	}
+	return scanPatterns(rows)
+}
+
+// scanPatterns reads hourly pattern rows and closes them
+func scanPatterns(rows *sql.Rows) ([]models.PatternDetail, error) {
 	defer rows.Close()
 
 	var patterns []models.PatternDetail
@@ -242,3 +693,77 @@ func (db *DB) GetPatterns(hour string) ([]models.PatternDetail, error) {
 
 	return patterns, nil
 }
+
+// localDate returns the date offset by days from today in the configured
+// location, formatted like hourly_patterns.date
+func (db *DB) localDate(days int) string {
+	return db.localDay(time.Now().AddDate(0, 0, days))
+}
+
+// localDay returns the date of t in the configured location, formatted like
+// hourly_patterns.date
+func (db *DB) localDay(t time.Time) string {
+	return t.In(db.location).Format("2006-01-02")
+}
+
+// RecordMonitoringEvent saves a monitoring state change such as a pause or resume
+func (db *DB) RecordMonitoringEvent(ctx context.Context, event models.MonitoringEvent) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO monitoring_events (timestamp, event) VALUES (?, ?)`,
+		event.Timestamp.UTC(), event.Event)
+	return err
+}
+
+// GetMonitoringEvents retrieves monitoring state changes between from and to
+func (db *DB) GetMonitoringEvents(ctx context.Context, from, to time.Time) ([]models.MonitoringEvent, error) {
+	query := `
+        SELECT timestamp, event
+        FROM monitoring_events
+        WHERE timestamp >= ? AND timestamp <= ?
+        ORDER BY timestamp DESC
+    `
+
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return scanEvents(rows)
+}
+
+// scanEvents reads timestamp, event rows and closes them
+func scanEvents(rows *sql.Rows) ([]models.MonitoringEvent, error) {
+	defer rows.Close()
+
+	var events []models.MonitoringEvent
+	for rows.Next() {
+		var e models.MonitoringEvent
+		if err := rows.Scan(&e.Timestamp, &e.Event); err != nil {
+			continue
+		}
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+// timestampLayouts are the text forms SQLite drivers use when storing time.Time
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05",
+}
+
+// ParseTimestamp parses a timestamp returned as text, which happens for aggregate
+// expressions like MIN(timestamp) that carry no column type for the driver to convert
+func ParseTimestamp(value string) (time.Time, error) {
+	// time.Time.String() appends the monotonic clock reading, which isn't parseable
+	if i := strings.Index(value, " m="); i >= 0 {
+		value = value[:i]
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
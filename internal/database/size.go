@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// evictionHorizon is how recent the results EnforceSizeLimit may delete are:
+// the last hour is kept, since hourly patterns may not have aggregated it yet
+const evictionHorizon = time.Hour
+
+// EnforceSizeLimit deletes the oldest raw results an hour at a time until the
+// data in the database takes up no more than maxBytes, rolling each hour up
+// into hourly_stats first so its aggregates outlive the raw rows. The freed
+// pages are then returned to the filesystem. It returns how many results were
+// deleted, and an error if the last hour alone is over the limit.
+func (db *DB) EnforceSizeLimit(ctx context.Context, maxBytes int64) (int64, error) {
+	var evicted int64
+	for {
+		size, err := db.dataSize(ctx)
+		if err != nil {
+			return evicted, fmt.Errorf("measure database size: %w", err)
+		}
+		if size <= maxBytes {
+			break
+		}
+
+		oldest, err := lastTimestamp(db.QueryRowContext(ctx, `SELECT timestamp FROM ping_results ORDER BY timestamp LIMIT 1`))
+		if err != nil {
+			return evicted, fmt.Errorf("find oldest result: %w", err)
+		}
+		cutoff := oldest.UTC().Truncate(time.Hour).Add(time.Hour)
+		if oldest.IsZero() || cutoff.After(time.Now().Add(-evictionHorizon)) {
+			return evicted, fmt.Errorf("database holds %d bytes, over the %d byte limit, with no results older than %v left to evict", size, maxBytes, evictionHorizon)
+		}
+
+		n, err := db.evictBefore(ctx, cutoff)
+		if err != nil {
+			return evicted, err
+		}
+		evicted += n
+	}
+
+	if evicted == 0 {
+		return 0, nil
+	}
+	return evicted, db.incrementalVacuum(ctx)
+}
+
+// dataSize is how many bytes of the database file hold data. Pages freed by
+// deletes count only until incremental vacuuming releases them, so this falls
+// as soon as rows are deleted.
+func (db *DB) dataSize(ctx context.Context) (int64, error) {
+	var size int64
+	err := db.QueryRowContext(ctx, `
+        SELECT (page_count - freelist_count) * page_size
+        FROM pragma_page_count(), pragma_freelist_count(), pragma_page_size()
+    `).Scan(&size)
+	return size, err
+}
+
+// evictBefore rolls the raw results older than cutoff up into hourly_stats and
+// deletes them, returning how many were deleted. cutoff falls on a whole hour,
+// so the hours rolled up are complete.
+func (db *DB) evictBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, archiveHourlyStatsQuery, cutoff, time.Time{}); err != nil {
+		return 0, fmt.Errorf("roll up results before %v: %w", cutoff, err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM ping_results WHERE timestamp < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("evict results before %v: %w", cutoff, err)
+	}
+	evicted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return evicted, tx.Commit()
+}
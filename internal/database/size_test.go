@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestEnforceSizeLimitEvictsOldestHours(t *testing.T) {
+	db := newTestDB(t)
+
+	// Two days of results, padded with error messages so they take some room
+	now := time.Now()
+	start := now.Add(-48 * time.Hour).Truncate(time.Hour)
+	var results []models.PingResult
+	for ts := start; ts.Before(now); ts = ts.Add(30 * time.Second) {
+		results = append(results, models.PingResult{Timestamp: ts, Target: "8.8.8.8", ErrorMessage: strings.Repeat("x", 200)})
+	}
+	if err := db.SaveResults(context.Background(), results); err != nil {
+		t.Fatalf("SaveResults() error = %v", err)
+	}
+
+	size, err := db.dataSize(context.Background())
+	if err != nil {
+		t.Fatalf("dataSize() error = %v", err)
+	}
+	limit := size / 2
+
+	evicted, err := db.EnforceSizeLimit(context.Background(), limit)
+	if err != nil {
+		t.Fatalf("EnforceSizeLimit() error = %v", err)
+	}
+	if evicted == 0 || evicted >= int64(len(results)) {
+		t.Fatalf("EnforceSizeLimit() evicted %d of %d results, want some", evicted, len(results))
+	}
+	if size, err := db.dataSize(context.Background()); err != nil || size > limit {
+		t.Errorf("dataSize() = %d, %v after eviction, want at most %d", size, err, limit)
+	}
+
+	// The oldest results went first, and whole hours at a time
+	oldest, err := lastTimestamp(db.QueryRowContext(context.Background(), `SELECT timestamp FROM ping_results ORDER BY timestamp LIMIT 1`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !oldest.After(start) || !oldest.Equal(oldest.Truncate(time.Hour)) {
+		t.Errorf("oldest result left is from %v, want a later whole hour than %v", oldest, start)
+	}
+
+	// The evicted hours live on in hourly_stats
+	var hours, pings int64
+	if err := db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(total_pings), 0) FROM hourly_stats`).Scan(&hours, &pings); err != nil {
+		t.Fatal(err)
+	}
+	if pings != evicted || hours != int64(oldest.Sub(start)/time.Hour) {
+		t.Errorf("hourly_stats has %d hours of %d pings, want %d hours of %d", hours, pings, oldest.Sub(start)/time.Hour, evicted)
+	}
+
+	// Under the limit, nothing more is evicted
+	if evicted, err := db.EnforceSizeLimit(context.Background(), limit); err != nil || evicted != 0 {
+		t.Errorf("EnforceSizeLimit() again = %d, %v, want nothing evicted", evicted, err)
+	}
+}
+
+func TestEnforceSizeLimitKeepsLastHour(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		if err := db.SaveResult(context.Background(), models.PingResult{Timestamp: now.Add(-time.Duration(i) * time.Minute), Target: "8.8.8.8"}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	evicted, err := db.EnforceSizeLimit(context.Background(), 1)
+	if err == nil || evicted != 0 {
+		t.Errorf("EnforceSizeLimit() = %d, %v, want an error and the last hour kept", evicted, err)
+	}
+}
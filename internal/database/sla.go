@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// GetSLA computes a target's availability between from and to; an empty
+// target covers all targets. Check counts come from raw pings, plus the
+// archived hourly stats for hours whose raw pings have been deleted; downtime
+// and the outage count come from the recorded outages, clipped to the period,
+// with ongoing outages counted up to now.
+func (db *DB) GetSLA(ctx context.Context, target string, from, to time.Time) (models.SLA, error) {
+	sla := models.SLA{Target: target, Since: from, Until: to}
+
+	var rawTotal, rawSuccessful int
+	rawQuery := `
+        SELECT COUNT(*), COALESCE(SUM(CASE WHEN success THEN 1 ELSE 0 END), 0)
+        FROM ping_results
+        WHERE timestamp >= ? AND timestamp <= ? AND (? = '' OR target = ?)
+    `
+	if err := db.QueryRowContext(ctx, rawQuery, from.UTC(), to.UTC(), target, target).Scan(&rawTotal, &rawSuccessful); err != nil {
+		return sla, fmt.Errorf("count checks: %w", err)
+	}
+
+	// Only hours before a target's oldest raw ping, so nothing is counted twice
+	var archivedTotal, archivedSuccessful int
+	archivedQuery := `
+        SELECT COALESCE(SUM(total_pings), 0), COALESCE(SUM(successful_pings), 0)
+        FROM hourly_stats h
+        WHERE hour >= ? AND hour < ? AND (? = '' OR target = ?)
+        AND hour < COALESCE(
+            (SELECT strftime('%Y-%m-%d %H:00:00', MIN(timestamp)) FROM ping_results p WHERE p.target = h.target),
+            '9999-12-31')
+    `
+	fromHour := from.UTC().Truncate(time.Hour).Format("2006-01-02 15:04:05")
+	toHour := to.UTC().Format("2006-01-02 15:04:05")
+	if err := db.QueryRowContext(ctx, archivedQuery, fromHour, toHour, target, target).Scan(&archivedTotal, &archivedSuccessful); err != nil {
+		return sla, fmt.Errorf("count archived checks: %w", err)
+	}
+
+	sla.TotalChecks = rawTotal + archivedTotal
+	sla.SuccessfulChecks = rawSuccessful + archivedSuccessful
+	if sla.TotalChecks > 0 {
+		sla.Availability = float64(sla.SuccessfulChecks) / float64(sla.TotalChecks) * 100
+	}
+
+	outageQuery := `
+        SELECT start_time, end_time
+        FROM outages
+        WHERE (end_time IS NULL OR end_time > ?) AND start_time < ? AND (? = '' OR target = ?)
+    `
+	rows, err := db.QueryContext(ctx, outageQuery, from.UTC(), to.UTC(), target, target)
+	if err != nil {
+		return sla, fmt.Errorf("query outages: %w", err)
+	}
+
+	downtime, count, err := sumDowntime(rows, from, to)
+	if err != nil {
+		return sla, fmt.Errorf("query outages: %w", err)
+	}
+	sla.Outages = count
+
+	sla.DowntimeSeconds = downtime.Seconds()
+	sla.Downtime = downtime.Round(time.Second).String()
+	return sla, nil
+}
+
+// sumDowntime adds up start_time, end_time outage rows clipped to the period
+// between from and to, counting outages without an end up to now, and closes
+// the rows
+func sumDowntime(rows *sql.Rows, from, to time.Time) (time.Duration, int, error) {
+	defer rows.Close()
+
+	now := time.Now()
+	var downtime time.Duration
+	var count int
+	for rows.Next() {
+		var start time.Time
+		var end sql.NullTime
+		if err := rows.Scan(&start, &end); err != nil {
+			continue
+		}
+		if start.Before(from) {
+			start = from
+		}
+		stop := now
+		if end.Valid {
+			stop = end.Time
+		}
+		if stop.After(to) {
+			stop = to
+		}
+		if stop.After(start) {
+			downtime += stop.Sub(start)
+		}
+		count++
+	}
+	return downtime, count, rows.Err()
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestGetSLA(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+
+	// 100 raw pings over the last day and a half, 5 of them failed
+	var oldestRaw time.Time
+	for i := 0; i < 100; i++ {
+		oldestRaw = now.Add(-time.Duration(i) * 20 * time.Minute)
+		if err := db.SaveResult(context.Background(), models.PingResult{Timestamp: oldestRaw, Target: "8.8.8.8", Success: i%20 != 0, RTT: 10}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+	if err := db.SaveResult(context.Background(), models.PingResult{Timestamp: now, Target: "1.1.1.1", Success: true, RTT: 5}); err != nil {
+		t.Fatalf("SaveResult() error = %v", err)
+	}
+
+	// Archived hours whose raw pings are gone; the ones outside the period or
+	// overlapping the remaining raw pings must not be counted
+	archived := []struct {
+		hour       time.Time
+		total      int
+		successful int
+	}{
+		{now.AddDate(0, 0, -10), 60, 57},
+		{now.AddDate(0, 0, -20), 60, 60},
+		{now.AddDate(0, 0, -40), 60, 0},
+		{oldestRaw, 60, 0},
+	}
+	for _, a := range archived {
+		_, err := db.Exec(`INSERT INTO hourly_stats (hour, target, total_pings, successful_pings) VALUES (?, ?, ?, ?)`,
+			a.hour.UTC().Truncate(time.Hour).Format("2006-01-02 15:04:05"), "8.8.8.8", a.total, a.successful)
+		if err != nil {
+			t.Fatalf("insert hourly_stats: %v", err)
+		}
+	}
+
+	outages := []struct {
+		target string
+		start  time.Time
+		end    time.Time // zero for an ongoing outage
+	}{
+		{"8.8.8.8", now.AddDate(0, 0, -3), now.AddDate(0, 0, -3).Add(10 * time.Minute)},
+		{"8.8.8.8", now.AddDate(0, 0, -31), now.AddDate(0, 0, -30).Add(time.Hour)}, // clipped to the last hour
+		{"8.8.8.8", now.AddDate(0, 0, -45), now.AddDate(0, 0, -44)},                // before the period
+		{"8.8.8.8", now.Add(-5 * time.Minute), time.Time{}},
+		{"1.1.1.1", now.AddDate(0, 0, -1), now.AddDate(0, 0, -1).Add(2 * time.Minute)},
+	}
+	for _, o := range outages {
+		id, err := db.RecordOutageStart(context.Background(), o.target, o.start, 0)
+		if err != nil {
+			t.Fatalf("RecordOutageStart() error = %v", err)
+		}
+		if !o.end.IsZero() {
+			if err := db.RecordOutageEnd(context.Background(), id, o.end, 3, 0); err != nil {
+				t.Fatalf("RecordOutageEnd() error = %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name             string
+		target           string
+		wantTotal        int
+		wantSuccessful   int
+		wantAvailability float64
+		wantOutages      int
+		wantDowntime     time.Duration
+	}{
+		{
+			name:             "single target",
+			target:           "8.8.8.8",
+			wantTotal:        220,
+			wantSuccessful:   212,
+			wantAvailability: 212.0 / 220 * 100,
+			wantOutages:      3,
+			wantDowntime:     10*time.Minute + time.Hour + 5*time.Minute,
+		},
+		{
+			name:             "all targets",
+			target:           "",
+			wantTotal:        221,
+			wantSuccessful:   213,
+			wantAvailability: 213.0 / 221 * 100,
+			wantOutages:      4,
+			wantDowntime:     10*time.Minute + time.Hour + 5*time.Minute + 2*time.Minute,
+		},
+		{
+			name:   "unknown target",
+			target: "192.0.2.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := lastHours(30 * 24)
+			sla, err := db.GetSLA(context.Background(), tt.target, from, to)
+			if err != nil {
+				t.Fatalf("GetSLA() error = %v", err)
+			}
+			if sla.TotalChecks != tt.wantTotal || sla.SuccessfulChecks != tt.wantSuccessful {
+				t.Errorf("checks = %d/%d, want %d/%d", sla.SuccessfulChecks, sla.TotalChecks, tt.wantSuccessful, tt.wantTotal)
+			}
+			if math.Abs(sla.Availability-tt.wantAvailability) > 1e-9 {
+				t.Errorf("Availability = %v, want %v", sla.Availability, tt.wantAvailability)
+			}
+			if sla.Outages != tt.wantOutages {
+				t.Errorf("Outages = %d, want %d", sla.Outages, tt.wantOutages)
+			}
+			// The ongoing outage and the clipped one move with the clock
+			if diff := time.Duration(sla.DowntimeSeconds*float64(time.Second)) - tt.wantDowntime; diff < -5*time.Second || diff > 5*time.Second {
+				t.Errorf("downtime = %vs, want about %v", sla.DowntimeSeconds, tt.wantDowntime)
+			}
+		})
+	}
+}
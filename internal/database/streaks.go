@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// GetStreaks returns each target's current run of consecutive successful or
+// failed pings: the pings since the last one with the opposite outcome
+func (db *DB) GetStreaks(ctx context.Context) ([]models.Streak, error) {
+	// The run's first timestamp is joined back to its row, as MIN() would
+	// return it as text rather than a DATETIME
+	query := `
+        SELECT s.target, s.success, s.checks, f.timestamp
+        FROM (
+            SELECT l.target, l.success, COUNT(*) AS checks, MIN(p.timestamp) AS since
+            FROM (
+                SELECT p.target, p.success, p.timestamp
+                FROM ping_results p
+                JOIN (SELECT target, MAX(timestamp) AS latest FROM ping_results GROUP BY target) m
+                    ON p.target = m.target AND p.timestamp = m.latest
+            ) l
+            JOIN ping_results p ON p.target = l.target AND p.success = l.success AND p.timestamp <= l.timestamp
+            WHERE p.timestamp > COALESCE(
+                (SELECT MAX(o.timestamp) FROM ping_results o WHERE o.target = l.target AND o.success != l.success), '')
+            GROUP BY l.target, l.success
+        ) s
+        JOIN ping_results f ON f.target = s.target AND f.timestamp = s.since
+        ORDER BY s.target
+    `
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return scanStreaks(rows, time.Now())
+}
+
+// scanStreaks reads target, success, checks and start timestamp rows and
+// closes them, keeping the first row of any target that appears twice
+func scanStreaks(rows *sql.Rows, now time.Time) ([]models.Streak, error) {
+	defer rows.Close()
+
+	streaks := []models.Streak{}
+	for rows.Next() {
+		var s models.Streak
+		if err := rows.Scan(&s.Target, &s.Success, &s.Checks, &s.Since); err != nil {
+			continue
+		}
+		if n := len(streaks); n > 0 && streaks[n-1].Target == s.Target {
+			continue
+		}
+		elapsed := now.Sub(s.Since)
+		s.Duration = elapsed.Round(time.Second).String()
+		s.DurationSeconds = elapsed.Seconds()
+		streaks = append(streaks, s)
+	}
+	return streaks, rows.Err()
+}
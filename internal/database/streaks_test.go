@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestGetStreaks(t *testing.T) {
+	db := newTestDB(t)
+
+	if streaks, err := db.GetStreaks(context.Background()); err != nil || len(streaks) != 0 {
+		t.Fatalf("GetStreaks() on an empty database = %v, %v; want none", streaks, err)
+	}
+
+	// Outcomes a minute apart, oldest first, ending at now
+	now := time.Now().Truncate(time.Second)
+	history := map[string][]bool{
+		"1.1.1.1": {true, false, true, true, true},  // up for the last 3 checks
+		"8.8.8.8": {true, true, false, true, false}, // down for the last check
+		"9.9.9.9": {false, false, false, false},     // down since its first check
+	}
+	for target, outcomes := range history {
+		// Save newest first so the run can't depend on insertion order
+		for i := len(outcomes) - 1; i >= 0; i-- {
+			result := models.PingResult{
+				Timestamp: now.Add(-time.Duration(len(outcomes)-1-i) * time.Minute),
+				Target:    target,
+				Success:   outcomes[i],
+			}
+			if err := db.SaveResult(context.Background(), result); err != nil {
+				t.Fatalf("SaveResult() error = %v", err)
+			}
+		}
+	}
+
+	streaks, err := db.GetStreaks(context.Background())
+	if err != nil {
+		t.Fatalf("GetStreaks() error = %v", err)
+	}
+
+	want := []struct {
+		target  string
+		success bool
+		checks  int
+		since   time.Time
+	}{
+		{"1.1.1.1", true, 3, now.Add(-2 * time.Minute)},
+		{"8.8.8.8", false, 1, now},
+		{"9.9.9.9", false, 4, now.Add(-3 * time.Minute)},
+	}
+	if len(streaks) != len(want) {
+		t.Fatalf("GetStreaks() returned %d streaks, want %d: %+v", len(streaks), len(want), streaks)
+	}
+	for i, w := range want {
+		s := streaks[i]
+		if s.Target != w.target || s.Success != w.success || s.Checks != w.checks || !s.Since.Equal(w.since) {
+			t.Errorf("streaks[%d] = %+v, want %s success=%v for %d checks since %v", i, s, w.target, w.success, w.checks, w.since)
+		}
+		if elapsed := time.Since(w.since).Seconds(); s.DurationSeconds < elapsed-60 || s.DurationSeconds > elapsed {
+			t.Errorf("streaks[%d].DurationSeconds = %v, want about %v", i, s.DurationSeconds, elapsed)
+		}
+	}
+	if streaks[0].Duration != "2m0s" && streaks[0].Duration != "2m1s" {
+		t.Errorf("streaks[0].Duration = %q, want about 2m0s", streaks[0].Duration)
+	}
+}
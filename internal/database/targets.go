@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"network-monitor/internal/models"
+)
+
+// selectTargetMetaQuery reads every target's metadata; it's the same on both backends
+const selectTargetMetaQuery = `SELECT target, name, color FROM target_meta ORDER BY target`
+
+// SetTargetMeta replaces the stored display names and colors with meta, so
+// the configuration stays their single source
+func (db *DB) SetTargetMeta(ctx context.Context, meta []models.TargetMeta) error {
+	return replaceTargetMeta(ctx, db.DB, `INSERT INTO target_meta (target, name, color) VALUES (?, ?, ?)`, meta)
+}
+
+// GetTargetMeta retrieves the stored display names and colors, by target
+func (db *DB) GetTargetMeta(ctx context.Context) ([]models.TargetMeta, error) {
+	rows, err := db.QueryContext(ctx, selectTargetMetaQuery)
+	if err != nil {
+		return nil, err
+	}
+	return scanTargetMeta(rows)
+}
+
+// replaceTargetMeta empties target_meta and inserts meta with one backend's
+// insert query, in one transaction
+func replaceTargetMeta(ctx context.Context, db *sql.DB, insert string, meta []models.TargetMeta) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM target_meta`); err != nil {
+		return err
+	}
+	for _, m := range meta {
+		if _, err := tx.ExecContext(ctx, insert, m.Target, m.Name, m.Color); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// scanTargetMeta reads target, name and color rows, and closes the rows
+func scanTargetMeta(rows *sql.Rows) ([]models.TargetMeta, error) {
+	defer rows.Close()
+
+	meta := []models.TargetMeta{}
+	for rows.Next() {
+		var m models.TargetMeta
+		if err := rows.Scan(&m.Target, &m.Name, &m.Color); err != nil {
+			continue
+		}
+		meta = append(meta, m)
+	}
+	return meta, rows.Err()
+}
@@ -0,0 +1,306 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// AggregateDaily rolls hourly patterns up into daily_stats, one row per target
+// per day in the configured location, with the outages that started that day.
+// It rebuilds from the latest day already rolled up, which may have been
+// partial, so it is cheap to run on every maintenance pass.
+func (db *DB) AggregateDaily(ctx context.Context) error {
+	return aggregateRollup(ctx, db.DB, dailyRollup, dayPeriod, db.location)
+}
+
+// dailyRollup rolls hourly_patterns up into daily_stats
+var dailyRollup = rollupQueries{
+	latest: `SELECT day FROM daily_stats ORDER BY day DESC LIMIT 1`,
+	source: `
+            SELECT date, target, total_pings, total_pings - failed_pings, avg_rtt_ms, p95_rtt_ms, 0
+            FROM hourly_patterns
+            WHERE date >= ?
+        `,
+	outages: `SELECT target, start_time FROM outages WHERE start_time >= ?`,
+	upsert: `
+            INSERT OR REPLACE INTO daily_stats (day, target, total_pings, successful_pings, avg_rtt_ms, p95_rtt_ms, outages)
+            VALUES (?, ?, ?, ?, ?, ?, ?)
+        `,
+}
+
+// AggregateMonthly rolls daily_stats up into monthly_stats, rebuilding from
+// the latest month already rolled up. Run it after AggregateDaily.
+func (db *DB) AggregateMonthly(ctx context.Context) error {
+	return aggregateRollup(ctx, db.DB, monthlyRollup, monthPeriod, db.location)
+}
+
+// monthlyRollup rolls daily_stats up into monthly_stats
+var monthlyRollup = rollupQueries{
+	latest: `SELECT month FROM monthly_stats ORDER BY month DESC LIMIT 1`,
+	source: `
+            SELECT day, target, total_pings, successful_pings, avg_rtt_ms, p95_rtt_ms, outages
+            FROM daily_stats
+            WHERE day >= ?
+        `,
+	upsert: `
+            INSERT OR REPLACE INTO monthly_stats (month, target, total_pings, successful_pings, avg_rtt_ms, p95_rtt_ms, outages)
+            VALUES (?, ?, ?, ?, ?, ?, ?)
+        `,
+}
+
+// GetTrends retrieves the daily or monthly rollups for the days or months
+// overlapping from to to, oldest first. An empty target matches all targets.
+func (db *DB) GetTrends(ctx context.Context, target, granularity string, from, to time.Time) ([]models.Trend, error) {
+	table, column, err := trendTable(granularity)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+        SELECT %[1]s, target, total_pings, successful_pings, avg_rtt_ms, p95_rtt_ms, outages
+        FROM %[2]s
+        WHERE %[1]s >= ? AND %[1]s <= ? AND (? = '' OR target = ?)
+        ORDER BY %[1]s, target
+    `, column, table)
+
+	start, end := trendBounds(granularity, from.In(db.location), to.In(db.location))
+	rows, err := db.QueryContext(ctx, query, start, end, target, target)
+	if err != nil {
+		return nil, err
+	}
+	return scanTrends(rows, granularity)
+}
+
+// rollupQueries are one backend's statements for a rollup table. latest
+// selects the newest period already rolled up, or is empty to rebuild them all; source selects the period's
+// finer rows from a date on as day, target, total and successful pings,
+// avg_rtt_ms, p95_rtt_ms and outages; outages, if set, selects the target and
+// start_time of outages from a time on; upsert writes a row in the same column
+// order as source.
+type rollupQueries struct {
+	latest, source, outages, upsert string
+}
+
+// rollupKey identifies one daily_stats or monthly_stats row
+type rollupKey struct {
+	period string
+	target string
+}
+
+// rollupStats accumulates the finer rows for one rollup row. RTTs are
+// weighted by successful pings, and only over rows that have them: the
+// 95th percentile is missing from patterns aggregated before it was recorded,
+// and both are from rows whose successes had no parsed RTT.
+type rollupStats struct {
+	total      int
+	successful int
+	rttSum     float64
+	rttWeight  int
+	p95Sum     float64
+	p95Weight  int
+	outages    int
+}
+
+// dayPeriod returns the daily_stats period holding date
+func dayPeriod(date time.Time) string {
+	return date.Format("2006-01-02")
+}
+
+// monthPeriod returns the monthly_stats period holding date: its month's first day
+func monthPeriod(date time.Time) string {
+	return date.Format("2006-01") + "-01"
+}
+
+// aggregateRollup rebuilds the rollup rows from the latest one already written
+// on, or all of them without a latest query, grouping the source rows by period, and counting outages by their start
+// day in loc when the queries select them
+func aggregateRollup(ctx context.Context, db *sql.DB, q rollupQueries, period func(time.Time) string, loc *time.Location) error {
+	from := "0001-01-01"
+	if q.latest != "" {
+		var latest time.Time
+		if err := db.QueryRowContext(ctx, q.latest).Scan(&latest); err == nil {
+			from = latest.Format("2006-01-02")
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("read latest rollup: %w", err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, q.source, from)
+	if err != nil {
+		return err
+	}
+	keys, stats, err := bucketRollup(rows, period)
+	if err != nil {
+		return err
+	}
+
+	if q.outages != "" {
+		start, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			return err
+		}
+		rows, err := db.QueryContext(ctx, q.outages, start.UTC())
+		if err != nil {
+			return err
+		}
+		if err := countRollupOutages(rows, stats, period, loc); err != nil {
+			return err
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, q.upsert)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, key := range keys {
+		s := stats[key]
+		avgRTT, p95RTT := s.rtts()
+		if _, err := stmt.ExecContext(ctx, key.period, key.target, s.total, s.successful, avgRTT, p95RTT, s.outages); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// rebuildRollups rolls every day and month up again, instead of only from the
+// latest ones already rolled up, so that patterns backfilled for days older
+// than those, e.g. from imported results, reach the trends
+func rebuildRollups(ctx context.Context, db *sql.DB, daily, monthly rollupQueries, loc *time.Location) error {
+	daily.latest, monthly.latest = "", ""
+	if err := aggregateRollup(ctx, db, daily, dayPeriod, loc); err != nil {
+		return fmt.Errorf("rebuild daily rollups: %w", err)
+	}
+	if err := aggregateRollup(ctx, db, monthly, monthPeriod, loc); err != nil {
+		return fmt.Errorf("rebuild monthly rollups: %w", err)
+	}
+	return nil
+}
+
+// bucketRollup reads rollup source rows into buckets by period and target,
+// and closes the rows. Keys are returned in the order they were first seen.
+func bucketRollup(rows *sql.Rows, period func(time.Time) string) ([]rollupKey, map[rollupKey]*rollupStats, error) {
+	defer rows.Close()
+
+	var keys []rollupKey
+	stats := make(map[rollupKey]*rollupStats)
+	for rows.Next() {
+		var date time.Time
+		var target string
+		var total, successful, outages int
+		var avgRTT, p95RTT sql.NullFloat64
+		if err := rows.Scan(&date, &target, &total, &successful, &avgRTT, &p95RTT, &outages); err != nil {
+			continue
+		}
+
+		key := rollupKey{period: period(date), target: target}
+		s, ok := stats[key]
+		if !ok {
+			s = &rollupStats{}
+			stats[key] = s
+			keys = append(keys, key)
+		}
+		s.total += total
+		s.successful += successful
+		s.outages += outages
+		if avgRTT.Valid {
+			s.rttSum += avgRTT.Float64 * float64(successful)
+			s.rttWeight += successful
+		}
+		if p95RTT.Valid {
+			s.p95Sum += p95RTT.Float64 * float64(successful)
+			s.p95Weight += successful
+		}
+	}
+	return keys, stats, rows.Err()
+}
+
+// countRollupOutages reads target and start_time outage rows, counting each
+// toward the period its start falls in, in loc, and closes the rows. Outages
+// in periods without pings are left out.
+func countRollupOutages(rows *sql.Rows, stats map[rollupKey]*rollupStats, period func(time.Time) string, loc *time.Location) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		var target string
+		var start time.Time
+		if err := rows.Scan(&target, &start); err != nil {
+			continue
+		}
+		if s, ok := stats[rollupKey{period: period(start.In(loc)), target: target}]; ok {
+			s.outages++
+		}
+	}
+	return rows.Err()
+}
+
+// rtts returns the weighted average and 95th percentile RTT, NULL without
+// successful pings that have them
+func (s *rollupStats) rtts() (avg, p95 sql.NullFloat64) {
+	if s.rttWeight > 0 {
+		avg = sql.NullFloat64{Float64: s.rttSum / float64(s.rttWeight), Valid: true}
+	}
+	if s.p95Weight > 0 {
+		p95 = sql.NullFloat64{Float64: s.p95Sum / float64(s.p95Weight), Valid: true}
+	}
+	return avg, p95
+}
+
+// trendTable returns the rollup table and its period column for granularity
+func trendTable(granularity string) (table, column string, err error) {
+	switch granularity {
+	case models.TrendDay:
+		return "daily_stats", "day", nil
+	case models.TrendMonth:
+		return "monthly_stats", "month", nil
+	}
+	return "", "", fmt.Errorf("unknown trend granularity %q", granularity)
+}
+
+// trendBounds returns the first and last period overlapping from to to, both
+// already in the configured location
+func trendBounds(granularity string, from, to time.Time) (start, end string) {
+	if granularity == models.TrendMonth {
+		return monthPeriod(from), monthPeriod(to)
+	}
+	return dayPeriod(from), dayPeriod(to)
+}
+
+// scanTrends reads rollup rows in GetTrends' column order, and closes the rows
+func scanTrends(rows *sql.Rows, granularity string) ([]models.Trend, error) {
+	defer rows.Close()
+
+	layout := "2006-01-02"
+	if granularity == models.TrendMonth {
+		layout = "2006-01"
+	}
+
+	trends := []models.Trend{}
+	for rows.Next() {
+		var t models.Trend
+		var period time.Time
+		var avgRTT, p95RTT sql.NullFloat64
+		if err := rows.Scan(&period, &t.Target, &t.TotalChecks, &t.SuccessfulChecks, &avgRTT, &p95RTT, &t.Outages); err != nil {
+			continue
+		}
+		t.Period = period.Format(layout)
+		if t.TotalChecks > 0 {
+			t.Availability = float64(t.SuccessfulChecks) / float64(t.TotalChecks) * 100
+		}
+		t.AvgRTT = avgRTT.Float64
+		t.P95RTT = p95RTT.Float64
+		trends = append(trends, t)
+	}
+	return trends, rows.Err()
+}
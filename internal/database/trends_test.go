@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestAggregateDailyAndMonthly(t *testing.T) {
+	db := newTestDB(t)
+	zone := time.FixedZone("UTC+3", 3*60*60)
+	db.SetLocation(zone)
+
+	type pattern struct {
+		date          string
+		hour          int
+		total, failed int
+		avgRTT, p95   sql.NullFloat64
+	}
+	patterns := []pattern{
+		{"2024-05-01", 10, 60, 0, valid(10), valid(20)},
+		{"2024-05-01", 11, 40, 20, valid(40), valid(50)},
+		{"2024-05-02", 0, 100, 0, valid(20), valid(30)},
+		{"2024-06-01", 5, 50, 50, sql.NullFloat64{}, sql.NullFloat64{}},
+	}
+	insertPatterns := func() {
+		for _, p := range patterns {
+			_, err := db.Exec(`INSERT OR REPLACE INTO hourly_patterns (date, hour, target, total_pings, failed_pings, avg_rtt_ms, p95_rtt_ms) VALUES (?, ?, '8.8.8.8', ?, ?, ?, ?)`,
+				p.date, p.hour, p.total, p.failed, p.avgRTT, p.p95)
+			if err != nil {
+				t.Fatalf("insert hourly_patterns: %v", err)
+			}
+		}
+	}
+	insertPatterns()
+
+	// 22:30 UTC on May 1 is already May 2 in UTC+3
+	if _, err := db.RecordOutageStart(context.Background(), "8.8.8.8", time.Date(2024, 5, 1, 22, 30, 0, 0, time.UTC), 0); err != nil {
+		t.Fatalf("RecordOutageStart() error = %v", err)
+	}
+
+	aggregate := func() {
+		t.Helper()
+		if err := db.AggregateDaily(context.Background()); err != nil {
+			t.Fatalf("AggregateDaily() error = %v", err)
+		}
+		if err := db.AggregateMonthly(context.Background()); err != nil {
+			t.Fatalf("AggregateMonthly() error = %v", err)
+		}
+	}
+	aggregate()
+
+	// A later pass picks up the rest of the latest day and month
+	patterns = append(patterns, pattern{"2024-06-01", 6, 50, 0, valid(10), valid(10)})
+	insertPatterns()
+	aggregate()
+
+	from := time.Date(2024, 4, 1, 0, 0, 0, 0, zone)
+	to := time.Date(2024, 7, 1, 0, 0, 0, 0, zone)
+	tests := []struct {
+		granularity string
+		want        []models.Trend
+	}{
+		{models.TrendDay, []models.Trend{
+			{Period: "2024-05-01", TotalChecks: 100, SuccessfulChecks: 80, Availability: 80, AvgRTT: 17.5, P95RTT: 27.5},
+			{Period: "2024-05-02", TotalChecks: 100, SuccessfulChecks: 100, Availability: 100, AvgRTT: 20, P95RTT: 30, Outages: 1},
+			{Period: "2024-06-01", TotalChecks: 100, SuccessfulChecks: 50, Availability: 50, AvgRTT: 10, P95RTT: 10},
+		}},
+		{models.TrendMonth, []models.Trend{
+			{Period: "2024-05", TotalChecks: 200, SuccessfulChecks: 180, Availability: 90, AvgRTT: 3400.0 / 180, P95RTT: 5200.0 / 180, Outages: 1},
+			{Period: "2024-06", TotalChecks: 100, SuccessfulChecks: 50, Availability: 50, AvgRTT: 10, P95RTT: 10},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.granularity, func(t *testing.T) {
+			got, err := db.GetTrends(context.Background(), "8.8.8.8", tt.granularity, from, to)
+			if err != nil {
+				t.Fatalf("GetTrends() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetTrends() = %+v, want %d periods", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				g := got[i]
+				if g.Period != want.Period || g.Target != "8.8.8.8" || g.TotalChecks != want.TotalChecks ||
+					g.SuccessfulChecks != want.SuccessfulChecks || g.Outages != want.Outages ||
+					math.Abs(g.Availability-want.Availability) > 1e-9 ||
+					math.Abs(g.AvgRTT-want.AvgRTT) > 1e-9 || math.Abs(g.P95RTT-want.P95RTT) > 1e-9 {
+					t.Errorf("period %d = %+v, want %+v", i, g, want)
+				}
+			}
+		})
+	}
+
+	if got, err := db.GetTrends(context.Background(), "1.1.1.1", models.TrendDay, from, to); err != nil || len(got) != 0 {
+		t.Errorf("GetTrends() for another target = %+v, %v, want none", got, err)
+	}
+	if _, err := db.GetTrends(context.Background(), "", "week", from, to); err == nil {
+		t.Error("GetTrends() accepted an unknown granularity")
+	}
+}
+
+func TestRollupSkipsPatternsWithoutRTT(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	// Successes whose RTT couldn't be parsed leave an hour without an average
+	for _, p := range []struct {
+		date        string
+		hour        int
+		avgRTT, p95 sql.NullFloat64
+	}{
+		{"2024-07-01", 1, sql.NullFloat64{}, sql.NullFloat64{}},
+		{"2024-07-01", 2, valid(10), valid(20)},
+		{"2024-07-02", 1, sql.NullFloat64{}, sql.NullFloat64{}},
+	} {
+		_, err := db.Exec(`INSERT INTO hourly_patterns (date, hour, target, total_pings, failed_pings, avg_rtt_ms, p95_rtt_ms) VALUES (?, ?, '8.8.8.8', 60, 0, ?, ?)`,
+			p.date, p.hour, p.avgRTT, p.p95)
+		if err != nil {
+			t.Fatalf("insert hourly_patterns: %v", err)
+		}
+	}
+	if err := db.AggregateDaily(ctx); err != nil {
+		t.Fatalf("AggregateDaily() error = %v", err)
+	}
+	if err := db.AggregateMonthly(ctx); err != nil {
+		t.Fatalf("AggregateMonthly() error = %v", err)
+	}
+
+	tests := []struct {
+		query       string
+		period      string
+		avgRTT, p95 sql.NullFloat64
+	}{
+		{`SELECT avg_rtt_ms, p95_rtt_ms FROM daily_stats WHERE day = ?`, "2024-07-01", valid(10), valid(20)},
+		{`SELECT avg_rtt_ms, p95_rtt_ms FROM daily_stats WHERE day = ?`, "2024-07-02", sql.NullFloat64{}, sql.NullFloat64{}},
+		{`SELECT avg_rtt_ms, p95_rtt_ms FROM monthly_stats WHERE month = ?`, "2024-07-01", valid(10), valid(20)},
+	}
+	for _, tt := range tests {
+		var avgRTT, p95 sql.NullFloat64
+		if err := db.QueryRow(tt.query, tt.period).Scan(&avgRTT, &p95); err != nil {
+			t.Fatalf("%s for %s: %v", tt.query, tt.period, err)
+		}
+		if avgRTT != tt.avgRTT || p95 != tt.p95 {
+			t.Errorf("%s for %s = %v, %v, want %v, %v", tt.query, tt.period, avgRTT, p95, tt.avgRTT, tt.p95)
+		}
+	}
+}
+
+func TestBackfillRollsUpOlderDays(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	save := func(at time.Time) {
+		t.Helper()
+		results := []models.PingResult{
+			{Timestamp: at, Target: "8.8.8.8", Success: true, RTT: 10},
+			{Timestamp: at.Add(time.Minute), Target: "8.8.8.8", Success: false},
+		}
+		if err := db.SaveResults(ctx, results); err != nil {
+			t.Fatalf("SaveResults() error = %v", err)
+		}
+		if err := db.BackfillHourlyPatterns(ctx); err != nil {
+			t.Fatalf("BackfillHourlyPatterns() error = %v", err)
+		}
+		// The maintenance pass that follows
+		if err := db.AggregateDaily(ctx); err != nil {
+			t.Fatalf("AggregateDaily() error = %v", err)
+		}
+		if err := db.AggregateMonthly(ctx); err != nil {
+			t.Fatalf("AggregateMonthly() error = %v", err)
+		}
+	}
+	save(time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC))
+	// Imported later, but older than the days and months already rolled up
+	save(time.Date(2024, 4, 15, 12, 0, 0, 0, time.UTC))
+
+	from := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	for granularity, want := range map[string][]string{
+		models.TrendDay:   {"2024-04-15", "2024-06-10"},
+		models.TrendMonth: {"2024-04", "2024-06"},
+	} {
+		got, err := db.GetTrends(ctx, "8.8.8.8", granularity, from, to)
+		if err != nil {
+			t.Fatalf("GetTrends(%s) error = %v", granularity, err)
+		}
+		var periods []string
+		for _, trend := range got {
+			periods = append(periods, trend.Period)
+			if trend.TotalChecks != 2 || trend.SuccessfulChecks != 1 {
+				t.Errorf("GetTrends(%s) period %s = %+v, want 1 of 2 checks successful", granularity, trend.Period, trend)
+			}
+		}
+		if !reflect.DeepEqual(periods, want) {
+			t.Errorf("GetTrends(%s) periods = %v, want %v", granularity, periods, want)
+		}
+	}
+}
+
+func valid(f float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: f, Valid: true}
+}
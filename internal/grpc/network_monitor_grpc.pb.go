@@ -0,0 +1,219 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: network_monitor.proto
+
+package grpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	NetworkMonitor_StreamResults_FullMethodName = "/networkmonitor.v1.NetworkMonitor/StreamResults"
+	NetworkMonitor_GetStats_FullMethodName      = "/networkmonitor.v1.NetworkMonitor/GetStats"
+	NetworkMonitor_GetOutages_FullMethodName    = "/networkmonitor.v1.NetworkMonitor/GetOutages"
+)
+
+// NetworkMonitorClient is the client API for NetworkMonitor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NetworkMonitorClient interface {
+	// StreamResults sends every ping result as it is recorded until the client
+	// cancels or the server shuts down
+	StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (NetworkMonitor_StreamResultsClient, error)
+	// GetStats returns per-target statistics, like /api/stats
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	// GetOutages returns outages, like /api/outages
+	GetOutages(ctx context.Context, in *GetOutagesRequest, opts ...grpc.CallOption) (*GetOutagesResponse, error)
+}
+
+type networkMonitorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNetworkMonitorClient(cc grpc.ClientConnInterface) NetworkMonitorClient {
+	return &networkMonitorClient{cc}
+}
+
+func (c *networkMonitorClient) StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (NetworkMonitor_StreamResultsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &NetworkMonitor_ServiceDesc.Streams[0], NetworkMonitor_StreamResults_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &networkMonitorStreamResultsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NetworkMonitor_StreamResultsClient interface {
+	Recv() (*PingResult, error)
+	grpc.ClientStream
+}
+
+type networkMonitorStreamResultsClient struct {
+	grpc.ClientStream
+}
+
+func (x *networkMonitorStreamResultsClient) Recv() (*PingResult, error) {
+	m := new(PingResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *networkMonitorClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, NetworkMonitor_GetStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkMonitorClient) GetOutages(ctx context.Context, in *GetOutagesRequest, opts ...grpc.CallOption) (*GetOutagesResponse, error) {
+	out := new(GetOutagesResponse)
+	err := c.cc.Invoke(ctx, NetworkMonitor_GetOutages_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NetworkMonitorServer is the server API for NetworkMonitor service.
+// All implementations must embed UnimplementedNetworkMonitorServer
+// for forward compatibility
+type NetworkMonitorServer interface {
+	// StreamResults sends every ping result as it is recorded until the client
+	// cancels or the server shuts down
+	StreamResults(*StreamResultsRequest, NetworkMonitor_StreamResultsServer) error
+	// GetStats returns per-target statistics, like /api/stats
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
+	// GetOutages returns outages, like /api/outages
+	GetOutages(context.Context, *GetOutagesRequest) (*GetOutagesResponse, error)
+	mustEmbedUnimplementedNetworkMonitorServer()
+}
+
+// UnimplementedNetworkMonitorServer must be embedded to have forward compatible implementations.
+type UnimplementedNetworkMonitorServer struct {
+}
+
+func (UnimplementedNetworkMonitorServer) StreamResults(*StreamResultsRequest, NetworkMonitor_StreamResultsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamResults not implemented")
+}
+func (UnimplementedNetworkMonitorServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedNetworkMonitorServer) GetOutages(context.Context, *GetOutagesRequest) (*GetOutagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOutages not implemented")
+}
+func (UnimplementedNetworkMonitorServer) mustEmbedUnimplementedNetworkMonitorServer() {}
+
+// UnsafeNetworkMonitorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NetworkMonitorServer will
+// result in compilation errors.
+type UnsafeNetworkMonitorServer interface {
+	mustEmbedUnimplementedNetworkMonitorServer()
+}
+
+func RegisterNetworkMonitorServer(s grpc.ServiceRegistrar, srv NetworkMonitorServer) {
+	s.RegisterService(&NetworkMonitor_ServiceDesc, srv)
+}
+
+func _NetworkMonitor_StreamResults_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamResultsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NetworkMonitorServer).StreamResults(m, &networkMonitorStreamResultsServer{stream})
+}
+
+type NetworkMonitor_StreamResultsServer interface {
+	Send(*PingResult) error
+	grpc.ServerStream
+}
+
+type networkMonitorStreamResultsServer struct {
+	grpc.ServerStream
+}
+
+func (x *networkMonitorStreamResultsServer) Send(m *PingResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _NetworkMonitor_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkMonitorServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NetworkMonitor_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkMonitorServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkMonitor_GetOutages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOutagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkMonitorServer).GetOutages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NetworkMonitor_GetOutages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkMonitorServer).GetOutages(ctx, req.(*GetOutagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// NetworkMonitor_ServiceDesc is the grpc.ServiceDesc for NetworkMonitor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NetworkMonitor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "networkmonitor.v1.NetworkMonitor",
+	HandlerType: (*NetworkMonitorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStats",
+			Handler:    _NetworkMonitor_GetStats_Handler,
+		},
+		{
+			MethodName: "GetOutages",
+			Handler:    _NetworkMonitor_GetOutages_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamResults",
+			Handler:       _NetworkMonitor_StreamResults_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "network_monitor.proto",
+}
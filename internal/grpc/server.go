@@ -0,0 +1,163 @@
+// Package grpc serves ping results and statistics over gRPC, for programmatic
+// consumers that want typed messages and a live stream instead of polling the
+// JSON API.
+package grpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative network_monitor.proto
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"network-monitor/internal/models"
+	"network-monitor/internal/sink/hub"
+)
+
+// Defaults for requests that leave the period unset, matching the JSON API
+const (
+	defaultStatsHours  = 24
+	defaultOutagesDays = 7
+)
+
+// Server implements the NetworkMonitor service on top of the database and the
+// result hub the monitor publishes to
+type Server struct {
+	UnimplementedNetworkMonitorServer
+
+	db     models.Database
+	hub    *hub.Hub
+	server *grpc.Server
+}
+
+// New creates a Server with the NetworkMonitor service registered
+func New(db models.Database, results *hub.Hub) *Server {
+	s := &Server{db: db, hub: results, server: grpc.NewServer()}
+	RegisterNetworkMonitorServer(s.server, s)
+	return s
+}
+
+// Serve accepts connections on listener until Stop is called
+func (s *Server) Serve(listener net.Listener) error {
+	return s.server.Serve(listener)
+}
+
+// Stop closes the listener and all connections, ending any open streams
+func (s *Server) Stop() {
+	s.server.Stop()
+}
+
+// StreamResults sends every new ping result, optionally for a single target,
+// until the client goes away or the hub is closed
+func (s *Server) StreamResults(req *StreamResultsRequest, stream NetworkMonitor_StreamResultsServer) error {
+	results, unsubscribe := s.hub.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case result, ok := <-results:
+			if !ok {
+				return nil
+			}
+			if req.GetTarget() != "" && result.Target != req.GetTarget() {
+				continue
+			}
+			if err := stream.Send(pingResultToProto(result)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetStats returns per-target statistics over the last hours (default 24)
+func (s *Server) GetStats(ctx context.Context, req *GetStatsRequest) (*GetStatsResponse, error) {
+	hours := int(req.GetHours())
+	if hours < 0 {
+		return nil, status.Error(codes.InvalidArgument, "hours must not be negative")
+	}
+	if hours == 0 {
+		hours = defaultStatsHours
+	}
+
+	now := time.Now()
+	stats, err := s.db.GetStats(ctx, now.Add(-time.Duration(hours)*time.Hour), now)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get stats: %v", err)
+	}
+
+	resp := &GetStatsResponse{Stats: make([]*Stats, 0, len(stats))}
+	for _, stat := range stats {
+		resp.Stats = append(resp.Stats, &Stats{
+			Target:          stat.Target,
+			TotalPings:      int64(stat.TotalPings),
+			SuccessfulPings: int64(stat.Successful),
+			AvgRtt:          stat.AvgRTT,
+			MaxRtt:          stat.MaxRTT,
+			MinRtt:          stat.MinRTT,
+			PacketLoss:      stat.PacketLoss,
+		})
+	}
+	return resp, nil
+}
+
+// GetOutages returns outages over the last days (default 7)
+func (s *Server) GetOutages(ctx context.Context, req *GetOutagesRequest) (*GetOutagesResponse, error) {
+	days := int(req.GetDays())
+	if days < 0 {
+		return nil, status.Error(codes.InvalidArgument, "days must not be negative")
+	}
+	if days == 0 {
+		days = defaultOutagesDays
+	}
+
+	now := time.Now()
+	outages, err := s.db.GetOutages(ctx, now.AddDate(0, 0, -days), now)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get outages: %v", err)
+	}
+
+	resp := &GetOutagesResponse{Outages: make([]*Outage, 0, len(outages))}
+	for _, outage := range outages {
+		resp.Outages = append(resp.Outages, &Outage{
+			Id:           outage.ID,
+			Target:       outage.Target,
+			StartTime:    timestamp(outage.StartTime),
+			EndTime:      timestamp(outage.EndTime),
+			FailedChecks: int32(outage.FailedChecks),
+			Duration:     outage.Duration,
+			Ongoing:      outage.Ongoing,
+		})
+	}
+	return resp, nil
+}
+
+// pingResultToProto converts a result to its wire form
+func pingResultToProto(result models.PingResult) *PingResult {
+	return &PingResult{
+		Timestamp:     timestamp(result.Timestamp),
+		Target:        result.Target,
+		Success:       result.Success,
+		RttMs:         result.RTT,
+		PacketLoss:    result.PacketLoss,
+		ErrorMessage:  result.ErrorMessage,
+		Attempts:      int32(result.Attempts),
+		ResolvedIp:    result.ResolvedIP,
+		Ttl:           int32(result.TTL),
+		FailureReason: string(result.FailureReason),
+	}
+}
+
+// timestamp converts t, leaving zero times unset
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
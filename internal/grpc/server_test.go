@@ -0,0 +1,183 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"network-monitor/internal/database"
+	"network-monitor/internal/models"
+	"network-monitor/internal/sink/hub"
+)
+
+// newTestClient serves a Server over an in-memory connection and returns a
+// client for it
+func newTestClient(t *testing.T, db models.Database, results *hub.Hub) NetworkMonitorClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := New(db, results)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return NewNetworkMonitorClient(conn)
+}
+
+func newMemoryDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	db, err := database.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestStreamResults(t *testing.T) {
+	results := hub.New()
+	client := newTestClient(t, newMemoryDB(t), results)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamResults(ctx, &StreamResultsRequest{Target: "8.8.8.8"})
+	if err != nil {
+		t.Fatalf("StreamResults() error = %v", err)
+	}
+
+	// The server subscribes once the stream is set up, which the client can't
+	// observe, so keep publishing until a result arrives
+	now := time.Now().UTC().Truncate(time.Second)
+	go func() {
+		for ctx.Err() == nil {
+			results.Write(models.PingResult{Timestamp: now, Target: "1.1.1.1", Success: false})
+			results.Write(models.PingResult{Timestamp: now, Target: "8.8.8.8", Success: true, RTT: 12.5, Attempts: 1})
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	got, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	if got.GetTarget() != "8.8.8.8" {
+		t.Errorf("target = %q, want 8.8.8.8 (other targets are filtered out)", got.GetTarget())
+	}
+	if !got.GetSuccess() || got.GetRttMs() != 12.5 || got.GetAttempts() != 1 {
+		t.Errorf("result = %+v, want success with 12.5ms over 1 attempt", got)
+	}
+	if !got.GetTimestamp().AsTime().Equal(now) {
+		t.Errorf("timestamp = %v, want %v", got.GetTimestamp().AsTime(), now)
+	}
+}
+
+func TestStreamResultsEndsWhenHubCloses(t *testing.T) {
+	results := hub.New()
+	client := newTestClient(t, newMemoryDB(t), results)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamResults(ctx, &StreamResultsRequest{})
+	if err != nil {
+		t.Fatalf("StreamResults() error = %v", err)
+	}
+
+	// Publish until the stream is live, then close the hub
+	go func() {
+		for ctx.Err() == nil {
+			results.Write(models.PingResult{Target: "8.8.8.8"})
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	results.Close()
+
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Errorf("stream ended with %v, want EOF", err)
+			}
+			return
+		}
+	}
+}
+
+func TestGetStatsAndOutages(t *testing.T) {
+	db := newMemoryDB(t)
+	now := time.Now().UTC()
+	for i := 0; i < 4; i++ {
+		result := models.PingResult{Timestamp: now.Add(time.Duration(i-4) * time.Minute), Target: "8.8.8.8", Success: true, RTT: 10}
+		if i > 0 {
+			// Three consecutive failures make an outage
+			result = models.PingResult{Timestamp: result.Timestamp, Target: "8.8.8.8", PacketLoss: 100}
+		}
+		if err := db.SaveResult(context.Background(), result); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	client := newTestClient(t, db, hub.New())
+	ctx := context.Background()
+
+	stats, err := client.GetStats(ctx, &GetStatsRequest{})
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+	if len(stats.GetStats()) != 1 {
+		t.Fatalf("got %d stats, want 1", len(stats.GetStats()))
+	}
+	if s := stats.GetStats()[0]; s.GetTarget() != "8.8.8.8" || s.GetTotalPings() != 4 || s.GetSuccessfulPings() != 1 {
+		t.Errorf("stats = %+v, want 1 of 4 pings to 8.8.8.8 successful", s)
+	}
+
+	outages, err := client.GetOutages(ctx, &GetOutagesRequest{Days: 1})
+	if err != nil {
+		t.Fatalf("GetOutages() error = %v", err)
+	}
+	if len(outages.GetOutages()) != 1 || outages.GetOutages()[0].GetFailedChecks() != 3 {
+		t.Errorf("outages = %+v, want one of 3 failed checks", outages.GetOutages())
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"negative hours", func() error {
+			_, err := client.GetStats(ctx, &GetStatsRequest{Hours: -1})
+			return err
+		}},
+		{"negative days", func() error {
+			_, err := client.GetOutages(ctx, &GetOutagesRequest{Days: -1})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.call()); code != codes.InvalidArgument {
+				t.Errorf("code = %v, want InvalidArgument", code)
+			}
+		})
+	}
+}
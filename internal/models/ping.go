@@ -1,6 +1,15 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // PingResult represents a single ping measurement
 type PingResult struct {
@@ -10,4 +19,245 @@ type PingResult struct {
 	RTT          float64   `json:"rtt_ms"`      // milliseconds
 	PacketLoss   float64   `json:"packet_loss"` // percentage
 	ErrorMessage string    `json:"error_message"`
+	Attempts     int       `json:"attempts"`              // ping attempts made, including retries
+	ResolvedIP   string    `json:"resolved_ip,omitempty"` // address the target resolved to
+	TTL          int       `json:"ttl,omitempty"`         // TTL (hop limit) of the reply; a change hints at a reroute
+
+	FailureReason FailureReason `json:"failure_reason,omitempty"` // why a failed ping failed; empty on success
+	Instance      string        `json:"instance,omitempty"`       // name of the monitor that recorded it, e.g. its hostname
+
+	// Output of the ping command, set only when output capture is on and the
+	// ping failed or its RTT couldn't be parsed; never stored with the result
+	RawOutput string `json:"-"`
+
+	// Anomaly flags an RTT spike or an isolated failure for the dashboard to
+	// highlight; set by /api/recent, never stored
+	Anomaly bool `json:"anomaly,omitempty"`
+}
+
+// HasRTT reports whether the result is a success with a round-trip time.
+// A ping that succeeded but whose output couldn't be parsed is stored with an
+// RTT of 0, which would drag latency figures down, so RTT aggregates only
+// count results that have one.
+func (r PingResult) HasRTT() bool {
+	return r.Success && r.RTT > 0
+}
+
+// TargetMeta is how the dashboard shows a target: a display name such as
+// "Comcast WAN" and a chart color, both optional. Results stay keyed by the
+// target itself.
+type TargetMeta struct {
+	Target string `json:"target"`
+	Name   string `json:"name,omitempty"`
+	Color  string `json:"color,omitempty"` // CSS hex color, e.g. #1f77b4
+}
+
+// TimestampFormat is the layout ping results' timestamps are written in as
+// JSON: RFC3339 in UTC with milliseconds, so every timestamp has the same
+// zone and length whatever the server's time zone
+const TimestampFormat = "2006-01-02T15:04:05.000Z"
+
+// MarshalJSON writes the result with its timestamp in TimestampFormat
+func (r PingResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pingResultJSON{
+		pingResultFields: pingResultFields(r),
+		Timestamp:        r.Timestamp.UTC().Format(TimestampFormat),
+	})
+}
+
+// UnixPingResult is a ping result whose JSON also carries its timestamp as
+// Unix milliseconds in timestamp_ms, the form charting libraries plot
+type UnixPingResult PingResult
+
+// MarshalJSON writes the result like PingResult does, plus timestamp_ms
+func (r UnixPingResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pingResultJSON{
+		pingResultFields: pingResultFields(r),
+		Timestamp:        r.Timestamp.UTC().Format(TimestampFormat),
+		TimestampMillis:  r.Timestamp.UnixMilli(),
+	})
+}
+
+// pingResultFields has PingResult's fields without its MarshalJSON, so
+// pingResultJSON can embed them without recursing
+type pingResultFields PingResult
+
+// pingResultJSON overrides the timestamp of the embedded result's fields
+type pingResultJSON struct {
+	pingResultFields
+	Timestamp       string `json:"timestamp"`
+	TimestampMillis int64  `json:"timestamp_ms,omitempty"`
+}
+
+// FailureReason classifies a failed ping from the ping command's output
+type FailureReason string
+
+// Failure reasons, from most to least specific
+const (
+	FailureDNS         FailureReason = "dns"         // the target name didn't resolve
+	FailureUnreachable FailureReason = "unreachable" // no route, or a router reported the destination unreachable or prohibited
+	FailureTimeout     FailureReason = "timeout"     // ping reported a timeout, or didn't finish in time
+	FailureLoss        FailureReason = "loss"        // the echo request went out but no reply came back
+	FailureUnknown     FailureReason = "unknown"     // any other failure
+)
+
+// SchemeDNS marks a target probed with a DNS query to a resolver
+// ("dns://1.1.1.1/example.com") rather than an ICMP ping
+const SchemeDNS = "dns"
+
+// Reserved names of the reference targets -include-loopback adds. Their
+// results tell a fault on this machine or the local network apart from one
+// beyond it.
+const (
+	TargetLoopback = "loopback" // pinged at 127.0.0.1
+	TargetGateway  = "gateway"  // pinged at the default gateway detected at startup
+)
+
+// IsReferenceTarget reports whether target is one of the reserved reference targets
+func IsReferenceTarget(target string) bool {
+	return target == TargetLoopback || target == TargetGateway
+}
+
+// Target is a ping destination, optionally sent from a specific source
+// interface or address. Its spec form ("8.8.8.8 via eth0") is the key results
+// are stored under, so one host pinged over two WAN links gives two series.
+type Target struct {
+	Host string
+	Via  string // source interface or address; empty uses the default route
+
+	// Probe type and its details; Scheme is empty for ICMP ping
+	Scheme string
+	Port   string // empty for the scheme's default port
+	Query  string // name a DNS probe asks the resolver for
+}
+
+// ParseTarget parses "host", "host via source" or "dns://resolver[:port]/name".
+// Extra whitespace is ignored. Hosts must be IP addresses or valid hostnames,
+// so nothing that reaches the ping command line can be taken for a flag.
+func ParseTarget(spec string) (Target, error) {
+	var target Target
+	fields := strings.Fields(spec)
+	switch {
+	case len(fields) == 1 && strings.Contains(fields[0], "://"):
+		return parseProbeTarget(fields[0])
+	case len(fields) > 1 && strings.Contains(fields[0], "://"):
+		return Target{}, fmt.Errorf("invalid target %q: \"via\" is only supported for ping targets", spec)
+	case len(fields) == 1:
+		target = Target{Host: fields[0]}
+	case len(fields) == 3 && fields[1] == "via":
+		target = Target{Host: fields[0], Via: fields[2]}
+	default:
+		return Target{}, fmt.Errorf("invalid target %q: want \"host\" or \"host via interface\"", spec)
+	}
+
+	if err := validateHost(target.Host); err != nil {
+		return Target{}, fmt.Errorf("invalid target %q: %w", spec, err)
+	}
+	if target.Via != "" {
+		if err := validateSource(target.Via); err != nil {
+			return Target{}, fmt.Errorf("invalid target %q: %w", spec, err)
+		}
+	}
+	return target, nil
+}
+
+// parseProbeTarget parses a target whose scheme selects a probe other than ping
+func parseProbeTarget(spec string) (Target, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return Target{}, fmt.Errorf("invalid target %q: %w", spec, err)
+	}
+	if u.Scheme != SchemeDNS {
+		return Target{}, fmt.Errorf("invalid target %q: unknown probe type %q, want dns://", spec, u.Scheme)
+	}
+
+	query := strings.TrimPrefix(u.Path, "/")
+	if u.Hostname() == "" || query == "" || strings.Contains(query, "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return Target{}, fmt.Errorf("invalid target %q: want \"dns://resolver/name\", e.g. dns://1.1.1.1/example.com", spec)
+	}
+	if err := validateHost(u.Hostname()); err != nil {
+		return Target{}, fmt.Errorf("invalid target %q: resolver: %w", spec, err)
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return Target{}, fmt.Errorf("invalid target %q: port must be between 1 and 65535", spec)
+		}
+	}
+	if !isHostname(query) {
+		return Target{}, fmt.Errorf("invalid target %q: %q is not a valid DNS name", spec, query)
+	}
+	return Target{Host: u.Hostname(), Scheme: u.Scheme, Port: u.Port(), Query: query}, nil
+}
+
+// validateHost checks host is an IP address or a hostname
+func validateHost(host string) error {
+	if strings.HasPrefix(host, "-") {
+		return fmt.Errorf("host %q must not start with \"-\"", host)
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return nil
+	}
+	if !isHostname(host) {
+		return fmt.Errorf("host %q is not a valid IP address or hostname", host)
+	}
+	return nil
+}
+
+// validateSource checks a "via" source is an IP address or an interface name
+func validateSource(via string) error {
+	if strings.HasPrefix(via, "-") {
+		return fmt.Errorf("source %q must not start with \"-\"", via)
+	}
+	if _, err := netip.ParseAddr(via); err == nil {
+		return nil
+	}
+	// Interface names are at most 15 characters on Linux and macOS
+	if len(via) > 15 || strings.IndexFunc(via, func(r rune) bool {
+		return !isAlphanumeric(r) && !strings.ContainsRune("._-:@", r)
+	}) >= 0 {
+		return fmt.Errorf("source %q is not a valid IP address or interface name", via)
+	}
+	return nil
+}
+
+// isHostname reports whether name is a syntactically valid hostname: dot
+// separated labels of letters, digits, hyphens and underscores, neither
+// starting nor ending with a hyphen, with an optional trailing dot
+func isHostname(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !isAlphanumeric(r) && r != '-' && r != '_' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// String returns the target's spec form
+func (t Target) String() string {
+	if t.Scheme != "" {
+		host := t.Host
+		if t.Port != "" {
+			host = net.JoinHostPort(t.Host, t.Port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]" // IPv6 address
+		}
+		return t.Scheme + "://" + host + "/" + t.Query
+	}
+	if t.Via == "" {
+		return t.Host
+	}
+	return t.Host + " via " + t.Via
 }
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Stats represents aggregated statistics for a target
 type Stats struct {
@@ -15,11 +18,184 @@ type Stats struct {
 
 // Outage represents a connectivity outage period
 type Outage struct {
+	ID           int64     `json:"id,omitempty"` // set for outages recorded in real time
 	Target       string    `json:"target"`
 	StartTime    time.Time `json:"start_time"`
 	EndTime      time.Time `json:"end_time"`
 	FailedChecks int       `json:"failed_checks"`
 	Duration     string    `json:"duration"`
+	Ongoing      bool      `json:"ongoing,omitempty"`
+
+	// RTTs of the last successful ping before the outage and the first one
+	// after it, in milliseconds; 0 when unknown. A spike before the drop
+	// suggests congestion, where a clean cutoff doesn't.
+	RTTBefore float64 `json:"rtt_before_ms,omitempty"`
+	RTTAfter  float64 `json:"rtt_after_ms,omitempty"`
+}
+
+// OutageThreshold defines when a target is in an outage: at least Failures of its
+// last Window pings failed. Window == Failures means that many consecutive failures.
+type OutageThreshold struct {
+	Window   int
+	Failures int
+}
+
+// DefaultOutageThreshold treats 3 consecutive failed pings as an outage
+var DefaultOutageThreshold = OutageThreshold{Window: 3, Failures: 3}
+
+// DefaultOutageRecovery is how many consecutive successful pings end an outage
+const DefaultOutageRecovery = 2
+
+// DefaultRTTBuckets are the upper bounds in ms of the /metrics RTT histogram
+// buckets
+var DefaultRTTBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000}
+
+// String describes the threshold, e.g. "3+ consecutive failures"
+func (t OutageThreshold) String() string {
+	if t.Window == t.Failures {
+		return fmt.Sprintf("%d+ consecutive failures", t.Failures)
+	}
+	return fmt.Sprintf("%d+ failures in %d pings", t.Failures, t.Window)
+}
+
+// Summary is an at-a-glance view across all targets over the last 24 hours.
+// "Current" values come from each target's most recent ping.
+type Summary struct {
+	Targets         int     `json:"targets"`
+	Availability    float64 `json:"availability_percent"`
+	WorstTarget     string  `json:"worst_target"` // highest packet loss
+	WorstPacketLoss float64 `json:"worst_packet_loss"`
+	BestRTTTarget   string  `json:"best_rtt_target"`
+	BestRTT         float64 `json:"best_rtt"`
+	WorstRTTTarget  string  `json:"worst_rtt_target"`
+	WorstRTT        float64 `json:"worst_rtt"`
+	TargetsDown     int     `json:"targets_down"` // most recent ping failed
+	Outages         int     `json:"outages_24h"`
+	FaultDomain     string  `json:"fault_domain,omitempty"` // where current failures lie, from the reference targets
+}
+
+// Fault domains a summary attributes current failures to when reference
+// targets are monitored
+const (
+	FaultLocal    = "local"    // loopback is down: this machine's network stack
+	FaultLAN      = "lan"      // the gateway is down: the local network or router
+	FaultInternet = "internet" // the gateway answers but other targets don't
+)
+
+// CurrentStatus is a target's most recent ping, for a quick "is it up right
+// now" view
+type CurrentStatus struct {
+	Target            string    `json:"target"`
+	LastCheck         time.Time `json:"last_check"`
+	Success           bool      `json:"success"`
+	RTT               float64   `json:"rtt_ms"`
+	SecondsSinceCheck float64   `json:"seconds_since_check"`
+}
+
+// Streak is a target's current run of consecutive successful or failed pings,
+// for "up for 3h12m" or "down for 5 checks". Runs are counted within the raw
+// results kept, so Since is never older than their retention.
+type Streak struct {
+	Target          string    `json:"target"`
+	Success         bool      `json:"success"` // whether the run is of successful pings
+	Checks          int       `json:"checks"`
+	Since           time.Time `json:"since"` // first ping of the run
+	Duration        string    `json:"duration"`
+	DurationSeconds float64   `json:"duration_seconds"` // from Since until now
+}
+
+// ErrorCount is how often a target's pings failed with one error message, for
+// telling whether failures are mostly timeouts or unreachables
+type ErrorCount struct {
+	Target        string        `json:"target"`
+	FailureReason FailureReason `json:"failure_reason"` // empty for results stored before reasons were recorded
+	ErrorMessage  string        `json:"error_message"`
+	Count         int           `json:"count"`
+}
+
+// BaselineComparison compares a target's latency over the last hour with its
+// usual latency at the same hour of the week. Deviations are percentages, e.g.
+// 200 when the last hour was three times slower than usual, and 0 when either
+// side has no successful pings.
+type BaselineComparison struct {
+	Target         string  `json:"target"`
+	Weekday        string  `json:"weekday"`
+	Hour           int     `json:"hour"`
+	CurrentPings   int     `json:"current_pings"`
+	CurrentAvgRTT  float64 `json:"current_avg_rtt"`
+	CurrentP95RTT  float64 `json:"current_p95_rtt"`
+	BaselineWeeks  int     `json:"baseline_weeks"` // earlier weeks with data for this hour
+	BaselineAvgRTT float64 `json:"baseline_avg_rtt"`
+	BaselineP95RTT float64 `json:"baseline_p95_rtt"`
+	AvgDeviation   float64 `json:"avg_deviation_percent"`
+	P95Deviation   float64 `json:"p95_deviation_percent"`
+}
+
+// TargetComparison compares two targets' latency and packet loss over the
+// same period, for telling which one is better from here. Buckets are aligned
+// across both targets; deltas are B minus A, so a negative RTT delta means B
+// is faster. Winner is A or B, or empty when either has no pings or neither
+// is better.
+type TargetComparison struct {
+	A             string             `json:"a"`
+	B             string             `json:"b"`
+	Since         time.Time          `json:"since"`
+	Until         time.Time          `json:"until"`
+	BucketSeconds int                `json:"bucket_seconds"`
+	Buckets       []ComparisonBucket `json:"buckets"`
+	AAvgRTT       float64            `json:"a_avg_rtt"`
+	BAvgRTT       float64            `json:"b_avg_rtt"`
+	ALoss         float64            `json:"a_loss_percent"`
+	BLoss         float64            `json:"b_loss_percent"`
+	RTTDelta      float64            `json:"rtt_delta"`
+	LossDelta     float64            `json:"loss_delta_percent"`
+	Winner        string             `json:"winner"`
+}
+
+// ComparisonBucket is one bucket of a TargetComparison. An average RTT is 0
+// when that target had no successful pings in the bucket.
+type ComparisonBucket struct {
+	Start   time.Time `json:"start"`
+	APings  int       `json:"a_pings"`
+	BPings  int       `json:"b_pings"`
+	AAvgRTT float64   `json:"a_avg_rtt,omitempty"`
+	BAvgRTT float64   `json:"b_avg_rtt,omitempty"`
+	ALoss   float64   `json:"a_loss_percent"`
+	BLoss   float64   `json:"b_loss_percent"`
+}
+
+// SLA summarizes a target's availability over a period, in the terms ISPs
+// state their uptime guarantees in. An empty Target covers all targets.
+type SLA struct {
+	Target           string    `json:"target"`
+	Since            time.Time `json:"since"`
+	Until            time.Time `json:"until"`
+	TotalChecks      int       `json:"total_checks"`
+	SuccessfulChecks int       `json:"successful_checks"`
+	Availability     float64   `json:"availability_percent"`
+	Downtime         string    `json:"downtime"`
+	DowntimeSeconds  float64   `json:"downtime_seconds"`
+	Outages          int       `json:"outages"`
+}
+
+// Trend granularities: a Trend covers one day or one calendar month
+const (
+	TrendDay   = "day"
+	TrendMonth = "month"
+)
+
+// Trend is a target's long-term rollup for one day or month, kept long after
+// the raw and hourly data is gone. Period is the day ("2024-05-01") or month
+// ("2024-05") in the configured time zone.
+type Trend struct {
+	Period           string  `json:"period"`
+	Target           string  `json:"target"`
+	TotalChecks      int     `json:"total_checks"`
+	SuccessfulChecks int     `json:"successful_checks"`
+	Availability     float64 `json:"availability_percent"`
+	AvgRTT           float64 `json:"avg_rtt_ms"`
+	P95RTT           float64 `json:"p95_rtt_ms"`
+	Outages          int     `json:"outages"`
 }
 
 // HeatmapPoint represents a data point for the heatmap visualization
@@ -32,6 +208,10 @@ type HeatmapPoint struct {
 	TotalFailures int     `json:"total_failures"`
 	TotalPings    int     `json:"total_pings"`
 	DaysWithData  int     `json:"days_with_data"`
+
+	// Severity grades the cell as good, warn or bad against the configured
+	// thresholds, so every client colors it the same; set by /api/heatmap
+	Severity string `json:"severity,omitempty"`
 }
 
 // PatternDetail represents detailed pattern data for a specific hour
@@ -44,3 +224,89 @@ type PatternDetail struct {
 	MaxRTT      float64 `json:"max_rtt"`
 	FailureRate float64 `json:"failure_rate"`
 }
+
+// Monitoring event types recorded at gaps in data collection: intentional
+// pauses, and the monitor not running at all, detected on startup
+const (
+	EventPaused  = "paused"
+	EventResumed = "resumed"
+	EventOffline = "monitor_offline" // at the last result stored before the gap
+	EventOnline  = "monitor_online"  // when the monitor started again
+)
+
+// MonitoringEvent marks a change in monitoring state, such as a pause or resume
+type MonitoringEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Event     string    `json:"event"`
+}
+
+// MonitorStatus represents the current state of the monitor
+type MonitorStatus struct {
+	Paused         bool       `json:"paused"`
+	PausedAt       *time.Time `json:"paused_at,omitempty"`
+	ResultsDropped int64      `json:"results_dropped_total"` // results lost to a backed-up consumer
+}
+
+// TracerouteHop represents loss and latency at a single hop of a traceroute
+type TracerouteHop struct {
+	Hop     int     `json:"hop"`
+	Address string  `json:"address"`
+	AvgRTT  float64 `json:"avg_rtt_ms"`
+	Loss    float64 `json:"loss_percent"`
+}
+
+// Traceroute represents a hop-by-hop trace captured during an outage
+type Traceroute struct {
+	OutageID  int64           `json:"outage_id"`
+	Target    string          `json:"target"`
+	Timestamp time.Time       `json:"timestamp"`
+	Hops      []TracerouteHop `json:"hops"`
+}
+
+// PingDebug is the raw output of a failed or unparseable ping, kept to
+// diagnose output formats the parser doesn't understand
+type PingDebug struct {
+	Timestamp time.Time `json:"timestamp"`
+	Target    string    `json:"target"`
+	Success   bool      `json:"success"`
+	Output    string    `json:"output"`
+}
+
+// Target states published when a target enters or leaves an outage
+const (
+	StateUp   = "up"
+	StateDown = "down"
+)
+
+// TargetState is a target's up/down state at the time it changed
+type TargetState struct {
+	Target    string    `json:"target"`
+	State     string    `json:"state"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Alert kinds sent when a target's state changes
+const (
+	AlertDown     = "down"
+	AlertUp       = "up"
+	AlertFlapping = "flapping"
+
+	// Connection quality alerts for targets that are up but degraded
+	AlertHighLatency      = "high_latency"
+	AlertLatencyRecovered = "latency_recovered"
+	AlertHighJitter       = "high_jitter"
+	AlertJitterRecovered  = "jitter_recovered"
+
+	// A synthetic alert sent on request to check that alerts are delivered
+	AlertTest = "test"
+)
+
+// Alert is a notification about a change in a target's state. Test is set on
+// synthetic alerts, which don't concern any target.
+type Alert struct {
+	Target    string    `json:"target"`
+	Kind      string    `json:"kind"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+	Test      bool      `json:"test"`
+}
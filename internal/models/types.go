@@ -7,22 +7,75 @@ import (
 
 // Database interface defines operations for data persistence
 type Database interface {
-	SaveResult(result PingResult) error
-	GetRecent(hours int) ([]PingResult, error)
-	GetStats(hours int) ([]Stats, error)
-	GetOutages(days int) ([]Outage, error)
-	GetHeatmapData(days int) ([]HeatmapPoint, error)
-	GetPatterns(hour string) ([]PatternDetail, error)
-	AggregateHourlyPatterns() error
-	BackfillHourlyPatterns() error
-	IsHourlyPatternsEmpty() (bool, error)
-	ArchiveOldData() error
+	SaveResult(ctx context.Context, result PingResult) error
+	SaveResults(ctx context.Context, results []PingResult) error
+	SetTargetMeta(ctx context.Context, meta []TargetMeta) error
+	GetTargetMeta(ctx context.Context) ([]TargetMeta, error)
+	GetRecent(ctx context.Context, hours int) ([]PingResult, error)
+	GetRecentFiltered(ctx context.Context, from, to time.Time, target, instance string, limit, offset int) ([]PingResult, error)
+	GetRecentSampled(ctx context.Context, from, to time.Time, maxPoints int) ([]PingResult, error)
+	GetRange(ctx context.Context, from, to time.Time) ([]PingResult, error)
+	GetTargets(ctx context.Context) ([]string, error)
+	GetLastTimestamp(ctx context.Context) (time.Time, error)
+	GetStats(ctx context.Context, from, to time.Time) ([]Stats, error)
+	GetSummary(ctx context.Context) (Summary, error)
+	GetCurrent(ctx context.Context) ([]CurrentStatus, error)
+	GetStreaks(ctx context.Context) ([]Streak, error)
+	GetErrorBreakdown(ctx context.Context, from, to time.Time) ([]ErrorCount, error)
+	GetOutages(ctx context.Context, from, to time.Time) ([]Outage, error)
+	GetOutagesFiltered(ctx context.Context, from, to time.Time, minDuration time.Duration) ([]Outage, error)
+	DetectOutages(ctx context.Context, from, to time.Time, threshold OutageThreshold) ([]Outage, error)
+	GetSLA(ctx context.Context, target string, from, to time.Time) (SLA, error)
+	GetBaselineComparison(ctx context.Context, target string) (BaselineComparison, error)
+	CompareTargets(ctx context.Context, a, b string, hours int) (TargetComparison, error)
+	GetHeatmapData(ctx context.Context, from, to time.Time) ([]HeatmapPoint, error)
+	GetPatterns(ctx context.Context, hour string, from, to time.Time) ([]PatternDetail, error)
+	AggregateHourlyPatterns(ctx context.Context) error
+	BackfillHourlyPatterns(ctx context.Context) error
+	IsHourlyPatternsEmpty(ctx context.Context) (bool, error)
+	ArchiveOldData(ctx context.Context) error
+	EnforceSizeLimit(ctx context.Context, maxBytes int64) (int64, error)
+	AggregateDaily(ctx context.Context) error
+	AggregateMonthly(ctx context.Context) error
+	GetTrends(ctx context.Context, target, granularity string, from, to time.Time) ([]Trend, error)
+	RecordMonitoringEvent(ctx context.Context, event MonitoringEvent) error
+	GetMonitoringEvents(ctx context.Context, from, to time.Time) ([]MonitoringEvent, error)
+	RecordOutageStart(ctx context.Context, target string, start time.Time, rttBefore float64) (int64, error)
+	RecordOutageEnd(ctx context.Context, id int64, end time.Time, checksFailed int, rttAfter float64) error
+	SaveTraceroute(ctx context.Context, trace Traceroute) error
+	GetTraceroute(ctx context.Context, outageID int64) (Traceroute, error)
+	SavePingDebug(ctx context.Context, entry PingDebug) error
+	GetPingDebug(ctx context.Context, target string, limit int) ([]PingDebug, error)
 	Close() error
 }
 
-// Pinger interface defines ping execution operations
+// Pinger interface defines ping execution operations. Close releases anything
+// the pinger holds open between pings, such as sockets or connection pools.
 type Pinger interface {
-	Ping(target string, timeout time.Duration) (PingResult, error)
+	Ping(ctx context.Context, target string, timeout time.Duration) (PingResult, error)
+	Close() error
+}
+
+// Tracer interface defines hop-by-hop route tracing operations
+type Tracer interface {
+	Trace(ctx context.Context, target string) ([]TracerouteHop, error)
+}
+
+// Sink interface defines an additional destination for ping results, written
+// alongside the database
+type Sink interface {
+	Write(result PingResult) error
+	Close() error
+}
+
+// StateSink is implemented by sinks that also receive outage transitions
+type StateSink interface {
+	WriteState(state TargetState) error
+}
+
+// Notifier interface defines alert delivery
+type Notifier interface {
+	Notify(alert Alert) error
 }
 
 // Monitor interface defines the monitoring lifecycle
@@ -32,14 +85,17 @@ type Monitor interface {
 	Wait()
 }
 
+// MonitorController interface defines runtime control of a running monitor
+type MonitorController interface {
+	Pause() error
+	Resume() error
+	Status() MonitorStatus
+	Current() []CurrentStatus
+	TestAlert() error
+}
+
 // WebServer interface defines web server operations
 type WebServer interface {
 	Start(port int) error
 	Stop() error
 }
-
-// ReportGenerator interface defines report generation operations
-type ReportGenerator interface {
-	GenerateChart(filename string, data []PingResult) error
-	GenerateTextReport(filename string, data []PingResult) error
-}
@@ -0,0 +1,39 @@
+package monitor
+
+import "time"
+
+// adaptiveStreak is how many consecutive successful pings it takes before the
+// adaptive interval is doubled again
+const adaptiveStreak = 10
+
+// adaptiveInterval tracks one worker's ping interval in adaptive mode: it
+// doubles after every adaptiveStreak consecutive successes, up to max, and
+// drops straight back to base on any failure so the onset of a problem is
+// sampled at full resolution
+type adaptiveInterval struct {
+	base    time.Duration
+	max     time.Duration
+	current time.Duration
+	streak  int
+}
+
+func newAdaptiveInterval(base, max time.Duration) *adaptiveInterval {
+	return &adaptiveInterval{base: base, max: max, current: base}
+}
+
+// record updates the interval with a ping outcome and returns the interval to
+// wait before the next ping
+func (a *adaptiveInterval) record(success bool) time.Duration {
+	if !success {
+		a.streak = 0
+		a.current = a.base
+		return a.current
+	}
+
+	a.streak++
+	if a.streak >= adaptiveStreak && a.current < a.max {
+		a.streak = 0
+		a.current = min(a.current*2, a.max)
+	}
+	return a.current
+}
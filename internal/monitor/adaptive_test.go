@@ -0,0 +1,77 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"network-monitor/internal/config"
+)
+
+// repeat returns n copies of outcome
+func repeat(outcome bool, n int) []bool {
+	outcomes := make([]bool, n)
+	for i := range outcomes {
+		outcomes[i] = outcome
+	}
+	return outcomes
+}
+
+func TestAdaptiveInterval(t *testing.T) {
+	base, maxInterval := time.Second, 5*time.Second
+
+	tests := []struct {
+		name         string
+		outcomes     []bool
+		wantInterval time.Duration
+	}{
+		{"short streak keeps base", repeat(true, adaptiveStreak-1), base},
+		{"streak doubles", repeat(true, adaptiveStreak), 2 * time.Second},
+		{"each streak doubles again", repeat(true, 2*adaptiveStreak), 4 * time.Second},
+		{"capped at max", repeat(true, 5*adaptiveStreak), maxInterval},
+		{"failure resets to base", append(repeat(true, 3*adaptiveStreak), false), base},
+		{
+			"streak restarts after failure",
+			append(append(repeat(true, adaptiveStreak-1), false), repeat(true, adaptiveStreak-1)...),
+			base,
+		},
+		{"backs off again after recovery", append(append(repeat(true, 2*adaptiveStreak), false), repeat(true, adaptiveStreak)...), 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pinger := &mockPinger{outcomes: tt.outcomes}
+			m := newTestMonitor(config.Config{Timeout: time.Second}, pinger)
+			defer m.cancel()
+
+			adaptive := newAdaptiveInterval(base, maxInterval)
+			var interval time.Duration
+			for range tt.outcomes {
+				success, recorded := m.performPing("8.8.8.8")
+				if !recorded {
+					t.Fatal("ping not recorded")
+				}
+				<-m.results
+				interval = adaptive.record(success)
+			}
+
+			if interval != tt.wantInterval {
+				t.Errorf("interval = %v, want %v", interval, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestAdaptiveIntervalFailureIsImmediate(t *testing.T) {
+	adaptive := newAdaptiveInterval(time.Second, time.Minute)
+	for i := 0; i < 4*adaptiveStreak; i++ {
+		adaptive.record(true)
+	}
+	if adaptive.current != 16*time.Second {
+		t.Fatalf("interval after %d successes = %v, want 16s", 4*adaptiveStreak, adaptive.current)
+	}
+
+	// The very next ping after a failure must use the base interval
+	if got := adaptive.record(false); got != time.Second {
+		t.Errorf("interval after failure = %v, want 1s", got)
+	}
+}
@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// latestResults caches each target's most recent result, so the dashboard's
+// current status is served from memory instead of the database, which may be
+// busy with archival. processResults updates it as results arrive; readers
+// may be any number of request handlers.
+type latestResults struct {
+	mu      sync.RWMutex
+	results map[string]models.PingResult
+}
+
+func newLatestResults() *latestResults {
+	return &latestResults{results: make(map[string]models.PingResult)}
+}
+
+// update records result as its target's latest, unless a newer result for the
+// target is already cached
+func (l *latestResults) update(result models.PingResult) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if cached, ok := l.results[result.Target]; ok && cached.Timestamp.After(result.Timestamp) {
+		return
+	}
+	l.results[result.Target] = result
+}
+
+// current returns each target's latest result as of now, ordered by target
+// like Database.GetCurrent
+func (l *latestResults) current(now time.Time) []models.CurrentStatus {
+	l.mu.RLock()
+	current := make([]models.CurrentStatus, 0, len(l.results))
+	for _, result := range l.results {
+		current = append(current, models.CurrentStatus{
+			Target:            result.Target,
+			LastCheck:         result.Timestamp,
+			Success:           result.Success,
+			RTT:               result.RTT,
+			SecondsSinceCheck: now.Sub(result.Timestamp).Seconds(),
+		})
+	}
+	l.mu.RUnlock()
+
+	slices.SortFunc(current, func(a, b models.CurrentStatus) int {
+		return strings.Compare(a.Target, b.Target)
+	})
+	return current
+}
+
+// Current returns each target's most recent result from memory. The cache is
+// loaded from the database when the monitor starts, so targets pinged before
+// a restart are included.
+func (m *Monitor) Current() []models.CurrentStatus {
+	return m.latest.current(time.Now())
+}
+
+// loadLatest fills the latest result cache from the database
+func (m *Monitor) loadLatest() error {
+	current, err := m.db.GetCurrent(m.ctx)
+	if err != nil {
+		return err
+	}
+	for _, c := range current {
+		m.latest.update(models.PingResult{Timestamp: c.LastCheck, Target: c.Target, Success: c.Success, RTT: c.RTT})
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"network-monitor/internal/config"
+	"network-monitor/internal/models"
+)
+
+func TestLatestResultsKeepsNewestPerTarget(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second}, &mockPinger{})
+	db := &resultRecorder{}
+	m.db = db
+	m.results = make(chan models.PingResult)
+
+	m.wg.Add(1)
+	go m.processResults()
+
+	// Each target's results arrive from several workers, so not in order
+	base := time.Now().Add(-time.Minute).Truncate(time.Second)
+	targets := []string{"8.8.8.8", "1.1.1.1", "9.9.9.9"}
+	const perTarget = 50
+	var senders sync.WaitGroup
+	for _, target := range targets {
+		for worker := 0; worker < 2; worker++ {
+			senders.Add(1)
+			go func(target string, worker int) {
+				defer senders.Done()
+				for i := worker; i < perTarget; i += 2 {
+					m.results <- models.PingResult{Timestamp: base.Add(time.Duration(i) * time.Second), Target: target, Success: i%3 != 0, RTT: float64(i)}
+				}
+			}(target, worker)
+		}
+	}
+
+	// Dashboard requests read the cache while results are being processed
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					for _, c := range m.Current() {
+						if c.LastCheck.Before(base) {
+							t.Errorf("cached %s result from %v, before any sent", c.Target, c.LastCheck)
+						}
+					}
+				}
+			}
+		}()
+	}
+
+	senders.Wait()
+	deadline := time.Now().Add(2 * time.Second)
+	for db.count() < len(targets)*perTarget && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(stop)
+	readers.Wait()
+	m.cancel()
+	m.wg.Wait()
+
+	current := m.Current()
+	if len(current) != len(targets) {
+		t.Fatalf("Current() has %d targets, want %d", len(current), len(targets))
+	}
+	newest := base.Add((perTarget - 1) * time.Second)
+	for i, want := range []string{"1.1.1.1", "8.8.8.8", "9.9.9.9"} {
+		c := current[i]
+		if c.Target != want || !c.LastCheck.Equal(newest) || c.RTT != perTarget-1 || c.Success != ((perTarget-1)%3 != 0) {
+			t.Errorf("Current()[%d] = %+v, want %s's result from %v", i, c, want, newest)
+		}
+	}
+}
+
+// currentDB serves a stored latest result per target
+type currentDB struct {
+	models.Database
+	current []models.CurrentStatus
+}
+
+func (db *currentDB) GetCurrent(ctx context.Context) ([]models.CurrentStatus, error) {
+	return db.current, nil
+}
+
+func TestLoadLatestFromDatabase(t *testing.T) {
+	stored := time.Now().Add(-time.Hour)
+	m := newTestMonitor(config.Config{}, &mockPinger{})
+	m.db = &currentDB{current: []models.CurrentStatus{{Target: "8.8.8.8", LastCheck: stored, Success: true, RTT: 12}}}
+
+	if err := m.loadLatest(); err != nil {
+		t.Fatalf("loadLatest() error = %v", err)
+	}
+
+	// A result older than the stored one doesn't replace it
+	m.latest.update(models.PingResult{Timestamp: stored.Add(-time.Minute), Target: "8.8.8.8"})
+	current := m.Current()
+	if len(current) != 1 || !current[0].LastCheck.Equal(stored) || !current[0].Success || current[0].RTT != 12 {
+		t.Fatalf("Current() = %+v, want the stored result", current)
+	}
+	if current[0].SecondsSinceCheck < 3600 {
+		t.Errorf("SecondsSinceCheck = %v, want at least an hour", current[0].SecondsSinceCheck)
+	}
+}
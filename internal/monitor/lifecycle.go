@@ -3,55 +3,134 @@ package monitor
 import (
 	"log"
 	"time"
+
+	"network-monitor/internal/models"
 )
 
-// maintenanceWorker runs periodic maintenance tasks
+// maintenanceStartupDelay is the longest wait before the first maintenance
+// run, to avoid racing with startup
+const maintenanceStartupDelay = 60 * time.Second
+
+// archiveInterval is how often raw data is archived. Archival deletes old rows
+// and may VACUUM, so it runs far less often than the incremental aggregation.
+const archiveInterval = 24 * time.Hour
+
+// maintenanceWorker aggregates hourly patterns and enforces any database size
+// limit every maintenance interval, and archives old data on the first run and
+// then once per archiveInterval
 func (m *Monitor) maintenanceWorker() {
 	defer m.wg.Done()
 
-	// Run maintenance every hour
-	ticker := time.NewTicker(time.Hour)
-	defer ticker.Stop()
-
-	// Wait 60 seconds before first maintenance to avoid startup race conditions
-	startupDelay := time.NewTimer(60 * time.Second)
+	interval := m.config.Maintenance
+	startupDelay := time.NewTimer(min(maintenanceStartupDelay, interval))
 	defer startupDelay.Stop()
 
 	// Wait for startup delay before running first maintenance
+	var lastArchive time.Time
 	select {
 	case <-m.ctx.Done():
 		return
-	case <-startupDelay.C:
-		m.performMaintenance()
+	case now := <-startupDelay.C:
+		m.aggregatePatterns()
+		m.archiveOldData()
+		m.enforceSizeLimit()
+		lastArchive = now
 	}
 
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-m.ctx.Done():
 			return
-		case <-ticker.C:
-			m.performMaintenance()
+		case now := <-ticker.C:
+			m.aggregatePatterns()
+			if now.Sub(lastArchive) >= archiveInterval {
+				m.archiveOldData()
+				lastArchive = now
+			}
+			m.enforceSizeLimit()
 		}
 	}
 }
 
-// performMaintenance runs maintenance tasks
-func (m *Monitor) performMaintenance() {
-	log.Println("Running maintenance tasks...")
-
-	// Aggregate hourly patterns for heatmap
-	if err := m.db.AggregateHourlyPatterns(); err != nil {
+// aggregatePatterns brings the heatmap's hourly patterns up to date, then the
+// daily and monthly trends rolled up from them. Each only processes what is
+// newer than its last run, so it is cheap to run often.
+func (m *Monitor) aggregatePatterns() {
+	if err := m.db.AggregateHourlyPatterns(m.ctx); err != nil {
 		log.Printf("Failed to aggregate hourly patterns: %v", err)
-	} else {
-		log.Println("Successfully aggregated hourly patterns")
+		return
 	}
+	if err := m.db.AggregateDaily(m.ctx); err != nil {
+		log.Printf("Failed to aggregate daily trends: %v", err)
+		return
+	}
+	if err := m.db.AggregateMonthly(m.ctx); err != nil {
+		log.Printf("Failed to aggregate monthly trends: %v", err)
+	}
+}
 
-	// Archive old detailed data (keep raw data for 7 days, aggregated for 90 days)
-	if err := m.db.ArchiveOldData(); err != nil {
+// archiveOldData archives old detailed data (keep raw data for 7 days,
+// aggregated for 90 days)
+func (m *Monitor) archiveOldData() {
+	log.Println("Archiving old data...")
+	if err := m.db.ArchiveOldData(m.ctx); err != nil {
 		log.Printf("Failed to archive old data: %v", err)
-	} else {
-		log.Println("Successfully archived old data")
+		return
 	}
+	log.Println("Successfully archived old data")
+}
 
-	log.Println("Maintenance complete")
+// enforceSizeLimit evicts the oldest raw results while the database is over
+// -max-db-size, so a small disk doesn't fill up between daily archival runs
+func (m *Monitor) enforceSizeLimit() {
+	if m.config.MaxDBSize <= 0 {
+		return
+	}
+	evicted, err := m.db.EnforceSizeLimit(m.ctx, m.config.MaxDBSize)
+	if evicted > 0 {
+		log.Printf("Database over %d bytes: evicted the %d oldest results", m.config.MaxDBSize, evicted)
+	}
+	if err != nil {
+		log.Printf("Failed to enforce the database size limit: %v", err)
+	}
+}
+
+// minOfflineGap is the shortest gap since the last stored result that is
+// recorded as the monitor having been offline
+const minOfflineGap = 2 * time.Minute
+
+// offlineThreshold is how long since the last stored result means the monitor
+// wasn't running: a few of the longest ping intervals, and at least
+// minOfflineGap so a quick restart isn't flagged
+func (m *Monitor) offlineThreshold() time.Duration {
+	interval := m.config.Interval
+	if m.config.Adaptive {
+		interval = max(interval, m.config.AdaptiveMax)
+	}
+	return max(minOfflineGap, 3*interval)
+}
+
+// recordOfflineGap records a gap between the last stored result and now that
+// is longer than the offline threshold, as an offline event at the last result
+// and an online event now, so the gap can be told apart from an outage or
+// normal ping spacing
+func (m *Monitor) recordOfflineGap(now time.Time) error {
+	last, err := m.db.GetLastTimestamp(m.ctx)
+	if err != nil {
+		return err
+	}
+	if last.IsZero() || now.Sub(last) <= m.offlineThreshold() {
+		return nil
+	}
+
+	if err := m.db.RecordMonitoringEvent(m.ctx, models.MonitoringEvent{Timestamp: last, Event: models.EventOffline}); err != nil {
+		return err
+	}
+	if err := m.db.RecordMonitoringEvent(m.ctx, models.MonitoringEvent{Timestamp: now, Event: models.EventOnline}); err != nil {
+		return err
+	}
+	log.Printf("Monitor was offline for %v since %s", now.Sub(last).Round(time.Second), last.Format(time.RFC3339))
+	return nil
 }
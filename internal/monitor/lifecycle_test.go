@@ -0,0 +1,150 @@
+package monitor
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"network-monitor/internal/config"
+	"network-monitor/internal/models"
+)
+
+// maintenanceRecorder counts maintenance runs
+type maintenanceRecorder struct {
+	models.Database
+	mu           sync.Mutex
+	aggregations int
+	archives     int
+	sizeLimits   []int64
+}
+
+func (r *maintenanceRecorder) AggregateHourlyPatterns(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aggregations++
+	return nil
+}
+
+func (r *maintenanceRecorder) AggregateDaily(ctx context.Context) error   { return nil }
+func (r *maintenanceRecorder) AggregateMonthly(ctx context.Context) error { return nil }
+
+func (r *maintenanceRecorder) ArchiveOldData(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.archives++
+	return nil
+}
+
+func (r *maintenanceRecorder) EnforceSizeLimit(ctx context.Context, maxBytes int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sizeLimits = append(r.sizeLimits, maxBytes)
+	return 0, nil
+}
+
+func (r *maintenanceRecorder) runs() (aggregations, archives int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.aggregations, r.archives
+}
+
+func TestMaintenanceWorkerAggregatesEachInterval(t *testing.T) {
+	m := newTestMonitor(config.Config{Maintenance: 10 * time.Millisecond}, &mockPinger{})
+	db := &maintenanceRecorder{}
+	m.db = db
+
+	m.wg.Add(1)
+	go m.maintenanceWorker()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if aggregations, _ := db.runs(); aggregations >= 3 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	m.cancel()
+	m.wg.Wait()
+
+	// Archival runs once at startup and then only daily
+	aggregations, archives := db.runs()
+	if aggregations < 3 {
+		t.Errorf("aggregated %d times, want at least 3 with a 10ms interval", aggregations)
+	}
+	if archives != 1 {
+		t.Errorf("archived %d times, want 1", archives)
+	}
+}
+
+func TestEnforceSizeLimitOnlyWhenConfigured(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxDBSize int64
+		want      []int64
+	}{
+		{"no limit", 0, nil},
+		{"limit", 500 << 20, []int64{500 << 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor(config.Config{MaxDBSize: tt.maxDBSize}, &mockPinger{})
+			db := &maintenanceRecorder{}
+			m.db = db
+
+			m.enforceSizeLimit()
+			if !slices.Equal(db.sizeLimits, tt.want) {
+				t.Errorf("enforced size limits %v, want %v", db.sizeLimits, tt.want)
+			}
+		})
+	}
+}
+
+// closingPinger records when it is closed, and any ping made after that
+type closingPinger struct {
+	mockPinger
+	closes      atomic.Int32
+	pingsClosed atomic.Int32
+}
+
+func (p *closingPinger) Ping(ctx context.Context, target string, timeout time.Duration) (models.PingResult, error) {
+	if p.closes.Load() > 0 {
+		p.pingsClosed.Add(1)
+	}
+	return p.mockPinger.Ping(ctx, target, timeout)
+}
+
+func (p *closingPinger) Close() error {
+	p.closes.Add(1)
+	return nil
+}
+
+func TestWaitClosesPingerAfterWorkers(t *testing.T) {
+	pinger := &closingPinger{mockPinger: mockPinger{outcomes: []bool{true}}}
+	m := newTestMonitor(config.Config{Timeout: time.Second}, pinger)
+
+	// A worker still pinging as the monitor stops
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		<-m.ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		m.pinger.Ping(context.Background(), "8.8.8.8", time.Second)
+	}()
+
+	m.Stop()
+	if n := pinger.closes.Load(); n != 0 {
+		t.Fatalf("pinger closed %d times before Wait", n)
+	}
+	m.Wait()
+
+	if n := pinger.closes.Load(); n != 1 {
+		t.Errorf("pinger closed %d times, want 1", n)
+	}
+	if n := pinger.pingsClosed.Load(); n != 0 {
+		t.Errorf("%d pings made after the pinger was closed", n)
+	}
+}
@@ -4,68 +4,179 @@ import (
 	"context"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"network-monitor/internal/alert"
 	"network-monitor/internal/config"
-	"network-monitor/internal/database"
 	"network-monitor/internal/models"
-	"network-monitor/internal/ping"
+	"network-monitor/internal/traceroute"
 )
 
 // Monitor coordinates ping monitoring operations
 type Monitor struct {
 	config  config.Config
-	db      *database.DB
-	pinger  *ping.Pinger
+	db      models.Database
+	pinger  models.Pinger
+	sinks   []models.Sink
 	results chan models.PingResult
+	jobs    chan pingJob
 	wg      sync.WaitGroup
 	ctx     context.Context
 	cancel  context.CancelFunc
+
+	resultWait time.Duration // see sendResult
+	dropped    atomic.Int64  // results dropped because the channel stayed full
+
+	pauseMu  sync.RWMutex
+	paused   bool
+	pausedAt time.Time
+
+	outages map[string]*outageState // owned by processResults
+	alerter *alert.Alerter
+	queue   *alert.Queue // background webhook delivery; nil without a webhook
+	latest  *latestResults
+
+	tracer    models.Tracer
+	traceMu   sync.Mutex
+	tracing   bool
+	lastTrace map[string]time.Time
 }
 
-// New creates a new Monitor
-func New(cfg config.Config, db *database.DB, pinger *ping.Pinger) *Monitor {
+// New creates a new Monitor. Results are always saved to db and additionally
+// written to any sinks.
+func New(cfg config.Config, db models.Database, pinger models.Pinger, sinks ...models.Sink) *Monitor {
 	ctx, cancel := context.WithCancel(context.Background())
+	notify, alertQueue := notifier(cfg)
+	alerter := alert.New(cfg.AlertCooldown, notify)
+	alerter.SetQualityThresholds(alert.QualityThresholds{
+		RTT:    cfg.AlertRTTThreshold,
+		Jitter: cfg.AlertJitterThreshold,
+		Window: cfg.AlertWindow,
+	})
 	return &Monitor{
 		config:  cfg,
 		db:      db,
 		pinger:  pinger,
+		sinks:   sinks,
 		results: make(chan models.PingResult, 100),
+		jobs:    make(chan pingJob),
 		ctx:     ctx,
 		cancel:  cancel,
+		outages: make(map[string]*outageState),
+		alerter: alerter,
+		queue:   alertQueue,
+		latest:  newLatestResults(),
+		tracer:  traceroute.New(),
+
+		resultWait: resultWait,
 	}
 }
 
 // Start begins the monitoring process
 func (m *Monitor) Start() error {
+	m.config.Targets = uniqueTargets(m.config.Targets)
 	log.Printf("Starting monitor with %d targets", len(m.config.Targets))
 
+	// Mark any time the monitor wasn't running before new results arrive
+	if err := m.recordOfflineGap(time.Now()); err != nil {
+		log.Printf("Failed to check for an offline gap: %v", err)
+	}
+	if err := m.loadLatest(); err != nil {
+		log.Printf("Failed to load the latest results: %v", err)
+	}
+
 	// Start result processor
 	m.wg.Add(1)
 	go m.processResults()
 
-	// Start pingers for each target
-	for _, target := range m.config.Targets {
+	// Start the ping worker pool, then a scheduler for each target that
+	// queues pings on it
+	workers := m.poolSize()
+	for i := 0; i < workers; i++ {
+		m.wg.Add(1)
+		go m.poolWorker()
+	}
+	for i, target := range m.config.Targets {
 		m.wg.Add(1)
-		go m.pingWorker(target)
+		go m.pingWorker(target, m.startOffset(i, len(m.config.Targets)))
 	}
 
 	// Start maintenance routines
 	m.wg.Add(1)
 	go m.maintenanceWorker()
 
-	log.Printf("Monitor process started. Pinging %v every %v", m.config.Targets, m.config.Interval)
+	if m.config.AlertSelfTest {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			if err := m.TestAlert(); err != nil {
+				log.Printf("Alert self-test failed: %v", err)
+				return
+			}
+			log.Println("Alert self-test sent")
+		}()
+	}
+
+	log.Printf("Monitor process started. Pinging %v every %v with %d workers", m.config.Targets, m.config.Interval, workers)
 	return nil
 }
 
-// Stop gracefully stops the monitor
+// Stop gracefully stops the monitor. The results channel is left open: workers
+// may still be waiting to send, and stop on the cancelled context instead.
 func (m *Monitor) Stop() {
 	log.Println("Stopping monitor...")
 	m.cancel()
-	close(m.results)
 }
 
-// Wait blocks until all goroutines finish
+// Wait blocks until all goroutines finish, then closes the pinger, which no
+// worker can be using any more, and delivers any alerts still queued
 func (m *Monitor) Wait() {
 	m.wg.Wait()
+	if m.queue != nil {
+		m.queue.Close()
+	}
+	if err := m.pinger.Close(); err != nil {
+		log.Printf("Failed to close pinger: %v", err)
+	}
 	log.Println("Monitor stopped")
 }
+
+// TestAlert sends a synthetic test alert through the configured notifiers and
+// returns any delivery error
+func (m *Monitor) TestAlert() error {
+	return m.alerter.Test(time.Now())
+}
+
+// uniqueTargets returns targets with any repeat dropped, keeping the first
+// occurrence's position. Config validation already rejects duplicates, but a
+// second worker for the same target would double its samples and skew its
+// stats, so the monitor refuses to start one regardless.
+func uniqueTargets(targets []string) []string {
+	seen := make(map[string]bool, len(targets))
+	unique := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if seen[target] {
+			log.Printf("Ignoring duplicate target %q", target)
+			continue
+		}
+		seen[target] = true
+		unique = append(unique, target)
+	}
+	return unique
+}
+
+// alertQueueSize is how many webhook alerts can wait for delivery before
+// further ones are dropped
+const alertQueueSize = 100
+
+// notifier returns where alerts are delivered: always the log, plus the
+// webhook when one is configured. Webhook alerts go through the returned
+// queue, so a slow endpoint can't stall result processing.
+func notifier(cfg config.Config) (models.Notifier, *alert.Queue) {
+	if cfg.AlertWebhook == "" {
+		return alert.LogNotifier{}, nil
+	}
+	queue := alert.NewQueue(alert.NewWebhookNotifier(cfg.AlertWebhook), alertQueueSize)
+	return alert.Notifiers{alert.LogNotifier{}, queue}, queue
+}
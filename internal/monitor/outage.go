@@ -0,0 +1,149 @@
+package monitor
+
+import (
+	"log"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// pingOutcome is a single result kept in a target's outage detection window
+type pingOutcome struct {
+	timestamp time.Time
+	success   bool
+	rtt       float64
+}
+
+// outageState tracks the recent results of a single target
+type outageState struct {
+	recent       []pingOutcome // the last threshold.Window results, oldest first
+	outageID     int64         // non-zero while an outage is open
+	outageStart  time.Time
+	failedChecks int
+	successes    int         // consecutive successful pings while an outage is open
+	recoveredAt  time.Time   // the first of those successes
+	recoveredRTT float64     // and its RTT
+	lastGood     pingOutcome // the newest successful ping to leave recent
+}
+
+// failures counts the failed pings in the window and returns the earliest one
+func (s *outageState) failures() (int, time.Time) {
+	count := 0
+	var first time.Time
+	for _, p := range s.recent {
+		if p.success {
+			continue
+		}
+		if count == 0 {
+			first = p.timestamp
+		}
+		count++
+	}
+	return count, first
+}
+
+// rttBefore returns the RTT of the last successful ping before start, or 0
+// when none has been seen
+func (s *outageState) rttBefore(start time.Time) float64 {
+	for i := len(s.recent) - 1; i >= 0; i-- {
+		if p := s.recent[i]; p.success && p.timestamp.Before(start) {
+			return p.rtt
+		}
+	}
+	return s.lastGood.rtt
+}
+
+// trackOutage adds a new result to the target's detection window, opening an
+// outage once the configured threshold is met. It closes the outage once the
+// window has dropped below the threshold again and the last few pings (the
+// configured recovery count) all succeeded, so a single lucky reply in the
+// middle of an outage doesn't split it in two. The outage is recorded as
+// ending at the first of those successes, with the RTT of the last success
+// before it and of that first success after it. It is only called from
+// processResults, so the state map needs no locking.
+func (m *Monitor) trackOutage(result models.PingResult) {
+	if m.outages == nil {
+		m.outages = make(map[string]*outageState)
+	}
+	state, ok := m.outages[result.Target]
+	if !ok {
+		state = &outageState{}
+		m.outages[result.Target] = state
+	}
+
+	threshold := m.config.OutageThreshold()
+	state.recent = append(state.recent, pingOutcome{timestamp: result.Timestamp, success: result.Success, rtt: result.RTT})
+	if len(state.recent) > threshold.Window {
+		for _, p := range state.recent[:len(state.recent)-threshold.Window] {
+			if p.success {
+				state.lastGood = p
+			}
+		}
+		state.recent = state.recent[len(state.recent)-threshold.Window:]
+	}
+	failures, firstFailure := state.failures()
+	inOutage := len(state.recent) == threshold.Window && failures >= threshold.Failures
+
+	if state.outageID != 0 {
+		if result.Success {
+			if state.successes == 0 {
+				state.recoveredAt = result.Timestamp
+				state.recoveredRTT = result.RTT
+			}
+			state.successes++
+		} else {
+			state.failedChecks++
+			state.successes = 0
+		}
+		if inOutage || state.successes < m.config.OutageRecoveryChecks() {
+			return
+		}
+		if err := m.db.RecordOutageEnd(m.ctx, state.outageID, state.recoveredAt, state.failedChecks, state.recoveredRTT); err != nil {
+			log.Printf("Failed to record outage end for %s: %v", result.Target, err)
+		} else {
+			log.Printf("RECOVERED: %s is responding again after %s (%d failed pings)",
+				result.Target, state.recoveredAt.Sub(state.outageStart).Round(time.Second), state.failedChecks)
+		}
+		state.outageID = 0
+		m.alerter.Up(result.Target, result.Timestamp)
+		m.publishState(result.Target, models.StateUp, result.Timestamp)
+		return
+	}
+
+	if !inOutage {
+		return
+	}
+
+	id, err := m.db.RecordOutageStart(m.ctx, result.Target, firstFailure, state.rttBefore(firstFailure))
+	if err != nil {
+		log.Printf("Failed to record outage for %s: %v", result.Target, err)
+		return
+	}
+	state.outageID = id
+	state.outageStart = firstFailure
+	state.failedChecks = failures
+	state.successes = 0
+	log.Printf("OUTAGE: %s has failed %d of its last %d pings since %s",
+		result.Target, failures, threshold.Window, firstFailure.Format("15:04:05"))
+
+	m.alerter.Down(result.Target, result.Timestamp)
+	m.publishState(result.Target, models.StateDown, result.Timestamp)
+
+	// Reference targets are a hop or none away; their names aren't hosts
+	if m.config.Traceroute && !models.IsReferenceTarget(result.Target) {
+		m.startTraceroute(id, result.Target)
+	}
+}
+
+// publishState sends an outage transition to the sinks that accept them
+func (m *Monitor) publishState(target, state string, at time.Time) {
+	for _, sink := range m.sinks {
+		stateSink, ok := sink.(models.StateSink)
+		if !ok {
+			continue
+		}
+		if err := stateSink.WriteState(models.TargetState{Target: target, State: state, Timestamp: at}); err != nil {
+			log.Printf("Failed to write %s state to sink: %v", target, err)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"network-monitor/internal/config"
+	"network-monitor/internal/models"
+)
+
+type recordedOutage struct {
+	target       string
+	start        time.Time
+	end          time.Time
+	checksFailed int
+	rttBefore    float64
+	rttAfter     float64
+	closed       bool
+}
+
+// outageRecorder captures outage and traceroute writes
+type outageRecorder struct {
+	models.Database
+	mu      sync.Mutex
+	nextID  int64
+	outages map[int64]*recordedOutage
+	traces  []models.Traceroute
+}
+
+func (r *outageRecorder) RecordOutageStart(ctx context.Context, target string, start time.Time, rttBefore float64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	if r.outages == nil {
+		r.outages = make(map[int64]*recordedOutage)
+	}
+	r.outages[r.nextID] = &recordedOutage{target: target, start: start, rttBefore: rttBefore}
+	return r.nextID, nil
+}
+
+func (r *outageRecorder) RecordOutageEnd(ctx context.Context, id int64, end time.Time, checksFailed int, rttAfter float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o := r.outages[id]
+	o.end = end
+	o.checksFailed = checksFailed
+	o.rttAfter = rttAfter
+	o.closed = true
+	return nil
+}
+
+func (r *outageRecorder) SaveTraceroute(ctx context.Context, trace models.Traceroute) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.traces = append(r.traces, trace)
+	return nil
+}
+
+func failThenRecover(m *Monitor, target string, failures int) {
+	for i := 0; i < failures; i++ {
+		m.trackOutage(models.PingResult{Timestamp: time.Now(), Target: target})
+	}
+	for i := 0; i < models.DefaultOutageRecovery; i++ {
+		m.trackOutage(models.PingResult{Timestamp: time.Now(), Target: target, Success: true})
+	}
+}
+
+func TestTrackOutageFailureAndRecovery(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second}, &mockPinger{outcomes: []bool{true}})
+	defer m.cancel()
+	db := &outageRecorder{}
+	m.db = db
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	sequence := []bool{true, false, false, false, false, false, true, true}
+	for i, success := range sequence {
+		m.trackOutage(models.PingResult{
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Target:    "8.8.8.8",
+			Success:   success,
+		})
+
+		// The outage opens on the third consecutive failure (index 3)
+		if i == 2 && db.nextID != 0 {
+			t.Fatalf("outage opened before threshold was reached")
+		}
+		if i == 3 && (db.nextID != 1 || db.outages[1].closed) {
+			t.Fatalf("expected an open outage after %d failures", models.DefaultOutageThreshold.Failures)
+		}
+	}
+
+	if db.nextID != 1 {
+		t.Fatalf("expected exactly 1 outage, got %d", db.nextID)
+	}
+	o := db.outages[1]
+	if !o.closed {
+		t.Fatal("expected outage to be closed on recovery")
+	}
+	if !o.start.Equal(base.Add(1 * time.Second)) {
+		t.Errorf("outage start = %v, want first failure at %v", o.start, base.Add(time.Second))
+	}
+	if !o.end.Equal(base.Add(6 * time.Second)) {
+		t.Errorf("outage end = %v, want recovery at %v", o.end, base.Add(6*time.Second))
+	}
+	if o.checksFailed != 5 {
+		t.Errorf("checks failed = %d, want 5", o.checksFailed)
+	}
+}
+
+func TestTrackOutageSurroundingRTTs(t *testing.T) {
+	tests := []struct {
+		name       string
+		window     int
+		failures   int
+		rtts       []float64 // one ping each, 0 = failed
+		wantBefore float64
+		wantAfter  float64
+	}{
+		{name: "spike then cutoff", window: 3, failures: 3, rtts: []float64{10, 12, 250, 0, 0, 0, 0, 15, 11}, wantBefore: 250, wantAfter: 15},
+		{name: "last success left the window", window: 3, failures: 3, rtts: []float64{20, 0, 0, 0, 0, 0, 0, 18, 19}, wantBefore: 20, wantAfter: 18},
+		{name: "success inside the window", window: 5, failures: 3, rtts: []float64{30, 0, 40, 0, 0, 0, 0, 25, 26, 27, 28}, wantBefore: 30, wantAfter: 25},
+		{name: "no success before", window: 3, failures: 3, rtts: []float64{0, 0, 0, 9, 9}, wantBefore: 0, wantAfter: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{Timeout: time.Second, OutageWindow: tt.window, OutageFailures: tt.failures}
+			m := newTestMonitor(cfg, &mockPinger{outcomes: []bool{true}})
+			defer m.cancel()
+			db := &outageRecorder{}
+			m.db = db
+
+			base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			for i, rtt := range tt.rtts {
+				m.trackOutage(models.PingResult{
+					Timestamp: base.Add(time.Duration(i) * time.Second),
+					Target:    "8.8.8.8",
+					Success:   rtt > 0,
+					RTT:       rtt,
+				})
+			}
+
+			if db.nextID != 1 || !db.outages[1].closed {
+				t.Fatalf("expected 1 closed outage, got %d", db.nextID)
+			}
+			o := db.outages[1]
+			if o.rttBefore != tt.wantBefore || o.rttAfter != tt.wantAfter {
+				t.Errorf("RTTs around outage = %v before, %v after, want %v and %v", o.rttBefore, o.rttAfter, tt.wantBefore, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestTrackOutageTargetsAreIndependent(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second}, &mockPinger{outcomes: []bool{true}})
+	defer m.cancel()
+	db := &outageRecorder{}
+	m.db = db
+
+	// Interleaved failures on two targets must not combine into an outage
+	for i := 0; i < models.DefaultOutageThreshold.Failures-1; i++ {
+		m.trackOutage(models.PingResult{Timestamp: time.Now(), Target: "8.8.8.8"})
+		m.trackOutage(models.PingResult{Timestamp: time.Now(), Target: "1.1.1.1"})
+	}
+	m.trackOutage(models.PingResult{Timestamp: time.Now(), Target: "8.8.8.8", Success: true})
+	m.trackOutage(models.PingResult{Timestamp: time.Now(), Target: "1.1.1.1"})
+
+	if db.nextID != 1 || db.outages[1].target != "1.1.1.1" {
+		t.Fatalf("expected a single outage for 1.1.1.1, got %d outages", db.nextID)
+	}
+}
+
+func TestTrackOutageThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		window     int
+		failures   int
+		pattern    string // one ping per character, X = failed
+		wantOpened bool
+	}{
+		{name: "consecutive below threshold", window: 3, failures: 3, pattern: "..XX.."},
+		{name: "consecutive at threshold", window: 3, failures: 3, pattern: "..XXX..", wantOpened: true},
+		{name: "window below threshold", window: 10, failures: 5, pattern: ".....X.X.X.X......"},
+		{name: "window at threshold", window: 10, failures: 5, pattern: ".....X.X.X.X.X.....", wantOpened: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{Timeout: time.Second, OutageWindow: tt.window, OutageFailures: tt.failures}
+			m := newTestMonitor(cfg, &mockPinger{outcomes: []bool{true}})
+			defer m.cancel()
+			db := &outageRecorder{}
+			m.db = db
+
+			base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			for i, c := range tt.pattern {
+				m.trackOutage(models.PingResult{
+					Timestamp: base.Add(time.Duration(i) * time.Second),
+					Target:    "8.8.8.8",
+					Success:   c != 'X',
+				})
+			}
+
+			if !tt.wantOpened {
+				if db.nextID != 0 {
+					t.Fatalf("expected no outage, got %d", db.nextID)
+				}
+				return
+			}
+			if db.nextID != 1 {
+				t.Fatalf("expected exactly 1 outage, got %d", db.nextID)
+			}
+			o := db.outages[1]
+			if !o.closed {
+				t.Error("expected outage to close once the window recovered")
+			}
+			first := base.Add(time.Duration(strings.IndexByte(tt.pattern, 'X')) * time.Second)
+			if !o.start.Equal(first) {
+				t.Errorf("outage start = %v, want first failure at %v", o.start, first)
+			}
+			if want := strings.Count(tt.pattern, "X"); o.checksFailed != want {
+				t.Errorf("checks failed = %d, want %d", o.checksFailed, want)
+			}
+		})
+	}
+}
+
+func TestTrackOutageRecoveryHysteresis(t *testing.T) {
+	tests := []struct {
+		name     string
+		recovery int
+		pattern  string // one ping per character, X = failed
+		wantEnds []int  // index of the first success of each closing run
+	}{
+		// Isolated replies during the outage don't close it
+		{name: "default", recovery: 0, pattern: "XXX.XX.XXX..", wantEnds: []int{10}},
+		{name: "three in a row", recovery: 3, pattern: "XXX..X..XX...", wantEnds: []int{10}},
+		{name: "still down", recovery: 2, pattern: "XXX.X.X.X", wantEnds: nil},
+		{name: "single success", recovery: 1, pattern: "XXX.XXX.", wantEnds: []int{3, 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor(config.Config{Timeout: time.Second, OutageRecovery: tt.recovery}, &mockPinger{outcomes: []bool{true}})
+			defer m.cancel()
+			db := &outageRecorder{}
+			m.db = db
+
+			base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			for i, c := range tt.pattern {
+				m.trackOutage(models.PingResult{
+					Timestamp: base.Add(time.Duration(i) * time.Second),
+					Target:    "8.8.8.8",
+					Success:   c != 'X',
+				})
+			}
+
+			var ends []int
+			for id := int64(1); id <= db.nextID; id++ {
+				if o := db.outages[id]; o.closed {
+					ends = append(ends, int(o.end.Sub(base)/time.Second))
+				}
+			}
+			if fmt.Sprint(ends) != fmt.Sprint(tt.wantEnds) {
+				t.Errorf("outages closed at %v, want %v", ends, tt.wantEnds)
+			}
+			if len(tt.wantEnds) == 0 && (db.nextID != 1 || db.outages[1].closed) {
+				t.Errorf("expected the outage to stay open, got %d outages", db.nextID)
+			}
+		})
+	}
+}
+
+// stateRecorder is a sink that also records outage transitions
+type stateRecorder struct {
+	sinkRecorder
+	states []models.TargetState
+}
+
+func (s *stateRecorder) WriteState(state models.TargetState) error {
+	s.states = append(s.states, state)
+	return nil
+}
+
+func TestTrackOutagePublishesStates(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second}, &mockPinger{outcomes: []bool{true}})
+	defer m.cancel()
+	m.db = &outageRecorder{}
+	sink := &stateRecorder{}
+	m.sinks = []models.Sink{&sinkRecorder{}, sink}
+
+	failThenRecover(m, "8.8.8.8", models.DefaultOutageThreshold.Failures)
+
+	if len(sink.states) != 2 {
+		t.Fatalf("expected down and up transitions, got %+v", sink.states)
+	}
+	if sink.states[0].State != models.StateDown || sink.states[1].State != models.StateUp {
+		t.Errorf("unexpected transitions %+v", sink.states)
+	}
+}
+
+// webhookDB counts saved results and records outages
+type webhookDB struct {
+	outageRecorder
+	saved atomic.Int64
+}
+
+func (db *webhookDB) SaveResult(ctx context.Context, result models.PingResult) error {
+	db.saved.Add(1)
+	return nil
+}
+
+func TestBlockingWebhookDoesNotStallResults(t *testing.T) {
+	release := make(chan struct{})
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hook.Close()
+	defer close(release)
+
+	db := &webhookDB{}
+	m := New(config.Config{Timeout: time.Second, AlertWebhook: hook.URL}, db, &mockPinger{})
+	m.wg.Add(1)
+	go m.processResults()
+	defer func() {
+		m.cancel()
+		m.wg.Wait()
+	}()
+
+	// Every target goes down, each raising an alert the webhook never answers
+	const targets, failures = 5, 5
+	for i := 0; i < failures; i++ {
+		for n := 0; n < targets; n++ {
+			m.results <- models.PingResult{Timestamp: time.Now(), Target: fmt.Sprintf("10.0.0.%d", n)}
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for db.saved.Load() < targets*failures && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := db.saved.Load(); got != targets*failures {
+		t.Errorf("saved %d results while the webhook hung, want all %d", got, targets*failures)
+	}
+}
@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"log"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// Pause stops pinging until Resume is called and records a marker so the gap
+// is not mistaken for an outage. Pausing an already paused monitor is a no-op.
+func (m *Monitor) Pause() error {
+	m.pauseMu.Lock()
+	defer m.pauseMu.Unlock()
+
+	if m.paused {
+		return nil
+	}
+
+	now := time.Now()
+	if err := m.db.RecordMonitoringEvent(m.ctx, models.MonitoringEvent{Timestamp: now, Event: models.EventPaused}); err != nil {
+		return err
+	}
+
+	m.paused = true
+	m.pausedAt = now
+	log.Println("Monitoring paused")
+	return nil
+}
+
+// Resume restarts pinging after a Pause. Resuming a running monitor is a no-op.
+func (m *Monitor) Resume() error {
+	m.pauseMu.Lock()
+	defer m.pauseMu.Unlock()
+
+	if !m.paused {
+		return nil
+	}
+
+	if err := m.db.RecordMonitoringEvent(m.ctx, models.MonitoringEvent{Timestamp: time.Now(), Event: models.EventResumed}); err != nil {
+		return err
+	}
+
+	m.paused = false
+	m.pausedAt = time.Time{}
+	log.Println("Monitoring resumed")
+	return nil
+}
+
+// TogglePause pauses a running monitor or resumes a paused one
+func (m *Monitor) TogglePause() error {
+	if m.Paused() {
+		return m.Resume()
+	}
+	return m.Pause()
+}
+
+// Paused reports whether monitoring is currently paused
+func (m *Monitor) Paused() bool {
+	m.pauseMu.RLock()
+	defer m.pauseMu.RUnlock()
+	return m.paused
+}
+
+// Status returns the current pause state
+func (m *Monitor) Status() models.MonitorStatus {
+	m.pauseMu.RLock()
+	defer m.pauseMu.RUnlock()
+
+	status := models.MonitorStatus{Paused: m.paused, ResultsDropped: m.dropped.Load()}
+	if m.paused {
+		pausedAt := m.pausedAt
+		status.PausedAt = &pausedAt
+	}
+	return status
+}
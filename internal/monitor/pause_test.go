@@ -0,0 +1,136 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"network-monitor/internal/config"
+	"network-monitor/internal/models"
+)
+
+// eventRecorder captures monitoring events; other Database methods are not used
+type eventRecorder struct {
+	models.Database
+	events []models.MonitoringEvent
+}
+
+func (r *eventRecorder) RecordMonitoringEvent(ctx context.Context, event models.MonitoringEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestPausedMonitorProducesNoResults(t *testing.T) {
+	pinger := &mockPinger{outcomes: []bool{true}}
+	m := newTestMonitor(config.Config{Timeout: time.Second}, pinger)
+	defer m.cancel()
+	db := &eventRecorder{}
+	m.db = db
+
+	if err := m.Pause(); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if !m.Status().Paused || m.Status().PausedAt == nil {
+		t.Fatalf("expected status to report paused with timestamp, got %+v", m.Status())
+	}
+
+	for i := 0; i < 3; i++ {
+		m.performPing("8.8.8.8")
+	}
+	if got := len(m.results); got != 0 {
+		t.Fatalf("expected no results while paused, got %d", got)
+	}
+	if pinger.calls != 0 {
+		t.Fatalf("expected no pings while paused, got %d", pinger.calls)
+	}
+
+	if err := m.Resume(); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	m.performPing("8.8.8.8")
+	if got := len(m.results); got != 1 {
+		t.Fatalf("expected 1 result after resume, got %d", got)
+	}
+
+	if len(db.events) != 2 || db.events[0].Event != models.EventPaused || db.events[1].Event != models.EventResumed {
+		t.Fatalf("expected paused/resumed markers, got %+v", db.events)
+	}
+}
+
+func TestTogglePauseIsIdempotentPerState(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second}, &mockPinger{outcomes: []bool{true}})
+	defer m.cancel()
+	db := &eventRecorder{}
+	m.db = db
+
+	if err := m.TogglePause(); err != nil {
+		t.Fatalf("TogglePause() error = %v", err)
+	}
+	if err := m.Pause(); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if !m.Paused() {
+		t.Fatal("expected monitor to be paused")
+	}
+	if err := m.TogglePause(); err != nil {
+		t.Fatalf("TogglePause() error = %v", err)
+	}
+	if m.Paused() {
+		t.Fatal("expected monitor to be resumed")
+	}
+	if len(db.events) != 2 {
+		t.Fatalf("expected 2 markers, got %d", len(db.events))
+	}
+}
+
+// lastResultDB reports a fixed last result time and captures monitoring events
+type lastResultDB struct {
+	eventRecorder
+	last time.Time
+}
+
+func (db *lastResultDB) GetLastTimestamp(ctx context.Context) (time.Time, error) {
+	return db.last, nil
+}
+
+func TestRecordOfflineGap(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		last       time.Time
+		cfg        config.Config
+		wantEvents bool
+	}{
+		{"empty database", time.Time{}, config.Config{Interval: time.Second}, false},
+		{"quick restart", now.Add(-30 * time.Second), config.Config{Interval: time.Second}, false},
+		{"down for hours", now.Add(-3 * time.Hour), config.Config{Interval: time.Second}, true},
+		{"within a few long intervals", now.Add(-10 * time.Minute), config.Config{Interval: 5 * time.Minute}, false},
+		{"within adaptive backoff", now.Add(-2 * time.Minute), config.Config{Interval: time.Second, Adaptive: true, AdaptiveMax: time.Minute}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor(tt.cfg, &mockPinger{outcomes: []bool{true}})
+			defer m.cancel()
+			db := &lastResultDB{last: tt.last}
+			m.db = db
+
+			if err := m.recordOfflineGap(now); err != nil {
+				t.Fatalf("recordOfflineGap() error = %v", err)
+			}
+
+			if !tt.wantEvents {
+				if len(db.events) != 0 {
+					t.Errorf("recorded %+v, want no events", db.events)
+				}
+				return
+			}
+			want := []models.MonitoringEvent{
+				{Timestamp: tt.last, Event: models.EventOffline},
+				{Timestamp: now, Event: models.EventOnline},
+			}
+			if len(db.events) != 2 || db.events[0] != want[0] || db.events[1] != want[1] {
+				t.Errorf("recorded %+v, want %+v", db.events, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package monitor
+
+// pingJob asks a pool worker to ping a target; the outcome is sent on done,
+// which must have room for it
+type pingJob struct {
+	target string
+	done   chan jobOutcome
+}
+
+// jobOutcome is the result of performPing for a pingJob
+type jobOutcome struct {
+	success  bool
+	recorded bool
+}
+
+// poolSize is the number of ping workers: the configured concurrency, or one
+// per target so nothing ever queues
+func (m *Monitor) poolSize() int {
+	if m.config.PingWorkers > 0 {
+		return m.config.PingWorkers
+	}
+	return max(len(m.config.Targets), 1)
+}
+
+// poolWorker runs queued pings until the monitor stops. Each target's ticker
+// queues its own jobs, so the pool bounds how many ping processes run at once
+// without coupling that to the number of targets.
+func (m *Monitor) poolWorker() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case job := <-m.jobs:
+			success, recorded := m.performPing(job.target)
+			job.done <- jobOutcome{success: success, recorded: recorded}
+		}
+	}
+}
+
+// queuePing hands a ping of target to the pool and waits for it to finish,
+// with the same results as performPing. Nothing is recorded if the monitor
+// stops first.
+func (m *Monitor) queuePing(target string) (success, recorded bool) {
+	done := make(chan jobOutcome, 1)
+	select {
+	case m.jobs <- pingJob{target: target, done: done}:
+	case <-m.ctx.Done():
+		return false, false
+	}
+
+	select {
+	case outcome := <-done:
+		return outcome.success, outcome.recorded
+	case <-m.ctx.Done():
+		return false, false
+	}
+}
@@ -0,0 +1,122 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"network-monitor/internal/config"
+	"network-monitor/internal/models"
+)
+
+// concurrencyPinger succeeds after a delay, recording the most pings it saw
+// running at once
+type concurrencyPinger struct {
+	delay time.Duration
+
+	mu      sync.Mutex
+	running int
+	peak    int
+	calls   int
+}
+
+func (p *concurrencyPinger) Ping(ctx context.Context, target string, timeout time.Duration) (models.PingResult, error) {
+	p.mu.Lock()
+	p.running++
+	p.calls++
+	p.peak = max(p.peak, p.running)
+	p.mu.Unlock()
+
+	time.Sleep(p.delay)
+
+	p.mu.Lock()
+	p.running--
+	p.mu.Unlock()
+	return models.PingResult{Timestamp: time.Now(), Target: target, Success: true, RTT: 10}, nil
+}
+
+func (p *concurrencyPinger) Close() error { return nil }
+
+func TestPingPoolBoundsConcurrency(t *testing.T) {
+	var targets []string
+	for i := 1; i <= 10; i++ {
+		targets = append(targets, fmt.Sprintf("192.0.2.%d", i))
+	}
+	pinger := &concurrencyPinger{delay: 20 * time.Millisecond}
+	m := newTestMonitor(config.Config{Targets: targets, Timeout: time.Second, PingWorkers: 2}, pinger)
+
+	for i := 0; i < m.poolSize(); i++ {
+		m.wg.Add(1)
+		go m.poolWorker()
+	}
+
+	// Every target comes due at once
+	var due sync.WaitGroup
+	for _, target := range targets {
+		due.Add(1)
+		go func(target string) {
+			defer due.Done()
+			if _, recorded := m.queuePing(target); !recorded {
+				t.Errorf("ping of %s was not recorded", target)
+			}
+		}(target)
+	}
+	due.Wait()
+	m.cancel()
+	m.wg.Wait()
+
+	if pinger.calls != len(targets) {
+		t.Errorf("pinger called %d times, want %d", pinger.calls, len(targets))
+	}
+	if pinger.peak > 2 {
+		t.Errorf("%d pings ran at once, want at most 2", pinger.peak)
+	}
+	if len(m.results) != len(targets) {
+		t.Errorf("%d results recorded, want %d", len(m.results), len(targets))
+	}
+}
+
+func TestPoolSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		targets int
+		want    int
+	}{
+		{"configured", 2, 10, 2},
+		{"one per target by default", 0, 3, 3},
+		{"more workers than targets", 8, 3, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor(config.Config{Targets: make([]string, tt.targets), PingWorkers: tt.workers}, &mockPinger{})
+			defer m.cancel()
+			if got := m.poolSize(); got != tt.want {
+				t.Errorf("poolSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueuePingStopsWithMonitor(t *testing.T) {
+	// No pool workers, so the job can never be taken
+	m := newTestMonitor(config.Config{Timeout: time.Second}, &mockPinger{outcomes: []bool{true}})
+
+	done := make(chan bool)
+	go func() {
+		_, recorded := m.queuePing("8.8.8.8")
+		done <- recorded
+	}()
+	m.cancel()
+
+	select {
+	case recorded := <-done:
+		if recorded {
+			t.Error("queuePing() recorded a ping after the monitor stopped")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queuePing() did not return after the monitor stopped")
+	}
+}
@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"log"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// tracerouteCooldown is the minimum time between traceroutes to the same target,
+// so a flapping link doesn't trigger a trace on every outage
+const tracerouteCooldown = 15 * time.Minute
+
+// startTraceroute traces the route to a target in the background and stores the
+// hops against the outage. Traces are skipped while another is still running or
+// if the target was traced within the cooldown.
+func (m *Monitor) startTraceroute(outageID int64, target string) {
+	if m.tracer == nil {
+		return
+	}
+
+	m.traceMu.Lock()
+	if m.tracing {
+		m.traceMu.Unlock()
+		log.Printf("Skipping traceroute to %s: another traceroute is in progress", target)
+		return
+	}
+	if last, ok := m.lastTrace[target]; ok && time.Since(last) < tracerouteCooldown {
+		m.traceMu.Unlock()
+		log.Printf("Skipping traceroute to %s: last trace was %s ago", target, time.Since(last).Round(time.Second))
+		return
+	}
+	if m.lastTrace == nil {
+		m.lastTrace = make(map[string]time.Time)
+	}
+	m.tracing = true
+	m.lastTrace[target] = time.Now()
+	m.traceMu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.traceMu.Lock()
+			m.tracing = false
+			m.traceMu.Unlock()
+		}()
+
+		log.Printf("Running traceroute to %s for outage %d", target, outageID)
+		hops, err := m.tracer.Trace(m.ctx, target)
+		if err != nil {
+			log.Printf("Traceroute to %s failed: %v", target, err)
+			return
+		}
+
+		trace := models.Traceroute{
+			OutageID:  outageID,
+			Target:    target,
+			Timestamp: time.Now(),
+			Hops:      hops,
+		}
+		if err := m.db.SaveTraceroute(m.ctx, trace); err != nil {
+			log.Printf("Failed to save traceroute to %s: %v", target, err)
+		}
+	}()
+}
@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"network-monitor/internal/config"
+	"network-monitor/internal/models"
+)
+
+type fakeTracer struct{}
+
+func (fakeTracer) Trace(ctx context.Context, target string) ([]models.TracerouteHop, error) {
+	return []models.TracerouteHop{{Hop: 1, Address: "192.168.1.1", AvgRTT: 1}}, nil
+}
+
+func TestTracerouteRateLimitedPerTarget(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second, Traceroute: true}, &mockPinger{outcomes: []bool{true}})
+	defer m.cancel()
+	db := &outageRecorder{}
+	m.db = db
+	m.tracer = fakeTracer{}
+
+	failThenRecover(m, "8.8.8.8", models.DefaultOutageThreshold.Failures)
+	m.wg.Wait()
+	failThenRecover(m, "8.8.8.8", models.DefaultOutageThreshold.Failures)
+	m.wg.Wait()
+	failThenRecover(m, "1.1.1.1", models.DefaultOutageThreshold.Failures)
+	m.wg.Wait()
+
+	if len(db.traces) != 2 {
+		t.Fatalf("expected 2 traceroutes (one per target), got %d", len(db.traces))
+	}
+	if db.traces[0].OutageID != 1 || db.traces[1].OutageID != 3 {
+		t.Errorf("traceroutes keyed to outages %d and %d, want 1 and 3", db.traces[0].OutageID, db.traces[1].OutageID)
+	}
+}
+
+func TestNoOutageBelowThreshold(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second, Traceroute: true}, &mockPinger{outcomes: []bool{true}})
+	defer m.cancel()
+	db := &outageRecorder{}
+	m.db = db
+	m.tracer = fakeTracer{}
+
+	failThenRecover(m, "8.8.8.8", models.DefaultOutageThreshold.Failures-1)
+	m.wg.Wait()
+
+	if db.nextID != 0 || len(db.traces) != 0 {
+		t.Fatalf("expected no outage or traceroute, got %d outages and %d traces", db.nextID, len(db.traces))
+	}
+}
@@ -5,40 +5,149 @@ import (
 	"errors"
 	"log"
 	"time"
+
+	"network-monitor/internal/models"
 )
 
-// pingWorker continuously pings a target at the configured interval
-func (m *Monitor) pingWorker(target string) {
+// pingWorker continuously pings a target at the configured interval, or in
+// adaptive mode at an interval that backs off while the target keeps answering.
+// The first ping is sent after offset. The pings themselves run on the shared
+// worker pool.
+func (m *Monitor) pingWorker(target string, offset time.Duration) {
 	defer m.wg.Done()
 
-	ticker := time.NewTicker(m.config.Interval)
+	if offset > 0 {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-time.After(offset):
+		}
+	}
+
+	interval := m.config.Interval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	// Immediate first ping
-	m.performPing(target)
+	var adaptive *adaptiveInterval
+	if m.config.Adaptive {
+		adaptive = newAdaptiveInterval(m.config.Interval, m.config.AdaptiveMax)
+	}
+
+	ping := func() {
+		success, recorded := m.queuePing(target)
+		if adaptive == nil || !recorded {
+			return
+		}
+		if next := adaptive.record(success); next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+	}
+
+	ping()
 
 	for {
 		select {
 		case <-m.ctx.Done():
 			return
 		case <-ticker.C:
-			m.performPing(target)
+			ping()
 		}
 	}
 }
 
-// performPing executes a single ping and sends the result to the results channel
-func (m *Monitor) performPing(target string) {
-	result, err := m.pinger.Ping(target, m.config.Timeout)
+// startOffset is how long the i-th of n targets waits before its first ping.
+// Offsets are spread evenly across the interval, so with many targets the
+// ping subprocesses don't all start together every interval; the first
+// target starts at once. With staggering off every target starts at once.
+func (m *Monitor) startOffset(i, n int) time.Duration {
+	if m.config.NoStagger || n <= 1 {
+		return 0
+	}
+	return m.config.Interval * time.Duration(i) / time.Duration(n)
+}
+
+// retryBackoff is the base delay between ping retries; it grows linearly per attempt
+const retryBackoff = 250 * time.Millisecond
+
+// performPing executes a single ping and sends the result to the results
+// channel. It reports whether the ping succeeded and whether one was recorded
+// at all (not while paused or shutting down).
+func (m *Monitor) performPing(target string) (success, recorded bool) {
+	if m.Paused() {
+		return false, false
+	}
+
+	result, err := m.pingWithRetries(target)
+	if errors.Is(err, context.Canceled) {
+		// Monitor is shutting down; an aborted ping is not a failure
+		return false, false
+	}
 	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
 		log.Printf("Failed to ping %s: %v", target, err)
 	}
 
+	result.Instance = m.config.InstanceName
+	if !m.sendResult(result) {
+		return false, false
+	}
+	return result.Success, true
+}
+
+// resultWait is how long a worker waits for room in a full results channel
+// before dropping its result. Waiting delays the worker's next ping, so a slow
+// consumer throttles pinging rather than silently losing results.
+const resultWait = 5 * time.Second
+
+// sendResult queues a result for processing, waiting up to m.resultWait while
+// the channel is full. It returns false only if the monitor is shutting down;
+// results dropped after the wait are counted in the monitor status.
+func (m *Monitor) sendResult(result models.PingResult) bool {
 	select {
 	case m.results <- result:
+		return true
 	default:
-		log.Printf("Result channel full, dropping result for %s", target)
 	}
+
+	timer := time.NewTimer(m.resultWait)
+	defer timer.Stop()
+
+	select {
+	case m.results <- result:
+	case <-m.ctx.Done():
+		return false
+	case <-timer.C:
+		dropped := m.dropped.Add(1)
+		log.Printf("Result channel full for %v, dropping result for %s (%d dropped in total)", m.resultWait, result.Target, dropped)
+	}
+	return true
+}
+
+// pingWithRetries pings the target, retrying failed attempts up to the configured
+// number of times. The first successful attempt wins; otherwise the last failure is returned.
+func (m *Monitor) pingWithRetries(target string) (models.PingResult, error) {
+	var (
+		result models.PingResult
+		err    error
+	)
+
+	for attempt := 0; attempt <= m.config.PingRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-m.ctx.Done():
+				return result, m.ctx.Err()
+			case <-time.After(time.Duration(attempt) * retryBackoff):
+			}
+		}
+
+		result, err = m.pinger.Ping(m.ctx, target, m.config.Timeout)
+		result.Attempts = attempt + 1
+		if err == nil && result.Success {
+			return result, nil
+		}
+	}
+
+	return result, err
 }
 
 // processResults processes ping results from the results channel
@@ -58,9 +167,33 @@ func (m *Monitor) processResults() {
 					result.ErrorMessage)
 			}
 
-			if err := m.db.SaveResult(result); err != nil {
+			m.latest.update(result)
+			if err := m.db.SaveResult(m.ctx, result); err != nil {
 				log.Printf("Failed to save result: %v", err)
 			}
+			if result.RawOutput != "" {
+				debug := models.PingDebug{
+					Timestamp: result.Timestamp,
+					Target:    result.Target,
+					Success:   result.Success,
+					Output:    result.RawOutput,
+				}
+				if err := m.db.SavePingDebug(m.ctx, debug); err != nil {
+					log.Printf("Failed to save ping output: %v", err)
+				}
+			}
+
+			for _, sink := range m.sinks {
+				if err := sink.Write(result); err != nil {
+					log.Printf("Failed to write result to sink: %v", err)
+				}
+			}
+
+			m.trackOutage(result)
+			// Successes without a parsed RTT carry no latency information
+			if result.HasRTT() {
+				m.alerter.Sample(result.Target, result.Timestamp, result.RTT)
+			}
 		}
 	}
 }
@@ -0,0 +1,466 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	"network-monitor/internal/alert"
+	"network-monitor/internal/config"
+	"network-monitor/internal/models"
+)
+
+// mockPinger returns scripted outcomes in order, repeating the last one when exhausted
+type mockPinger struct {
+	mu       sync.Mutex
+	outcomes []bool
+	calls    int
+}
+
+func (p *mockPinger) Ping(ctx context.Context, target string, timeout time.Duration) (models.PingResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return models.PingResult{Timestamp: time.Now(), Target: target, PacketLoss: 100}, err
+	}
+
+	idx := p.calls
+	if idx >= len(p.outcomes) {
+		idx = len(p.outcomes) - 1
+	}
+	p.calls++
+
+	result := models.PingResult{Timestamp: time.Now(), Target: target}
+	if !p.outcomes[idx] {
+		result.PacketLoss = 100
+		result.ErrorMessage = "request timeout"
+		return result, errors.New("request timeout")
+	}
+	result.Success = true
+	result.RTT = 12.5
+	return result, nil
+}
+
+func (p *mockPinger) Close() error { return nil }
+
+func newTestMonitor(cfg config.Config, pinger models.Pinger) *Monitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Monitor{
+		config:  cfg,
+		pinger:  pinger,
+		results: make(chan models.PingResult, 10),
+		jobs:    make(chan pingJob),
+		alerter: alert.New(cfg.AlertCooldown, alert.LogNotifier{}),
+		latest:  newLatestResults(),
+		ctx:     ctx,
+		cancel:  cancel,
+
+		resultWait: resultWait,
+	}
+}
+
+func TestPerformPingRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		retries      int
+		outcomes     []bool
+		wantSuccess  bool
+		wantAttempts int
+	}{
+		{
+			name:         "no retries records failure",
+			retries:      0,
+			outcomes:     []bool{false, true},
+			wantSuccess:  false,
+			wantAttempts: 1,
+		},
+		{
+			name:         "retry recovers from transient failure",
+			retries:      2,
+			outcomes:     []bool{false, true},
+			wantSuccess:  true,
+			wantAttempts: 2,
+		},
+		{
+			name:         "retries exhausted records failure",
+			retries:      2,
+			outcomes:     []bool{false},
+			wantSuccess:  false,
+			wantAttempts: 3,
+		},
+		{
+			name:         "first success skips retries",
+			retries:      3,
+			outcomes:     []bool{true},
+			wantSuccess:  true,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pinger := &mockPinger{outcomes: tt.outcomes}
+			m := newTestMonitor(config.Config{Timeout: time.Second, PingRetries: tt.retries}, pinger)
+			defer m.cancel()
+
+			m.performPing("8.8.8.8")
+
+			if got := len(m.results); got != 1 {
+				t.Fatalf("expected exactly 1 recorded result, got %d", got)
+			}
+			result := <-m.results
+			if result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", result.Success, tt.wantSuccess)
+			}
+			if result.Attempts != tt.wantAttempts {
+				t.Errorf("Attempts = %d, want %d", result.Attempts, tt.wantAttempts)
+			}
+			if pinger.calls != tt.wantAttempts {
+				t.Errorf("pinger called %d times, want %d", pinger.calls, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestPerformPingRecordsInstance(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second, InstanceName: "office"}, &mockPinger{outcomes: []bool{true}})
+	defer m.cancel()
+
+	m.performPing("8.8.8.8")
+
+	if got := (<-m.results).Instance; got != "office" {
+		t.Errorf("Instance = %q, want the configured instance name", got)
+	}
+}
+
+// blockingPinger blocks until the context is cancelled, like a ping to an unresponsive host
+type blockingPinger struct{}
+
+func (blockingPinger) Close() error { return nil }
+
+func (blockingPinger) Ping(ctx context.Context, target string, timeout time.Duration) (models.PingResult, error) {
+	select {
+	case <-ctx.Done():
+		return models.PingResult{Timestamp: time.Now(), Target: target, PacketLoss: 100}, ctx.Err()
+	case <-time.After(timeout):
+		return models.PingResult{Timestamp: time.Now(), Target: target, PacketLoss: 100}, context.DeadlineExceeded
+	}
+}
+
+func TestPerformPingAbortsOnCancel(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: 10 * time.Second, PingRetries: 3}, blockingPinger{})
+
+	done := make(chan struct{})
+	go func() {
+		m.performPing("192.0.2.1")
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	m.cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("performPing did not return promptly after cancellation")
+	}
+
+	if got := len(m.results); got != 0 {
+		t.Fatalf("expected cancelled ping not to be recorded, got %d results", got)
+	}
+}
+
+// resultRecorder captures saved results
+type resultRecorder struct {
+	models.Database
+	mu    sync.Mutex
+	saved []models.PingResult
+}
+
+func (r *resultRecorder) SaveResult(ctx context.Context, result models.PingResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved = append(r.saved, result)
+	return nil
+}
+
+func (r *resultRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.saved)
+}
+
+// sinkRecorder captures results written to a sink
+type sinkRecorder struct {
+	resultRecorder
+}
+
+func (s *sinkRecorder) Write(result models.PingResult) error {
+	return s.SaveResult(context.Background(), result)
+}
+
+func (s *sinkRecorder) Close() error { return nil }
+
+func TestProcessResultsFansOutToSinks(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second}, &mockPinger{outcomes: []bool{true}})
+	db := &resultRecorder{}
+	sinks := []*sinkRecorder{{}, {}}
+	m.db = db
+	m.sinks = []models.Sink{sinks[0], sinks[1]}
+
+	m.wg.Add(1)
+	go m.processResults()
+
+	for i := 0; i < 3; i++ {
+		m.results <- models.PingResult{Timestamp: time.Now(), Target: "8.8.8.8", Success: true, RTT: 10}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for sinks[1].count() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	m.cancel()
+	m.wg.Wait()
+
+	if got := db.count(); got != 3 {
+		t.Errorf("database saved %d results, want 3", got)
+	}
+	for i, sink := range sinks {
+		if got := sink.count(); got != 3 {
+			t.Errorf("sink %d received %d results, want 3", i, got)
+		}
+	}
+}
+
+// debugRecorder also captures stored ping output
+type debugRecorder struct {
+	resultRecorder
+	debug []models.PingDebug
+}
+
+func (r *debugRecorder) SavePingDebug(ctx context.Context, entry models.PingDebug) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.debug = append(r.debug, entry)
+	return nil
+}
+
+func TestProcessResultsStoresRawOutput(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second}, &mockPinger{outcomes: []bool{true}})
+	db := &debugRecorder{}
+	m.db = db
+
+	m.wg.Add(1)
+	go m.processResults()
+
+	m.results <- models.PingResult{Timestamp: time.Now(), Target: "8.8.8.8", Success: true, RTT: 10}
+	m.results <- models.PingResult{Timestamp: time.Now(), Target: "8.8.8.8", Success: true, RawOutput: "Antwort von 8.8.8.8: Zeit=12ms"}
+
+	deadline := time.Now().Add(time.Second)
+	for db.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	m.cancel()
+	m.wg.Wait()
+
+	if len(db.debug) != 1 || db.debug[0].Output != "Antwort von 8.8.8.8: Zeit=12ms" || !db.debug[0].Success {
+		t.Errorf("stored ping output = %+v, want only the unparseable reply", db.debug)
+	}
+}
+
+// slowRecorder is a database that takes a while to save each result
+type slowRecorder struct {
+	resultRecorder
+	delay time.Duration
+}
+
+func (r *slowRecorder) SaveResult(ctx context.Context, result models.PingResult) error {
+	time.Sleep(r.delay)
+	return r.resultRecorder.SaveResult(ctx, result)
+}
+
+func TestSendResultWaitsForSlowConsumer(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second}, &mockPinger{outcomes: []bool{true}})
+	m.results = make(chan models.PingResult, 1)
+	db := &slowRecorder{delay: 20 * time.Millisecond}
+	m.db = db
+
+	m.wg.Add(1)
+	go m.processResults()
+
+	// Pinging is far faster than saving, so the channel is full almost every time
+	const pings = 10
+	for i := 0; i < pings; i++ {
+		if _, recorded := m.performPing("8.8.8.8"); !recorded {
+			t.Fatalf("ping %d was not recorded", i)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for db.count() < pings && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	m.cancel()
+	m.wg.Wait()
+
+	if got := db.count(); got != pings {
+		t.Errorf("database saved %d results, want %d", got, pings)
+	}
+	if dropped := m.Status().ResultsDropped; dropped != 0 {
+		t.Errorf("ResultsDropped = %d, want 0", dropped)
+	}
+}
+
+func TestSendResultDropsAfterWait(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second}, &mockPinger{outcomes: []bool{true}})
+	defer m.cancel()
+	m.results = make(chan models.PingResult, 1)
+	m.resultWait = 20 * time.Millisecond
+
+	// Nothing consumes results, so the second send waits and then drops
+	result := models.PingResult{Timestamp: time.Now(), Target: "8.8.8.8", Success: true}
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		if !m.sendResult(result) {
+			t.Fatalf("sendResult() = false while the monitor is running")
+		}
+		if i == 1 && time.Since(start) < m.resultWait {
+			t.Errorf("dropped after %v, want a wait of at least %v", time.Since(start), m.resultWait)
+		}
+	}
+
+	if dropped := m.Status().ResultsDropped; dropped != 1 {
+		t.Errorf("ResultsDropped = %d, want 1", dropped)
+	}
+}
+
+func TestSendResultStopsOnCancel(t *testing.T) {
+	m := newTestMonitor(config.Config{Timeout: time.Second}, &mockPinger{outcomes: []bool{true}})
+	m.results = make(chan models.PingResult, 1)
+	m.results <- models.PingResult{}
+
+	time.AfterFunc(20*time.Millisecond, m.cancel)
+	start := time.Now()
+	if m.sendResult(models.PingResult{Target: "8.8.8.8"}) {
+		t.Error("sendResult() = true after the monitor was cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("sendResult took %v to notice cancellation", elapsed)
+	}
+	if dropped := m.Status().ResultsDropped; dropped != 0 {
+		t.Errorf("ResultsDropped = %d, want 0 for a shutdown", dropped)
+	}
+}
+
+func TestStartOffsetsSpreadAcrossInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		targets   int
+		noStagger bool
+		want      []time.Duration
+	}{
+		{"single target", 1, false, []time.Duration{0}},
+		{"four targets", 4, false, []time.Duration{0, 15 * time.Second, 30 * time.Second, 45 * time.Second}},
+		{"three targets", 3, false, []time.Duration{0, 20 * time.Second, 40 * time.Second}},
+		{"staggering off", 4, true, []time.Duration{0, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor(config.Config{Interval: time.Minute, NoStagger: tt.noStagger}, &mockPinger{})
+			for i, want := range tt.want {
+				if got := m.startOffset(i, tt.targets); got != want {
+					t.Errorf("startOffset(%d, %d) = %v, want %v", i, tt.targets, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPingWorkerWaitsForOffset(t *testing.T) {
+	m := newTestMonitor(config.Config{Interval: time.Minute}, &mockPinger{outcomes: []bool{true}})
+
+	m.wg.Add(1)
+	done := make(chan struct{})
+	go func() {
+		m.pingWorker("8.8.8.8", time.Hour)
+		close(done)
+	}()
+
+	select {
+	case <-m.jobs:
+		t.Fatal("ping queued before the start offset")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	m.cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker didn't stop while waiting for its start offset")
+	}
+}
+
+// savedResultsDB counts the results saved for each target
+type savedResultsDB struct {
+	models.Database
+	mu    sync.Mutex
+	saved map[string]int
+}
+
+func (db *savedResultsDB) GetLastTimestamp(ctx context.Context) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (db *savedResultsDB) GetCurrent(ctx context.Context) ([]models.CurrentStatus, error) {
+	return nil, nil
+}
+
+func (db *savedResultsDB) SaveResult(ctx context.Context, result models.PingResult) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.saved[result.Target]++
+	return nil
+}
+
+func (db *savedResultsDB) counts() map[string]int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return maps.Clone(db.saved)
+}
+
+func TestStartIgnoresDuplicateTargets(t *testing.T) {
+	db := &savedResultsDB{saved: make(map[string]int)}
+	cfg := config.Config{
+		Targets:     []string{"8.8.8.8", "1.1.1.1", "8.8.8.8"},
+		Interval:    time.Hour, // each worker pings once, straight away
+		Timeout:     time.Second,
+		Maintenance: time.Hour,
+		NoStagger:   true,
+	}
+	m := New(cfg, db, &mockPinger{outcomes: []bool{true}})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(db.counts()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Give a second worker for 8.8.8.8 time to ping too, if one was started
+	time.Sleep(50 * time.Millisecond)
+	m.Stop()
+	m.Wait()
+
+	want := map[string]int{"8.8.8.8": 1, "1.1.1.1": 1}
+	if got := db.counts(); !maps.Equal(got, want) {
+		t.Errorf("saved results per target = %v, want %v", got, want)
+	}
+	if got := m.poolSize(); got != 2 {
+		t.Errorf("pool size = %d, want 2 for the distinct targets", got)
+	}
+}
@@ -0,0 +1,174 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"network-monitor/internal/models"
+)
+
+// defaultDNSPort is used for dns:// targets that don't name a port
+const defaultDNSPort = "53"
+
+// DNSPinger probes a resolver by sending it an A query over UDP. The time to
+// the response is recorded as the RTT, so a resolver that is pingable but not
+// answering queries shows up as down. A response saying the name doesn't
+// exist still counts as success, since the resolver answered; SERVFAIL, a
+// refusal or no response at all is a failure.
+type DNSPinger struct{}
+
+// Close does nothing: each query opens and closes its own connection
+func (DNSPinger) Close() error {
+	return nil
+}
+
+// Ping queries the resolver of a dns://resolver/name target
+func (DNSPinger) Ping(parent context.Context, target string, timeout time.Duration) (models.PingResult, error) {
+	result := models.PingResult{
+		Timestamp:  time.Now(),
+		Target:     target,
+		PacketLoss: 100,
+	}
+
+	spec, err := models.ParseTarget(target)
+	if err == nil && spec.Scheme != models.SchemeDNS {
+		err = fmt.Errorf("not a DNS target: %q", target)
+	}
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		result.FailureReason = models.FailureUnknown
+		return result, err
+	}
+
+	normalizedTimeout := normalizeTimeout(timeout)
+	ctx, cancel := context.WithTimeout(parent, normalizedTimeout)
+	defer cancel()
+
+	rtt, answer, err := queryA(ctx, spec)
+	if parent.Err() != nil {
+		result.ErrorMessage = "ping cancelled"
+		return result, parent.Err()
+	}
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		result.FailureReason = classifyDNSFailure(err)
+		if result.FailureReason == models.FailureTimeout {
+			result.ErrorMessage = fmt.Sprintf("DNS query timed out after %s", normalizedTimeout)
+		}
+		return result, err
+	}
+
+	result.Success = true
+	result.PacketLoss = 0
+	result.RTT = float64(rtt) / float64(time.Millisecond)
+	result.ResolvedIP = answer
+	return result, nil
+}
+
+// errDNSResponse is wrapped by errors for responses with a failure code
+var errDNSResponse = errors.New("DNS query failed")
+
+// queryA sends an A query for spec's name to its resolver and waits for the
+// matching response. It returns the time the exchange took and the first
+// address in the answer, if any.
+func queryA(ctx context.Context, spec models.Target) (time.Duration, string, error) {
+	name, err := dnsmessage.NewName(fqdn(spec.Query))
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid query name %q: %w", spec.Query, err)
+	}
+
+	id := uint16(rand.Intn(1 << 16))
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}},
+	}
+	packet, err := query.Pack()
+	if err != nil {
+		return 0, "", fmt.Errorf("build query: %w", err)
+	}
+
+	port := spec.Port
+	if port == "" {
+		port = defaultDNSPort
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(spec.Host, port))
+	if err != nil {
+		return 0, "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// Unblock the read if the monitor is stopped before the deadline
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	start := time.Now()
+	if _, err := conn.Write(packet); err != nil {
+		return 0, "", err
+	}
+
+	buf := make([]byte, 1232) // the EDNS buffer size resolvers settled on; plain UDP answers fit in 512
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return 0, "", err
+		}
+		rtt := time.Since(start)
+
+		var response dnsmessage.Message
+		// Skip stray packets, e.g. a late answer to an earlier query
+		if err := response.Unpack(buf[:n]); err != nil || !response.Response || response.ID != id {
+			continue
+		}
+
+		switch response.RCode {
+		case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+			return rtt, firstA(response.Answers), nil
+		default:
+			return 0, "", fmt.Errorf("%w: %s", errDNSResponse, strings.TrimPrefix(response.RCode.String(), "RCode"))
+		}
+	}
+}
+
+// firstA returns the first IPv4 address in answers, or "" if there is none
+func firstA(answers []dnsmessage.Resource) string {
+	for _, answer := range answers {
+		if a, ok := answer.Body.(*dnsmessage.AResource); ok {
+			return net.IP(a.A[:]).String()
+		}
+	}
+	return ""
+}
+
+// fqdn makes name fully qualified so the resolver doesn't apply search domains
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// classifyDNSFailure maps a failed DNS probe to a failure reason
+func classifyDNSFailure(err error) models.FailureReason {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.Is(err, context.DeadlineExceeded):
+		return models.FailureTimeout
+	case errors.Is(err, errDNSResponse), errors.As(err, &dnsErr):
+		// The resolver couldn't answer, or its own name didn't resolve
+		return models.FailureDNS
+	default:
+		// e.g. no route to the resolver, or ICMP port unreachable on Linux
+		return models.FailureUnreachable
+	}
+}
@@ -0,0 +1,161 @@
+package ping
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"network-monitor/internal/models"
+)
+
+// mockResolver answers A queries on a local UDP port. With rcode
+// RCodeSuccess it answers 192.0.2.1; silent drops queries so they time out.
+type mockResolver struct {
+	conn   net.PacketConn
+	rcode  dnsmessage.RCode
+	silent bool
+}
+
+func newMockResolver(t *testing.T, rcode dnsmessage.RCode, silent bool) *mockResolver {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	r := &mockResolver{conn: conn, rcode: rcode, silent: silent}
+	t.Cleanup(func() { conn.Close() })
+	go r.serve()
+	return r
+}
+
+// target returns a dns:// target querying name on the mock resolver
+func (r *mockResolver) target(name string) string {
+	return "dns://" + r.conn.LocalAddr().String() + "/" + name
+}
+
+func (r *mockResolver) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := r.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if r.silent {
+			continue
+		}
+
+		var query dnsmessage.Message
+		if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) != 1 {
+			continue
+		}
+		response := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true, RCode: r.rcode},
+			Questions: query.Questions,
+		}
+		if r.rcode == dnsmessage.RCodeSuccess {
+			response.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: query.Questions[0].Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}},
+			}}
+		}
+		packet, err := response.Pack()
+		if err != nil {
+			continue
+		}
+		r.conn.WriteTo(packet, addr)
+	}
+}
+
+func TestDNSPingerPing(t *testing.T) {
+	tests := []struct {
+		name        string
+		rcode       dnsmessage.RCode
+		silent      bool
+		wantSuccess bool
+		wantIP      string
+		wantReason  models.FailureReason
+	}{
+		{name: "answer", rcode: dnsmessage.RCodeSuccess, wantSuccess: true, wantIP: "192.0.2.1"},
+		{name: "name does not exist", rcode: dnsmessage.RCodeNameError, wantSuccess: true},
+		{name: "SERVFAIL", rcode: dnsmessage.RCodeServerFailure, wantReason: models.FailureDNS},
+		{name: "refused", rcode: dnsmessage.RCodeRefused, wantReason: models.FailureDNS},
+		{name: "no response", silent: true, wantReason: models.FailureTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := newMockResolver(t, tt.rcode, tt.silent)
+			target := resolver.target("example.com")
+
+			result, err := DNSPinger{}.Ping(context.Background(), target, 200*time.Millisecond)
+			if (err == nil) != tt.wantSuccess || result.Success != tt.wantSuccess {
+				t.Fatalf("Ping() success = %v, error = %v; want success %v", result.Success, err, tt.wantSuccess)
+			}
+			if result.Target != target {
+				t.Errorf("Target = %q, want %q", result.Target, target)
+			}
+			if result.ResolvedIP != tt.wantIP {
+				t.Errorf("ResolvedIP = %q, want %q", result.ResolvedIP, tt.wantIP)
+			}
+			if result.FailureReason != tt.wantReason {
+				t.Errorf("FailureReason = %q, want %q", result.FailureReason, tt.wantReason)
+			}
+			if tt.wantSuccess && (result.RTT <= 0 || result.PacketLoss != 0) {
+				t.Errorf("RTT = %v, PacketLoss = %v; want a positive RTT and no loss", result.RTT, result.PacketLoss)
+			}
+			if !tt.wantSuccess && (result.PacketLoss != 100 || result.ErrorMessage == "") {
+				t.Errorf("PacketLoss = %v, ErrorMessage = %q; want 100 and a message", result.PacketLoss, result.ErrorMessage)
+			}
+		})
+	}
+}
+
+func TestDNSPingerPingCancelled(t *testing.T) {
+	resolver := newMockResolver(t, 0, true)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	result, err := DNSPinger{}.Ping(ctx, resolver.target("example.com"), 5*time.Second)
+	if err != context.Canceled {
+		t.Fatalf("Ping() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Ping() took %v after cancellation", elapsed)
+	}
+	if result.Success || result.FailureReason != "" {
+		t.Errorf("cancelled probe recorded as success %v, reason %q", result.Success, result.FailureReason)
+	}
+}
+
+// recordingPinger records the targets it was asked to ping
+type recordingPinger struct {
+	targets []string
+}
+
+func (p *recordingPinger) Ping(ctx context.Context, target string, timeout time.Duration) (models.PingResult, error) {
+	p.targets = append(p.targets, target)
+	return models.PingResult{Target: target, Success: true}, nil
+}
+
+func (p *recordingPinger) Close() error { return nil }
+
+func TestRouterRoutesByScheme(t *testing.T) {
+	resolver := newMockResolver(t, dnsmessage.RCodeSuccess, false)
+	icmp := &recordingPinger{}
+	router := NewRouter(icmp)
+
+	for _, target := range []string{"8.8.8.8", "8.8.8.8 via eth0", resolver.target("example.com")} {
+		result, err := router.Ping(context.Background(), target, time.Second)
+		if err != nil || !result.Success {
+			t.Errorf("Ping(%q) success = %v, error = %v", target, result.Success, err)
+		}
+	}
+	if len(icmp.targets) != 2 || icmp.targets[0] != "8.8.8.8" || icmp.targets[1] != "8.8.8.8 via eth0" {
+		t.Errorf("ICMP pinger got %q, want only the plain targets", icmp.targets)
+	}
+}
@@ -0,0 +1,78 @@
+package ping
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+)
+
+// DefaultGateway returns the IPv4 address of the default gateway, read from
+// the platform's routing table command
+func DefaultGateway(ctx context.Context) (string, error) {
+	name, args := gatewayCommand(runtime.GOOS)
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("read routing table with %s: %w", name, err)
+	}
+	return parseGateway(runtime.GOOS, string(output))
+}
+
+// gatewayCommand returns the command that prints the default route on goos
+func gatewayCommand(goos string) (string, []string) {
+	switch goos {
+	case "windows":
+		return "route", []string{"print", "-4", "0.0.0.0"}
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		return "route", []string{"-n", "get", "default"}
+	default:
+		return "ip", []string{"-4", "route", "show", "default"}
+	}
+}
+
+var (
+	// Linux: "default via 192.168.1.1 dev eth0 proto dhcp metric 100"
+	linuxGatewayPattern = regexp.MustCompile(`(?m)^default via (\S+)`)
+	// macOS and BSD: "    gateway: 192.168.1.1"
+	bsdGatewayPattern = regexp.MustCompile(`(?m)^\s*gateway:\s*(\S+)`)
+	// Windows active routes: "0.0.0.0  0.0.0.0  192.168.1.1  192.168.1.100  25"
+	windowsGatewayPattern = regexp.MustCompile(`(?m)^\s*0\.0\.0\.0\s+0\.0\.0\.0\s+(\S+)\s+\S+\s+(\d+)\s*$`)
+)
+
+// parseGateway extracts the default gateway from gatewayCommand's output on
+// goos. With several default routes Linux lists the preferred one first, and
+// on Windows the one with the lowest metric wins. Routes without a gateway
+// address, such as a VPN's "On-link" route, are skipped.
+func parseGateway(goos, output string) (string, error) {
+	var candidates []string
+	switch goos {
+	case "windows":
+		bestMetric := -1
+		for _, m := range windowsGatewayPattern.FindAllStringSubmatch(output, -1) {
+			metric, _ := strconv.Atoi(m[2])
+			if net.ParseIP(m[1]) == nil || (bestMetric >= 0 && metric >= bestMetric) {
+				continue
+			}
+			bestMetric = metric
+			candidates = []string{m[1]}
+		}
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		for _, m := range bsdGatewayPattern.FindAllStringSubmatch(output, -1) {
+			candidates = append(candidates, m[1])
+		}
+	default:
+		for _, m := range linuxGatewayPattern.FindAllStringSubmatch(output, -1) {
+			candidates = append(candidates, m[1])
+		}
+	}
+
+	for _, gateway := range candidates {
+		if net.ParseIP(gateway) != nil {
+			return gateway, nil
+		}
+	}
+	return "", fmt.Errorf("no default gateway in routing table")
+}
@@ -0,0 +1,88 @@
+package ping
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+const linuxRoutes = `default via 192.168.1.1 dev wlan0 proto dhcp src 192.168.1.23 metric 600
+default via 10.0.0.1 dev eth1 proto static metric 700
+`
+
+const darwinRoute = `   route to: default
+destination: default
+       mask: default
+    gateway: 192.168.0.254
+  interface: en0
+      flags: <UP,GATEWAY,DONE,STATIC,PRCLONING>
+ recvpipe  sendpipe  ssthresh  rtt,msec    rttvar  hopcount      mtu     expire
+       0         0         0         0         0         0      1500         0
+`
+
+const windowsRoutes = `===========================================================================
+Interface List
+ 12...00 15 5d 01 02 03 ......Microsoft Hyper-V Network Adapter
+  1...........................Software Loopback Interface 1
+===========================================================================
+
+IPv4 Route Table
+===========================================================================
+Active Routes:
+Network Destination        Netmask          Gateway       Interface  Metric
+          0.0.0.0          0.0.0.0         On-link      10.8.0.2      5
+          0.0.0.0          0.0.0.0      10.10.0.1    10.10.0.57     35
+          0.0.0.0          0.0.0.0    172.16.20.1   172.16.20.14     25
+===========================================================================
+Persistent Routes:
+  Network Address          Netmask  Gateway Address  Metric
+          0.0.0.0          0.0.0.0    172.16.20.254  Default
+===========================================================================
+`
+
+func TestParseGateway(t *testing.T) {
+	tests := []struct {
+		name    string
+		goos    string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{"linux", "linux", linuxRoutes, "192.168.1.1", false},
+		{"linux point-to-point only", "linux", "default dev ppp0 scope link\n", "", true},
+		{"linux no default route", "linux", "", "", true},
+		{"darwin", "darwin", darwinRoute, "192.168.0.254", false},
+		{"freebsd", "freebsd", darwinRoute, "192.168.0.254", false},
+		{"darwin no default route", "darwin", "route: writing to routing socket: not in table\n", "", true},
+		{"windows lowest metric", "windows", windowsRoutes, "172.16.20.1", false},
+		{"windows on-link only", "windows", "          0.0.0.0          0.0.0.0         On-link      10.8.0.2      5\n", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGateway(tt.goos, tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGateway() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseGateway() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterPingsAliasUnderItsName(t *testing.T) {
+	icmp := &recordingPinger{}
+	router := NewRouter(icmp)
+	router.SetAlias(models.TargetGateway, "192.168.1.1")
+
+	result, err := router.Ping(context.Background(), models.TargetGateway, time.Second)
+	if err != nil || result.Target != models.TargetGateway {
+		t.Errorf("Ping() target = %q, error = %v, want %q", result.Target, err, models.TargetGateway)
+	}
+	if len(icmp.targets) != 1 || icmp.targets[0] != "192.168.1.1" {
+		t.Errorf("ICMP pinger got %q, want the gateway address", icmp.targets)
+	}
+}
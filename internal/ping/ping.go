@@ -2,7 +2,10 @@ package ping
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
 	"os/exec"
 	"regexp"
 	"runtime"
@@ -13,56 +16,155 @@ import (
 	"network-monitor/internal/models"
 )
 
+// DefaultBinary is the ping executable used unless Config.Binary is set
+const DefaultBinary = "ping"
+
+// maxCapturedOutput bounds the raw output kept on a result with
+// Config.CaptureOutput; a single ping's output is far shorter
+const maxCapturedOutput = 4096
+
+// Config selects the ping command. Args are placed before the platform's own
+// arguments, e.g. "-I eth0" to ping out of a specific interface.
+// CaptureOutput keeps the command's output on results that failed or whose RTT
+// couldn't be parsed, for debugging.
+type Config struct {
+	Binary        string
+	Args          []string
+	CaptureOutput bool
+}
+
 // Pinger implements the Pinger interface
-type Pinger struct{}
+type Pinger struct {
+	binary        string   // ping executable to run
+	extraArgs     []string // prepended to the platform arguments
+	captureOutput bool     // set RawOutput on failed or unparseable results
+}
 
-// New creates a new Pinger
-func New() *Pinger {
-	return &Pinger{}
+// New creates a Pinger, checking that the ping binary can be found
+func New(cfg Config) (*Pinger, error) {
+	binary := cfg.Binary
+	if binary == "" {
+		binary = DefaultBinary
+	}
+	if err := CheckBinary(binary); err != nil {
+		return nil, err
+	}
+	return &Pinger{binary: binary, extraArgs: cfg.Args, captureOutput: cfg.CaptureOutput}, nil
 }
 
-// Ping executes a ping to the target and returns the result
-func (p *Pinger) Ping(target string, timeout time.Duration) (models.PingResult, error) {
+// CheckBinary verifies that binary, a name looked up on PATH or a path, can
+// be run as the ping command. Its error says what to do about it, since every
+// ping would otherwise fail with the same opaque exec error.
+func CheckBinary(binary string) error {
+	_, err := exec.LookPath(binary)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, exec.ErrNotFound):
+		return fmt.Errorf("ping binary %q not found on PATH: install ping (iputils-ping on Debian and Ubuntu, iputils on Alpine and Fedora) or set -ping-binary to its full path", binary)
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("ping binary %q does not exist: check the -ping-binary path", binary)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("ping binary %q is not executable: make it executable (chmod +x) or check the -ping-binary path", binary)
+	}
+	return fmt.Errorf("ping binary %q: %w", binary, err)
+}
+
+// Close does nothing: each ping runs its own command, so nothing is held open
+func (p *Pinger) Close() error {
+	return nil
+}
+
+// Ping executes a ping to the target and returns the result. The target may
+// name a source interface ("8.8.8.8 via eth0"). The ping is aborted early if
+// ctx is cancelled, in which case ctx's error is returned.
+func (p *Pinger) Ping(parent context.Context, target string, timeout time.Duration) (models.PingResult, error) {
 	result := models.PingResult{
 		Timestamp:  time.Now(),
 		Target:     target,
 		PacketLoss: 100,
 	}
 
+	spec, err := models.ParseTarget(target)
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		result.FailureReason = models.FailureUnknown
+		return result, err
+	}
+
 	normalizedTimeout := normalizeTimeout(timeout)
 	contextTimeout := normalizedTimeout + 500*time.Millisecond
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	ctx, cancel := context.WithTimeout(parent, contextTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ping", buildPingArgs(target, normalizedTimeout)...)
+	cmd := exec.CommandContext(ctx, p.binary, buildPingArgs(runtime.GOOS, p.extraArgs, spec, normalizedTimeout)...)
 	output, err := cmd.CombinedOutput()
 	outputStr := string(output)
 
+	if parent.Err() != nil {
+		result.ErrorMessage = "ping cancelled"
+		return result, parent.Err()
+	}
+
 	if ctx.Err() == context.DeadlineExceeded {
 		result.ErrorMessage = fmt.Sprintf("ping timed out after %s", normalizedTimeout)
+		result.FailureReason = models.FailureTimeout
 		return result, ctx.Err()
 	}
 
+	// Known even when no reply came back, as long as the name resolved
+	result.ResolvedIP, result.TTL = parseReplyDetails(outputStr)
+
 	if err != nil {
 		result.ErrorMessage = strings.TrimSpace(outputStr)
 		if result.ErrorMessage == "" {
 			result.ErrorMessage = err.Error()
 		}
+		result.FailureReason = classifyFailure(outputStr)
+		p.capture(&result, outputStr)
 		return result, err
 	}
 
-	rtt := parsePingOutput(outputStr)
-	if rtt <= 0 {
-		result.ErrorMessage = "unable to parse round-trip time"
-		return result, fmt.Errorf("unable to parse ping output: %s", strings.TrimSpace(outputStr))
+	// Windows ping exits 0 when a router answers "Destination host unreachable"
+	if classifyFailure(outputStr) == models.FailureUnreachable {
+		result.ErrorMessage = strings.TrimSpace(outputStr)
+		result.FailureReason = models.FailureUnreachable
+		p.capture(&result, outputStr)
+		return result, fmt.Errorf("destination unreachable")
 	}
 
+	// A zero exit status means the host answered. If the output is in a format
+	// we don't recognize (other locales, less common ping variants), keep the
+	// success and leave RTT at 0 rather than counting it as packet loss.
 	result.Success = true
 	result.PacketLoss = 0
-	result.RTT = rtt
+	if loss, ok := parsePacketLoss(outputStr); ok && loss < 100 {
+		result.PacketLoss = loss
+	}
+	result.RTT = parsePingOutput(outputStr)
+	if result.RTT <= 0 {
+		result.RTT = 0
+		result.ErrorMessage = "reply received but round-trip time could not be parsed"
+		p.capture(&result, outputStr)
+	}
 	return result, nil
 }
 
+// capture keeps output on the result when output capture is on
+func (p *Pinger) capture(result *models.PingResult, output string) {
+	if p.captureOutput {
+		result.RawOutput = truncateOutput(output)
+	}
+}
+
+// truncateOutput cuts output to maxCapturedOutput bytes, keeping it valid UTF-8
+func truncateOutput(output string) string {
+	if len(output) <= maxCapturedOutput {
+		return output
+	}
+	return strings.ToValidUTF8(output[:maxCapturedOutput], "")
+}
+
 func normalizeTimeout(timeout time.Duration) time.Duration {
 	if timeout <= 0 {
 		return time.Second
@@ -70,45 +172,134 @@ func normalizeTimeout(timeout time.Duration) time.Duration {
 	return timeout
 }
 
-func buildPingArgs(target string, timeout time.Duration) []string {
-	switch runtime.GOOS {
+// buildPingArgs returns the arguments for a single ping on goos, with extra
+// user-supplied arguments first. Windows, macOS and FreeBSD take the timeout in
+// milliseconds, so sub-second timeouts are kept. Elsewhere -W takes whole
+// seconds: only recent iputils accepts fractions, and busybox rejects them, so
+// the timeout is rounded up to the next second, which is at least 1.
+func buildPingArgs(goos string, extra []string, target models.Target, timeout time.Duration) []string {
+	args := append([]string(nil), extra...)
+	args = append(args, sourceArgs(goos, target.Via)...)
+	host := target.Host
+	switch goos {
 	case "windows":
 		ms := int(timeout / time.Millisecond)
 		if ms < 1 {
 			ms = 1
 		}
-		return []string{"-n", "1", "-w", strconv.Itoa(ms), target}
-	case "darwin":
+		return append(args, "-n", "1", "-w", strconv.Itoa(ms), host)
+	case "darwin", "freebsd", "dragonfly":
 		ms := int(timeout / time.Millisecond)
 		if ms < 1 {
 			ms = 1
 		}
-		return []string{"-n", "-c", "1", "-W", strconv.Itoa(ms), target}
+		return append(args, "-n", "-c", "1", "-W", strconv.Itoa(ms), host)
 	default:
 		secs := int((timeout + time.Second - 1) / time.Second)
 		if secs < 1 {
 			secs = 1
 		}
-		return []string{"-n", "-c", "1", "-W", strconv.Itoa(secs), target}
+		return append(args, "-n", "-c", "1", "-W", strconv.Itoa(secs), host)
 	}
 }
 
-// parsePingOutput parses RTT from ping output
-func parsePingOutput(output string) float64 {
-	// Parse RTT from ping output
-	// macOS: "time=XX.X ms" or "round-trip min/avg/max/stddev = X.X/X.X/X.X/X.X ms"
-	// Linux: "time=XX.X ms" or "round-trip min/avg/max = X.X/X.X/X.X ms"
-	// Windows: "time=XXms" or "time<1ms"
+// sourceArgs binds the ping to a source interface or address. Linux's -I takes
+// either; Windows only accepts a source address, and macOS uses -b for interfaces.
+func sourceArgs(goos, via string) []string {
+	switch {
+	case via == "":
+		return nil
+	case goos == "windows":
+		return []string{"-S", via}
+	case goos == "darwin" && net.ParseIP(via) == nil:
+		return []string{"-b", via}
+	case goos == "darwin":
+		return []string{"-S", via}
+	default:
+		return []string{"-I", via}
+	}
+}
 
-	var patterns = []string{
-		`time=([0-9.]+)ms`,    // Windows: time=44ms or time=44.5ms (not time<1ms)
-		`time=([0-9.]+)\s*ms`, // macOS/Linux individual: time=44.347 ms
-		`round-trip min/avg/max/stddev = [0-9.]+/([0-9.]+)/[0-9.]+/[0-9.]+\s*ms`, // macOS summary: round-trip min/avg/max/stddev = 44.347/44.347/44.347/0.000 ms
-		`round-trip min/avg/max = [0-9.]+/([0-9.]+)/[0-9.]+\s*ms`,                // Linux summary: round-trip min/avg/max = 44.347/44.347/44.347 ms
+var (
+	// Resolved address in the header: "PING example.com (93.184.216.34)" on
+	// Linux/macOS, "Pinging example.com [93.184.216.34]" on Windows
+	headerAddressPattern = regexp.MustCompile(`(?mi)^(?:PING|Pinging)\s+\S+?\s*[(\[]([0-9A-Fa-f.:]+)[)\]]`)
+	// Reply source: "64 bytes from 8.8.8.8:" or "Reply from 8.8.8.8:"
+	replyAddressPattern = regexp.MustCompile(`(?i)\bfrom\s+([0-9A-Fa-f.:]*[0-9A-Fa-f]):`)
+	// Reply TTL: "ttl=117" (Linux/macOS), "TTL=117" (Windows), "hlim=57" (macOS IPv6)
+	ttlPattern = regexp.MustCompile(`(?i)\b(?:ttl|hlim)=(\d+)`)
+)
+
+// parseReplyDetails extracts the address the target resolved to and the TTL of
+// the first echo reply. Either is zero when the output doesn't include it.
+func parseReplyDetails(output string) (resolvedIP string, ttl int) {
+	if matches := ttlPattern.FindStringSubmatch(output); len(matches) > 1 {
+		ttl, _ = strconv.Atoi(matches[1])
 	}
 
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
+	if matches := headerAddressPattern.FindStringSubmatch(output); len(matches) > 1 && net.ParseIP(matches[1]) != nil {
+		return matches[1], ttl
+	}
+	// Windows omits the bracketed address when pinging an IP, so fall back to the
+	// reply's source; only for echo replies (which carry a TTL), as errors such as
+	// "Destination host unreachable" come from a router instead
+	if ttl > 0 {
+		if matches := replyAddressPattern.FindStringSubmatch(output); len(matches) > 1 && net.ParseIP(matches[1]) != nil {
+			resolvedIP = matches[1]
+		}
+	}
+	return resolvedIP, ttl
+}
+
+// failurePatterns map the error lines of Linux (iputils), macOS and Windows ping
+// to a failure reason, checked in order so the most specific reason wins
+var failurePatterns = []struct {
+	reason  models.FailureReason
+	pattern *regexp.Regexp
+}{
+	// Linux: "ping: unknown host", "Name or service not known", "Temporary failure
+	// in name resolution"; macOS: "cannot resolve example.invalid: Unknown host";
+	// Windows: "Ping request could not find host example.invalid."
+	{models.FailureDNS, regexp.MustCompile(`(?i)unknown host|name or service not known|failure in name resolution|no address associated with hostname|cannot resolve|could not find host`)},
+	// "Destination Host/Net/Port Unreachable", "Destination Host Prohibited",
+	// "connect: Network is unreachable", "No route to host", "Packet filtered"
+	// (Linux); "Communication prohibited by filter" (macOS); "Destination host
+	// unreachable." and "Destination net unreachable." (Windows)
+	{models.FailureUnreachable, regexp.MustCompile(`(?i)unreachable|prohibited|no route to host|packet filtered`)},
+	// Windows: "Request timed out."; macOS: "Request timeout for icmp_seq 0"
+	{models.FailureTimeout, regexp.MustCompile(`(?i)request timed out|request timeout`)},
+	// Linux: "1 packets transmitted, 0 received, 100% packet loss"; macOS:
+	// "100.0% packet loss"; Windows: "Sent = 1, Received = 0, Lost = 1 (100% loss)"
+	{models.FailureLoss, regexp.MustCompile(`(?i)\b100(?:\.0+)?% (?:packet )?loss`)},
+}
+
+// classifyFailure derives why a ping failed from its output
+func classifyFailure(output string) models.FailureReason {
+	for _, fp := range failurePatterns {
+		if fp.pattern.MatchString(output) {
+			return fp.reason
+		}
+	}
+	return models.FailureUnknown
+}
+
+// rttPatterns find the RTT in ping output, in order of preference: the
+// summary's average, which covers every reply when several packets are sent,
+// then the first reply's time
+var rttPatterns = []*regexp.Regexp{
+	// Windows summary: "Minimum = 14ms, Maximum = 16ms, Average = 15ms"
+	regexp.MustCompile(`Average = ([0-9.]+)ms`),
+	// macOS summary: "round-trip min/avg/max/stddev = 44.347/44.347/44.347/0.000 ms";
+	// Linux (iputils): "rtt min/avg/max/mdev = 12.3/12.3/12.3/0.000 ms";
+	// BusyBox: "round-trip min/avg/max = 12.3/12.3/12.3 ms"
+	regexp.MustCompile(`(?:round-trip|rtt) min/avg/max(?:/(?:stddev|mdev))? = [0-9.]+/([0-9.]+)/[0-9.]+(?:/[0-9.]+)?\s*ms`),
+	// Windows reply: "time=44ms" (not "time<1ms"); macOS/Linux reply: "time=44.347 ms"
+	regexp.MustCompile(`time=([0-9.]+)\s*ms`),
+}
+
+// parsePingOutput parses RTT from ping output
+func parsePingOutput(output string) float64 {
+	for _, re := range rttPatterns {
 		matches := re.FindStringSubmatch(output)
 		if len(matches) > 1 {
 			if rtt, err := strconv.ParseFloat(matches[1], 64); err == nil {
@@ -119,3 +310,30 @@ func parsePingOutput(output string) float64 {
 
 	return 0
 }
+
+var (
+	// Windows summary: "Packets: Sent = 4, Received = 3, Lost = 1 (25% loss)".
+	// The percentage is rounded, so the loss is worked out from the counts.
+	windowsLossPattern = regexp.MustCompile(`Sent = (\d+), Received = (\d+)`)
+	// Linux: "4 packets transmitted, 3 received, 25% packet loss"; macOS:
+	// "4 packets transmitted, 3 packets received, 25.0% packet loss"
+	unixLossPattern = regexp.MustCompile(`([0-9.]+)% packet loss`)
+)
+
+// parsePacketLoss parses the percentage of packets lost from the ping
+// statistics summary. ok is false when the output has no summary.
+func parsePacketLoss(output string) (loss float64, ok bool) {
+	if matches := windowsLossPattern.FindStringSubmatch(output); len(matches) > 2 {
+		sent, _ := strconv.Atoi(matches[1])
+		received, _ := strconv.Atoi(matches[2])
+		if sent > 0 && received <= sent {
+			return float64(sent-received) * 100 / float64(sent), true
+		}
+	}
+	if matches := unixLossPattern.FindStringSubmatch(output); len(matches) > 1 {
+		if loss, err := strconv.ParseFloat(matches[1], 64); err == nil {
+			return loss, true
+		}
+	}
+	return 0, false
+}
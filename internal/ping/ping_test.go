@@ -1,9 +1,18 @@
 package ping
 
 import (
+	"context"
+	"errors"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
 	"time"
+
+	"network-monitor/internal/models"
 )
 
 func TestParsePingOutput(t *testing.T) {
@@ -72,6 +81,21 @@ round-trip min/avg/max/stddev = 44.347/44.347/44.347/0.000 ms`,
 			output:   "64 bytes from 8.8.8.8: icmp_seq=0 ttl=118 time=5.2 ms",
 			expected: 5.2,
 		},
+		{
+			name: "Linux iputils summary uses the average",
+			output: `64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=10.0 ms
+64 bytes from 8.8.8.8: icmp_seq=2 ttl=118 time=14.0 ms
+
+--- 8.8.8.8 ping statistics ---
+2 packets transmitted, 2 received, 0% packet loss, time 1001ms
+rtt min/avg/max/mdev = 10.000/12.000/14.000/2.000 ms`,
+			expected: 12,
+		},
+		{
+			name:     "Windows summary uses the average",
+			output:   windowsPartialLoss,
+			expected: 15,
+		},
 	}
 
 	for _, tt := range tests {
@@ -84,6 +108,188 @@ round-trip min/avg/max/stddev = 44.347/44.347/44.347/0.000 ms`,
 	}
 }
 
+// windowsPartialLoss is Windows ping output for 4 packets with one lost
+const windowsPartialLoss = `
+Pinging 8.8.8.8 with 32 bytes of data:
+Reply from 8.8.8.8: bytes=32 time=14ms TTL=118
+Request timed out.
+Reply from 8.8.8.8: bytes=32 time=16ms TTL=118
+Reply from 8.8.8.8: bytes=32 time=15ms TTL=118
+
+Ping statistics for 8.8.8.8:
+    Packets: Sent = 4, Received = 3, Lost = 1 (25% loss),
+Approximate round trip times in milli-seconds:
+    Minimum = 14ms, Maximum = 16ms, Average = 15ms
+`
+
+func TestParsePacketLoss(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		wantLoss float64
+		wantOK   bool
+	}{
+		{name: "Windows partial loss", output: windowsPartialLoss, wantLoss: 25, wantOK: true},
+		{
+			// Windows rounds the percentage; the counts give the exact loss
+			name:     "Windows rounded percentage",
+			output:   "    Packets: Sent = 3, Received = 2, Lost = 1 (33% loss),",
+			wantLoss: 100.0 / 3,
+			wantOK:   true,
+		},
+		{name: "Windows no loss", output: "    Packets: Sent = 1, Received = 1, Lost = 0 (0% loss),", wantLoss: 0, wantOK: true},
+		{name: "Linux partial loss", output: "4 packets transmitted, 3 received, 25% packet loss, time 3004ms", wantLoss: 25, wantOK: true},
+		{name: "macOS no loss", output: "1 packets transmitted, 1 packets received, 0.0% packet loss", wantLoss: 0, wantOK: true},
+		{name: "No summary", output: "64 bytes from 8.8.8.8: icmp_seq=0 ttl=118 time=5.2 ms", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loss, ok := parsePacketLoss(tt.output)
+			if ok != tt.wantOK || loss != tt.wantLoss {
+				t.Errorf("parsePacketLoss() = %v, %v, want %v, %v", loss, ok, tt.wantLoss, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseReplyDetails(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		wantIP  string
+		wantTTL int
+	}{
+		{
+			name: "Linux hostname",
+			output: `PING dns.google (8.8.4.4) 56(84) bytes of data.
+64 bytes from 8.8.4.4: icmp_seq=1 ttl=117 time=12.3 ms`,
+			wantIP:  "8.8.4.4",
+			wantTTL: 117,
+		},
+		{
+			name: "Linux IPv6",
+			output: `PING 2001:4860:4860::8888(2001:4860:4860::8888) 56 data bytes
+64 bytes from 2001:4860:4860::8888: icmp_seq=1 ttl=58 time=9.87 ms`,
+			wantIP:  "2001:4860:4860::8888",
+			wantTTL: 58,
+		},
+		{
+			name: "macOS hostname",
+			output: `PING example.com (93.184.216.34): 56 data bytes
+64 bytes from 93.184.216.34: icmp_seq=0 ttl=56 time=44.347 ms`,
+			wantIP:  "93.184.216.34",
+			wantTTL: 56,
+		},
+		{
+			name: "macOS IPv6 hop limit",
+			output: `PING6(56=40+8+8 bytes) 2001:db8::1 --> 2001:4860:4860::8888
+16 bytes from 2001:4860:4860::8888, icmp_seq=0 hlim=57 time=10.2 ms`,
+			wantIP:  "",
+			wantTTL: 57,
+		},
+		{
+			name: "Windows hostname",
+			output: `Pinging dns.google [8.8.8.8] with 32 bytes of data:
+Reply from 8.8.8.8: bytes=32 time=15ms TTL=118`,
+			wantIP:  "8.8.8.8",
+			wantTTL: 118,
+		},
+		{
+			name: "Windows IP target",
+			output: `Pinging 1.1.1.1 with 32 bytes of data:
+Reply from 1.1.1.1: bytes=32 time<1ms TTL=59`,
+			wantIP:  "1.1.1.1",
+			wantTTL: 59,
+		},
+		{
+			name: "Windows unreachable reply from router",
+			output: `Pinging 192.0.2.1 with 32 bytes of data:
+Reply from 192.168.1.1: Destination host unreachable.`,
+			wantIP:  "",
+			wantTTL: 0,
+		},
+		{
+			name: "Linux timeout keeps the resolved address",
+			output: `PING example.com (93.184.216.34) 56(84) bytes of data.
+
+--- example.com ping statistics ---
+1 packets transmitted, 0 received, 100% packet loss, time 0ms`,
+			wantIP:  "93.184.216.34",
+			wantTTL: 0,
+		},
+		{
+			name:    "Unknown host",
+			output:  "ping: unknown host example.invalid",
+			wantIP:  "",
+			wantTTL: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, ttl := parseReplyDetails(tt.output)
+			if ip != tt.wantIP || ttl != tt.wantTTL {
+				t.Errorf("parseReplyDetails() = (%q, %d), want (%q, %d)", ip, ttl, tt.wantIP, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   models.FailureReason
+	}{
+		{"Linux unknown host", "ping: unknown host example.invalid", models.FailureDNS},
+		{"Linux name resolution", "ping: example.invalid: Temporary failure in name resolution", models.FailureDNS},
+		{"macOS unknown host", "ping: cannot resolve example.invalid: Unknown host", models.FailureDNS},
+		{"Windows unknown host", "Ping request could not find host example.invalid. Please check the name and try again.", models.FailureDNS},
+		{
+			name: "Linux host unreachable",
+			output: `PING 192.168.1.50 (192.168.1.50) 56(84) bytes of data.
+From 192.168.1.1 icmp_seq=1 Destination Host Unreachable
+
+--- 192.168.1.50 ping statistics ---
+1 packets transmitted, 0 received, +1 errors, 100% packet loss, time 0ms`,
+			want: models.FailureUnreachable,
+		},
+		{"Linux no route", "ping: connect: Network is unreachable", models.FailureUnreachable},
+		{"macOS filtered", "36 bytes from 10.0.0.1: Communication prohibited by filter", models.FailureUnreachable},
+		{"Windows host unreachable", "Reply from 192.168.1.1: Destination host unreachable.", models.FailureUnreachable},
+		{
+			name: "Windows timeout",
+			output: `Request timed out.
+
+Ping statistics for 192.0.2.1:
+    Packets: Sent = 1, Received = 0, Lost = 1 (100% loss),`,
+			want: models.FailureTimeout,
+		},
+		{"macOS timeout", "Request timeout for icmp_seq 0", models.FailureTimeout},
+		{
+			name: "Linux silent loss",
+			output: `PING 192.0.2.1 (192.0.2.1) 56(84) bytes of data.
+
+--- 192.0.2.1 ping statistics ---
+1 packets transmitted, 0 received, 100% packet loss, time 0ms`,
+			want: models.FailureLoss,
+		},
+		{"macOS silent loss", "1 packets transmitted, 0 packets received, 100.0% packet loss", models.FailureLoss},
+		{"Partial loss is not a failure pattern", "2 packets transmitted, 1 received, 50% packet loss", models.FailureUnknown},
+		{"Unrecognized output", "ping: permission denied (are you root?)", models.FailureUnknown},
+		{"Empty output", "", models.FailureUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyFailure(tt.output); got != tt.want {
+				t.Errorf("classifyFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPingerPing(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping ping integration test in short mode")
@@ -93,10 +299,13 @@ func TestPingerPing(t *testing.T) {
 		t.Skip("ping binary not available on PATH")
 	}
 
-	pinger := New()
+	pinger, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
 
 	// Test with a reliable target
-	result, err := pinger.Ping("127.0.0.1", 5*time.Second)
+	result, err := pinger.Ping(context.Background(), "127.0.0.1", 5*time.Second)
 	if err != nil {
 		t.Skipf("skipping due to unexpected ping failure: %v", err)
 	}
@@ -116,7 +325,7 @@ func TestPingerPing(t *testing.T) {
 	}
 
 	// Test with invalid target
-	result, err = pinger.Ping("invalid.host.that.does.not.exist", 2*time.Second)
+	result, err = pinger.Ping(context.Background(), "invalid.host.that.does.not.exist", 2*time.Second)
 	if err == nil {
 		t.Fatalf("Expected ping to invalid host to return an error")
 	}
@@ -129,3 +338,346 @@ func TestPingerPing(t *testing.T) {
 		t.Errorf("Expected RTT to be 0 for failed ping, got %v", result.RTT)
 	}
 }
+
+func TestBuildPingArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		goos    string
+		extra   []string
+		via     string
+		timeout time.Duration
+		want    []string
+	}{
+		{
+			name:    "linux rounds timeout up to seconds",
+			goos:    "linux",
+			timeout: 1500 * time.Millisecond,
+			want:    []string{"-n", "-c", "1", "-W", "2", "8.8.8.8"},
+		},
+		{
+			name:    "linux interface binding",
+			goos:    "linux",
+			extra:   []string{"-I", "eth0"},
+			timeout: time.Second,
+			want:    []string{"-I", "eth0", "-n", "-c", "1", "-W", "1", "8.8.8.8"},
+		},
+		{
+			name:    "darwin source address",
+			goos:    "darwin",
+			extra:   []string{"-S", "192.168.1.10"},
+			timeout: 1500 * time.Millisecond,
+			want:    []string{"-S", "192.168.1.10", "-n", "-c", "1", "-W", "1500", "8.8.8.8"},
+		},
+		{
+			name:    "windows source address",
+			goos:    "windows",
+			extra:   []string{"-S", "192.168.1.10"},
+			timeout: 2 * time.Second,
+			want:    []string{"-S", "192.168.1.10", "-n", "1", "-w", "2000", "8.8.8.8"},
+		},
+		{
+			name:    "linux via interface",
+			goos:    "linux",
+			via:     "wwan0",
+			timeout: time.Second,
+			want:    []string{"-I", "wwan0", "-n", "-c", "1", "-W", "1", "8.8.8.8"},
+		},
+		{
+			name:    "linux via after extra args",
+			goos:    "linux",
+			extra:   []string{"-4"},
+			via:     "eth0",
+			timeout: time.Second,
+			want:    []string{"-4", "-I", "eth0", "-n", "-c", "1", "-W", "1", "8.8.8.8"},
+		},
+		{
+			name:    "darwin via interface",
+			goos:    "darwin",
+			via:     "en1",
+			timeout: time.Second,
+			want:    []string{"-b", "en1", "-n", "-c", "1", "-W", "1000", "8.8.8.8"},
+		},
+		{
+			name:    "darwin via address",
+			goos:    "darwin",
+			via:     "10.0.0.2",
+			timeout: time.Second,
+			want:    []string{"-S", "10.0.0.2", "-n", "-c", "1", "-W", "1000", "8.8.8.8"},
+		},
+		{
+			name:    "windows via address",
+			goos:    "windows",
+			via:     "10.0.0.2",
+			timeout: time.Second,
+			want:    []string{"-S", "10.0.0.2", "-n", "1", "-w", "1000", "8.8.8.8"},
+		},
+		{
+			name:    "windows without extra args",
+			goos:    "windows",
+			timeout: time.Second,
+			want:    []string{"-n", "1", "-w", "1000", "8.8.8.8"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildPingArgs(tt.goos, tt.extra, models.Target{Host: "8.8.8.8", Via: tt.via}, tt.timeout)
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("buildPingArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPingArgsTimeout(t *testing.T) {
+	tests := []struct {
+		goos    string
+		flag    string
+		timeout time.Duration
+		want    string
+	}{
+		{"linux", "-W", 500 * time.Millisecond, "1"},
+		{"linux", "-W", 1500 * time.Millisecond, "2"},
+		{"linux", "-W", 5 * time.Second, "5"},
+		{"darwin", "-W", 500 * time.Millisecond, "500"},
+		{"darwin", "-W", 1500 * time.Millisecond, "1500"},
+		{"darwin", "-W", 5 * time.Second, "5000"},
+		{"freebsd", "-W", 500 * time.Millisecond, "500"},
+		{"freebsd", "-W", 1500 * time.Millisecond, "1500"},
+		{"freebsd", "-W", 5 * time.Second, "5000"},
+		{"windows", "-w", 500 * time.Millisecond, "500"},
+		{"windows", "-w", 1500 * time.Millisecond, "1500"},
+		{"windows", "-w", 5 * time.Second, "5000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos+" "+tt.timeout.String(), func(t *testing.T) {
+			args := buildPingArgs(tt.goos, nil, models.Target{Host: "8.8.8.8"}, tt.timeout)
+			i := slices.Index(args, tt.flag)
+			if i < 0 || i+1 >= len(args) {
+				t.Fatalf("buildPingArgs() = %q, want a %s argument", args, tt.flag)
+			}
+			if got := args[i+1]; got != tt.want {
+				t.Errorf("%s %s, want %s", tt.flag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPingArgsDoesNotModifyExtra(t *testing.T) {
+	extra := make([]string, 2, 10)
+	copy(extra, []string{"-I", "eth0"})
+
+	first := buildPingArgs("linux", extra, models.Target{Host: "8.8.8.8"}, time.Second)
+	second := buildPingArgs("linux", extra, models.Target{Host: "1.1.1.1"}, time.Second)
+	if first[len(first)-1] != "8.8.8.8" || second[len(second)-1] != "1.1.1.1" {
+		t.Errorf("arguments shared between calls: %q and %q", first, second)
+	}
+}
+
+func TestNewRejectsMissingBinary(t *testing.T) {
+	if _, err := New(Config{Binary: "network-monitor-no-such-ping"}); err == nil {
+		t.Fatal("expected an error for a ping binary that isn't on PATH")
+	}
+}
+
+func TestCheckBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bits don't apply on Windows")
+	}
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "ping")
+	if err := os.WriteFile(executable, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	plain := filepath.Join(dir, "not-executable")
+	if err := os.WriteFile(plain, []byte("#!/bin/sh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	tests := []struct {
+		name    string
+		binary  string
+		wantErr string // substring of the error; empty for none
+	}{
+		{"on PATH", "ping", ""},
+		{"full path", executable, ""},
+		{"not on PATH", "network-monitor-no-such-ping", "not found on PATH: install ping"},
+		{"missing path", filepath.Join(dir, "missing"), "does not exist"},
+		{"not executable", plain, "not executable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckBinary(tt.binary)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckBinary(%q) error = %v", tt.binary, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckBinary(%q) error = %v, want one saying %q", tt.binary, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPingerPingUnparseableOutput(t *testing.T) {
+	// echo exits 0 and prints its arguments, which look nothing like ping output
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo binary not available on PATH")
+	}
+
+	p := &Pinger{binary: "echo"}
+	result, err := p.Ping(context.Background(), "8.8.8.8", time.Second)
+	if err != nil {
+		t.Fatalf("Ping() error = %v, want nil for a successful exit", err)
+	}
+	if !result.Success {
+		t.Error("expected unparseable but successful ping to be recorded as a success")
+	}
+	if result.RTT != 0 {
+		t.Errorf("RTT = %v, want 0 when the output can't be parsed", result.RTT)
+	}
+	if result.PacketLoss != 0 {
+		t.Errorf("PacketLoss = %v, want 0", result.PacketLoss)
+	}
+	if result.ErrorMessage == "" {
+		t.Error("expected a diagnostic note explaining the missing RTT")
+	}
+}
+
+func TestPingerPingPartialLoss(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo binary not available on PATH")
+	}
+
+	// echo prints the Windows summary lines it is given as arguments
+	summary := "Packets: Sent = 4, Received = 3, Lost = 1 (25% loss), Minimum = 14ms, Maximum = 16ms, Average = 15ms"
+	p := &Pinger{binary: "echo", extraArgs: []string{summary}}
+	result, err := p.Ping(context.Background(), "8.8.8.8", time.Second)
+	if err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if !result.Success || result.PacketLoss != 25 || result.RTT != 15 {
+		t.Errorf("Ping() = success %v, loss %v, RTT %v; want success with 25%% loss at 15ms", result.Success, result.PacketLoss, result.RTT)
+	}
+}
+
+func TestPingerCaptureOutput(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo binary not available on PATH")
+	}
+
+	tests := []struct {
+		name    string
+		capture bool
+		extra   []string
+		want    string
+	}{
+		{"unparseable output captured", true, []string{"Antwort von 8.8.8.8: Zeit=12ms"}, "Antwort von 8.8.8.8: Zeit=12ms"},
+		{"capture off", false, []string{"Antwort von 8.8.8.8: Zeit=12ms"}, ""},
+		{"parsed reply not captured", true, []string{"64 bytes from 8.8.8.8: icmp_seq=1 ttl=117 time=12.3 ms"}, ""},
+		{"failure captured", true, []string{"Reply from 192.168.1.1: Destination host unreachable."}, "Reply from 192.168.1.1: Destination host unreachable."},
+		{"long output truncated", true, []string{strings.Repeat("x", 2*maxCapturedOutput)}, strings.Repeat("x", maxCapturedOutput)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pinger{binary: "echo", extraArgs: tt.extra, captureOutput: tt.capture}
+			result, _ := p.Ping(context.Background(), "8.8.8.8", time.Second)
+			// echo also prints the platform arguments after ours
+			if tt.want == "" && result.RawOutput != "" {
+				t.Errorf("RawOutput = %q, want none", result.RawOutput)
+			}
+			if tt.want != "" && !strings.HasPrefix(result.RawOutput, tt.want) {
+				t.Errorf("RawOutput = %q, want it to start with %q", result.RawOutput, tt.want)
+			}
+			if len(result.RawOutput) > maxCapturedOutput {
+				t.Errorf("RawOutput is %d bytes, want at most %d", len(result.RawOutput), maxCapturedOutput)
+			}
+		})
+	}
+}
+
+func TestPingerPingRejectsInvalidTarget(t *testing.T) {
+	// echo would exit 0, so a success means the target reached the command line
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo binary not available on PATH")
+	}
+
+	p := &Pinger{binary: "echo"}
+	for _, target := range []string{"-f", "--flood", "8.8.8.8 via -f", "host;reboot"} {
+		result, err := p.Ping(context.Background(), target, time.Second)
+		if err == nil || result.Success {
+			t.Errorf("Ping(%q) = success %v, error %v; want the target rejected", target, result.Success, err)
+		}
+	}
+}
+
+func TestPingerPingUnreachableWithZeroExit(t *testing.T) {
+	// Windows ping exits 0 when a router answers with an unreachable reply
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo binary not available on PATH")
+	}
+
+	p := &Pinger{binary: "echo", extraArgs: []string{"Reply from 192.168.1.1: Destination host unreachable."}}
+	result, err := p.Ping(context.Background(), "192.168.1.50", time.Second)
+	if err == nil {
+		t.Fatal("Ping() error = nil, want an error for an unreachable reply")
+	}
+	if result.Success {
+		t.Error("expected unreachable reply not to be recorded as a success")
+	}
+	if result.FailureReason != models.FailureUnreachable {
+		t.Errorf("FailureReason = %q, want %q", result.FailureReason, models.FailureUnreachable)
+	}
+}
+
+func TestPingerPingCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	// The ping is abandoned before the binary runs, so it needn't exist
+	result, err := (&Pinger{binary: DefaultBinary}).Ping(ctx, "192.0.2.1", 5*time.Second)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled ping took %v, expected it to return promptly", elapsed)
+	}
+	if result.Success {
+		t.Error("expected cancelled ping not to succeed")
+	}
+}
+
+func TestPingerPingCancelledInFlight(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping ping integration test in short mode")
+	}
+
+	if _, err := exec.LookPath("ping"); err != nil {
+		t.Skip("ping binary not available on PATH")
+	}
+
+	pinger, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	// 192.0.2.0/24 is TEST-NET-1 and should never answer
+	start := time.Now()
+	_, err = pinger.Ping(ctx, "192.0.2.1", 5*time.Second)
+	if !errors.Is(err, context.Canceled) {
+		t.Skipf("ping returned before cancellation: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("in-flight ping took %v to abort, expected it to stop promptly", elapsed)
+	}
+}
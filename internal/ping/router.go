@@ -0,0 +1,60 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// Router sends each target to the pinger for its probe type: dns:// targets
+// to a DNSPinger and plain hosts to the ICMP pinger
+type Router struct {
+	icmp    models.Pinger
+	schemes map[string]models.Pinger
+	aliases map[string]string // target name to the host pinged for it
+}
+
+// NewRouter creates a Router that pings plain hosts with icmp
+func NewRouter(icmp models.Pinger) *Router {
+	return &Router{
+		icmp:    icmp,
+		schemes: map[string]models.Pinger{models.SchemeDNS: DNSPinger{}},
+	}
+}
+
+// SetAlias pings host whenever target is asked for, keeping target as the
+// name results are stored under. Reference targets use it to give a detected
+// address a stable name.
+func (r *Router) SetAlias(target, host string) {
+	if r.aliases == nil {
+		r.aliases = make(map[string]string)
+	}
+	r.aliases[target] = host
+}
+
+// Ping probes target with the pinger for its scheme. Targets that don't parse
+// go to the ICMP pinger, which reports the error.
+func (r *Router) Ping(ctx context.Context, target string, timeout time.Duration) (models.PingResult, error) {
+	if host, ok := r.aliases[target]; ok {
+		result, err := r.icmp.Ping(ctx, host, timeout)
+		result.Target = target
+		return result, err
+	}
+	if spec, err := models.ParseTarget(target); err == nil {
+		if pinger, ok := r.schemes[spec.Scheme]; ok {
+			return pinger.Ping(ctx, target, timeout)
+		}
+	}
+	return r.icmp.Ping(ctx, target, timeout)
+}
+
+// Close closes the pinger of every probe type, returning their errors joined
+func (r *Router) Close() error {
+	errs := []error{r.icmp.Close()}
+	for _, pinger := range r.schemes {
+		errs = append(errs, pinger.Close())
+	}
+	return errors.Join(errs...)
+}
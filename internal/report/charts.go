@@ -4,117 +4,54 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/wcharczuk/go-chart/v2"
 	"github.com/wcharczuk/go-chart/v2/drawing"
 )
 
+// seriesData is a time series collected for one target
+type seriesData struct {
+	timestamps []time.Time
+	values     []float64
+}
+
+// outageBandColor shades outage periods behind the data series
+var outageBandColor = drawing.Color{R: 220, G: 53, B: 69, A: 50}
+
 func (g *Generator) generateLatencyChart(outputDir string, hours int) error {
-	query := `
-        SELECT timestamp, target, rtt_ms
-        FROM ping_results
-        WHERE success = 1
-        AND timestamp > datetime('now', '-' || ? || ' hours')
-        ORDER BY timestamp
-    `
-
-	rows, err := g.db.Query(query, hours)
+	results, err := g.recentResults(hours)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
 
 	// Group data by target
-	targetData := make(map[string]struct {
-		timestamps []time.Time
-		values     []float64
-	})
+	targetData := make(map[string]seriesData)
 
-	for rows.Next() {
-		var timestamp time.Time
-		var target string
-		var rtt float64
-
-		if err := rows.Scan(&timestamp, &target, &rtt); err != nil {
+	for _, r := range results {
+		if !r.HasRTT() {
 			continue
 		}
 
-		data := targetData[target]
-		data.timestamps = append(data.timestamps, timestamp)
-		data.values = append(data.values, rtt)
-		targetData[target] = data
+		data := targetData[r.Target]
+		data.timestamps = append(data.timestamps, r.Timestamp.In(g.location))
+		data.values = append(data.values, r.RTT)
+		targetData[r.Target] = data
+	}
+
+	outages, err := g.queryOutagePeriods(hours)
+	if err != nil {
+		return fmt.Errorf("failed to query outages: %w", err)
+	}
+	targetOutages := make(map[string][]outagePeriod)
+	for _, o := range outages {
+		targetOutages[o.Target] = append(targetOutages[o.Target], o)
 	}
 
 	// Create chart for each target
 	for target, data := range targetData {
-		graph := chart.Chart{
-			Title: fmt.Sprintf("Network Latency - %s", target),
-			TitleStyle: chart.Style{
-				FontSize: 16,
-			},
-			Background: chart.Style{
-				Padding: chart.Box{
-					Top:    20,
-					Left:   20,
-					Right:  20,
-					Bottom: 20,
-				},
-			},
-			Width:  1200,
-			Height: 400,
-			XAxis: chart.XAxis{
-				Name: "Time",
-				NameStyle: chart.Style{
-					FontSize: 12,
-				},
-				Style: chart.Style{
-					StrokeColor: drawing.ColorBlack,
-					FontSize:    10,
-				},
-				ValueFormatter: chart.TimeMinuteValueFormatter,
-			},
-			YAxis: chart.YAxis{
-				Name: "Latency (ms)",
-				NameStyle: chart.Style{
-					FontSize: 12,
-				},
-				Style: chart.Style{
-					StrokeColor: drawing.ColorBlack,
-					FontSize:    10,
-				},
-				GridMajorStyle: chart.Style{
-					StrokeColor: drawing.Color{R: 200, G: 200, B: 200, A: 255},
-					StrokeWidth: 1.0,
-				},
-			},
-			Series: []chart.Series{
-				chart.TimeSeries{
-					Name: target,
-					Style: chart.Style{
-						StrokeColor: chart.GetDefaultColor(0),
-						StrokeWidth: 2,
-					},
-					XValues: data.timestamps,
-					YValues: data.values,
-				},
-			},
-		}
-
-		// Add moving average
-		if len(data.values) > 10 {
-			ts := graph.Series[0].(chart.TimeSeries)
-			graph.Series = append(graph.Series, chart.SMASeries{
-				Name: "Moving Avg",
-				Style: chart.Style{
-					StrokeColor:     chart.GetDefaultColor(1),
-					StrokeWidth:     2,
-					StrokeDashArray: []float64{5, 5},
-				},
-				InnerSeries: ts,
-				Period:      10,
-			})
-		}
+		graph := latencyChart(target, data, targetOutages[target])
 
 		filename := filepath.Join(outputDir, fmt.Sprintf("latency_%s.png", sanitizeFilename(target)))
 		file, err := os.Create(filename)
@@ -132,54 +69,298 @@ func (g *Generator) generateLatencyChart(outputDir string, hours int) error {
 	return nil
 }
 
-func (g *Generator) generateAvailabilityChart(outputDir string, hours int) error {
-	query := `
-        WITH hourly_stats AS (
-            SELECT
-                strftime('%Y-%m-%d %H:00:00', timestamp) as hour,
-                target,
-                COUNT(*) as total,
-                SUM(CASE WHEN success THEN 1 ELSE 0 END) as successful
-            FROM ping_results
-            WHERE timestamp > datetime('now', '-' || ? || ' hours')
-            GROUP BY hour, target
-            ORDER BY hour
-        )
-        SELECT
-            hour,
-            target,
-            (CAST(successful AS REAL) / total) * 100 as uptime_percent
-        FROM hourly_stats
-    `
-
-	rows, err := g.db.Query(query, hours)
+// latencyChart builds the latency chart for a target with its outages shaded
+func latencyChart(target string, data seriesData, outages []outagePeriod) chart.Chart {
+	ts := chart.TimeSeries{
+		Name: target,
+		Style: chart.Style{
+			StrokeColor: chart.GetDefaultColor(0),
+			StrokeWidth: 2,
+		},
+		XValues: data.timestamps,
+		YValues: data.values,
+	}
+
+	top := 0.0
+	for _, v := range data.values {
+		top = max(top, v)
+	}
+
+	graph := chart.Chart{
+		Title: fmt.Sprintf("Network Latency - %s", target),
+		TitleStyle: chart.Style{
+			FontSize: 16,
+		},
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    20,
+				Left:   20,
+				Right:  20,
+				Bottom: 20,
+			},
+		},
+		Width:  1200,
+		Height: 400,
+		XAxis: chart.XAxis{
+			Name: "Time",
+			NameStyle: chart.Style{
+				FontSize: 12,
+			},
+			Style: chart.Style{
+				StrokeColor: drawing.ColorBlack,
+				FontSize:    10,
+			},
+			ValueFormatter: chart.TimeMinuteValueFormatter,
+		},
+		YAxis: chart.YAxis{
+			Name: "Latency (ms)",
+			NameStyle: chart.Style{
+				FontSize: 12,
+			},
+			Style: chart.Style{
+				StrokeColor: drawing.ColorBlack,
+				FontSize:    10,
+			},
+			// Fixed from zero: go-chart can't lay out a flat line (e.g. a steady LAN target)
+			// with an auto-fitted zero-height range
+			Range: &chart.ContinuousRange{
+				Min: 0,
+				Max: max(top*1.1, 1),
+			},
+			GridMajorStyle: chart.Style{
+				StrokeColor: drawing.Color{R: 200, G: 200, B: 200, A: 255},
+				StrokeWidth: 1.0,
+			},
+		},
+		// Outage bands go first so the latency line is drawn over them
+		Series: append(outageOverlays(outages, top, false), ts),
+	}
+
+	// Add moving average
+	if len(data.values) > 10 {
+		graph.Series = append(graph.Series, chart.SMASeries{
+			Name: "Moving Avg",
+			Style: chart.Style{
+				StrokeColor:     chart.GetDefaultColor(1),
+				StrokeWidth:     2,
+				StrokeDashArray: []float64{5, 5},
+			},
+			InnerSeries: ts,
+			Period:      10,
+		})
+	}
+
+	return graph
+}
+
+// comparisonFile is the chart overlaying every target's latency
+const comparisonFile = "latency_comparison.png"
+
+// generateComparisonChart draws every target's latency on one axis, so a
+// healthy gateway can be shown next to a struggling upstream
+func (g *Generator) generateComparisonChart(outputDir string, hours int) error {
+	results, err := g.recentResults(hours)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-
-	targetData := make(map[string]struct {
-		timestamps []time.Time
-		values     []float64
-	})
 
-	for rows.Next() {
-		var hourStr string
-		var target string
-		var uptime float64
+	// Average each target per minute; raw per-ping lines of several targets
+	// bury each other
+	type minuteRTT struct {
+		minute time.Time
+		sum    float64
+		count  int
+	}
+	buckets := make(map[string][]minuteRTT)
 
-		if scanErr := rows.Scan(&hourStr, &target, &uptime); scanErr != nil {
+	for _, r := range results {
+		if !r.HasRTT() {
 			continue
 		}
+		minute := r.Timestamp.In(g.location).Truncate(time.Minute)
+
+		b := buckets[r.Target]
+		if len(b) == 0 || !b[len(b)-1].minute.Equal(minute) {
+			b = append(b, minuteRTT{minute: minute})
+		}
+		b[len(b)-1].sum += r.RTT
+		b[len(b)-1].count++
+		buckets[r.Target] = b
+	}
+
+	targetData := make(map[string]seriesData)
+	for target, b := range buckets {
+		var data seriesData
+		for _, m := range b {
+			data.timestamps = append(data.timestamps, m.minute)
+			data.values = append(data.values, m.sum/float64(m.count))
+		}
+		targetData[target] = data
+	}
+	if len(targetData) == 0 {
+		return nil
+	}
+
+	outages, err := g.queryOutagePeriods(hours)
+	if err != nil {
+		return fmt.Errorf("failed to query outages: %w", err)
+	}
+
+	graph := comparisonChart(targetData, outages)
+
+	filename := filepath.Join(outputDir, comparisonFile)
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return graph.Render(chart.PNG, file)
+}
 
-		hour, _ := time.Parse("2006-01-02 15:04:05", hourStr)
+// comparisonChart overlays the targets' latency series with a legend, each
+// target in its own color, and every target's outages shaded
+func comparisonChart(targetData map[string]seriesData, outages []outagePeriod) chart.Chart {
+	// Sorted so a target keeps its color from one report to the next
+	targets := make([]string, 0, len(targetData))
+	for target := range targetData {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
 
+	var allSeries []chart.Series
+	top := 0.0
+	for i, target := range targets {
 		data := targetData[target]
-		data.timestamps = append(data.timestamps, hour)
-		data.values = append(data.values, uptime)
+		for _, v := range data.values {
+			top = max(top, v)
+		}
+		allSeries = append(allSeries, chart.TimeSeries{
+			Name: target,
+			Style: chart.Style{
+				StrokeColor: chart.GetDefaultColor(i),
+				StrokeWidth: 2,
+			},
+			XValues: data.timestamps,
+			YValues: data.values,
+		})
+	}
+
+	graph := chart.Chart{
+		Title: "Network Latency - All Targets (1-minute average)",
+		TitleStyle: chart.Style{
+			FontSize: 16,
+		},
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:    20,
+				Left:   20,
+				Right:  20,
+				Bottom: 20,
+			},
+		},
+		Width:  1200,
+		Height: 400,
+		XAxis: chart.XAxis{
+			Name: "Time",
+			NameStyle: chart.Style{
+				FontSize: 12,
+			},
+			Style: chart.Style{
+				StrokeColor: drawing.ColorBlack,
+				FontSize:    10,
+			},
+			ValueFormatter: chart.TimeMinuteValueFormatter,
+		},
+		YAxis: chart.YAxis{
+			Name: "Latency (ms)",
+			NameStyle: chart.Style{
+				FontSize: 12,
+			},
+			Style: chart.Style{
+				StrokeColor: drawing.ColorBlack,
+				FontSize:    10,
+			},
+			Range: &chart.ContinuousRange{
+				Min: 0,
+				Max: max(top*1.1, 1),
+			},
+			GridMajorStyle: chart.Style{
+				StrokeColor: drawing.Color{R: 200, G: 200, B: 200, A: 255},
+				StrokeWidth: 1.0,
+			},
+		},
+		Series: append(outageOverlays(outages, top, true), allSeries...),
+	}
+
+	// The legend only lists targets, not one entry per outage band
+	legend := graph
+	legend.Series = allSeries
+	graph.Elements = []chart.Renderable{
+		chart.Legend(&legend),
+	}
+
+	return graph
+}
+
+func (g *Generator) generateAvailabilityChart(outputDir string, hours int) error {
+	results, err := g.recentResults(hours)
+	if err != nil {
+		return err
+	}
+
+	// Bucket by hour in the report's time zone
+	type hourCount struct {
+		hour              time.Time
+		total, successful int
+	}
+	buckets := make(map[string][]hourCount)
+
+	for _, r := range results {
+		local := r.Timestamp.In(g.location)
+		hour := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, g.location)
+
+		b := buckets[r.Target]
+		if len(b) == 0 || !b[len(b)-1].hour.Equal(hour) {
+			b = append(b, hourCount{hour: hour})
+		}
+		b[len(b)-1].total++
+		if r.Success {
+			b[len(b)-1].successful++
+		}
+		buckets[r.Target] = b
+	}
+
+	targetData := make(map[string]seriesData)
+	for target, b := range buckets {
+		var data seriesData
+		for _, c := range b {
+			data.timestamps = append(data.timestamps, c.hour)
+			data.values = append(data.values, float64(c.successful)/float64(c.total)*100)
+		}
 		targetData[target] = data
 	}
 
+	outages, err := g.queryOutagePeriods(hours)
+	if err != nil {
+		return fmt.Errorf("failed to query outages: %w", err)
+	}
+
+	graph := availabilityChart(targetData, outages)
+
+	filename := filepath.Join(outputDir, "availability.png")
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return graph.Render(chart.PNG, file)
+}
+
+// availabilityChart builds the combined hourly availability chart with every
+// target's outages shaded
+func availabilityChart(targetData map[string]seriesData, outages []outagePeriod) chart.Chart {
 	// Combined availability chart
 	var allSeries []chart.Series
 	colorIndex := 0
@@ -235,88 +416,84 @@ func (g *Generator) generateAvailabilityChart(outputDir string, hours int) error
 				StrokeWidth: 1.0,
 			},
 		},
-		Series: allSeries,
+		Series: append(outageOverlays(outages, 100, true), allSeries...),
 	}
 
+	// The legend only lists targets, not one entry per outage band
+	legend := graph
+	legend.Series = allSeries
 	graph.Elements = []chart.Renderable{
-		chart.Legend(&graph),
+		chart.Legend(&legend),
 	}
 
-	filename := filepath.Join(outputDir, "availability.png")
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
+	return graph
+}
+
+// outageOverlays returns a shaded band per outage reaching up to top, followed by
+// an annotation series labelling where each outage started
+func outageOverlays(outages []outagePeriod, top float64, labelTarget bool) []chart.Series {
+	if len(outages) == 0 {
+		return nil
 	}
-	defer file.Close()
 
-	return graph.Render(chart.PNG, file)
+	var series []chart.Series
+	annotations := chart.AnnotationSeries{Name: "Outages"}
+	for _, o := range outages {
+		series = append(series, chart.TimeSeries{
+			Name: "Outage",
+			Style: chart.Style{
+				StrokeColor: drawing.ColorTransparent,
+				FillColor:   outageBandColor,
+			},
+			XValues: []time.Time{o.Start, o.End},
+			YValues: []float64{top, top},
+		})
+
+		label := fmt.Sprintf("Outage %s", o.End.Sub(o.Start).Round(time.Second))
+		if labelTarget {
+			label = fmt.Sprintf("%s: %s", o.Target, label)
+		}
+		annotations.Annotations = append(annotations.Annotations, chart.Value2{
+			XValue: chart.TimeToFloat64(o.Start),
+			YValue: top,
+			Label:  label,
+		})
+	}
+
+	return append(series, annotations)
 }
 
 func (g *Generator) generateOutageSummary(outputDir string, hours int) error {
-	// Query for outage periods
-	query := `
-        WITH outage_detection AS (
-            SELECT
-                target,
-                timestamp,
-                success,
-                LAG(success, 1, 1) OVER (PARTITION BY target ORDER BY timestamp) as prev_success,
-                LEAD(success, 1, 1) OVER (PARTITION BY target ORDER BY timestamp) as next_success
-            FROM ping_results
-            WHERE timestamp > datetime('now', '-' || ? || ' hours')
-        ),
-        outage_events AS (
-            SELECT
-                target,
-                timestamp,
-                CASE
-                    WHEN success = 0 AND prev_success = 1 THEN 'start'
-                    WHEN success = 0 AND next_success = 1 THEN 'end'
-                    WHEN success = 0 THEN 'ongoing'
-                END as event_type
-            FROM outage_detection
-            WHERE success = 0
-        )
-        SELECT * FROM outage_events
-        ORDER BY timestamp
-    `
-
-	rows, err := g.db.Query(query, hours)
+	results, err := g.recentResults(hours)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
 
-	type OutageEvent struct {
-		Target    string
-		Timestamp time.Time
-		EventType string
-	}
-
-	var events []OutageEvent
-	for rows.Next() {
-		var e OutageEvent
-		if err := rows.Scan(&e.Target, &e.Timestamp, &e.EventType); err != nil {
+	// Create bar chart showing failed checks by hour
+	hourlyOutages := make(map[string]int)
+	for _, r := range results {
+		if r.Success {
 			continue
 		}
-		events = append(events, e)
-	}
-
-	// Create bar chart showing outage count by hour
-	hourlyOutages := make(map[string]int)
-	for _, e := range events {
-		hour := e.Timestamp.Format("2006-01-02 15:00")
+		hour := r.Timestamp.In(g.location).Format("2006-01-02 15:00")
 		hourlyOutages[hour]++
 	}
 
 	if len(hourlyOutages) > 0 {
-		var values []chart.Value
+		hours := make([]string, 0, len(hourlyOutages))
+		for hour := range hourlyOutages {
+			hours = append(hours, hour)
+		}
+		sort.Strings(hours)
 
-		for hour, count := range hourlyOutages {
+		var values []chart.Value
+		peak := 0
+		for _, hour := range hours {
 			values = append(values, chart.Value{
 				Label: hour,
-				Value: float64(count),
+				Value: float64(hourlyOutages[hour]),
 			})
+			peak = max(peak, hourlyOutages[hour])
 		}
 
 		graph := chart.BarChart{
@@ -332,8 +509,16 @@ func (g *Generator) generateOutageSummary(outputDir string, hours int) error {
 					Bottom: 20,
 				},
 			},
-			Width:    1200,
-			Height:   400,
+			Width:  1200,
+			Height: 400,
+			// Anchor at zero: with equal counts in every hour (e.g. a single
+			// outage) go-chart can't auto-fit a range
+			YAxis: chart.YAxis{
+				Range: &chart.ContinuousRange{
+					Min: 0,
+					Max: float64(peak),
+				},
+			},
 			Bars:     values,
 			BarWidth: 40,
 		}
@@ -352,3 +537,107 @@ func (g *Generator) generateOutageSummary(outputDir string, hours int) error {
 
 	return nil
 }
+
+// latencyBuckets are the upper bounds (ms) of the histogram buckets; anything
+// above the last bound falls into a final open-ended bucket
+var latencyBuckets = []float64{5, 10, 20, 50, 100, 200, 500}
+
+// bucketLatencies returns the share of RTTs (as a percentage) falling into each
+// latency bucket, including empty buckets so the distribution keeps its shape
+func bucketLatencies(rtts []float64) []chart.Value {
+	counts := make([]int, len(latencyBuckets)+1)
+	for _, rtt := range rtts {
+		idx := len(latencyBuckets)
+		for i, upper := range latencyBuckets {
+			if rtt < upper {
+				idx = i
+				break
+			}
+		}
+		counts[idx]++
+	}
+
+	values := make([]chart.Value, len(counts))
+	lower := 0.0
+	for i, count := range counts {
+		var label string
+		if i < len(latencyBuckets) {
+			label = fmt.Sprintf("%g-%gms", lower, latencyBuckets[i])
+			lower = latencyBuckets[i]
+		} else {
+			label = fmt.Sprintf(">%gms", lower)
+		}
+
+		var percent float64
+		if len(rtts) > 0 {
+			percent = float64(count) / float64(len(rtts)) * 100
+		}
+		values[i] = chart.Value{Label: label, Value: percent}
+	}
+	return values
+}
+
+// generateLatencyHistogram renders the RTT distribution per target, which shows
+// bimodal latency (e.g. mostly 10ms with a tail above 200ms) that averages hide
+func (g *Generator) generateLatencyHistogram(outputDir string, hours int) error {
+	results, err := g.recentResults(hours)
+	if err != nil {
+		return err
+	}
+
+	targetRTTs := make(map[string][]float64)
+	for _, r := range results {
+		if r.HasRTT() {
+			targetRTTs[r.Target] = append(targetRTTs[r.Target], r.RTT)
+		}
+	}
+
+	for target, rtts := range targetRTTs {
+		graph := chart.BarChart{
+			Title: fmt.Sprintf("Latency Distribution - %s", target),
+			TitleStyle: chart.Style{
+				FontSize: 16,
+			},
+			Background: chart.Style{
+				Padding: chart.Box{
+					Top:    40,
+					Left:   20,
+					Right:  20,
+					Bottom: 20,
+				},
+			},
+			Width:    1200,
+			Height:   400,
+			BarWidth: 80,
+			XAxis: chart.Style{
+				FontSize: 10,
+			},
+			YAxis: chart.YAxis{
+				Name: "% of pings",
+				Style: chart.Style{
+					StrokeColor: drawing.ColorBlack,
+					FontSize:    10,
+				},
+				Range: &chart.ContinuousRange{
+					Min: 0,
+					Max: 100,
+				},
+			},
+			Bars: bucketLatencies(rtts),
+		}
+
+		filename := filepath.Join(outputDir, fmt.Sprintf("histogram_%s.png", sanitizeFilename(target)))
+		file, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+
+		if err := graph.Render(chart.PNG, file); err != nil {
+			file.Close()
+			return err
+		}
+		file.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+
+	"network-monitor/internal/database"
+	"network-monitor/internal/models"
+)
+
+func TestBucketLatencies(t *testing.T) {
+	// 95 fast pings and 5 slow ones: the bimodal case an average hides
+	var rtts []float64
+	for i := 0; i < 95; i++ {
+		rtts = append(rtts, 12)
+	}
+	for i := 0; i < 5; i++ {
+		rtts = append(rtts, 250)
+	}
+
+	values := bucketLatencies(rtts)
+	if len(values) != len(latencyBuckets)+1 {
+		t.Fatalf("got %d buckets, want %d", len(values), len(latencyBuckets)+1)
+	}
+
+	want := map[string]float64{
+		"10-20ms":   95,
+		"200-500ms": 5,
+		">500ms":    0,
+		"0-5ms":     0,
+	}
+	for _, v := range values {
+		if expected, ok := want[v.Label]; ok && v.Value != expected {
+			t.Errorf("bucket %s = %.1f%%, want %.1f%%", v.Label, v.Value, expected)
+		}
+	}
+
+	for _, v := range bucketLatencies(nil) {
+		if v.Value != 0 {
+			t.Errorf("empty input: bucket %s = %.1f%%, want 0", v.Label, v.Value)
+		}
+	}
+}
+
+func TestGenerateLatencyHistogram(t *testing.T) {
+	dir := t.TempDir()
+	db, err := database.New(filepath.Join(dir, "test.db"), "")
+	if err != nil {
+		t.Fatalf("database.New() error = %v", err)
+	}
+	defer db.Close()
+	if err := db.InitSchema(); err != nil {
+		t.Fatalf("InitSchema() error = %v", err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 100; i++ {
+		rtt := 10.0 + float64(i%5)
+		if i%20 == 0 {
+			rtt = 240
+		}
+		if err := db.SaveResult(context.Background(), models.PingResult{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Target:    "8.8.8.8",
+			Success:   true,
+			RTT:       rtt,
+		}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	g := NewGenerator(db, models.DefaultOutageThreshold, time.UTC)
+	if err := g.generateLatencyHistogram(dir, 24); err != nil {
+		t.Fatalf("generateLatencyHistogram() error = %v", err)
+	}
+
+	file, err := os.Open(filepath.Join(dir, "histogram_8_8_8_8.png"))
+	if err != nil {
+		t.Fatalf("histogram not written: %v", err)
+	}
+	defer file.Close()
+	if _, err := png.Decode(file); err != nil {
+		t.Errorf("histogram is not a valid PNG: %v", err)
+	}
+}
+
+// outageAnnotations returns the annotations of the chart's annotation series
+func outageAnnotations(t *testing.T, graph chart.Chart) []chart.Value2 {
+	t.Helper()
+
+	for _, s := range graph.Series {
+		if annotations, ok := s.(chart.AnnotationSeries); ok {
+			return annotations.Annotations
+		}
+	}
+	t.Fatal("chart has no annotation series")
+	return nil
+}
+
+func TestChartsShadeOutages(t *testing.T) {
+	start := time.Now().Add(-2 * time.Hour)
+	data := seriesData{}
+	for i := 0; i < 120; i++ {
+		data.timestamps = append(data.timestamps, start.Add(time.Duration(i)*time.Minute))
+		data.values = append(data.values, 15)
+	}
+	outages := []outagePeriod{
+		{Target: "8.8.8.8", Start: start.Add(30 * time.Minute), End: start.Add(40 * time.Minute), FailedChecks: 20},
+	}
+
+	t.Run("latency", func(t *testing.T) {
+		graph := latencyChart("8.8.8.8", data, outages)
+
+		annotations := outageAnnotations(t, graph)
+		if len(annotations) != 1 {
+			t.Fatalf("got %d annotations, want 1", len(annotations))
+		}
+		if annotations[0].XValue != chart.TimeToFloat64(outages[0].Start) {
+			t.Errorf("annotation at %v, want outage start", annotations[0].XValue)
+		}
+
+		var buf bytes.Buffer
+		if err := graph.Render(chart.PNG, &buf); err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+	})
+
+	t.Run("availability", func(t *testing.T) {
+		graph := availabilityChart(map[string]seriesData{"8.8.8.8": data}, outages)
+
+		annotations := outageAnnotations(t, graph)
+		if len(annotations) != 1 || annotations[0].Label != "8.8.8.8: Outage 10m0s" {
+			t.Fatalf("unexpected annotations: %+v", annotations)
+		}
+
+		var buf bytes.Buffer
+		if err := graph.Render(chart.PNG, &buf); err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+	})
+
+	t.Run("no outages", func(t *testing.T) {
+		graph := latencyChart("8.8.8.8", data, nil)
+		for _, s := range graph.Series {
+			if _, ok := s.(chart.AnnotationSeries); ok {
+				t.Fatal("expected no annotation series without outages")
+			}
+		}
+	})
+}
+
+func TestComparisonChart(t *testing.T) {
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	gateway, upstream := seriesData{}, seriesData{}
+	for i := 0; i < 60; i++ {
+		stamp := start.Add(time.Duration(i) * time.Minute)
+		gateway.timestamps = append(gateway.timestamps, stamp)
+		gateway.values = append(gateway.values, 1.5)
+		upstream.timestamps = append(upstream.timestamps, stamp)
+		upstream.values = append(upstream.values, 20+float64(i%10)*15)
+	}
+
+	graph := comparisonChart(map[string]seriesData{"8.8.8.8": upstream, "192.168.1.1": gateway}, nil)
+
+	var names []string
+	for _, s := range graph.Series {
+		if ts, ok := s.(chart.TimeSeries); ok {
+			names = append(names, ts.Name)
+		}
+	}
+	if len(names) != 2 || names[0] != "192.168.1.1" || names[1] != "8.8.8.8" {
+		t.Errorf("series = %v, want both targets in sorted order", names)
+	}
+	if yRange, ok := graph.YAxis.Range.(*chart.ContinuousRange); !ok || yRange.Max < 155 {
+		t.Errorf("Y axis range = %+v, want room for the slowest target", graph.YAxis.Range)
+	}
+
+	var buf bytes.Buffer
+	if err := graph.Render(chart.PNG, &buf); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if _, err := png.Decode(&buf); err != nil {
+		t.Errorf("comparison chart is not a valid PNG: %v", err)
+	}
+}
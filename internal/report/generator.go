@@ -1,53 +1,240 @@
 package report
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"network-monitor/internal/models"
+)
+
+// noDataFile explains, in a report directory, why the report has no charts
+const noDataFile = "NO_DATA.txt"
+
+// incompleteFile lists, in a report directory, the parts left out because
+// their queries timed out
+const incompleteFile = "INCOMPLETE.txt"
+
+// DefaultQueryTimeout is how long each of a report's database queries may run
+// until SetQueryTimeout is called
+const DefaultQueryTimeout = 5 * time.Minute
+
+// errQueryTimeout is returned for a report query cut off by the query timeout
+var errQueryTimeout = errors.New("query timed out")
+
+// Report output formats
+const (
+	FormatFiles = "files" // PNG charts and a text summary in a report directory
+	FormatPDF   = "pdf"   // a single PDF combining charts and statistics
+	FormatBoth  = "both"
 )
 
 // Generator creates static images and reports for ISP evidence
 type Generator struct {
-	db *sql.DB
+	db        models.Database
+	threshold models.OutageThreshold
+	location  *time.Location
+	terms     SLATerms
+	timeout   time.Duration // limit on each database query; 0 for none
+}
+
+// NewGenerator creates a new report generator that detects outages with the
+// given threshold and presents times in loc
+func NewGenerator(db models.Database, threshold models.OutageThreshold, loc *time.Location) *Generator {
+	return &Generator{db: db, threshold: threshold, location: loc, timeout: DefaultQueryTimeout}
+}
+
+// SetSLATerms has reports judge each target's availability against the SLA
+// and estimate the credit owed for a breach
+func (g *Generator) SetSLATerms(terms SLATerms) {
+	g.terms = terms
+}
+
+// SetQueryTimeout limits how long each database query behind a report may
+// run, so a report over a busy database can't hang. The parts whose queries
+// time out are left out with a note saying so. Zero or less removes the limit.
+func (g *Generator) SetQueryTimeout(timeout time.Duration) {
+	g.timeout = max(timeout, 0)
 }
 
-// NewGenerator creates a new report generator
-func NewGenerator(db *sql.DB) *Generator {
-	return &Generator{db: db}
+// queryContext returns the context for one report query
+func (g *Generator) queryContext() (context.Context, context.CancelFunc) {
+	if g.timeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), g.timeout)
 }
 
-// GenerateReport creates a comprehensive report with charts
+// queryError returns err from a query run with ctx, as errQueryTimeout when the
+// query was cut off by the timeout. Drivers report cancellation differently,
+// so the context decides.
+func (g *Generator) queryError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %v", errQueryTimeout, g.timeout)
+	}
+	return err
+}
+
+// timeoutNote explains that a part of the report was left out because its
+// query timed out with err
+func timeoutNote(part string, err error) string {
+	return fmt.Sprintf("The %s was left out: its %v. Try again when the database is less busy, or raise -report-timeout.", part, err)
+}
+
+// recentResults loads every ping result from the last hours, oldest first
+func (g *Generator) recentResults(hours int) ([]models.PingResult, error) {
+	ctx, cancel := g.queryContext()
+	defer cancel()
+	now := time.Now()
+	results, err := g.db.GetRange(ctx, now.Add(-time.Duration(hours)*time.Hour), now)
+	if err != nil {
+		return nil, g.queryError(ctx, err)
+	}
+	return results, nil
+}
+
+// reportStep writes one part of a report into the report directory
+type reportStep struct {
+	name string
+	run  func(outputDir string, hours int) error
+}
+
+// GenerateReport creates a comprehensive report with charts. The charts and
+// summary are generated concurrently; every part that can be produced is
+// written, and the failures of the rest are returned together.
 func (g *Generator) GenerateReport(outputDir string, hours int) error {
 	if err := os.MkdirAll(outputDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
+	timestamp := time.Now().In(g.location).Format("2006-01-02_15-04-05")
 	reportDir := filepath.Join(outputDir, fmt.Sprintf("network_report_%s", timestamp))
 	if err := os.MkdirAll(reportDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create report directory: %w", err)
 	}
 
-	// Generate various charts
-	if err := g.generateLatencyChart(reportDir, hours); err != nil {
-		log.Printf("Failed to generate latency chart: %v", err)
+	// Without any results there is nothing to chart, only the explanation.
+	// When the statistics time out, the charts are tried anyway.
+	summaries, err := g.queryTargetSummaries(hours)
+	statsTimedOut := errors.Is(err, errQueryTimeout)
+	if err != nil && !statsTimedOut {
+		return fmt.Errorf("failed to query statistics: %w", err)
+	}
+	steps := []reportStep{{"text report", g.generateTextReport}}
+	if total, _ := countPings(summaries); total > 0 || statsTimedOut {
+		steps = append(g.chartSteps(), steps...)
+	}
+	if note := noDataNote(summaries, hours); note != "" && !statsTimedOut {
+		steps = append(steps, reportStep{"no data note", func(dir string, _ int) error {
+			return os.WriteFile(filepath.Join(dir, noDataFile), []byte(note+"\n"), 0o644)
+		}})
+		log.Printf("Report has no latency data: see %s", noDataFile)
+	}
+	timedOut, err := runReportSteps(reportDir, hours, steps)
+	if len(timedOut) > 0 {
+		note := incompleteNote(timedOut)
+		if writeErr := os.WriteFile(filepath.Join(reportDir, incompleteFile), []byte(note), 0o644); writeErr != nil {
+			err = errors.Join(err, writeErr)
+		}
+		log.Printf("Report is incomplete, queries timed out: see %s", incompleteFile)
 	}
 
-	if err := g.generateAvailabilityChart(reportDir, hours); err != nil {
-		log.Printf("Failed to generate availability chart: %v", err)
+	log.Printf("Report generated in: %s", reportDir)
+	return err
+}
+
+// incompleteNote lists the notes on the parts of a report left out because
+// their queries timed out
+func incompleteNote(timedOut []string) string {
+	return "This report is incomplete.\n" + strings.Join(timedOut, "\n") + "\n"
+}
+
+// countPings totals the pings, and the successful ones, across targets
+func countPings(summaries []targetSummary) (total, successful int) {
+	for _, s := range summaries {
+		total += s.Total
+		successful += s.Successful
 	}
+	return total, successful
+}
 
-	if err := g.generateOutageSummary(reportDir, hours); err != nil {
-		log.Printf("Failed to generate outage summary: %v", err)
+// noDataNote explains why a report over the last hours has few or no charts:
+// there were no results at all, or none succeeded so there are no latencies to
+// plot. It is empty when there is latency data.
+func noDataNote(summaries []targetSummary, hours int) string {
+	total, successful := countPings(summaries)
+	switch {
+	case total == 0:
+		return fmt.Sprintf("No ping results were recorded in the last %d hours, so this report has no charts.\n"+
+			"Check that the monitor was running and that -db points at its database, or cover a longer period with -hours.", hours)
+	case successful == 0:
+		return fmt.Sprintf("None of the %d pings in the last %d hours succeeded, so there are no latency charts.\n"+
+			"The availability and outage charts and the statistics show the failures.", total, hours)
+	default:
+		return ""
 	}
+}
 
-	if err := g.generateTextReport(reportDir, hours); err != nil {
-		log.Printf("Failed to generate text report: %v", err)
+// chartSteps lists every chart in a report; the file and PDF formats share it
+// so they stay in sync
+func (g *Generator) chartSteps() []reportStep {
+	return []reportStep{
+		{"latency chart", g.generateLatencyChart},
+		{"latency comparison chart", g.generateComparisonChart},
+		{"latency histogram", g.generateLatencyHistogram},
+		{"availability chart", g.generateAvailabilityChart},
+		{"outage summary", g.generateOutageSummary},
 	}
+}
 
-	log.Printf("Report generated in: %s", reportDir)
-	return nil
+// runReportSteps runs the steps concurrently. Steps left out because a query
+// timed out are returned as notes saying so; the other failures are joined.
+func runReportSteps(reportDir string, hours int, steps []reportStep) (timedOut []string, err error) {
+	errs := make([]error, len(steps))
+	var group errgroup.Group
+	for i, step := range steps {
+		i, step := i, step
+		group.Go(func() error {
+			errs[i] = step.run(reportDir, hours)
+			// Never fail the group, so one broken step doesn't hide the others' errors
+			return nil
+		})
+	}
+	group.Wait()
+
+	var failed []error
+	for i, err := range errs {
+		switch {
+		case err == nil:
+		case errors.Is(err, errQueryTimeout):
+			timedOut = append(timedOut, timeoutNote(steps[i].name, err))
+		default:
+			failed = append(failed, fmt.Errorf("failed to generate %s: %w", steps[i].name, err))
+		}
+	}
+	return timedOut, errors.Join(failed...)
+}
+
+// Generate creates a report in the requested format
+func (g *Generator) Generate(outputDir string, hours int, format string) error {
+	switch format {
+	case FormatFiles:
+		return g.GenerateReport(outputDir, hours)
+	case FormatPDF:
+		return g.GeneratePDF(outputDir, hours)
+	case FormatBoth:
+		if err := g.GenerateReport(outputDir, hours); err != nil {
+			return err
+		}
+		return g.GeneratePDF(outputDir, hours)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
 }
@@ -0,0 +1,239 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"network-monitor/internal/database"
+	"network-monitor/internal/models"
+)
+
+// newSeededGenerator returns a generator over an hour of pings to 8.8.8.8
+// that includes a five-minute outage
+func newSeededGenerator(t *testing.T, dir string) *Generator {
+	t.Helper()
+
+	db, err := database.New(filepath.Join(dir, "test.db"), "")
+	if err != nil {
+		t.Fatalf("database.New() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitSchema(); err != nil {
+		t.Fatalf("InitSchema() error = %v", err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 60; i++ {
+		if err := db.SaveResult(context.Background(), models.PingResult{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Target:    "8.8.8.8",
+			Success:   i < 20 || i >= 25,
+			RTT:       10 + float64(i%7),
+		}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	return NewGenerator(db, models.DefaultOutageThreshold, time.UTC)
+}
+
+func TestGenerateReportOutputs(t *testing.T) {
+	dir := t.TempDir()
+	g := newSeededGenerator(t, dir)
+
+	outDir := filepath.Join(dir, "reports")
+	if err := g.GenerateReport(outDir, 24); err != nil {
+		t.Fatalf("GenerateReport() error = %v", err)
+	}
+
+	reportDirs, err := filepath.Glob(filepath.Join(outDir, "network_report_*"))
+	if err != nil || len(reportDirs) != 1 {
+		t.Fatalf("expected one report directory, got %v (%v)", reportDirs, err)
+	}
+
+	outputs := []struct {
+		name string
+		file string
+	}{
+		{"latency chart", "latency_8_8_8_8.png"},
+		{"latency comparison chart", "latency_comparison.png"},
+		{"latency histogram", "histogram_8_8_8_8.png"},
+		{"availability chart", "availability.png"},
+		{"outage frequency bar chart", "outage_frequency.png"},
+		{"text report", "summary.txt"},
+	}
+	for _, out := range outputs {
+		t.Run(out.name, func(t *testing.T) {
+			info, err := os.Stat(filepath.Join(reportDirs[0], out.file))
+			if err != nil {
+				t.Fatalf("%s not written: %v", out.file, err)
+			}
+			if info.Size() == 0 {
+				t.Errorf("%s is empty", out.file)
+			}
+		})
+	}
+
+	summary, err := os.ReadFile(filepath.Join(reportDirs[0], "summary.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"8.8.8.8", "SLA SUMMARY", "Availability: 91.667% (55 of 60 checks)"} {
+		if !strings.Contains(string(summary), want) {
+			t.Errorf("summary does not contain %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestRunReportStepsCollectsFailures(t *testing.T) {
+	dir := t.TempDir()
+	g := newSeededGenerator(t, dir)
+	reportDir := filepath.Join(dir, "report")
+	if err := os.MkdirAll(reportDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	errBroken := errors.New("renderer exploded")
+	_, err := runReportSteps(reportDir, 24, []reportStep{
+		{"latency chart", g.generateLatencyChart},
+		{"availability chart", func(string, int) error { return errBroken }},
+		{"text report", g.generateTextReport},
+	})
+
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("runReportSteps() error = %v, want the failing step's error", err)
+	}
+	for _, name := range []string{"latency_8_8_8_8.png", "summary.txt"} {
+		if _, statErr := os.Stat(filepath.Join(reportDir, name)); statErr != nil {
+			t.Errorf("%s not written despite another step failing: %v", name, statErr)
+		}
+	}
+	if _, statErr := os.Stat(filepath.Join(reportDir, "availability.png")); statErr == nil {
+		t.Error("failed step unexpectedly produced output")
+	}
+}
+
+// slowOutagesDB blocks outage detection until the query is cancelled
+type slowOutagesDB struct {
+	models.Database
+}
+
+func (db slowOutagesDB) DetectOutages(ctx context.Context, from, to time.Time, threshold models.OutageThreshold) ([]models.Outage, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestGenerateReportQueryTimeout(t *testing.T) {
+	dir := t.TempDir()
+	g := newSeededGenerator(t, dir)
+	g.db = slowOutagesDB{g.db}
+	g.SetQueryTimeout(50 * time.Millisecond)
+
+	outDir := filepath.Join(dir, "reports")
+	if err := g.GenerateReport(outDir, 24); err != nil {
+		t.Fatalf("GenerateReport() error = %v, want a partial report", err)
+	}
+	reportDirs, err := filepath.Glob(filepath.Join(outDir, "network_report_*"))
+	if err != nil || len(reportDirs) != 1 {
+		t.Fatalf("expected one report directory, got %v (%v)", reportDirs, err)
+	}
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(reportDirs[0], name))
+		if err != nil {
+			t.Fatalf("%s not written: %v", name, err)
+		}
+		return string(data)
+	}
+
+	// Parts that don't detect outages are unaffected
+	summary := read("summary.txt")
+	for _, want := range []string{"Total Pings: 60", "SLA SUMMARY", "The outage list was left out: its query timed out after 50ms"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary.txt missing %q:\n%s", want, summary)
+		}
+	}
+	for _, name := range []string{"histogram_8_8_8_8.png", "outage_frequency.png"} {
+		read(name)
+	}
+
+	// The charts shaded with outages are left out, and the note says so
+	if _, err := os.Stat(filepath.Join(reportDirs[0], "latency_8_8_8_8.png")); err == nil {
+		t.Error("latency chart written although its outage query timed out")
+	}
+	note := read(incompleteFile)
+	for _, want := range []string{"latency chart", "availability chart", "-report-timeout"} {
+		if !strings.Contains(note, want) {
+			t.Errorf("%s missing %q:\n%s", incompleteFile, want, note)
+		}
+	}
+}
+
+func TestGenerateReportWithoutLatencyData(t *testing.T) {
+	tests := []struct {
+		name      string
+		outcomes  []bool
+		wantNote  string
+		wantChart bool
+	}{
+		{name: "no results", wantNote: "No ping results were recorded in the last 24 hours"},
+		{name: "only failures", outcomes: []bool{false, false, false, false}, wantNote: "None of the 4 pings in the last 24 hours succeeded", wantChart: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			db, err := database.New(filepath.Join(dir, "test.db"), "")
+			if err != nil {
+				t.Fatalf("database.New() error = %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			if err := db.InitSchema(); err != nil {
+				t.Fatalf("InitSchema() error = %v", err)
+			}
+			start := time.Now().Add(-time.Hour)
+			for i, success := range tt.outcomes {
+				if err := db.SaveResult(context.Background(), models.PingResult{
+					Timestamp:  start.Add(time.Duration(i) * 10 * time.Minute),
+					Target:     "8.8.8.8",
+					Success:    success,
+					PacketLoss: 100,
+				}); err != nil {
+					t.Fatalf("SaveResult() error = %v", err)
+				}
+			}
+			g := NewGenerator(db, models.DefaultOutageThreshold, time.UTC)
+
+			outDir := filepath.Join(dir, "reports")
+			if err := g.GenerateReport(outDir, 24); err != nil {
+				t.Fatalf("GenerateReport() error = %v", err)
+			}
+			reportDirs, err := filepath.Glob(filepath.Join(outDir, "network_report_*"))
+			if err != nil || len(reportDirs) != 1 {
+				t.Fatalf("expected one report directory, got %v (%v)", reportDirs, err)
+			}
+
+			for _, name := range []string{noDataFile, "summary.txt"} {
+				content, err := os.ReadFile(filepath.Join(reportDirs[0], name))
+				if err != nil {
+					t.Fatalf("%s not written: %v", name, err)
+				}
+				if !strings.Contains(string(content), tt.wantNote) {
+					t.Errorf("%s does not contain %q:\n%s", name, tt.wantNote, content)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(reportDirs[0], "availability.png")); (err == nil) != tt.wantChart {
+				t.Errorf("availability.png written = %v, want %v", err == nil, tt.wantChart)
+			}
+
+			if err := g.GeneratePDF(filepath.Join(dir, "pdf"), 24); err != nil {
+				t.Fatalf("GeneratePDF() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"network-monitor/internal/models"
+)
+
+// pdfContent is everything rendered into a PDF report
+type pdfContent struct {
+	Generated time.Time
+	Hours     int
+	Summaries []targetSummary
+	SLA       []slaAssessment
+	Outages   []outagePeriod
+	Threshold models.OutageThreshold
+	Charts    []string // PNG files, one per page
+	Notes     []string // parts left out because their queries timed out
+}
+
+// GeneratePDF creates a single PDF report with the statistics and SLA tables,
+// outage list and every chart, suitable for sending to an ISP
+func (g *Generator) GeneratePDF(outputDir string, hours int) error {
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Parts whose queries time out are left out, with a note saying so
+	var notes []string
+	summaries, statsErr := g.queryTargetSummaries(hours)
+	statsTimedOut := errors.Is(statsErr, errQueryTimeout)
+	if statsErr != nil && !statsTimedOut {
+		return fmt.Errorf("failed to query statistics: %w", statsErr)
+	}
+	if statsTimedOut {
+		notes = append(notes, timeoutNote("statistics table", statsErr), timeoutNote("SLA table", statsErr))
+	}
+
+	chartDir, err := os.MkdirTemp("", "network_report_charts")
+	if err != nil {
+		return fmt.Errorf("failed to create chart directory: %w", err)
+	}
+	defer os.RemoveAll(chartDir)
+
+	// A missing chart shouldn't cost the whole PDF, and without results there
+	// is nothing to chart; the statistics table says so
+	if total, _ := countPings(summaries); total > 0 || statsTimedOut {
+		timedOut, err := runReportSteps(chartDir, hours, g.chartSteps())
+		if err != nil {
+			log.Printf("PDF report is missing charts: %v", err)
+		}
+		notes = append(notes, timedOut...)
+	}
+
+	charts, err := orderedCharts(chartDir)
+	if err != nil {
+		return err
+	}
+	assessments, err := g.querySLAs(summaries, hours)
+	if errors.Is(err, errQueryTimeout) {
+		notes = append(notes, timeoutNote("SLA table", err))
+	} else if err != nil {
+		return fmt.Errorf("failed to query SLA: %w", err)
+	}
+	outages, err := g.queryOutagePeriods(hours)
+	if errors.Is(err, errQueryTimeout) {
+		notes = append(notes, timeoutNote("outage list", err))
+	} else if err != nil {
+		return fmt.Errorf("failed to query outages: %w", err)
+	}
+	if len(notes) > 0 {
+		log.Printf("PDF report is incomplete, queries timed out")
+	}
+
+	now := time.Now().In(g.location)
+	filename := filepath.Join(outputDir, fmt.Sprintf("network_report_%s.pdf", now.Format("2006-01-02_15-04-05")))
+	content := pdfContent{
+		Generated: now,
+		Hours:     hours,
+		Summaries: summaries,
+		SLA:       assessments,
+		Outages:   outages,
+		Threshold: g.threshold,
+		Charts:    charts,
+		Notes:     notes,
+	}
+	if err := writePDF(filename, content); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	log.Printf("PDF report generated: %s", filename)
+	return nil
+}
+
+// orderedCharts lists the PNG charts in a directory: the latency comparison,
+// per-target latency charts and histograms first, then the combined
+// availability and outage charts
+func orderedCharts(dir string) ([]string, error) {
+	var charts []string
+	comparison := filepath.Join(dir, comparisonFile)
+	if _, err := os.Stat(comparison); err == nil {
+		charts = append(charts, comparison)
+	}
+
+	for _, pattern := range []string{"latency_*.png", "histogram_*.png"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if match != comparison {
+				charts = append(charts, match)
+			}
+		}
+	}
+
+	for _, name := range []string{"availability.png", "outage_frequency.png"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			charts = append(charts, path)
+		}
+	}
+	return charts, nil
+}
+
+// writePDF renders the report: a summary page (which may flow onto further
+// pages when there are many outages) followed by one landscape page per chart
+func writePDF(filename string, content pdfContent) error {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetTitle("Network Connectivity Report", false)
+	pdf.SetCreator("network-monitor", false)
+	pdf.SetAutoPageBreak(true, 15)
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, "Network Connectivity Report", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Generated: %s", content.Generated.Format("2006-01-02 15:04:05")), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Period: Last %d hours", content.Hours), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+	if len(content.Notes) > 0 {
+		pdf.SetFont("Helvetica", "I", 10)
+		pdf.SetTextColor(180, 0, 0)
+		pdf.MultiCell(0, 6, "This report is incomplete.\n"+strings.Join(content.Notes, "\n"), "", "L", false)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Ln(4)
+	}
+
+	writeStatsTable(pdf, content.Summaries)
+	pdf.Ln(6)
+	if len(content.SLA) > 0 {
+		writeSLATable(pdf, content.SLA)
+		pdf.Ln(6)
+	}
+	writeOutageTable(pdf, content.Outages, content.Threshold)
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, top, right, _ := pdf.GetMargins()
+	for _, chart := range content.Charts {
+		pdf.AddPage()
+		// Charts are rendered at 1200x400, so scale to the page width and keep the aspect ratio
+		pdf.ImageOptions(chart, left, top, pageWidth-left-right, 0, false,
+			gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	}
+
+	return pdf.OutputFileAndClose(filename)
+}
+
+func writeStatsTable(pdf *gofpdf.Fpdf, summaries []targetSummary) {
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 8, "Overall Statistics", "", 1, "L", false, 0, "")
+
+	headers := []string{"Target", "Total Pings", "Successful", "Packet Loss", "Avg RTT", "Min RTT", "Max RTT"}
+	widths := []float64{70, 30, 45, 30, 30, 30, 30}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	for i, h := range headers {
+		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	if len(summaries) == 0 {
+		pdf.CellFormat(sum(widths), 7, "No data for this period", "1", 1, "C", false, 0, "")
+		return
+	}
+
+	for _, s := range summaries {
+		uptime := s.Uptime()
+		cells := []string{
+			s.Target,
+			fmt.Sprintf("%d", s.Total),
+			fmt.Sprintf("%d (%.2f%%)", s.Successful, uptime),
+			fmt.Sprintf("%.2f%%", 100-uptime),
+			formatRTT(s.AvgRTT.Float64, s.AvgRTT.Valid),
+			formatRTT(s.MinRTT.Float64, s.MinRTT.Valid),
+			formatRTT(s.MaxRTT.Float64, s.MaxRTT.Valid),
+		}
+		for i, c := range cells {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], 7, c, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+// writeSLATable lists each target's availability and downtime, with the SLA
+// verdict and credit columns only when SLA terms were given
+func writeSLATable(pdf *gofpdf.Fpdf, assessments []slaAssessment) {
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 8, "SLA Summary", "", 1, "L", false, 0, "")
+
+	terms := assessments[0].Terms
+	headers := []string{"Target", "Availability", "Downtime", "Outages"}
+	widths := []float64{60, 45, 35, 20}
+	if terms.Target > 0 {
+		headers = append(headers, "SLA", "Allowed Downtime")
+		widths = append(widths, 50, 35)
+	}
+	if terms.MonthlyCost > 0 {
+		headers = append(headers, "Credit")
+		widths = append(widths, 25)
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	for i, h := range headers {
+		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, a := range assessments {
+		cells := []string{
+			a.Target,
+			fmt.Sprintf("%.3f%% (%d/%d)", a.Availability, a.SuccessfulChecks, a.TotalChecks),
+			a.Downtime,
+			fmt.Sprintf("%d", a.Outages),
+		}
+		if terms.Target > 0 {
+			cells = append(cells, a.Verdict(), a.AllowedDowntime.String())
+		}
+		if terms.MonthlyCost > 0 {
+			cells = append(cells, fmt.Sprintf("%.2f", a.Credit))
+		}
+		for i, c := range cells {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], 7, c, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+func writeOutageTable(pdf *gofpdf.Fpdf, outages []outagePeriod, threshold models.OutageThreshold) {
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 8, fmt.Sprintf("Outage Periods (%s)", threshold), "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	if len(outages) == 0 {
+		pdf.CellFormat(0, 7, "No significant outages detected.", "", 1, "L", false, 0, "")
+		return
+	}
+
+	headers := []string{"#", "Target", "Start", "End", "Duration", "Failed Checks"}
+	widths := []float64{12, 70, 50, 50, 50, 33}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	for i, h := range headers {
+		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for i, o := range outages {
+		cells := []string{
+			fmt.Sprintf("%d", i+1),
+			o.Target,
+			o.Start.Format("2006-01-02 15:04:05"),
+			o.End.Format("2006-01-02 15:04:05"),
+			o.End.Sub(o.Start).String(),
+			fmt.Sprintf("%d", o.FailedChecks),
+		}
+		for j, c := range cells {
+			pdf.CellFormat(widths[j], 7, c, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+func formatRTT(value float64, valid bool) string {
+	if !valid {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f ms", value)
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
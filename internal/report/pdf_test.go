@@ -0,0 +1,90 @@
+package report
+
+import (
+	"database/sql"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// writeTestPNG writes a small solid PNG standing in for a rendered chart
+func writeTestPNG(t *testing.T, path string) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 120, 40))
+	for x := 0; x < 120; x++ {
+		for y := 0; y < 40; y++ {
+			img.Set(x, y, color.RGBA{R: 40, G: 120, B: 200, A: 255})
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create %s: %v", path, err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("encode %s: %v", path, err)
+	}
+}
+
+var pdfPagePattern = regexp.MustCompile(`/Type /Page\b[^s]`)
+
+func TestWritePDF(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"latency_8_8_8_8.png", "latency_1_1_1_1.png", "latency_comparison.png", "availability.png"} {
+		writeTestPNG(t, filepath.Join(dir, name))
+	}
+
+	charts, err := orderedCharts(dir)
+	if err != nil {
+		t.Fatalf("orderedCharts() error = %v", err)
+	}
+	if len(charts) != 4 || filepath.Base(charts[0]) != "latency_comparison.png" || filepath.Base(charts[3]) != "availability.png" {
+		t.Fatalf("unexpected chart order: %v", charts)
+	}
+
+	now := time.Now()
+	content := pdfContent{
+		Generated: now,
+		Hours:     24,
+		Summaries: []targetSummary{
+			{Target: "8.8.8.8", Total: 100, Successful: 98, AvgRTT: sql.NullFloat64{Float64: 12.5, Valid: true}},
+			{Target: "1.1.1.1", Total: 100, Successful: 0},
+		},
+		SLA: []slaAssessment{
+			assessSLA(models.SLA{Target: "8.8.8.8", Since: now.Add(-24 * time.Hour), Until: now, TotalChecks: 100, SuccessfulChecks: 98, Availability: 98}, SLATerms{Target: 99.9, MonthlyCost: 50}),
+			assessSLA(models.SLA{Target: "1.1.1.1", Since: now.Add(-24 * time.Hour), Until: now, TotalChecks: 100, Availability: 0, DowntimeSeconds: 600, Outages: 1}, SLATerms{Target: 99.9, MonthlyCost: 50}),
+		},
+		Outages: []outagePeriod{
+			{Target: "1.1.1.1", Start: now.Add(-time.Hour), End: now.Add(-50 * time.Minute), FailedChecks: 600},
+		},
+		Threshold: models.DefaultOutageThreshold,
+		Charts:    charts,
+	}
+
+	filename := filepath.Join(dir, "report.pdf")
+	if err := writePDF(filename, content); err != nil {
+		t.Fatalf("writePDF() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("read PDF: %v", err)
+	}
+	if len(data) == 0 {
+		t.Fatal("PDF is empty")
+	}
+
+	// One summary page plus one page per chart
+	if pages := len(pdfPagePattern.FindAll(data, -1)); pages != 5 {
+		t.Errorf("PDF has %d pages, want 5", pages)
+	}
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// billingMonth is the month length downtime credits are pro-rated over
+const billingMonth = 30 * 24 * time.Hour
+
+// SLATerms is what the ISP promised, to judge the measured availability by
+type SLATerms struct {
+	Target      float64 // promised availability in percent, e.g. 99.9; 0 leaves out the assessment
+	MonthlyCost float64 // subscription price per month; 0 leaves out the credit
+}
+
+// slaAssessment is one target's availability over the report period, and
+// whether it fell short of the SLA
+type slaAssessment struct {
+	models.SLA
+	Terms           SLATerms
+	AllowedDowntime time.Duration // downtime the SLA permits over the period
+	Breached        bool
+	Credit          float64 // monthly cost pro-rated by the downtime, owed only on a breach
+}
+
+// assessSLA compares a target's availability with the SLA terms. On a breach
+// the credit is the share of the monthly cost the downtime covers, e.g. 30 for
+// 3 days of downtime on a 300 per month subscription.
+func assessSLA(sla models.SLA, terms SLATerms) slaAssessment {
+	a := slaAssessment{SLA: sla, Terms: terms}
+	if terms.Target <= 0 {
+		return a
+	}
+
+	period := sla.Until.Sub(sla.Since)
+	a.AllowedDowntime = time.Duration(float64(period) * (100 - terms.Target) / 100).Round(time.Second)
+	a.Breached = sla.TotalChecks > 0 && sla.Availability < terms.Target
+	if a.Breached && terms.MonthlyCost > 0 {
+		downtime := time.Duration(sla.DowntimeSeconds * float64(time.Second))
+		a.Credit = terms.MonthlyCost * float64(downtime) / float64(billingMonth)
+	}
+	return a
+}
+
+// Verdict states the outcome of the assessment in a few words
+func (a slaAssessment) Verdict() string {
+	switch {
+	case a.TotalChecks == 0:
+		return "no data"
+	case a.Breached:
+		return fmt.Sprintf("BREACHED (below %.3f%%)", a.Terms.Target)
+	default:
+		return fmt.Sprintf("met (%.3f%%)", a.Terms.Target)
+	}
+}
+
+// querySLAs assesses every target's availability over the last hours
+func (g *Generator) querySLAs(summaries []targetSummary, hours int) ([]slaAssessment, error) {
+	ctx, cancel := g.queryContext()
+	defer cancel()
+	now := time.Now()
+	since := now.Add(-time.Duration(hours) * time.Hour)
+
+	assessments := make([]slaAssessment, 0, len(summaries))
+	for _, s := range summaries {
+		sla, err := g.db.GetSLA(ctx, s.Target, since, now)
+		if err != nil {
+			return nil, g.queryError(ctx, err)
+		}
+		assessments = append(assessments, assessSLA(sla, g.terms))
+	}
+	return assessments, nil
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestAssessSLA(t *testing.T) {
+	until := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	month := models.SLA{
+		Since:            until.Add(-billingMonth),
+		Until:            until,
+		TotalChecks:      1000,
+		SuccessfulChecks: 995,
+		Availability:     99.5,
+		DowntimeSeconds:  (3 * time.Hour).Seconds(),
+	}
+	met := month
+	met.Availability = 99.95
+
+	tests := []struct {
+		name         string
+		sla          models.SLA
+		terms        SLATerms
+		wantBreached bool
+		wantAllowed  time.Duration
+		wantCredit   float64
+	}{
+		// 3 hours of a 720-hour month on a 300 subscription
+		{"breached with cost", month, SLATerms{Target: 99.9, MonthlyCost: 300}, true, 43*time.Minute + 12*time.Second, 1.25},
+		{"breached without cost", month, SLATerms{Target: 99.9}, true, 43*time.Minute + 12*time.Second, 0},
+		{"met", met, SLATerms{Target: 99.9, MonthlyCost: 300}, false, 43*time.Minute + 12*time.Second, 0},
+		{"no SLA target", month, SLATerms{}, false, 0, 0},
+		{"no checks", models.SLA{Since: month.Since, Until: until}, SLATerms{Target: 99.9, MonthlyCost: 300}, false, 43*time.Minute + 12*time.Second, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assessSLA(tt.sla, tt.terms)
+			if a.Breached != tt.wantBreached || a.AllowedDowntime != tt.wantAllowed {
+				t.Errorf("assessSLA() breached %v allowing %v, want %v allowing %v", a.Breached, a.AllowedDowntime, tt.wantBreached, tt.wantAllowed)
+			}
+			if diff := a.Credit - tt.wantCredit; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("assessSLA() credit = %v, want %v", a.Credit, tt.wantCredit)
+			}
+		})
+	}
+}
+
+func TestTextReportSLABreach(t *testing.T) {
+	dir := t.TempDir()
+	g := newSeededGenerator(t, dir)
+
+	// The seeded five minutes of failures, recorded as the monitor would
+	start := time.Now().Add(-40 * time.Minute)
+	id, err := g.db.RecordOutageStart(context.Background(), "8.8.8.8", start, 0)
+	if err != nil {
+		t.Fatalf("RecordOutageStart() error = %v", err)
+	}
+	if err := g.db.RecordOutageEnd(context.Background(), id, start.Add(5*time.Minute), 5, 0); err != nil {
+		t.Fatalf("RecordOutageEnd() error = %v", err)
+	}
+	// 5 minutes is 1/8640 of a 30-day month
+	g.SetSLATerms(SLATerms{Target: 99.9, MonthlyCost: 8640})
+
+	if err := g.generateTextReport(dir, 24); err != nil {
+		t.Fatalf("generateTextReport() error = %v", err)
+	}
+	summary, err := os.ReadFile(filepath.Join(dir, "summary.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"SLA: BREACHED (below 99.900%), allowing 1m26s of downtime in this period",
+		"Estimated Credit: 1.00 (5m0s of downtime at 8640.00 per 30-day month)",
+	} {
+		if !strings.Contains(string(summary), want) {
+			t.Errorf("summary does not contain %q:\n%s", want, summary)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package report
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,82 @@ import (
 	"time"
 )
 
+// targetSummary holds overall statistics for a single target
+type targetSummary struct {
+	Target     string
+	Total      int
+	Successful int
+	AvgRTT     sql.NullFloat64
+	MaxRTT     sql.NullFloat64
+	MinRTT     sql.NullFloat64
+}
+
+// Uptime returns the percentage of successful pings, or 0 without any pings
+func (s targetSummary) Uptime() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Successful) / float64(s.Total) * 100
+}
+
+// outagePeriod is an outage detected for a target
+type outagePeriod struct {
+	Target       string
+	Start        time.Time
+	End          time.Time
+	FailedChecks int
+}
+
+// queryTargetSummaries retrieves per-target statistics for the last hours
+func (g *Generator) queryTargetSummaries(hours int) ([]targetSummary, error) {
+	ctx, cancel := g.queryContext()
+	defer cancel()
+	now := time.Now()
+	stats, err := g.db.GetStats(ctx, now.Add(-time.Duration(hours)*time.Hour), now)
+	if err != nil {
+		return nil, g.queryError(ctx, err)
+	}
+
+	summaries := make([]targetSummary, 0, len(stats))
+	for _, st := range stats {
+		// RTTs only exist for targets with successful pings
+		valid := st.Successful > 0
+		summaries = append(summaries, targetSummary{
+			Target:     st.Target,
+			Total:      st.TotalPings,
+			Successful: st.Successful,
+			AvgRTT:     sql.NullFloat64{Float64: st.AvgRTT, Valid: valid},
+			MaxRTT:     sql.NullFloat64{Float64: st.MaxRTT, Valid: valid},
+			MinRTT:     sql.NullFloat64{Float64: st.MinRTT, Valid: valid},
+		})
+	}
+
+	return summaries, nil
+}
+
+// queryOutagePeriods retrieves outages detected in the last hours
+func (g *Generator) queryOutagePeriods(hours int) ([]outagePeriod, error) {
+	ctx, cancel := g.queryContext()
+	defer cancel()
+	now := time.Now()
+	detected, err := g.db.DetectOutages(ctx, now.Add(-time.Duration(hours)*time.Hour), now, g.threshold)
+	if err != nil {
+		return nil, g.queryError(ctx, err)
+	}
+
+	outages := make([]outagePeriod, 0, len(detected))
+	for _, o := range detected {
+		outages = append(outages, outagePeriod{
+			Target:       o.Target,
+			Start:        o.StartTime.In(g.location),
+			End:          o.EndTime.In(g.location),
+			FailedChecks: o.FailedChecks,
+		})
+	}
+
+	return outages, nil
+}
+
 func (g *Generator) generateTextReport(outputDir string, hours int) error {
 	filename := filepath.Join(outputDir, "summary.txt")
 	file, err := os.Create(filename)
@@ -18,122 +95,104 @@ func (g *Generator) generateTextReport(outputDir string, hours int) error {
 	defer file.Close()
 
 	fmt.Fprintf(file, "Network Connectivity Report\n")
-	fmt.Fprintf(file, "Generated: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(file, "Generated: %s\n", time.Now().In(g.location).Format("2006-01-02 15:04:05"))
 	fmt.Fprintf(file, "Period: Last %d hours\n\n", hours)
 	fmt.Fprintln(file, strings.Repeat("=", 60))
 
-	// Overall statistics
-	query := `
-        SELECT
-            target,
-            COUNT(*) as total_pings,
-            SUM(CASE WHEN success THEN 1 ELSE 0 END) as successful_pings,
-            AVG(CASE WHEN success THEN rtt_ms ELSE NULL END) as avg_rtt,
-            MAX(CASE WHEN success THEN rtt_ms ELSE NULL END) as max_rtt,
-            MIN(CASE WHEN success THEN rtt_ms ELSE NULL END) as min_rtt
-        FROM ping_results
-        WHERE timestamp > datetime('now', '-' || ? || ' hours')
-        GROUP BY target
-    `
-
-	rows, err := g.db.Query(query, hours)
-	if err != nil {
-		return err
+	// Overall statistics. A section whose query times out is replaced by a
+	// note saying so, and the report carries on with the next.
+	summaries, statsErr := g.queryTargetSummaries(hours)
+	statsTimedOut := errors.Is(statsErr, errQueryTimeout)
+	if statsErr != nil && !statsTimedOut {
+		return statsErr
 	}
-	defer rows.Close()
 
 	fmt.Fprintln(file, "\nOVERALL STATISTICS")
+	if statsTimedOut {
+		fmt.Fprintf(file, "%s\n\n", timeoutNote("statistics section", statsErr))
+	} else if note := noDataNote(summaries, hours); note != "" {
+		fmt.Fprintf(file, "%s\n\n", note)
+	}
 
-	for rows.Next() {
-		var target string
-		var total, successful int
-		var avgRTT, maxRTT, minRTT sql.NullFloat64
-
-		if err := rows.Scan(&target, &total, &successful, &avgRTT, &maxRTT, &minRTT); err != nil {
-			continue
-		}
-
-		uptime := float64(successful) / float64(total) * 100
+	for _, s := range summaries {
+		uptime := s.Uptime()
 		packetLoss := 100 - uptime
 
-		fmt.Fprintf(file, "Target: %s\n", target)
-		fmt.Fprintf(file, "  Total Pings: %d\n", total)
-		fmt.Fprintf(file, "  Successful: %d (%.2f%%)\n", successful, uptime)
+		fmt.Fprintf(file, "Target: %s\n", s.Target)
+		fmt.Fprintf(file, "  Total Pings: %d\n", s.Total)
+		fmt.Fprintf(file, "  Successful: %d (%.2f%%)\n", s.Successful, uptime)
 		fmt.Fprintf(file, "  Packet Loss: %.2f%%\n", packetLoss)
 
-		if avgRTT.Valid {
-			fmt.Fprintf(file, "  Average RTT: %.2f ms\n", avgRTT.Float64)
-			fmt.Fprintf(file, "  Min RTT: %.2f ms\n", minRTT.Float64)
-			fmt.Fprintf(file, "  Max RTT: %.2f ms\n", maxRTT.Float64)
+		if s.AvgRTT.Valid {
+			fmt.Fprintf(file, "  Average RTT: %.2f ms\n", s.AvgRTT.Float64)
+			fmt.Fprintf(file, "  Min RTT: %.2f ms\n", s.MinRTT.Float64)
+			fmt.Fprintf(file, "  Max RTT: %.2f ms\n", s.MaxRTT.Float64)
 		}
 		fmt.Fprintln(file)
 	}
 
 	fmt.Fprintln(file, strings.Repeat("=", 60))
 
-	// Outage periods
-	outageQuery := `
-        WITH grouped_failures AS (
-            SELECT
-                target,
-                timestamp,
-                success,
-                ROW_NUMBER() OVER (PARTITION BY target ORDER BY timestamp) -
-                ROW_NUMBER() OVER (PARTITION BY target, success ORDER BY timestamp) as grp
-            FROM ping_results
-            WHERE timestamp > datetime('now', '-' || ? || ' hours')
-        )
-        SELECT
-            target,
-            MIN(timestamp) as start_time,
-            MAX(timestamp) as end_time,
-            COUNT(*) as failed_checks
-        FROM grouped_failures
-        WHERE success = 0
-        GROUP BY target, grp
-        HAVING COUNT(*) >= 3
-        ORDER BY start_time DESC
-    `
-
-	outageRows, outageErr := g.db.Query(outageQuery, hours)
-	if outageErr != nil {
-		return outageErr
+	// Availability against the recorded outages, as an ISP SLA would state it
+	fmt.Fprintln(file, "\nSLA SUMMARY")
+
+	// It covers the targets in the statistics, so it goes when they do
+	assessments, err := g.querySLAs(summaries, hours)
+	switch {
+	case statsTimedOut:
+		fmt.Fprintf(file, "%s\n\n", timeoutNote("SLA summary", statsErr))
+	case errors.Is(err, errQueryTimeout):
+		fmt.Fprintf(file, "%s\n\n", timeoutNote("SLA summary", err))
+	case err != nil:
+		return err
+	}
+	for _, a := range assessments {
+		fmt.Fprintf(file, "Target: %s\n", a.Target)
+		fmt.Fprintf(file, "  Availability: %.3f%% (%d of %d checks)\n", a.Availability, a.SuccessfulChecks, a.TotalChecks)
+		fmt.Fprintf(file, "  Downtime: %s across %d recorded outages\n", a.Downtime, a.Outages)
+		if g.terms.Target > 0 {
+			fmt.Fprintf(file, "  SLA: %s, allowing %s of downtime in this period\n", a.Verdict(), a.AllowedDowntime)
+		}
+		if a.Credit > 0 {
+			fmt.Fprintf(file, "  Estimated Credit: %.2f (%s of downtime at %.2f per 30-day month)\n", a.Credit, a.Downtime, g.terms.MonthlyCost)
+		}
+		fmt.Fprintln(file)
 	}
-	defer outageRows.Close()
 
-	fmt.Fprintln(file, "\nOUTAGE PERIODS (3+ consecutive failures)")
+	fmt.Fprintln(file, strings.Repeat("=", 60))
 
-	outageCount := 0
-	for outageRows.Next() {
-		var target string
-		var startTime, endTime time.Time
-		var failedChecks int
+	// Outage periods
+	outages, err := g.queryOutagePeriods(hours)
+	if err != nil && !errors.Is(err, errQueryTimeout) {
+		return err
+	}
 
-		if scanErr := outageRows.Scan(&target, &startTime, &endTime, &failedChecks); scanErr != nil {
-			continue
-		}
+	fmt.Fprintf(file, "\nOUTAGE PERIODS (%s)\n", g.threshold)
 
-		duration := endTime.Sub(startTime)
-		fmt.Fprintf(file, "Outage #%d\n", outageCount+1)
-		fmt.Fprintf(file, "  Target: %s\n", target)
-		fmt.Fprintf(file, "  Start: %s\n", startTime.Format("2006-01-02 15:04:05"))
-		fmt.Fprintf(file, "  End: %s\n", endTime.Format("2006-01-02 15:04:05"))
-		fmt.Fprintf(file, "  Duration: %s\n", duration)
-		fmt.Fprintf(file, "  Failed Checks: %d\n", failedChecks)
+	for i, o := range outages {
+		fmt.Fprintf(file, "Outage #%d\n", i+1)
+		fmt.Fprintf(file, "  Target: %s\n", o.Target)
+		fmt.Fprintf(file, "  Start: %s\n", o.Start.Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(file, "  End: %s\n", o.End.Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(file, "  Duration: %s\n", o.End.Sub(o.Start))
+		fmt.Fprintf(file, "  Failed Checks: %d\n", o.FailedChecks)
 		fmt.Fprintln(file)
-
-		outageCount++
 	}
 
-	if outageCount == 0 {
+	switch {
+	case err != nil:
+		fmt.Fprintf(file, "%s\n", timeoutNote("outage list", err))
+	case len(outages) == 0:
 		fmt.Fprintln(file, "No significant outages detected.")
-	} else {
-		fmt.Fprintf(file, "\nTotal Outages: %d\n", outageCount)
+	default:
+		fmt.Fprintf(file, "\nTotal Outages: %d\n", len(outages))
 	}
 
 	fmt.Fprintln(file, strings.Repeat("=", 60))
 	fmt.Fprintln(file, "\nThis report documents network connectivity issues.")
-	fmt.Fprintln(file, "Charts and detailed data are available in the accompanying files.")
+	if total, _ := countPings(summaries); total > 0 || statsTimedOut {
+		fmt.Fprintln(file, "Charts and detailed data are available in the accompanying files.")
+	}
 
 	return nil
 }
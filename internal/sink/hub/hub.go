@@ -0,0 +1,74 @@
+package hub
+
+import (
+	"sync"
+
+	"network-monitor/internal/models"
+)
+
+// subscriberBuffer is how many results a subscriber can fall behind by before
+// further results are dropped for it
+const subscriberBuffer = 64
+
+// Hub fans ping results out to live subscribers, such as streaming API
+// clients. It is a sink, so the monitor publishes every result to it; a slow
+// subscriber misses results rather than holding up the monitor.
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[chan models.PingResult]struct{}
+	closed bool
+}
+
+// New creates an empty Hub
+func New() *Hub {
+	return &Hub{subs: make(map[chan models.PingResult]struct{})}
+}
+
+// Subscribe returns a channel receiving every result written from now on and
+// a function that stops the subscription. The channel is closed when the
+// subscription stops or the hub is closed.
+func (h *Hub) Subscribe() (<-chan models.PingResult, func()) {
+	ch := make(chan models.PingResult, subscriberBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	h.subs[ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Write publishes a result to all subscribers without blocking
+func (h *Hub) Write(result models.PingResult) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- result:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close ends all subscriptions; later subscribers get a closed channel
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		delete(h.subs, ch)
+		close(ch)
+	}
+	h.closed = true
+	return nil
+}
@@ -0,0 +1,69 @@
+package hub
+
+import (
+	"testing"
+
+	"network-monitor/internal/models"
+)
+
+func TestHubPublishesToSubscribers(t *testing.T) {
+	h := New()
+	first, unsubscribeFirst := h.Subscribe()
+	second, unsubscribeSecond := h.Subscribe()
+	defer unsubscribeSecond()
+
+	h.Write(models.PingResult{Target: "8.8.8.8", Success: true})
+	for i, ch := range []<-chan models.PingResult{first, second} {
+		select {
+		case result := <-ch:
+			if result.Target != "8.8.8.8" {
+				t.Errorf("subscriber %d got target %q", i, result.Target)
+			}
+		default:
+			t.Errorf("subscriber %d got no result", i)
+		}
+	}
+
+	unsubscribeFirst()
+	unsubscribeFirst() // stopping twice is harmless
+	h.Write(models.PingResult{Target: "1.1.1.1"})
+	if _, ok := <-first; ok {
+		t.Error("unsubscribed channel still received a result")
+	}
+	if result := <-second; result.Target != "1.1.1.1" {
+		t.Errorf("remaining subscriber got target %q", result.Target)
+	}
+}
+
+func TestHubDropsForSlowSubscriber(t *testing.T) {
+	h := New()
+	ch, unsubscribe := h.Subscribe()
+	defer unsubscribe()
+
+	// Nobody reads, so writes beyond the buffer must not block
+	for i := 0; i < subscriberBuffer*2; i++ {
+		h.Write(models.PingResult{Target: "8.8.8.8"})
+	}
+	if len(ch) != subscriberBuffer {
+		t.Errorf("buffered %d results, expected %d", len(ch), subscriberBuffer)
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	h := New()
+	ch, unsubscribe := h.Subscribe()
+
+	h.Close()
+	if _, ok := <-ch; ok {
+		t.Error("channel still open after Close")
+	}
+	unsubscribe() // after Close, must not close the channel again
+
+	late, _ := h.Subscribe()
+	if _, ok := <-late; ok {
+		t.Error("subscribing to a closed hub returned an open channel")
+	}
+	if err := h.Write(models.PingResult{}); err != nil {
+		t.Errorf("Write after Close: %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package influx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = 10 * time.Second
+	requestTimeout       = 10 * time.Second
+	queueSize            = 1000
+)
+
+// Config holds the InfluxDB connection settings. Bucket selects the v2 write API
+// (/api/v2/write); Database selects the v1 API (/write).
+type Config struct {
+	URL      string
+	Token    string
+	Org      string
+	Bucket   string
+	Database string
+
+	BatchSize     int           // points per write request (default 100)
+	FlushInterval time.Duration // maximum time a point waits before being written (default 10s)
+}
+
+// Writer batches ping results and writes them to InfluxDB in the background
+type Writer struct {
+	endpoint string
+	token    string
+	client   *http.Client
+
+	batchSize     int
+	flushInterval time.Duration
+
+	points    chan string
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New creates a Writer and starts its background flusher
+func New(cfg Config) (*Writer, error) {
+	endpoint, err := writeEndpoint(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{
+		endpoint:      endpoint,
+		token:         cfg.Token,
+		client:        &http.Client{Timeout: requestTimeout},
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+		points:        make(chan string, queueSize),
+		done:          make(chan struct{}),
+	}
+	if w.batchSize <= 0 {
+		w.batchSize = defaultBatchSize
+	}
+	if w.flushInterval <= 0 {
+		w.flushInterval = defaultFlushInterval
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// writeEndpoint builds the write URL for the configured API version
+func writeEndpoint(cfg Config) (string, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return "", fmt.Errorf("invalid InfluxDB URL %q", cfg.URL)
+	}
+
+	query := url.Values{"precision": {"ns"}}
+	switch {
+	case cfg.Bucket != "":
+		base.Path += "/api/v2/write"
+		query.Set("bucket", cfg.Bucket)
+		query.Set("org", cfg.Org)
+	case cfg.Database != "":
+		base.Path += "/write"
+		query.Set("db", cfg.Database)
+	default:
+		return "", fmt.Errorf("InfluxDB bucket or database must be set")
+	}
+	base.RawQuery = query.Encode()
+	return base.String(), nil
+}
+
+// Write queues a result for the next batch. It never blocks the caller: when
+// InfluxDB falls too far behind, points are dropped (SQLite still has them).
+func (w *Writer) Write(result models.PingResult) error {
+	select {
+	case w.points <- LineProtocol(result):
+		return nil
+	default:
+		return fmt.Errorf("InfluxDB queue full, dropping point for %s", result.Target)
+	}
+}
+
+// Close flushes queued points and stops the background flusher
+func (w *Writer) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.points)
+		<-w.done
+	})
+	return nil
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]string, 0, w.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.send(batch); err != nil {
+			log.Printf("Failed to write %d points to InfluxDB: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case point, ok := <-w.points:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, point)
+			if len(batch) >= w.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// send writes one batch of points
+func (w *Writer) send(batch []string) error {
+	body := strings.Join(batch, "\n") + "\n"
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint, bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("InfluxDB returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// tagEscaper escapes the characters that are special in line protocol tag values
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// LineProtocol formats a result as an InfluxDB line protocol point, e.g.
+// ping,target=8.8.8.8 rtt=12.3,loss=0,up=1 1700000000000000000
+func LineProtocol(result models.PingResult) string {
+	up := 0.0
+	if result.Success {
+		up = 1
+	}
+
+	fields := make([]string, 0, 3)
+	if result.HasRTT() {
+		fields = append(fields, "rtt="+formatFloat(result.RTT))
+	}
+	fields = append(fields, "loss="+formatFloat(result.PacketLoss), "up="+formatFloat(up))
+
+	return fmt.Sprintf("ping,target=%s %s %d",
+		tagEscaper.Replace(result.Target), strings.Join(fields, ","), result.Timestamp.UnixNano())
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,142 @@
+package influx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestLineProtocol(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	tests := []struct {
+		name   string
+		result models.PingResult
+		want   string
+	}{
+		{
+			name:   "success",
+			result: models.PingResult{Timestamp: ts, Target: "8.8.8.8", Success: true, RTT: 12.3},
+			want:   "ping,target=8.8.8.8 rtt=12.3,loss=0,up=1 1700000000000000000",
+		},
+		{
+			name:   "failure has no rtt",
+			result: models.PingResult{Timestamp: ts, Target: "8.8.8.8", PacketLoss: 100},
+			want:   "ping,target=8.8.8.8 loss=100,up=0 1700000000000000000",
+		},
+		{
+			name:   "tag value is escaped",
+			result: models.PingResult{Timestamp: ts, Target: "my host,1=2", Success: true, RTT: 1},
+			want:   `ping,target=my\ host\,1\=2 rtt=1,loss=0,up=1 1700000000000000000`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LineProtocol(tt.result); got != tt.want {
+				t.Errorf("LineProtocol() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// influxServer records write requests
+type influxServer struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func (s *influxServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests = append(s.requests, r)
+	s.bodies = append(s.bodies, string(body))
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestWriterBatches(t *testing.T) {
+	recorder := &influxServer{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	writer, err := New(Config{
+		URL:           server.URL,
+		Token:         "secret",
+		Org:           "home",
+		Bucket:        "network",
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	base := time.Unix(1700000000, 0)
+	for i := 0; i < 3; i++ {
+		result := models.PingResult{Timestamp: base.Add(time.Duration(i) * time.Second), Target: "8.8.8.8", Success: true, RTT: 10}
+		if err := writer.Write(result); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	// Close flushes the final partial batch
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(recorder.bodies) != 2 {
+		t.Fatalf("expected 2 batches, got %d: %q", len(recorder.bodies), recorder.bodies)
+	}
+	wantFirst := "ping,target=8.8.8.8 rtt=10,loss=0,up=1 1700000000000000000\n" +
+		"ping,target=8.8.8.8 rtt=10,loss=0,up=1 1700000001000000000\n"
+	if recorder.bodies[0] != wantFirst {
+		t.Errorf("first batch = %q, want %q", recorder.bodies[0], wantFirst)
+	}
+	if lines := strings.Count(recorder.bodies[1], "\n"); lines != 1 {
+		t.Errorf("second batch has %d lines, want 1", lines)
+	}
+
+	req := recorder.requests[0]
+	if req.Method != http.MethodPost || req.URL.Path != "/api/v2/write" {
+		t.Errorf("request = %s %s, want POST /api/v2/write", req.Method, req.URL.Path)
+	}
+	query := req.URL.Query()
+	if query.Get("bucket") != "network" || query.Get("org") != "home" || query.Get("precision") != "ns" {
+		t.Errorf("unexpected query %q", req.URL.RawQuery)
+	}
+	if got := req.Header.Get("Authorization"); got != "Token secret" {
+		t.Errorf("Authorization = %q, want %q", got, "Token secret")
+	}
+}
+
+func TestWriteEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    string
+		wantErr bool
+	}{
+		{name: "v2 bucket", cfg: Config{URL: "http://influx:8086/", Org: "home", Bucket: "net"}, want: "http://influx:8086/api/v2/write?bucket=net&org=home&precision=ns"},
+		{name: "v1 database", cfg: Config{URL: "http://influx:8086", Database: "net"}, want: "http://influx:8086/write?db=net&precision=ns"},
+		{name: "no destination", cfg: Config{URL: "http://influx:8086"}, wantErr: true},
+		{name: "invalid url", cfg: Config{URL: "influx", Database: "net"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := writeEndpoint(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("writeEndpoint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
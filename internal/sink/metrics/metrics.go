@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"network-monitor/internal/models"
+)
+
+// rttMetric is the name of the per-target RTT histogram
+const rttMetric = "network_monitor_rtt_milliseconds"
+
+// contentType is the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// labelEscaper escapes a label value for the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// histogram counts observations per bucket. counts[i] holds the observations
+// at or below buckets[i] but above the bucket before; the last entry counts
+// those above every bucket.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Histograms keeps an RTT histogram per target from the results written to
+// it, and serves them in the Prometheus text format so histogram_quantile()
+// can compute percentiles across scrapes. It is a sink, so the monitor
+// observes every result in it; failed pings, and successes whose RTT couldn't
+// be parsed, have no RTT and aren't observed.
+type Histograms struct {
+	buckets []float64
+
+	mu      sync.Mutex
+	targets map[string]*histogram
+}
+
+// New creates empty histograms with the given upper bucket bounds in
+// milliseconds, ascending, or models.DefaultRTTBuckets when there are none
+func New(buckets []float64) *Histograms {
+	if len(buckets) == 0 {
+		buckets = models.DefaultRTTBuckets
+	}
+	return &Histograms{
+		buckets: slices.Clone(buckets),
+		targets: make(map[string]*histogram),
+	}
+}
+
+// Write observes the RTT of a successful result that has one
+func (h *Histograms) Write(result models.PingResult) error {
+	if !result.HasRTT() {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hist := h.targets[result.Target]
+	if hist == nil {
+		hist = &histogram{counts: make([]uint64, len(h.buckets)+1)}
+		h.targets[result.Target] = hist
+	}
+	// The first bucket whose bound is at or above the RTT; past the end when
+	// it's above them all
+	i, _ := slices.BinarySearch(h.buckets, result.RTT)
+	hist.counts[i]++
+	hist.sum += result.RTT
+	hist.count++
+	return nil
+}
+
+// Close is a no-op; the histograms live as long as the process
+func (h *Histograms) Close() error {
+	return nil
+}
+
+// ServeHTTP writes the histograms in the Prometheus text format
+func (h *Histograms) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	h.WriteTo(w)
+}
+
+// WriteTo writes the histograms in the Prometheus text format, with the
+// targets in order and cumulative bucket counts
+func (h *Histograms) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s Round-trip time of successful pings.\n", rttMetric)
+	fmt.Fprintf(&b, "# TYPE %s histogram\n", rttMetric)
+
+	h.mu.Lock()
+	targets := make([]string, 0, len(h.targets))
+	for target := range h.targets {
+		targets = append(targets, target)
+	}
+	slices.Sort(targets)
+	for _, target := range targets {
+		hist := h.targets[target]
+		label := labelEscaper.Replace(target)
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += hist.counts[i]
+			fmt.Fprintf(&b, "%s_bucket{target=\"%s\",le=\"%s\"} %d\n", rttMetric, label, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(&b, "%s_bucket{target=\"%s\",le=\"+Inf\"} %d\n", rttMetric, label, hist.count)
+		fmt.Fprintf(&b, "%s_sum{target=\"%s\"} %s\n", rttMetric, label, formatFloat(hist.sum))
+		fmt.Fprintf(&b, "%s_count{target=\"%s\"} %d\n", rttMetric, label, hist.count)
+	}
+	h.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// formatFloat formats a bucket bound or sum as Prometheus expects, without
+// an exponent for everyday values
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"network-monitor/internal/models"
+)
+
+func TestHistogramBuckets(t *testing.T) {
+	h := New([]float64{1, 10, 100})
+	for _, result := range []models.PingResult{
+		{Target: "8.8.8.8", Success: true, RTT: 0.5},
+		{Target: "8.8.8.8", Success: true, RTT: 1},
+		{Target: "8.8.8.8", Success: true, RTT: 7.5},
+		{Target: "8.8.8.8", Success: true, RTT: 250},
+		{Target: "8.8.8.8", Success: false},
+		{Target: "8.8.8.8", Success: true, RTT: 0}, // output couldn't be parsed
+		{Target: "1.1.1.1", Success: true, RTT: 50},
+	} {
+		if err := h.Write(result); err != nil {
+			t.Fatalf("Write(%+v): %v", result, err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", got)
+	}
+
+	want := `# HELP network_monitor_rtt_milliseconds Round-trip time of successful pings.
+# TYPE network_monitor_rtt_milliseconds histogram
+network_monitor_rtt_milliseconds_bucket{target="1.1.1.1",le="1"} 0
+network_monitor_rtt_milliseconds_bucket{target="1.1.1.1",le="10"} 0
+network_monitor_rtt_milliseconds_bucket{target="1.1.1.1",le="100"} 1
+network_monitor_rtt_milliseconds_bucket{target="1.1.1.1",le="+Inf"} 1
+network_monitor_rtt_milliseconds_sum{target="1.1.1.1"} 50
+network_monitor_rtt_milliseconds_count{target="1.1.1.1"} 1
+network_monitor_rtt_milliseconds_bucket{target="8.8.8.8",le="1"} 2
+network_monitor_rtt_milliseconds_bucket{target="8.8.8.8",le="10"} 3
+network_monitor_rtt_milliseconds_bucket{target="8.8.8.8",le="100"} 3
+network_monitor_rtt_milliseconds_bucket{target="8.8.8.8",le="+Inf"} 4
+network_monitor_rtt_milliseconds_sum{target="8.8.8.8"} 259
+network_monitor_rtt_milliseconds_count{target="8.8.8.8"} 4
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("metrics =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogramEscapesTargets(t *testing.T) {
+	h := New(nil)
+	h.Write(models.PingResult{Target: `https://example.com/?q="a\b"`, Success: true, RTT: 3})
+
+	var b strings.Builder
+	h.WriteTo(&b)
+	want := `network_monitor_rtt_milliseconds_count{target="https://example.com/?q=\"a\\b\""} 1`
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("metrics =\n%s\nwant a line %s", b.String(), want)
+	}
+	if !strings.Contains(b.String(), `le="1000"}`) {
+		t.Errorf("metrics =\n%s\nwant the default buckets", b.String())
+	}
+}
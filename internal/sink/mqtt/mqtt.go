@@ -0,0 +1,194 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"network-monitor/internal/models"
+)
+
+const (
+	defaultTopicPrefix = "network-monitor"
+	defaultClientID    = "network-monitor"
+	publishTimeout     = 5 * time.Second
+	disconnectQuiesce  = 250 // milliseconds; publishes have already completed by then
+	reconnectInterval  = 10 * time.Second
+	queueSize          = 1000
+)
+
+// Config holds the MQTT broker settings
+type Config struct {
+	Broker      string // e.g. tcp://homeassistant.local:1883
+	TopicPrefix string
+	Username    string
+	Password    string
+	ClientID    string
+}
+
+// message is a queued publish
+type message struct {
+	topic    string
+	payload  []byte
+	qos      byte
+	retained bool
+}
+
+// Publisher publishes ping results to <prefix>/<target>/rtt and outage
+// transitions to <prefix>/<target>/state. Publishing happens on a background
+// goroutine so a slow or unreachable broker never holds up the monitor.
+type Publisher struct {
+	client paho.Client
+	prefix string
+
+	messages  chan message
+	done      chan struct{}
+	closeOnce sync.Once
+
+	stateMu sync.Mutex
+	states  map[string]message // latest state per topic, republished on reconnect
+}
+
+// New creates a Publisher and starts connecting in the background. The broker
+// does not need to be reachable yet; the client keeps retrying and reconnects
+// automatically after disconnects.
+func New(cfg Config) (*Publisher, error) {
+	if cfg.Broker == "" {
+		return nil, fmt.Errorf("MQTT broker must be set")
+	}
+
+	p := &Publisher{
+		prefix:   strings.TrimSuffix(cfg.TopicPrefix, "/"),
+		messages: make(chan message, queueSize),
+		done:     make(chan struct{}),
+		states:   make(map[string]message),
+	}
+	if p.prefix == "" {
+		p.prefix = defaultTopicPrefix
+	}
+	clientID := cfg.ClientID
+	if clientID == "" {
+		clientID = defaultClientID
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(clientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(reconnectInterval).
+		SetMaxReconnectInterval(reconnectInterval).
+		SetWriteTimeout(publishTimeout).
+		SetOnConnectHandler(p.onConnect).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			log.Printf("MQTT connection lost: %v", err)
+		})
+	p.client = paho.NewClient(opts)
+	p.client.Connect()
+
+	go p.run()
+	return p, nil
+}
+
+// topic builds a per-target topic; MQTT wildcard and level separators in the
+// target would otherwise change the topic structure
+func (p *Publisher) topic(target, leaf string) string {
+	return fmt.Sprintf("%s/%s/%s", p.prefix, topicEscaper.Replace(target), leaf)
+}
+
+var topicEscaper = strings.NewReplacer("/", "_", "+", "_", "#", "_")
+
+// Write queues a result for publishing to <prefix>/<target>/rtt as JSON
+func (p *Publisher) Write(result models.PingResult) error {
+	payload, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return p.enqueue(message{topic: p.topic(result.Target, "rtt"), payload: payload})
+}
+
+// WriteState queues a retained "up"/"down" message for <prefix>/<target>/state
+func (p *Publisher) WriteState(state models.TargetState) error {
+	return p.enqueue(message{
+		topic:    p.topic(state.Target, "state"),
+		payload:  []byte(state.State),
+		qos:      1,
+		retained: true,
+	})
+}
+
+func (p *Publisher) enqueue(msg message) error {
+	select {
+	case p.messages <- msg:
+		return nil
+	default:
+		return fmt.Errorf("MQTT queue full, dropping message for %s", msg.topic)
+	}
+}
+
+// Close publishes queued messages and disconnects from the broker
+func (p *Publisher) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.messages)
+		<-p.done
+		p.client.Disconnect(disconnectQuiesce)
+	})
+	return nil
+}
+
+func (p *Publisher) run() {
+	defer close(p.done)
+
+	for msg := range p.messages {
+		if msg.retained {
+			p.stateMu.Lock()
+			p.states[msg.topic] = msg
+			p.stateMu.Unlock()
+		}
+
+		// Results published while disconnected are dropped; states are
+		// republished by onConnect once the broker is back
+		if !p.client.IsConnectionOpen() {
+			continue
+		}
+		p.publish(msg)
+	}
+}
+
+func (p *Publisher) publish(msg message) {
+	token := p.client.Publish(msg.topic, msg.qos, msg.retained, msg.payload)
+	if !token.WaitTimeout(publishTimeout) {
+		log.Printf("MQTT publish to %s timed out", msg.topic)
+		return
+	}
+	if err := token.Error(); err != nil {
+		log.Printf("MQTT publish to %s failed: %v", msg.topic, err)
+	}
+}
+
+// onConnect republishes the latest state of every target, so the broker's
+// retained states are correct after an outage of the broker itself
+func (p *Publisher) onConnect(paho.Client) {
+	log.Printf("Connected to MQTT broker")
+
+	p.stateMu.Lock()
+	states := make([]message, 0, len(p.states))
+	for _, msg := range p.states {
+		states = append(states, msg)
+	}
+	p.stateMu.Unlock()
+
+	// Handlers must not block the client, so publish from a new goroutine
+	go func() {
+		for _, msg := range states {
+			p.publish(msg)
+		}
+	}()
+}
@@ -0,0 +1,211 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+type publishedMessage struct {
+	topic    string
+	payload  string
+	qos      byte
+	retained bool
+}
+
+// mockBroker speaks just enough MQTT 3.1.1 to accept a client and record its publishes
+type mockBroker struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []publishedMessage
+}
+
+func newMockBroker(t *testing.T) *mockBroker {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	b := &mockBroker{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *mockBroker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *mockBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	for {
+		header, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		length, err := readRemainingLength(r)
+		if err != nil {
+			return
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return
+		}
+
+		switch header >> 4 {
+		case 1: // CONNECT
+			conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+		case 3: // PUBLISH
+			qos := (header >> 1) & 0x03
+			topicLen := int(binary.BigEndian.Uint16(body))
+			msg := publishedMessage{
+				topic:    string(body[2 : 2+topicLen]),
+				qos:      qos,
+				retained: header&0x01 == 1,
+			}
+			rest := body[2+topicLen:]
+			if qos > 0 {
+				conn.Write([]byte{0x40, 0x02, rest[0], rest[1]}) // PUBACK
+				rest = rest[2:]
+			}
+			msg.payload = string(rest)
+
+			b.mu.Lock()
+			b.messages = append(b.messages, msg)
+			b.mu.Unlock()
+		case 12: // PINGREQ
+			conn.Write([]byte{0xD0, 0x00})
+		case 14: // DISCONNECT
+			return
+		}
+	}
+}
+
+func readRemainingLength(r *bufio.Reader) (int, error) {
+	length, multiplier := 0, 1
+	for {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		length += int(digit&0x7F) * multiplier
+		if digit&0x80 == 0 {
+			return length, nil
+		}
+		multiplier *= 128
+	}
+}
+
+func (b *mockBroker) published() []publishedMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]publishedMessage(nil), b.messages...)
+}
+
+func TestPublisherPublishesResultsAndStates(t *testing.T) {
+	broker := newMockBroker(t)
+
+	publisher, err := New(Config{Broker: broker.url(), TopicPrefix: "home/net/"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !publisher.client.IsConnectionOpen() {
+		if time.Now().After(deadline) {
+			t.Fatal("publisher did not connect to the broker")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	result := models.PingResult{Timestamp: now, Target: "8.8.8.8", Success: true, RTT: 12.3, Attempts: 1}
+	if err := publisher.Write(result); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := publisher.WriteState(models.TargetState{Target: "8.8.8.8", State: models.StateDown, Timestamp: now}); err != nil {
+		t.Fatalf("WriteState() error = %v", err)
+	}
+	if err := publisher.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	messages := broker.published()
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 published messages, got %+v", messages)
+	}
+
+	rtt := messages[0]
+	if rtt.topic != "home/net/8.8.8.8/rtt" || rtt.retained {
+		t.Errorf("unexpected rtt message %+v", rtt)
+	}
+	var got models.PingResult
+	if err := json.Unmarshal([]byte(rtt.payload), &got); err != nil {
+		t.Fatalf("rtt payload is not a JSON ping result: %v", err)
+	}
+	if got.RTT != 12.3 || !got.Success || !got.Timestamp.Equal(now) {
+		t.Errorf("rtt payload = %+v, want %+v", got, result)
+	}
+
+	state := messages[1]
+	want := publishedMessage{topic: "home/net/8.8.8.8/state", payload: "down", qos: 1, retained: true}
+	if state != want {
+		t.Errorf("state message = %+v, want %+v", state, want)
+	}
+}
+
+func TestPublisherDoesNotBlockWithoutBroker(t *testing.T) {
+	// Nothing listens on this port, so the client keeps retrying in the background
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	publisher, err := New(Config{Broker: "tcp://" + addr})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer publisher.Close()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < queueSize*2; i++ {
+			publisher.Write(models.PingResult{Timestamp: time.Now(), Target: "8.8.8.8"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Write blocked while the broker was unreachable")
+	}
+}
+
+func TestTopicEscapesTarget(t *testing.T) {
+	p := &Publisher{prefix: "nm"}
+	if got := p.topic("a/b+c#", "rtt"); got != "nm/a_b_c_/rtt" {
+		t.Errorf("topic() = %q", got)
+	}
+}
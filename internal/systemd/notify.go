@@ -0,0 +1,81 @@
+// Package systemd tells the service manager about the process's state through
+// the sd_notify protocol: a datagram to the socket named in NOTIFY_SOCKET. It
+// does nothing when that variable is unset, i.e. when not run by systemd as a
+// Type=notify service.
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// States sent to the service manager
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Watchdog = "WATCHDOG=1"
+)
+
+// Notify sends state, e.g. Ready, to the service manager. It reports whether
+// it was sent: false with no error when there is no notify socket.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// A leading @ names a socket in the abstract namespace
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("connect to notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("send %s: %w", state, err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns how often the service manager expects Watchdog
+// pings, or 0 if it doesn't watch this process. That is half of WATCHDOG_USEC,
+// as sd_watchdog_enabled recommends, so one late ping isn't fatal.
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	// WATCHDOG_PID, when set, names the process being watched
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// RunWatchdog sends a Watchdog ping every interval until ctx is cancelled.
+// It returns at once when the service manager isn't watching.
+func RunWatchdog(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := Notify(Watchdog); err != nil {
+				log.Printf("Failed to ping the systemd watchdog: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package traceroute
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+const (
+	maxHops       = 30
+	probesPerHop  = 3
+	probeWait     = 2 * time.Second
+	commandBuffer = 5 * time.Second
+)
+
+// Tracer runs hop-by-hop traces using the OS-native traceroute/tracert binary
+type Tracer struct{}
+
+// New creates a new Tracer
+func New() *Tracer {
+	return &Tracer{}
+}
+
+// Trace runs a traceroute to the target and returns per-hop loss and latency
+func (t *Tracer) Trace(parent context.Context, target string) ([]models.TracerouteHop, error) {
+	// Worst case every probe on every hop waits out its timeout
+	ctx, cancel := context.WithTimeout(parent, maxHops*probesPerHop*probeWait+commandBuffer)
+	defer cancel()
+
+	spec, err := models.ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	name, args := buildTracerouteCommand(spec)
+	output, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%s to %s aborted: %w", name, target, ctx.Err())
+	}
+
+	hops := parseTracerouteOutput(string(output))
+	if len(hops) == 0 {
+		if err != nil {
+			return nil, fmt.Errorf("%s to %s failed: %w: %s", name, target, err, strings.TrimSpace(string(output)))
+		}
+		return nil, fmt.Errorf("unable to parse %s output for %s", name, target)
+	}
+
+	// traceroute exits non-zero on some platforms when the destination never answers;
+	// the hops gathered so far are still the evidence we want
+	return hops, nil
+}
+
+// buildTracerouteCommand traces from the target's source interface or address,
+// if any, so the path matches the one its pings take
+func buildTracerouteCommand(target models.Target) (string, []string) {
+	if runtime.GOOS == "windows" {
+		args := []string{
+			"-d",
+			"-h", strconv.Itoa(maxHops),
+			"-w", strconv.Itoa(int(probeWait / time.Millisecond)),
+		}
+		if target.Via != "" {
+			args = append(args, "-S", target.Via)
+		}
+		return "tracert", append(args, target.Host)
+	}
+	args := []string{
+		"-n",
+		"-q", strconv.Itoa(probesPerHop),
+		"-w", strconv.Itoa(int(probeWait / time.Second)),
+		"-m", strconv.Itoa(maxHops),
+	}
+	switch {
+	case target.Via == "":
+	case net.ParseIP(target.Via) != nil:
+		args = append(args, "-s", target.Via)
+	default:
+		args = append(args, "-i", target.Via)
+	}
+	return "traceroute", append(args, target.Host)
+}
+
+var (
+	// Leading hop number, e.g. " 3  10.0.0.1  8.1 ms" or "  3    12 ms  ..."
+	hopLinePattern = regexp.MustCompile(`^\s*(\d+)\s+(.*)$`)
+	// Unix probe RTT: "8.123 ms"
+	unixRTTPattern = regexp.MustCompile(`^([0-9.]+)$`)
+	// Windows probe RTT: "8", "<1" (followed by a separate "ms" token)
+	windowsRTTPattern = regexp.MustCompile(`^<?([0-9]+)$`)
+	ipPattern         = regexp.MustCompile(`^(\d{1,3}(\.\d{1,3}){3}|[0-9a-fA-F:]*:[0-9a-fA-F:]+)$`)
+)
+
+// parseTracerouteOutput parses traceroute (Linux/macOS) or tracert (Windows) output
+// into hops. Each probe is either an RTT or "*" for a lost probe.
+//
+// Linux/macOS: " 2  10.0.0.1  8.123 ms  7.456 ms *"
+// Windows:     "  2     8 ms     7 ms     *     10.0.0.1"
+func parseTracerouteOutput(output string) []models.TracerouteHop {
+	var hops []models.TracerouteHop
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		matches := hopLinePattern.FindStringSubmatch(scanner.Text())
+		if matches == nil {
+			continue
+		}
+		hopNum, err := strconv.Atoi(matches[1])
+		if err != nil {
+			continue
+		}
+
+		hop, ok := parseHopFields(strings.Fields(matches[2]))
+		if !ok {
+			continue
+		}
+		hop.Hop = hopNum
+		hops = append(hops, hop)
+	}
+
+	return hops
+}
+
+// parseHopFields extracts the responding address, RTTs and lost probes from a hop line
+func parseHopFields(fields []string) (models.TracerouteHop, bool) {
+	var (
+		hop   models.TracerouteHop
+		rtts  []float64
+		lost  int
+		probe bool
+	)
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		next := ""
+		if i+1 < len(fields) {
+			next = fields[i+1]
+		}
+
+		switch {
+		case field == "*":
+			lost++
+			probe = true
+		case next == "ms" && windowsRTTPattern.MatchString(field):
+			value, _ := strconv.ParseFloat(windowsRTTPattern.FindStringSubmatch(field)[1], 64)
+			rtts = append(rtts, value)
+			probe = true
+			i++ // skip "ms"
+		case next == "ms" && unixRTTPattern.MatchString(field):
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				continue
+			}
+			rtts = append(rtts, value)
+			probe = true
+			i++ // skip "ms"
+		case strings.HasSuffix(field, "ms") && unixRTTPattern.MatchString(strings.TrimSuffix(field, "ms")):
+			// Some builds print "8.1ms" without a space
+			value, err := strconv.ParseFloat(strings.TrimSuffix(field, "ms"), 64)
+			if err != nil {
+				continue
+			}
+			rtts = append(rtts, value)
+			probe = true
+		case hop.Address == "" && ipPattern.MatchString(strings.Trim(field, "()[]")):
+			hop.Address = strings.Trim(field, "()[]")
+		}
+	}
+
+	if !probe {
+		return hop, false
+	}
+
+	total := len(rtts) + lost
+	hop.Loss = float64(lost) / float64(total) * 100
+	if len(rtts) > 0 {
+		var sum float64
+		for _, rtt := range rtts {
+			sum += rtt
+		}
+		hop.AvgRTT = sum / float64(len(rtts))
+	}
+
+	return hop, true
+}
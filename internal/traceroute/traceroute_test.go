@@ -0,0 +1,81 @@
+package traceroute
+
+import (
+	"math"
+	"testing"
+
+	"network-monitor/internal/models"
+)
+
+func TestParseTracerouteOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []models.TracerouteHop
+	}{
+		{
+			name: "Linux traceroute",
+			output: `traceroute to 8.8.8.8 (8.8.8.8), 30 hops max, 60 byte packets
+ 1  192.168.1.1  0.512 ms  0.480 ms  0.470 ms
+ 2  * * *
+ 3  10.0.0.1  8.100 ms *  8.500 ms
+ 4  8.8.8.8  12.000 ms  12.000 ms  12.000 ms`,
+			want: []models.TracerouteHop{
+				{Hop: 1, Address: "192.168.1.1", AvgRTT: 0.4873, Loss: 0},
+				{Hop: 2, Address: "", AvgRTT: 0, Loss: 100},
+				{Hop: 3, Address: "10.0.0.1", AvgRTT: 8.3, Loss: 33.3333},
+				{Hop: 4, Address: "8.8.8.8", AvgRTT: 12, Loss: 0},
+			},
+		},
+		{
+			name: "macOS traceroute with load-balanced hop",
+			output: `traceroute to 1.1.1.1 (1.1.1.1), 30 hops max, 52 byte packets
+ 1  192.168.0.1  2.345 ms  1.234 ms  1.111 ms
+ 2  100.64.0.1  9.000 ms
+    100.64.0.2  11.000 ms  10.000 ms`,
+			want: []models.TracerouteHop{
+				{Hop: 1, Address: "192.168.0.1", AvgRTT: 1.5633, Loss: 0},
+				{Hop: 2, Address: "100.64.0.1", AvgRTT: 9, Loss: 0},
+			},
+		},
+		{
+			name: "Windows tracert",
+			output: `
+Tracing route to 8.8.8.8 over a maximum of 30 hops
+
+  1    <1 ms    <1 ms    <1 ms  192.168.1.1
+  2     8 ms     7 ms     9 ms  10.0.0.1
+  3     *        *        *     Request timed out.
+  4    15 ms     *       17 ms  8.8.8.8
+
+Trace complete.`,
+			want: []models.TracerouteHop{
+				{Hop: 1, Address: "192.168.1.1", AvgRTT: 1, Loss: 0},
+				{Hop: 2, Address: "10.0.0.1", AvgRTT: 8, Loss: 0},
+				{Hop: 3, Address: "", AvgRTT: 0, Loss: 100},
+				{Hop: 4, Address: "8.8.8.8", AvgRTT: 16, Loss: 33.3333},
+			},
+		},
+		{
+			name:   "Unknown host",
+			output: "traceroute: unknown host example.invalid",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseTracerouteOutput(tt.output)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parsed %d hops, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				g, w := got[i], tt.want[i]
+				if g.Hop != w.Hop || g.Address != w.Address ||
+					math.Abs(g.AvgRTT-w.AvgRTT) > 0.001 || math.Abs(g.Loss-w.Loss) > 0.001 {
+					t.Errorf("hop %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
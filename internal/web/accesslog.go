@@ -0,0 +1,66 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// SetAccessLog logs every request to logger once it has been answered: who
+// made it, its method and path, the response status and size, and how long
+// it took. A nil logger turns the access log off.
+func (s *Server) SetAccessLog(logger *slog.Logger) {
+	s.accessLog = logger
+}
+
+// withAccessLog logs each request handled by next to the access log. Without
+// an access log it adds nothing.
+func (s *Server) withAccessLog(next http.Handler) http.Handler {
+	if s.accessLog == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		s.accessLog.LogAttrs(r.Context(), slog.LevelInfo, "HTTP request",
+			slog.String("client", clientIP(r)),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.status),
+			slog.Int64("bytes", recorder.bytes),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
+
+// statusRecorder captures the status code and body size a handler writes.
+// Handlers that never call WriteHeader answer 200.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
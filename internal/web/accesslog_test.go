@@ -0,0 +1,65 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name       string
+		basePath   string
+		path       string
+		wantPath   string
+		wantStatus int
+	}{
+		{"API request", "", "/api/stats", "/api/stats", http.StatusOK},
+		{"bad request", "", "/api/stats?hours=0", "/api/stats", http.StatusBadRequest},
+		{"missing file", "", "/missing.js", "/missing.js", http.StatusNotFound},
+		{"outside the base path", "/netmon", "/api/stats", "/api/stats", http.StatusNotFound},
+		{"under the base path", "/netmon", "/netmon/api/stats", "/netmon/api/stats", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := New(&stubDB{}, nil, 0, fstest.MapFS{"index.html": {Data: []byte("<html></html>")}})
+			s.SetBasePath(tt.basePath)
+			s.SetAccessLog(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, req)
+
+			var record struct {
+				Client   string `json:"client"`
+				Method   string `json:"method"`
+				Path     string `json:"path"`
+				Status   int    `json:"status"`
+				Bytes    int    `json:"bytes"`
+				Duration int64  `json:"duration"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("decode access log %q: %v", buf.String(), err)
+			}
+			if record.Status != tt.wantStatus || record.Status != rec.Code {
+				t.Errorf("logged status = %d, response %d, want %d", record.Status, rec.Code, tt.wantStatus)
+			}
+			if record.Client != "192.0.2.1" || record.Method != http.MethodGet || record.Path != tt.wantPath {
+				t.Errorf("logged %s %s from %s, want GET %s from 192.0.2.1", record.Method, record.Path, record.Client, tt.wantPath)
+			}
+			if record.Bytes != rec.Body.Len() {
+				t.Errorf("logged %d bytes, response has %d", record.Bytes, rec.Body.Len())
+			}
+			if record.Duration <= 0 {
+				t.Errorf("logged duration = %d, want positive", record.Duration)
+			}
+		})
+	}
+}
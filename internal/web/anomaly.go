@@ -0,0 +1,96 @@
+package web
+
+import (
+	"math"
+	"sort"
+
+	"network-monitor/internal/models"
+)
+
+// DefaultAnomalySigma is how many standard deviations above a target's
+// rolling mean an RTT must be to count as an anomaly, until SetAnomalySigma
+// is called
+const DefaultAnomalySigma = 3.0
+
+// anomalyWindow is how many of a target's preceding successful pings each RTT
+// is compared with, and anomalyMinSamples how many of them there must be
+// before any RTT is flagged
+const (
+	anomalyWindow     = 30
+	anomalyMinSamples = 10
+)
+
+// A flat baseline, such as a LAN target or ping output in whole milliseconds,
+// has no deviation to speak of, which would make any RTT above its mean an
+// anomaly. The deviation an RTT is compared with is at least
+// anomalyMinDeviation of the mean, and anomalyMinDeviationMs.
+const (
+	anomalyMinDeviation   = 0.1
+	anomalyMinDeviationMs = 1.0
+)
+
+// SetAnomalySigma sets how many standard deviations above a target's rolling
+// mean RTT /api/recent flags a result as an anomaly. Zero or less turns
+// anomaly flagging off.
+func (s *Server) SetAnomalySigma(sigma float64) {
+	s.sigma = max(sigma, 0)
+}
+
+// markAnomalies flags the results that stand out from their target's
+// neighbours: an RTT more than sigma standard deviations above the mean of
+// the target's preceding successful pings, or a failure between two successes.
+// The standard deviation has a floor, so that a flat baseline doesn't make
+// every slightly slower ping a spike.
+// Results may be in any order; only those given are compared, so the first
+// few of each target have no history to stand out from.
+func markAnomalies(results []models.PingResult, sigma float64) {
+	if sigma <= 0 {
+		return
+	}
+
+	byTarget := make(map[string][]int)
+	for i, r := range results {
+		byTarget[r.Target] = append(byTarget[r.Target], i)
+	}
+
+	for _, indexes := range byTarget {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return results[indexes[a]].Timestamp.Before(results[indexes[b]].Timestamp)
+		})
+
+		var recent []float64 // RTTs of the latest successful pings, oldest first
+		for n, i := range indexes {
+			r := &results[i]
+			if !r.Success {
+				r.Anomaly = n > 0 && n < len(indexes)-1 &&
+					results[indexes[n-1]].Success && results[indexes[n+1]].Success
+				continue
+			}
+			if !r.HasRTT() {
+				continue
+			}
+
+			if len(recent) >= anomalyMinSamples {
+				mean, stddev := meanStddev(recent)
+				deviation := max(stddev, anomalyMinDeviation*mean, anomalyMinDeviationMs)
+				r.Anomaly = r.RTT > mean+sigma*deviation
+			}
+			recent = append(recent, r.RTT)
+			if len(recent) > anomalyWindow {
+				recent = recent[1:]
+			}
+		}
+	}
+}
+
+// meanStddev returns the mean and population standard deviation of values
+func meanStddev(values []float64) (mean, stddev float64) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		stddev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(values)))
+}
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// rttSeries returns one result a second for target: an RTT each, 0 = failed
+func rttSeries(target string, start time.Time, rtts ...float64) []models.PingResult {
+	results := make([]models.PingResult, len(rtts))
+	for i, rtt := range rtts {
+		results[i] = models.PingResult{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Target:    target,
+			Success:   rtt > 0,
+			RTT:       rtt,
+		}
+	}
+	return results
+}
+
+func TestMarkAnomalies(t *testing.T) {
+	steady := []float64{10, 11, 10, 12, 11, 10, 11, 12, 10, 11, 11, 10}
+	tests := []struct {
+		name  string
+		sigma float64
+		rtts  []float64
+		want  []int // indexes flagged
+	}{
+		{name: "steady", sigma: 3, rtts: steady},
+		{name: "spike", sigma: 3, rtts: append(append([]float64{}, steady...), 80, 11), want: []int{12}},
+		{name: "bump on a flat baseline", sigma: 3, rtts: []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 2, 1}},
+		{name: "bump on a flat slower baseline", sigma: 3, rtts: []float64{40, 40, 40, 40, 40, 40, 40, 40, 40, 40, 45, 40}},
+		{name: "spike on a flat baseline", sigma: 3, rtts: []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 5, 1}, want: []int{10}},
+		{name: "spike too early to judge", sigma: 3, rtts: []float64{10, 11, 80, 10}},
+		{name: "isolated failure", sigma: 3, rtts: []float64{10, 0, 11, 0, 0, 10}, want: []int{1}},
+		{name: "disabled", sigma: 0, rtts: append(append([]float64{}, steady...), 80, 0, 11)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := rttSeries("8.8.8.8", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), tt.rtts...)
+			markAnomalies(results, tt.sigma)
+
+			want := make(map[int]bool)
+			for _, i := range tt.want {
+				want[i] = true
+			}
+			for i, r := range results {
+				if r.Anomaly != want[i] {
+					t.Errorf("result %d (rtt %v) anomaly = %v, want %v", i, tt.rtts[i], r.Anomaly, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHandleRecentMarksAnomalies(t *testing.T) {
+	s := newTestServer(t)
+	start := time.Now().Add(-time.Hour)
+	seeded := append(
+		rttSeries("8.8.8.8", start, 20, 21, 20, 22, 21, 20, 21, 22, 20, 21, 21, 20, 250, 21),
+		// Another target's slower pings don't count towards 8.8.8.8's mean
+		rttSeries("1.1.1.1", start, 200, 210, 200, 220, 210, 200, 210, 220, 200, 210, 210, 200, 205, 210)...)
+	if err := s.db.SaveResults(context.Background(), seeded); err != nil {
+		t.Fatalf("SaveResults() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleRecent(rec, httptest.NewRequest(http.MethodGet, "/api/recent?hours=2", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var results []struct {
+		Target  string  `json:"target"`
+		RTT     float64 `json:"rtt_ms"`
+		Anomaly bool    `json:"anomaly"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(results) != len(seeded) {
+		t.Fatalf("got %d results, want %d", len(results), len(seeded))
+	}
+	for _, r := range results {
+		want := r.Target == "8.8.8.8" && r.RTT == 250
+		if r.Anomaly != want {
+			t.Errorf("%s at %v ms anomaly = %v, want %v", r.Target, r.RTT, r.Anomaly, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// CORS response headers for allowed origins
+const (
+	corsAllowMethods = "GET, POST, OPTIONS"
+	corsAllowHeaders = "Content-Type"
+	corsMaxAge       = "600" // seconds browsers may cache a preflight result
+)
+
+// withCORS lets the configured origins call the /api/* routes from another
+// site. Without configured origins it adds nothing, so browsers keep the API
+// same-origin only.
+func (s *Server) withCORS(next http.Handler) http.Handler {
+	if len(s.corsOrigins) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The response depends on the Origin header, so caches must key on it
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		allowed := s.corsAllowOrigin(origin)
+		if allowed == "" {
+			if preflight {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", allowed)
+		h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+		h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+		if preflight {
+			h.Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsAllowOrigin returns the Access-Control-Allow-Origin value for a request
+// from origin, or "" when the origin isn't allowed
+func (s *Server) corsAllowOrigin(origin string) string {
+	if slices.Contains(s.corsOrigins, "*") {
+		return "*"
+	}
+	if slices.Contains(s.corsOrigins, origin) {
+		return origin
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		method      string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantAllowed string // expected Access-Control-Allow-Origin, "" for none
+	}{
+		{name: "allowed origin", origins: []string{"https://dash.example.com"}, method: http.MethodGet, origin: "https://dash.example.com", wantStatus: http.StatusOK, wantAllowed: "https://dash.example.com"},
+		{name: "disallowed origin", origins: []string{"https://dash.example.com"}, method: http.MethodGet, origin: "https://evil.example.com", wantStatus: http.StatusOK},
+		{name: "any origin", origins: []string{"*"}, method: http.MethodGet, origin: "https://evil.example.com", wantStatus: http.StatusOK, wantAllowed: "*"},
+		{name: "same-origin request", origins: []string{"https://dash.example.com"}, method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "disabled by default", method: http.MethodGet, origin: "https://dash.example.com", wantStatus: http.StatusOK},
+		{name: "preflight allowed", origins: []string{"https://dash.example.com"}, method: http.MethodOptions, origin: "https://dash.example.com", preflight: true, wantStatus: http.StatusNoContent, wantAllowed: "https://dash.example.com"},
+		{name: "preflight disallowed", origins: []string{"https://dash.example.com"}, method: http.MethodOptions, origin: "https://evil.example.com", preflight: true, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(&stubDB{}, nil, 0, nil)
+			s.SetCORSOrigins(tt.origins)
+
+			req := httptest.NewRequest(tt.method, "/api/stats", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowed {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllowed)
+			}
+			if tt.wantAllowed != "" && rec.Header().Get("Access-Control-Allow-Methods") == "" {
+				t.Error("Access-Control-Allow-Methods not set")
+			}
+		})
+	}
+}
+
+func TestCORSOnlyCoversAPI(t *testing.T) {
+	s := New(&stubDB{}, nil, 0, nil)
+	s.SetCORSOrigins([]string{"*"})
+
+	req := httptest.NewRequest(http.MethodGet, "/grafana/", nil)
+	req.Header.Set("Origin", "https://dash.example.com")
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q on a non-API route, want none", got)
+	}
+}
@@ -0,0 +1,187 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// Metrics exposed to Grafana's SimpleJSON datasource. Targets are named
+// "<metric>:<host>", e.g. "rtt:8.8.8.8"; the metric comes first so IPv6 hosts
+// can be split on the first colon.
+const (
+	metricRTT        = "rtt"
+	metricPacketLoss = "packet_loss"
+	metricUp         = "up"
+)
+
+var grafanaMetrics = []string{metricRTT, metricPacketLoss, metricUp}
+
+// grafanaQuery is the subset of a SimpleJSON /query request body we use
+type grafanaQuery struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	IntervalMs    int64 `json:"intervalMs"`
+	MaxDataPoints int64 `json:"maxDataPoints"`
+	Targets       []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+}
+
+// grafanaSeries is a SimpleJSON timeserie response; each datapoint is
+// [value, unix_ms]
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// bucketStats accumulates the pings falling into one datapoint interval
+type bucketStats struct {
+	total      int
+	successful int
+	timed      int // successful pings with an RTT
+	rttSum     float64
+}
+
+// handleGrafanaRoot handles /grafana/ requests; SimpleJSON uses it as a connection test
+func (s *Server) handleGrafanaRoot(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/grafana/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleGrafanaSearch handles /grafana/search requests
+func (s *Server) handleGrafanaSearch(w http.ResponseWriter, r *http.Request) {
+	hosts, err := s.db.GetTargets(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	names := make([]string, 0, len(hosts)*len(grafanaMetrics))
+	for _, metric := range grafanaMetrics {
+		for _, host := range hosts {
+			names = append(names, metric+":"+host)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(names)
+}
+
+// handleGrafanaQuery handles POST /grafana/query requests
+func (s *Server) handleGrafanaQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var query grafanaQuery
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		http.Error(w, "invalid query: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.Range.From.IsZero() || query.Range.To.IsZero() || !query.Range.To.After(query.Range.From) {
+		http.Error(w, "invalid query range", http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.db.GetRange(r.Context(), query.Range.From, query.Range.To)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	interval := grafanaInterval(query)
+	series := make([]grafanaSeries, 0, len(query.Targets))
+	for _, t := range query.Targets {
+		metric, host, ok := strings.Cut(t.Target, ":")
+		if !ok || !isGrafanaMetric(metric) {
+			http.Error(w, "unknown target "+t.Target, http.StatusBadRequest)
+			return
+		}
+		series = append(series, grafanaSeries{
+			Target:     t.Target,
+			Datapoints: grafanaDatapoints(results, host, metric, interval),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+func isGrafanaMetric(metric string) bool {
+	for _, m := range grafanaMetrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// grafanaInterval picks the datapoint width in milliseconds: the panel's
+// interval, widened if needed so the range fits in maxDataPoints
+func grafanaInterval(query grafanaQuery) int64 {
+	interval := query.IntervalMs
+	if query.MaxDataPoints > 0 {
+		rangeMs := query.Range.To.Sub(query.Range.From).Milliseconds()
+		interval = max(interval, (rangeMs+query.MaxDataPoints-1)/query.MaxDataPoints)
+	}
+	return max(interval, 1)
+}
+
+// grafanaDatapoints aggregates one host's results into interval-wide buckets:
+// mean RTT of successful pings, percentage of failed pings, and the fraction
+// of successful pings (1 = up). Buckets without successful pings have no RTT point.
+func grafanaDatapoints(results []models.PingResult, host, metric string, interval int64) [][2]float64 {
+	var keys []int64
+	buckets := make(map[int64]*bucketStats)
+	for _, result := range results {
+		if result.Target != host {
+			continue
+		}
+		key := result.Timestamp.UnixMilli() / interval * interval
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucketStats{}
+			buckets[key] = b
+			keys = append(keys, key)
+		}
+		b.total++
+		if result.Success {
+			b.successful++
+		}
+		if result.HasRTT() {
+			b.timed++
+			b.rttSum += result.RTT
+		}
+	}
+
+	datapoints := make([][2]float64, 0, len(keys))
+	for _, key := range keys {
+		b := buckets[key]
+		var value float64
+		switch metric {
+		case metricRTT:
+			if b.timed == 0 {
+				continue
+			}
+			value = b.rttSum / float64(b.timed)
+		case metricPacketLoss:
+			value = float64(b.total-b.successful) / float64(b.total) * 100
+		case metricUp:
+			value = float64(b.successful) / float64(b.total)
+		}
+		datapoints = append(datapoints, [2]float64{value, float64(key)})
+	}
+
+	return datapoints
+}
@@ -0,0 +1,177 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"network-monitor/internal/database"
+	"network-monitor/internal/models"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	db, err := database.NewMemory()
+	if err != nil {
+		t.Fatalf("database.NewMemory() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return New(db, nil, 0, nil)
+}
+
+func TestGrafanaSearch(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	for _, target := range []string{"8.8.8.8", "1.1.1.1"} {
+		if err := s.db.SaveResult(context.Background(), models.PingResult{Timestamp: now, Target: target, Success: true, RTT: 10}); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleGrafanaSearch(rec, httptest.NewRequest(http.MethodPost, "/grafana/search", strings.NewReader(`{"target":""}`)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var names []string
+	if err := json.NewDecoder(rec.Body).Decode(&names); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := []string{
+		"rtt:1.1.1.1", "rtt:8.8.8.8",
+		"packet_loss:1.1.1.1", "packet_loss:8.8.8.8",
+		"up:1.1.1.1", "up:8.8.8.8",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("search = %v, want %v", names, want)
+	}
+}
+
+func TestGrafanaQuery(t *testing.T) {
+	s := newTestServer(t)
+
+	// Two one-minute buckets: the first has one failure out of two pings,
+	// the second is a total outage
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pings := []struct {
+		offset  time.Duration
+		success bool
+		rtt     float64
+	}{
+		{0, true, 10},
+		{30 * time.Second, false, 0},
+		{60 * time.Second, false, 0},
+		{90 * time.Second, false, 0},
+	}
+	for _, p := range pings {
+		result := models.PingResult{Timestamp: base.Add(p.offset), Target: "8.8.8.8", Success: p.success, RTT: p.rtt}
+		if err := s.db.SaveResult(context.Background(), result); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+	outside := models.PingResult{Timestamp: base.Add(-time.Hour), Target: "8.8.8.8", Success: true, RTT: 99}
+	if err := s.db.SaveResult(context.Background(), outside); err != nil {
+		t.Fatalf("SaveResult() error = %v", err)
+	}
+
+	body := `{
+		"panelId": 1,
+		"range": {"from": "2024-05-01T12:00:00.000Z", "to": "2024-05-01T12:05:00.000Z", "raw": {"from": "now-5m", "to": "now"}},
+		"interval": "1m",
+		"intervalMs": 60000,
+		"maxDataPoints": 500,
+		"targets": [
+			{"target": "rtt:8.8.8.8", "refId": "A", "type": "timeserie"},
+			{"target": "packet_loss:8.8.8.8", "refId": "B", "type": "timeserie"},
+			{"target": "up:8.8.8.8", "refId": "C", "type": "timeserie"}
+		],
+		"format": "json"
+	}`
+
+	rec := httptest.NewRecorder()
+	s.handleGrafanaQuery(rec, httptest.NewRequest(http.MethodPost, "/grafana/query", strings.NewReader(body)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+
+	var series []struct {
+		Target     string            `json:"target"`
+		Datapoints []json.RawMessage `json:"datapoints"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&series); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	first := float64(base.UnixMilli())
+	second := float64(base.Add(time.Minute).UnixMilli())
+	want := map[string][][2]float64{
+		"rtt:8.8.8.8":         {{10, first}},
+		"packet_loss:8.8.8.8": {{50, first}, {100, second}},
+		"up:8.8.8.8":          {{0.5, first}, {0, second}},
+	}
+
+	if len(series) != len(want) {
+		t.Fatalf("got %d series, want %d", len(series), len(want))
+	}
+	for _, s := range series {
+		wantPoints, ok := want[s.Target]
+		if !ok {
+			t.Errorf("unexpected series %q", s.Target)
+			continue
+		}
+		if len(s.Datapoints) != len(wantPoints) {
+			t.Errorf("%s: got %d datapoints, want %d", s.Target, len(s.Datapoints), len(wantPoints))
+			continue
+		}
+		for i, raw := range s.Datapoints {
+			// Each datapoint must be a two-element [value, ms_timestamp] array
+			var point []float64
+			if err := json.Unmarshal(raw, &point); err != nil || len(point) != 2 {
+				t.Errorf("%s: datapoint %s is not [value, ms_timestamp]", s.Target, raw)
+				continue
+			}
+			if point[0] != wantPoints[i][0] || point[1] != wantPoints[i][1] {
+				t.Errorf("%s: datapoint %d = %v, want %v", s.Target, i, point, wantPoints[i])
+			}
+		}
+	}
+}
+
+func TestGrafanaQueryRejectsBadRequests(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+	}{
+		{"GET not allowed", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"malformed body", http.MethodPost, "{", http.StatusBadRequest},
+		{"missing range", http.MethodPost, `{"targets":[{"target":"rtt:8.8.8.8"}]}`, http.StatusBadRequest},
+		{
+			"unknown metric",
+			http.MethodPost,
+			`{"range":{"from":"2024-05-01T12:00:00Z","to":"2024-05-01T13:00:00Z"},"targets":[{"target":"jitter:8.8.8.8"}]}`,
+			http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleGrafanaQuery(rec, httptest.NewRequest(tt.method, "/grafana/query", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -2,32 +2,118 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"runtime"
 	"strconv"
+	"strings"
+	"time"
+
+	"network-monitor/internal/database"
+	"network-monitor/internal/models"
 )
 
-// handleRecent handles /api/recent requests
+// versionResponse is the /api/version payload
+type versionResponse struct {
+	Version       string    `json:"version"`
+	Commit        string    `json:"commit"`
+	GoVersion     string    `json:"go_version"`
+	StartTime     time.Time `json:"start_time"`
+	Uptime        string    `json:"uptime"`
+	UptimeSeconds float64   `json:"uptime_seconds"`
+}
+
+// configResponse is the /api/config payload, also injected into the index page
+type configResponse struct {
+	Theme           string   `json:"theme"`
+	DefaultHours    int      `json:"default_hours"`
+	RefreshSeconds  int      `json:"refresh_seconds"`
+	Targets         []string `json:"targets"`
+	IntervalSeconds float64  `json:"interval_seconds"`
+	Version         string   `json:"version"`
+	BasePath        string   `json:"base_path"` // prefix for API and asset URLs, "" at the root
+}
+
+// handleRecent handles /api/recent requests. Results can be narrowed to one
+// target or monitor instance and paged with limit and offset, and ts=unix adds
+// each timestamp in Unix milliseconds.
 func (s *Server) handleRecent(w http.ResponseWriter, r *http.Request) {
-	hours := 24
-	if h := r.URL.Query().Get("hours"); h != "" {
-		if parsed, err := strconv.Atoi(h); err == nil {
-			hours = parsed
+	query := r.URL.Query()
+	from, to, err := parseTimeRange(query, 24*time.Hour, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	unixTimestamps := false
+	switch query.Get("ts") {
+	case "", "rfc3339":
+	case "unix":
+		unixTimestamps = true
+	default:
+		http.Error(w, "ts must be rfc3339 or unix", http.StatusBadRequest)
+		return
+	}
+
+	limit := database.MaxRecentLimit
+	if l := query.Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > database.MaxRecentLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", database.MaxRecentLimit), http.StatusBadRequest)
+			return
 		}
+		limit = parsed
 	}
 
-	results, err := s.db.GetRecent(hours)
+	offset := 0
+	if o := query.Get("offset"); o != "" {
+		parsed, err := strconv.Atoi(o)
+		if err != nil || parsed < 0 {
+			http.Error(w, "offset must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		offset = parsed
+	}
+
+	// Downsampling thins out all targets evenly instead of paging
+	var results []models.PingResult
+	if query.Get("downsample") == "true" {
+		if query.Get("target") != "" || query.Get("instance") != "" || offset != 0 {
+			http.Error(w, "downsample can't be combined with target, instance or offset", http.StatusBadRequest)
+			return
+		}
+		results, err = s.db.GetRecentSampled(r.Context(), from, to, limit)
+	} else {
+		results, err = s.db.GetRecentFiltered(r.Context(), from, to, query.Get("target"), query.Get("instance"), limit, offset)
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	markAnomalies(results, s.sigma)
 
 	w.Header().Set("Content-Type", "application/json")
+	if unixTimestamps {
+		unixResults := make([]models.UnixPingResult, len(results))
+		for i, result := range results {
+			unixResults[i] = models.UnixPingResult(result)
+		}
+		json.NewEncoder(w).Encode(unixResults)
+		return
+	}
 	json.NewEncoder(w).Encode(results)
 }
 
 // handleStats handles /api/stats requests
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := s.db.GetStats(24)
+	from, to, err := parseTimeRange(r.URL.Query(), 24*time.Hour, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := s.db.GetStats(r.Context(), from, to)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -37,9 +123,110 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
-// handleOutages handles /api/outages requests
+// handleSummary handles /api/summary requests
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := s.db.GetSummary(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// handleCurrent handles /api/current requests. It is answered from the
+// monitor's in-memory cache when there is a monitor, and the database
+// otherwise.
+func (s *Server) handleCurrent(w http.ResponseWriter, r *http.Request) {
+	var current []models.CurrentStatus
+	if s.monitor != nil {
+		current = s.monitor.Current()
+	} else {
+		var err error
+		if current, err = s.db.GetCurrent(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(current)
+}
+
+// handleStreaks handles /api/streaks requests
+func (s *Server) handleStreaks(w http.ResponseWriter, r *http.Request) {
+	streaks, err := s.db.GetStreaks(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(streaks)
+}
+
+// handleErrors handles /api/errors requests
+func (s *Server) handleErrors(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseTimeRange(r.URL.Query(), 24*time.Hour, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	breakdown, err := s.db.GetErrorBreakdown(r.Context(), from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(breakdown)
+}
+
+// handleDebugOutput handles /api/debug/output requests, listing the raw output
+// stored for failed or unparseable pings when -debug-store-output is on. The
+// newest 100 are returned unless limit asks for more.
+func (s *Server) handleDebugOutput(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 100
+	if l := query.Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > database.MaxPingDebugRows {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", database.MaxPingDebugRows), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := s.db.GetPingDebug(r.Context(), query.Get("target"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// handleOutages handles /api/outages requests. min_duration, e.g. 2m, leaves
+// out outages shorter than it.
 func (s *Server) handleOutages(w http.ResponseWriter, r *http.Request) {
-	outages, err := s.db.GetOutages(7)
+	from, to, err := parseTimeRange(r.URL.Query(), 7*24*time.Hour, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var minDuration time.Duration
+	if d := r.URL.Query().Get("min_duration"); d != "" {
+		if minDuration, err = time.ParseDuration(d); err != nil || minDuration < 0 {
+			http.Error(w, "min_duration must be a non-negative duration such as 2m", http.StatusBadRequest)
+			return
+		}
+	}
+
+	outages, err := s.db.GetOutagesFiltered(r.Context(), from, to, minDuration)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -49,20 +236,154 @@ func (s *Server) handleOutages(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(outages)
 }
 
+// handleOutageTrace handles /api/outages/{id}/trace requests
+func (s *Server) handleOutageTrace(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/outages/"), "/")
+	if len(parts) != 2 || parts[1] != "trace" {
+		http.NotFound(w, r)
+		return
+	}
+
+	outageID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid outage id", http.StatusBadRequest)
+		return
+	}
+
+	trace, err := s.db.GetTraceroute(r.Context(), outageID)
+	if errors.Is(err, database.ErrTracerouteNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trace)
+}
+
+// handleSLA handles /api/sla requests; without a target it covers all targets
+func (s *Server) handleSLA(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseTimeRange(r.URL.Query(), 30*24*time.Hour, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sla, err := s.db.GetSLA(r.Context(), r.URL.Query().Get("target"), from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sla)
+}
+
+// handleBaseline handles /api/baseline requests
+func (s *Server) handleBaseline(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		http.Error(w, "target is required", http.StatusBadRequest)
+		return
+	}
+
+	comparison, err := s.db.GetBaselineComparison(r.Context(), target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparison)
+}
+
+// handleCompare handles /api/compare requests, comparing targets a and b over
+// the last hours (default 24)
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	a, b := query.Get("a"), query.Get("b")
+	if a == "" || b == "" {
+		http.Error(w, "a and b are required", http.StatusBadRequest)
+		return
+	}
+	if a == b {
+		http.Error(w, "a and b must be different targets", http.StatusBadRequest)
+		return
+	}
+
+	hours := 24
+	if h := query.Get("hours"); h != "" {
+		n, err := positiveInt("hours", h)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hours = n
+	}
+
+	comparison, err := s.db.CompareTargets(r.Context(), a, b, hours)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparison)
+}
+
+// trendSpans is the period /api/trends covers by default, per granularity
+var trendSpans = map[string]time.Duration{
+	models.TrendDay:   90 * 24 * time.Hour,
+	models.TrendMonth: 365 * 24 * time.Hour,
+}
+
+// handleTrends handles /api/trends requests
+func (s *Server) handleTrends(w http.ResponseWriter, r *http.Request) {
+	granularity := r.URL.Query().Get("granularity")
+	if granularity == "" {
+		granularity = models.TrendDay
+	}
+	span, ok := trendSpans[granularity]
+	if !ok {
+		http.Error(w, "granularity must be day or month", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseTimeRange(r.URL.Query(), span, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	trends, err := s.db.GetTrends(r.Context(), r.URL.Query().Get("target"), granularity, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trends)
+}
+
 // handleHeatmap handles /api/heatmap requests
 func (s *Server) handleHeatmap(w http.ResponseWriter, r *http.Request) {
-	days := 30
-	if d := r.URL.Query().Get("days"); d != "" {
-		if parsed, err := strconv.Atoi(d); err == nil {
-			days = parsed
-		}
+	from, to, err := parseDayRange(r.URL.Query(), 30, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	heatmapData, err := s.db.GetHeatmapData(days)
+	heatmapData, err := s.db.GetHeatmapData(r.Context(), from, to)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	for i := range heatmapData {
+		heatmapData[i].Severity = s.thresholds.severity(heatmapData[i])
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(heatmapData)
@@ -77,7 +398,13 @@ func (s *Server) handlePatterns(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	patterns, err := s.db.GetPatterns(hour)
+	from, to, err := parseDayRange(r.URL.Query(), 30, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	patterns, err := s.db.GetPatterns(r.Context(), hour, from, to)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -86,3 +413,161 @@ func (s *Server) handlePatterns(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(patterns)
 }
+
+// handleEvents handles /api/events requests
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseTimeRange(r.URL.Query(), 7*24*time.Hour, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := s.db.GetMonitoringEvents(r.Context(), from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// handleStatus handles /api/status requests
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.monitor.Status())
+}
+
+// handlePause handles POST /api/pause requests
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.monitor.Pause(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.monitor.Status())
+}
+
+// handleResume handles POST /api/resume requests
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.monitor.Resume(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.monitor.Status())
+}
+
+// alertTestResponse is the /api/alert/test payload
+type alertTestResponse struct {
+	Sent  bool   `json:"sent"`
+	Error string `json:"error,omitempty"`
+}
+
+// handleAlertTest handles POST /api/alert/test requests by sending a test
+// alert. A delivery failure is answered with 502 and the notifier's error, so
+// webhook URLs and credentials can be checked without waiting for an outage.
+func (s *Server) handleAlertTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := alertTestResponse{Sent: true}
+	status := http.StatusOK
+	if err := s.monitor.TestAlert(); err != nil {
+		resp = alertTestResponse{Error: err.Error()}
+		status = http.StatusBadGateway
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleVersion handles /api/version requests
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	uptime := time.Since(s.started)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versionResponse{
+		Version:       s.build.Version,
+		Commit:        s.build.Commit,
+		GoVersion:     runtime.Version(),
+		StartTime:     s.started,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: uptime.Seconds(),
+	})
+}
+
+// handleConfig handles /api/config requests with the dashboard's display
+// preferences, so the static UI follows the server's flags
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.clientConfig())
+}
+
+// handleTargets handles /api/targets requests: every monitored target with
+// the display name and color the dashboard shows it with, in configured
+// order, followed by any other targets with stored metadata
+func (s *Server) handleTargets(w http.ResponseWriter, r *http.Request) {
+	stored, err := s.db.GetTargetMeta(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	byTarget := make(map[string]models.TargetMeta, len(stored))
+	for _, m := range stored {
+		byTarget[m.Target] = m
+	}
+	targets := make([]models.TargetMeta, 0, len(s.display.Targets)+len(stored))
+	for _, target := range s.display.Targets {
+		meta, ok := byTarget[target]
+		if !ok {
+			meta = models.TargetMeta{Target: target}
+		}
+		targets = append(targets, meta)
+		delete(byTarget, target)
+	}
+	for _, m := range stored {
+		if _, ok := byTarget[m.Target]; ok {
+			targets = append(targets, m)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(targets)
+}
+
+// clientConfig collects the settings the dashboard initializes from
+func (s *Server) clientConfig() configResponse {
+	targets := s.display.Targets
+	if targets == nil {
+		targets = []string{}
+	}
+	return configResponse{
+		Theme:           s.display.Theme,
+		DefaultHours:    s.display.DefaultHours,
+		RefreshSeconds:  int(s.display.RefreshInterval / time.Second),
+		Targets:         targets,
+		IntervalSeconds: s.display.PingInterval.Seconds(),
+		Version:         s.build.Version,
+		BasePath:        s.basePath,
+	}
+}
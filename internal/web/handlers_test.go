@@ -0,0 +1,780 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestHandleVersion(t *testing.T) {
+	s := New(nil, nil, 0, nil)
+	s.SetBuildInfo(BuildInfo{Version: "1.2.3", Commit: "abc1234"})
+
+	get := func() map[string]interface{} {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.handleVersion(rec, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return body
+	}
+
+	first := get()
+	for _, field := range []string{"version", "commit", "go_version", "start_time", "uptime", "uptime_seconds"} {
+		if _, ok := first[field]; !ok {
+			t.Errorf("response missing %q: %v", field, first)
+		}
+	}
+	if first["version"] != "1.2.3" || first["commit"] != "abc1234" {
+		t.Errorf("build = %v/%v, want 1.2.3/abc1234", first["version"], first["commit"])
+	}
+	if first["go_version"] != runtime.Version() {
+		t.Errorf("go_version = %v, want %s", first["go_version"], runtime.Version())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	second := get()
+
+	if second["start_time"] != first["start_time"] {
+		t.Errorf("start_time changed between requests: %v -> %v", first["start_time"], second["start_time"])
+	}
+	if second["uptime_seconds"].(float64) <= first["uptime_seconds"].(float64) {
+		t.Errorf("uptime did not increase: %v -> %v", first["uptime_seconds"], second["uptime_seconds"])
+	}
+}
+
+func TestHandleConfig(t *testing.T) {
+	get := func(s *Server) configResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		var body configResponse
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return body
+	}
+
+	want := configResponse{Theme: "auto", DefaultHours: 24, RefreshSeconds: 30, Targets: []string{}}
+	if got := get(New(nil, nil, 0, nil)); !reflect.DeepEqual(got, want) {
+		t.Errorf("default config = %+v, want %+v", got, want)
+	}
+
+	s := New(nil, nil, 0, nil)
+	s.SetBuildInfo(BuildInfo{Version: "1.2.3"})
+	s.SetDisplay(Display{
+		Theme:           "dark",
+		DefaultHours:    168,
+		RefreshInterval: 2 * time.Minute,
+		Targets:         []string{"8.8.8.8", "1.1.1.1 via wwan0"},
+		PingInterval:    500 * time.Millisecond,
+	})
+	want = configResponse{
+		Theme:           "dark",
+		DefaultHours:    168,
+		RefreshSeconds:  120,
+		Targets:         []string{"8.8.8.8", "1.1.1.1 via wwan0"},
+		IntervalSeconds: 0.5,
+		Version:         "1.2.3",
+	}
+	if got := get(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("config = %+v, want %+v", got, want)
+	}
+}
+
+func TestHandleTargets(t *testing.T) {
+	s := newTestServer(t)
+	s.SetDisplay(Display{Targets: []string{"8.8.8.8", "1.1.1.1 via wwan0", "9.9.9.9"}})
+	stored := []models.TargetMeta{
+		{Target: "1.1.1.1 via wwan0", Name: "Backup LTE", Color: "#e4572e"},
+		{Target: "8.8.8.8", Name: "Comcast WAN"},
+		{Target: "192.0.2.1", Name: "Old ISP", Color: "#999"},
+	}
+	if err := s.db.SetTargetMeta(context.Background(), stored); err != nil {
+		t.Fatalf("SetTargetMeta() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/targets", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var got []models.TargetMeta
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	// Configured targets in order, then stored ones no longer configured
+	want := []models.TargetMeta{
+		{Target: "8.8.8.8", Name: "Comcast WAN"},
+		{Target: "1.1.1.1 via wwan0", Name: "Backup LTE", Color: "#e4572e"},
+		{Target: "9.9.9.9"},
+		{Target: "192.0.2.1", Name: "Old ISP", Color: "#999"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("targets = %+v, want %+v", got, want)
+	}
+}
+
+func TestHandleSLA(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		result := models.PingResult{Timestamp: now.Add(-time.Duration(i) * time.Minute), Target: "8.8.8.8", Success: i != 0, RTT: 10}
+		if err := s.db.SaveResult(context.Background(), result); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantChecks float64
+	}{
+		{"target with default period", "?target=8.8.8.8", http.StatusOK, 4},
+		{"explicit days", "?target=8.8.8.8&days=7", http.StatusOK, 4},
+		{"other target", "?target=1.1.1.1", http.StatusOK, 0},
+		{"invalid days", "?target=8.8.8.8&days=abc", http.StatusBadRequest, 0},
+		{"zero days", "?days=0", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleSLA(rec, httptest.NewRequest(http.MethodGet, "/api/sla"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body map[string]interface{}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body["total_checks"] != tt.wantChecks {
+				t.Errorf("total_checks = %v, want %v", body["total_checks"], tt.wantChecks)
+			}
+			if tt.wantChecks > 0 && body["availability_percent"] != 75.0 {
+				t.Errorf("availability_percent = %v, want 75", body["availability_percent"])
+			}
+		})
+	}
+}
+
+func TestHandleOutagesMinDuration(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	// A 30s blip, a 5 minute outage and one ongoing for 10 minutes
+	for _, o := range []struct {
+		target string
+		start  time.Time
+		length time.Duration
+	}{
+		{"blip", now.Add(-time.Hour), 30 * time.Second},
+		{"long", now.Add(-2 * time.Hour), 5 * time.Minute},
+		{"ongoing", now.Add(-10 * time.Minute), 0},
+	} {
+		id, err := s.db.RecordOutageStart(ctx, o.target, o.start, 0)
+		if err != nil {
+			t.Fatalf("RecordOutageStart() error = %v", err)
+		}
+		if o.length == 0 {
+			continue
+		}
+		if err := s.db.RecordOutageEnd(ctx, id, o.start.Add(o.length), 3, 0); err != nil {
+			t.Fatalf("RecordOutageEnd() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantTargets []string
+	}{
+		{"no threshold", "", http.StatusOK, []string{"ongoing", "blip", "long"}},
+		{"threshold", "?min_duration=2m", http.StatusOK, []string{"ongoing", "long"}},
+		{"threshold and days", "?min_duration=7m&days=1", http.StatusOK, []string{"ongoing"}},
+		{"invalid threshold", "?min_duration=abc", http.StatusBadRequest, nil},
+		{"negative threshold", "?min_duration=-1m", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleOutages(rec, httptest.NewRequest(http.MethodGet, "/api/outages"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var outages []models.Outage
+			if err := json.NewDecoder(rec.Body).Decode(&outages); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			var got []string
+			for _, o := range outages {
+				got = append(got, o.Target)
+			}
+			if !reflect.DeepEqual(got, tt.wantTargets) {
+				t.Errorf("outages = %v, want %v", got, tt.wantTargets)
+			}
+		})
+	}
+}
+
+func TestHandleCompare(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		at := now.Add(-time.Duration(i) * time.Minute)
+		for _, result := range []models.PingResult{
+			{Timestamp: at, Target: "8.8.8.8", Success: true, RTT: 10},
+			{Timestamp: at, Target: "1.1.1.1", Success: i != 0, RTT: 10},
+		} {
+			if err := s.db.SaveResult(context.Background(), result); err != nil {
+				t.Fatalf("SaveResult() error = %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantWinner string
+	}{
+		{"default period", "?a=8.8.8.8&b=1.1.1.1", http.StatusOK, "8.8.8.8"},
+		{"explicit hours", "?a=1.1.1.1&b=8.8.8.8&hours=1", http.StatusOK, "8.8.8.8"},
+		{"missing b", "?a=8.8.8.8", http.StatusBadRequest, ""},
+		{"same target", "?a=8.8.8.8&b=8.8.8.8", http.StatusBadRequest, ""},
+		{"invalid hours", "?a=8.8.8.8&b=1.1.1.1&hours=0", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleCompare(rec, httptest.NewRequest(http.MethodGet, "/api/compare"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got models.TargetComparison
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if got.Winner != tt.wantWinner {
+				t.Errorf("winner = %q, want %q", got.Winner, tt.wantWinner)
+			}
+		})
+	}
+}
+
+func TestHandleTrends(t *testing.T) {
+	s := newTestServer(t)
+	// Noon yesterday, so the pings can't straddle midnight
+	yesterday := time.Now().AddDate(0, 0, -1)
+	noon := time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 12, 0, 0, 0, time.Local)
+	for i := 0; i < 4; i++ {
+		result := models.PingResult{Timestamp: noon.Add(time.Duration(i) * time.Minute), Target: "8.8.8.8", Success: i != 0, RTT: 10}
+		if err := s.db.SaveResult(context.Background(), result); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+	for _, aggregate := range []func(context.Context) error{s.db.AggregateHourlyPatterns, s.db.AggregateDaily, s.db.AggregateMonthly} {
+		if err := aggregate(context.Background()); err != nil {
+			t.Fatalf("aggregate: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantPeriods int
+	}{
+		{"daily by default", "?target=8.8.8.8", http.StatusOK, 1},
+		{"monthly", "?target=8.8.8.8&granularity=month", http.StatusOK, 1},
+		{"other target", "?target=1.1.1.1&days=30", http.StatusOK, 0},
+		{"unknown granularity", "?granularity=week", http.StatusBadRequest, 0},
+		{"invalid range", "?days=abc", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleTrends(rec, httptest.NewRequest(http.MethodGet, "/api/trends"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var trends []models.Trend
+			if err := json.NewDecoder(rec.Body).Decode(&trends); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(trends) != tt.wantPeriods {
+				t.Fatalf("got %d periods, want %d", len(trends), tt.wantPeriods)
+			}
+			if tt.wantPeriods > 0 && (trends[0].TotalChecks != 4 || trends[0].Availability != 75) {
+				t.Errorf("trend = %+v, want 4 checks at 75%% availability", trends[0])
+			}
+		})
+	}
+}
+
+func TestHandleErrors(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		result := models.PingResult{Timestamp: now.Add(-time.Duration(i) * 90 * time.Minute), Target: "8.8.8.8", ErrorMessage: "timeout", FailureReason: models.FailureTimeout}
+		if err := s.db.SaveResult(context.Background(), result); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCount  int
+	}{
+		{"default period", "", http.StatusOK, 3},
+		{"last two hours", "?hours=2", http.StatusOK, 2},
+		{"invalid hours", "?hours=abc", http.StatusBadRequest, 0},
+		{"zero hours", "?hours=0", http.StatusBadRequest, 0},
+		{"absolute range", "?from=" + rfc3339(now.Add(-100*time.Minute)) + "&to=" + rfc3339(now.Add(-time.Minute)), http.StatusOK, 1},
+		{"hours with from", "?hours=2&from=" + rfc3339(now.Add(-time.Hour)), http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleErrors(rec, httptest.NewRequest(http.MethodGet, "/api/errors"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body []models.ErrorCount
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(body) != 1 || body[0].FailureReason != models.FailureTimeout || body[0].Count != tt.wantCount {
+				t.Errorf("breakdown = %+v, want %d timeouts", body, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestHandleDebugOutput(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	for i, target := range []string{"8.8.8.8", "1.1.1.1", "8.8.8.8"} {
+		entry := models.PingDebug{Timestamp: now.Add(-time.Duration(i) * time.Minute), Target: target, Output: "Zeitüberschreitung der Anforderung."}
+		if err := s.db.SavePingDebug(context.Background(), entry); err != nil {
+			t.Fatalf("SavePingDebug() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCount  int
+	}{
+		{"all targets", "", http.StatusOK, 3},
+		{"one target", "?target=8.8.8.8", http.StatusOK, 2},
+		{"limited", "?limit=1", http.StatusOK, 1},
+		{"invalid limit", "?limit=abc", http.StatusBadRequest, 0},
+		{"limit too large", "?limit=1000000", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleDebugOutput(rec, httptest.NewRequest(http.MethodGet, "/api/debug/output"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body []models.PingDebug
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(body) != tt.wantCount || body[0].Output != "Zeitüberschreitung der Anforderung." {
+				t.Errorf("entries = %+v, want %d", body, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestHandleSummary(t *testing.T) {
+	getSummary := func(t *testing.T, s *Server) map[string]interface{} {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.handleSummary(rec, httptest.NewRequest(http.MethodGet, "/api/summary", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return body
+	}
+
+	t.Run("seeded", func(t *testing.T) {
+		s := newTestServer(t)
+
+		// Ten pings per target a minute apart; index 9 is the most recent
+		now := time.Now()
+		targets := []struct {
+			target string
+			failed func(i int) bool
+			rtt    func(i int) float64
+		}{
+			// Healthy, currently fastest
+			{"8.8.8.8", func(i int) bool { return false }, func(i int) float64 { return 10 + float64(i)/3 }},
+			// Scattered loss, currently slowest
+			{"1.1.1.1", func(i int) bool { return i%3 == 2 }, func(i int) float64 { return 30 }},
+			// Healthy until it went down for the last four pings
+			{"9.9.9.9", func(i int) bool { return i >= 6 }, func(i int) float64 { return 20 }},
+		}
+		for _, tt := range targets {
+			for i := 0; i < 10; i++ {
+				result := models.PingResult{Timestamp: now.Add(-time.Duration(9-i) * time.Minute), Target: tt.target}
+				if !tt.failed(i) {
+					result.Success = true
+					result.RTT = tt.rtt(i)
+				}
+				if err := s.db.SaveResult(context.Background(), result); err != nil {
+					t.Fatalf("SaveResult() error = %v", err)
+				}
+			}
+		}
+
+		body := getSummary(t, s)
+		want := map[string]interface{}{
+			"targets":           3.0,
+			"worst_target":      "9.9.9.9",
+			"worst_packet_loss": 40.0,
+			"best_rtt_target":   "8.8.8.8",
+			"best_rtt":          13.0,
+			"worst_rtt_target":  "1.1.1.1",
+			"worst_rtt":         30.0,
+			"targets_down":      1.0,
+			"outages_24h":       1.0,
+		}
+		for field, value := range want {
+			if body[field] != value {
+				t.Errorf("%s = %v, want %v", field, body[field], value)
+			}
+		}
+		if got, ok := body["availability_percent"].(float64); !ok || math.Abs(got-23.0/30*100) > 1e-9 {
+			t.Errorf("availability_percent = %v, want %v", body["availability_percent"], 23.0/30*100)
+		}
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		body := getSummary(t, newTestServer(t))
+		for _, field := range []string{"targets", "availability_percent", "worst_packet_loss", "best_rtt", "worst_rtt", "targets_down", "outages_24h"} {
+			if body[field] != 0.0 {
+				t.Errorf("%s = %v, want 0", field, body[field])
+			}
+		}
+	})
+}
+
+func TestHandleRecentFilterAndPagination(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		for _, target := range []string{"8.8.8.8", "1.1.1.1"} {
+			result := models.PingResult{Timestamp: now.Add(-time.Duration(i) * time.Minute), Target: target, Success: true, RTT: float64(i)}
+			if err := s.db.SaveResult(context.Background(), result); err != nil {
+				t.Fatalf("SaveResult() error = %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantRTTs   []float64 // newest first; each result's RTT is its age in minutes
+		wantTarget string
+	}{
+		{"all targets", "", http.StatusOK, []float64{0, 0, 1, 1, 2, 2, 3, 3, 4, 4}, ""},
+		{"single target", "?target=8.8.8.8", http.StatusOK, []float64{0, 1, 2, 3, 4}, "8.8.8.8"},
+		{"first page", "?target=8.8.8.8&limit=2", http.StatusOK, []float64{0, 1}, "8.8.8.8"},
+		{"second page", "?target=8.8.8.8&limit=2&offset=2", http.StatusOK, []float64{2, 3}, "8.8.8.8"},
+		{"partial last page", "?target=8.8.8.8&limit=2&offset=4", http.StatusOK, []float64{4}, "8.8.8.8"},
+		{"past the end", "?target=8.8.8.8&limit=2&offset=5", http.StatusOK, []float64{}, ""},
+		{"unknown target", "?target=192.0.2.1", http.StatusOK, []float64{}, ""},
+		{"maximum limit", "?limit=10000", http.StatusOK, []float64{0, 0, 1, 1, 2, 2, 3, 3, 4, 4}, ""},
+		{"limit too large", "?limit=10001", http.StatusBadRequest, nil, ""},
+		{"zero limit", "?limit=0", http.StatusBadRequest, nil, ""},
+		{"non-numeric limit", "?limit=all", http.StatusBadRequest, nil, ""},
+		{"negative offset", "?offset=-1", http.StatusBadRequest, nil, ""},
+		{"last hour", "?hours=1", http.StatusOK, []float64{0, 0, 1, 1, 2, 2, 3, 3, 4, 4}, ""},
+		{"invalid hours", "?hours=all", http.StatusBadRequest, nil, ""},
+		{"downsampled", "?downsample=true&limit=4", http.StatusOK, []float64{0, 0, 3, 3}, ""},
+		{"downsample under the limit", "?downsample=true", http.StatusOK, []float64{0, 0, 1, 1, 2, 2, 3, 3, 4, 4}, ""},
+		{"downsample with target", "?downsample=true&target=8.8.8.8", http.StatusBadRequest, nil, ""},
+		{"downsample with offset", "?downsample=true&offset=2", http.StatusBadRequest, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleRecent(rec, httptest.NewRequest(http.MethodGet, "/api/recent"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var results []models.PingResult
+			if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if results == nil {
+				t.Fatal("response is null, want an array")
+			}
+			if len(results) != len(tt.wantRTTs) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.wantRTTs))
+			}
+			for i, r := range results {
+				if r.RTT != tt.wantRTTs[i] {
+					t.Errorf("result %d RTT = %v, want %v", i, r.RTT, tt.wantRTTs[i])
+				}
+				if tt.wantTarget != "" && r.Target != tt.wantTarget {
+					t.Errorf("result %d target = %s, want %s", i, r.Target, tt.wantTarget)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleRecentTimestampFormats(t *testing.T) {
+	s := newTestServer(t)
+	// Stored in another zone to check the response doesn't echo it
+	at := time.Date(2024, 5, 1, 15, 4, 5, 120_000_000, time.FixedZone("UTC+3", 3*60*60))
+	if err := s.db.SaveResult(context.Background(), models.PingResult{Timestamp: at, Target: "8.8.8.8", Success: true}); err != nil {
+		t.Fatalf("SaveResult() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantMillis interface{} // nil when timestamp_ms should be absent
+	}{
+		{"default", "", http.StatusOK, nil},
+		{"rfc3339", "&ts=rfc3339", http.StatusOK, nil},
+		{"unix", "&ts=unix", http.StatusOK, float64(at.UnixMilli())},
+		{"unknown format", "&ts=iso", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			url := "/api/recent?from=2024-05-01T00:00:00Z&to=2024-05-02T00:00:00Z" + tt.query
+			s.handleRecent(rec, httptest.NewRequest(http.MethodGet, url, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var results []map[string]interface{}
+			if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want 1", len(results))
+			}
+			if got := results[0]["timestamp"]; got != "2024-05-01T12:04:05.120Z" {
+				t.Errorf("timestamp = %v, want 2024-05-01T12:04:05.120Z", got)
+			}
+			if got := results[0]["timestamp_ms"]; got != tt.wantMillis {
+				t.Errorf("timestamp_ms = %v, want %v", got, tt.wantMillis)
+			}
+		})
+	}
+}
+
+// stubDB is a models.Database test double serving canned stats. Methods it
+// doesn't override panic through the nil embedded interface, so a handler
+// reaching for anything else fails the test loudly.
+type stubDB struct {
+	models.Database
+	stats []models.Stats
+	err   error
+}
+
+func (db *stubDB) GetStats(ctx context.Context, from, to time.Time) ([]models.Stats, error) {
+	return db.stats, db.err
+}
+
+func TestHandleStats(t *testing.T) {
+	tests := []struct {
+		name       string
+		db         *stubDB
+		wantStatus int
+		wantStats  []models.Stats
+	}{
+		{
+			name: "stats",
+			db: &stubDB{stats: []models.Stats{
+				{Target: "8.8.8.8", TotalPings: 10, Successful: 9, AvgRTT: 12.5, MaxRTT: 20, MinRTT: 8, PacketLoss: 10},
+			}},
+			wantStatus: http.StatusOK,
+			wantStats: []models.Stats{
+				{Target: "8.8.8.8", TotalPings: 10, Successful: 9, AvgRTT: 12.5, MaxRTT: 20, MinRTT: 8, PacketLoss: 10},
+			},
+		},
+		{
+			name:       "database error",
+			db:         &stubDB{err: errors.New("disk I/O error")},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.db, nil, 0, nil)
+
+			rec := httptest.NewRecorder()
+			s.handleStats(rec, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got []models.Stats
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.wantStats) {
+				t.Errorf("stats = %+v, want %+v", got, tt.wantStats)
+			}
+		})
+	}
+}
+
+// stubController is a models.MonitorController whose test alerts fail with err
+type stubController struct {
+	models.MonitorController
+	err    error
+	alerts int
+}
+
+func (c *stubController) TestAlert() error {
+	c.alerts++
+	return c.err
+}
+
+// currentController is a models.MonitorController serving cached current status
+type currentController struct {
+	models.MonitorController
+	current []models.CurrentStatus
+}
+
+func (c *currentController) Current() []models.CurrentStatus {
+	return c.current
+}
+
+func TestHandleCurrentFromMonitor(t *testing.T) {
+	// stubDB panics on GetCurrent, so the response can only come from the monitor
+	want := []models.CurrentStatus{{Target: "8.8.8.8", Success: true, RTT: 12}}
+	s := New(&stubDB{}, &currentController{current: want}, 0, nil)
+
+	rec := httptest.NewRecorder()
+	s.handleCurrent(rec, httptest.NewRequest(http.MethodGet, "/api/current", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var got []models.CurrentStatus
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(got) != 1 || got[0].Target != want[0].Target || got[0].RTT != want[0].RTT || !got[0].Success {
+		t.Errorf("current = %+v, want %+v", got, want)
+	}
+}
+
+func TestHandleAlertTest(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		err        error
+		wantStatus int
+		wantBody   alertTestResponse
+		wantAlerts int
+	}{
+		{"delivered", http.MethodPost, nil, http.StatusOK, alertTestResponse{Sent: true}, 1},
+		{"delivery failed", http.MethodPost, errors.New("webhook: returned 401 Unauthorized"), http.StatusBadGateway,
+			alertTestResponse{Error: "webhook: returned 401 Unauthorized"}, 1},
+		{"GET", http.MethodGet, nil, http.StatusMethodNotAllowed, alertTestResponse{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := &stubController{err: tt.err}
+			s := New(nil, controller, 0, nil)
+
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(tt.method, "/api/alert/test", nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if controller.alerts != tt.wantAlerts {
+				t.Errorf("sent %d test alerts, want %d", controller.alerts, tt.wantAlerts)
+			}
+			if tt.wantStatus == http.StatusMethodNotAllowed {
+				return
+			}
+			var body alertTestResponse
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %+v, want %+v", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"network-monitor/internal/models"
+)
+
+// icsTimeFormat is an iCalendar UTC date-time, e.g. 20240501T120000Z
+const icsTimeFormat = "20060102T150405Z"
+
+// icsLineLimit is the longest content line iCalendar allows, in octets,
+// before it has to be folded onto continuation lines
+const icsLineLimit = 75
+
+// handleOutagesICS handles /api/outages.ics requests, serving the outages of
+// the period as an iCalendar feed a calendar app can subscribe to
+func (s *Server) handleOutagesICS(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	from, to, err := parseTimeRange(r.URL.Query(), 7*24*time.Hour, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	outages, err := s.db.GetOutages(r.Context(), from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="outages.ics"`)
+	w.Write([]byte(outagesCalendar(outages, now)))
+}
+
+// outagesCalendar renders outages as the VEVENTs of a VCALENDAR, stamped now
+func outagesCalendar(outages []models.Outage, now time.Time) string {
+	var b strings.Builder
+	line := func(name, value string) {
+		b.WriteString(icsFold(name + ":" + value))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//network-monitor//Outages//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", "Network outages")
+	for _, o := range outages {
+		summary := "Outage: " + o.Target
+		if o.Ongoing {
+			summary += " (ongoing)"
+		}
+		line("BEGIN", "VEVENT")
+		line("UID", outageUID(o))
+		line("DTSTAMP", now.UTC().Format(icsTimeFormat))
+		line("DTSTART", o.StartTime.UTC().Format(icsTimeFormat))
+		line("DTEND", o.EndTime.UTC().Format(icsTimeFormat))
+		line("SUMMARY", icsEscape(summary))
+		line("DESCRIPTION", icsEscape(fmt.Sprintf("Duration: %s\nFailed checks: %d", o.Duration, o.FailedChecks)))
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.String()
+}
+
+// outageUID identifies an outage across feed refreshes, so calendar apps
+// update its event rather than adding another: by ID for recorded outages,
+// and by target and start for detected ones
+func outageUID(o models.Outage) string {
+	if o.ID != 0 {
+		return fmt.Sprintf("outage-%d@network-monitor", o.ID)
+	}
+	target := strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' {
+			return '-'
+		}
+		return r
+	}, o.Target)
+	return fmt.Sprintf("outage-%s-%d@network-monitor", target, o.StartTime.Unix())
+}
+
+// icsEscape escapes a TEXT property value
+var icsEscape = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace
+
+// icsFold breaks a content line longer than icsLineLimit octets into
+// continuation lines starting with a space, without splitting a character
+func icsFold(line string) string {
+	var b strings.Builder
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsLineLimit - 1 // the leading space counts
+	}
+	b.WriteString(line)
+	return b.String()
+}
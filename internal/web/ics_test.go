@@ -0,0 +1,106 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestHandleOutagesICS(t *testing.T) {
+	s := newTestServer(t)
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	end := start.Add(30 * time.Minute)
+	id, err := s.db.RecordOutageStart(context.Background(), "8.8.8.8", start, 0)
+	if err != nil {
+		t.Fatalf("RecordOutageStart() error = %v", err)
+	}
+	if err := s.db.RecordOutageEnd(context.Background(), id, end, 5, 0); err != nil {
+		t.Fatalf("RecordOutageEnd() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantEvents int
+	}{
+		{"default period", "", http.StatusOK, 1},
+		{"explicit days", "?days=1", http.StatusOK, 1},
+		{"before the outage", "?to=" + start.Add(-time.Hour).UTC().Format(time.RFC3339), http.StatusOK, 0},
+		{"invalid days", "?days=abc", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/outages.ics"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+				t.Errorf("Content-Type = %q, want text/calendar", ct)
+			}
+
+			body := rec.Body.String()
+			if !strings.HasSuffix(body, "\r\n") {
+				t.Fatalf("calendar doesn't end with CRLF:\n%s", body)
+			}
+			lines := strings.Split(strings.TrimSuffix(body, "\r\n"), "\r\n")
+			if lines[0] != "BEGIN:VCALENDAR" || lines[len(lines)-1] != "END:VCALENDAR" {
+				t.Errorf("calendar not wrapped in BEGIN/END:VCALENDAR:\n%s", body)
+			}
+			for _, line := range lines {
+				if len(line) > icsLineLimit {
+					t.Errorf("line longer than %d octets: %q", icsLineLimit, line)
+				}
+			}
+			if got := strings.Count(body, "BEGIN:VEVENT\r\n"); got != tt.wantEvents || strings.Count(body, "END:VEVENT\r\n") != got {
+				t.Fatalf("calendar has %d events, want %d:\n%s", got, tt.wantEvents, body)
+			}
+			if tt.wantEvents == 0 {
+				return
+			}
+			for _, want := range []string{
+				"DTSTART:" + start.UTC().Format(icsTimeFormat),
+				"DTEND:" + end.UTC().Format(icsTimeFormat),
+				"SUMMARY:Outage: 8.8.8.8",
+				"UID:outage-1@network-monitor",
+				`DESCRIPTION:Duration: 30m0s\nFailed checks: 5`,
+			} {
+				if !strings.Contains(body, want+"\r\n") {
+					t.Errorf("calendar has no %q line:\n%s", want, body)
+				}
+			}
+		})
+	}
+}
+
+func TestICSFold(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("é", 100)
+	folded := icsFold(line)
+
+	parts := strings.Split(folded, "\r\n")
+	if len(parts) < 2 {
+		t.Fatalf("icsFold() didn't fold a %d octet line", len(line))
+	}
+	var unfolded strings.Builder
+	for i, part := range parts {
+		if len(part) > icsLineLimit || !utf8.ValidString(part) {
+			t.Errorf("part %d = %q, want valid UTF-8 of at most %d octets", i, part, icsLineLimit)
+		}
+		if i > 0 {
+			part = strings.TrimPrefix(part, " ")
+		}
+		unfolded.WriteString(part)
+	}
+	if unfolded.String() != line {
+		t.Errorf("unfolded line = %q, want %q", unfolded.String(), line)
+	}
+}
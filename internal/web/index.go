@@ -0,0 +1,43 @@
+package web
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+)
+
+// indexPage is the dashboard page rendered as a template; every other static
+// file is served verbatim
+const indexPage = "index.html"
+
+// handleIndex renders the index page with the server's settings injected, so
+// the dashboard can initialize without first calling /api/config. Other paths
+// go to next.
+func (s *Server) handleIndex(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" && r.URL.Path != "/"+indexPage {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Parsed per request so -dev edits show up without a restart
+		tmpl, err := template.ParseFS(s.staticFiles, indexPage)
+		if err != nil {
+			log.Printf("Failed to parse %s: %v", indexPage, err)
+			http.Error(w, "dashboard unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		// Render to a buffer so a template error doesn't leave a half-written page
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, s.clientConfig()); err != nil {
+			log.Printf("Failed to render %s: %v", indexPage, err)
+			http.Error(w, "dashboard unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		buf.WriteTo(w)
+	})
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIndexInjectsConfig(t *testing.T) {
+	s := New(nil, nil, 0, os.DirFS("../../static"))
+	s.SetBuildInfo(BuildInfo{Version: "1.2.3"})
+	s.SetDisplay(Display{
+		Theme:           "dark",
+		DefaultHours:    6,
+		RefreshInterval: time.Minute,
+		Targets:         []string{"8.8.8.8", "</script><script>alert(1)</script>"},
+		PingInterval:    time.Second,
+	})
+
+	for _, path := range []string{"/", "/index.html"} {
+		t.Run(path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+				t.Errorf("Content-Type = %q, want text/html", ct)
+			}
+
+			body := rec.Body.String()
+			if strings.Contains(body, "</script><script>alert(1)") {
+				t.Fatal("target was injected without escaping")
+			}
+			m := regexp.MustCompile(`window\.serverConfig = (.*);`).FindStringSubmatch(body)
+			if m == nil {
+				t.Fatalf("rendered index has no injected config:\n%s", body)
+			}
+			var got configResponse
+			if err := json.Unmarshal([]byte(m[1]), &got); err != nil {
+				t.Fatalf("injected config %s is not JSON: %v", m[1], err)
+			}
+			if want := s.clientConfig(); !reflect.DeepEqual(got, want) {
+				t.Errorf("injected config = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestIndexLeavesOtherAssetsVerbatim(t *testing.T) {
+	s := New(nil, nil, 0, os.DirFS("../../static"))
+
+	want, err := os.ReadFile("../../static/css/main.css")
+	if err != nil {
+		t.Fatalf("read main.css: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/css/main.css", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if rec.Body.String() != string(want) {
+		t.Error("main.css was not served verbatim")
+	}
+}
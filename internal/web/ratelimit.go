@@ -0,0 +1,109 @@
+package web
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// minRateBurst lets a client make this many requests at once even under a low
+// limit; one dashboard refresh fires four API requests in parallel
+const minRateBurst = 4
+
+// rateClientIdle is how long a client's bucket is kept after its last request
+const rateClientIdle = 5 * time.Minute
+
+// rateLimiter hands out a token bucket per client IP
+type rateLimiter struct {
+	limit rate.Limit
+	burst int
+	now   func() time.Time // replaced in tests
+
+	mu        sync.Mutex
+	clients   map[string]*rateClient
+	lastSweep time.Time
+}
+
+type rateClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	return &rateLimiter{
+		limit:   rate.Limit(perSecond),
+		burst:   max(int(math.Ceil(perSecond)), minRateBurst),
+		now:     time.Now,
+		clients: make(map[string]*rateClient),
+	}
+}
+
+// allow takes a token from ip's bucket, reporting whether one was available
+func (l *rateLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) > rateClientIdle {
+		for key, c := range l.clients {
+			if now.Sub(c.lastSeen) > rateClientIdle {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	c, ok := l.clients[ip]
+	if !ok {
+		c = &rateClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[ip] = c
+	}
+	c.lastSeen = now
+	return c.limiter.AllowN(now, 1)
+}
+
+// retryAfter is the Retry-After value in whole seconds until a token is free
+func (l *rateLimiter) retryAfter() string {
+	return strconv.Itoa(int(math.Ceil(1 / float64(l.limit))))
+}
+
+// withRateLimit answers clients exceeding the configured requests per second
+// on the data routes (/api/* and /grafana/*) with 429 Too Many Requests, so a
+// client polling /api/recent can't starve the monitor's database writes.
+// Static files are never limited. Without a limit it adds nothing.
+func (s *Server) withRateLimit(next http.Handler) http.Handler {
+	if s.limiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") && !strings.HasPrefix(r.URL.Path, "/grafana/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !s.limiter.allow(clientIP(r)) {
+			w.Header().Set("Retry-After", s.limiter.retryAfter())
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP is the address the request came from. Forwarding headers are
+// ignored since any client can set them, so behind a reverse proxy all
+// clients share the proxy's bucket.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	s := New(&stubDB{}, nil, 0, fstest.MapFS{"app.css": {Data: []byte("body {}")}})
+	s.SetRateLimit(2) // bursts of up to 4 requests
+	now := time.Now()
+	s.limiter.now = func() time.Time { return now }
+	handler := s.routes()
+
+	get := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 4; i++ {
+		if rec := get("/api/stats", "192.0.2.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want 200 within the burst", i+1, rec.Code)
+		}
+	}
+
+	rec := get("/api/stats", "192.0.2.1:5678")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status beyond the burst = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+
+	// Other clients and static files are unaffected
+	if rec := get("/api/stats", "192.0.2.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("other client status = %d, want 200", rec.Code)
+	}
+	if rec := get("/app.css", "192.0.2.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("static file status = %d, want 200", rec.Code)
+	}
+
+	// Half a second refills one token at 2 requests/sec
+	now = now.Add(500 * time.Millisecond)
+	if rec := get("/api/stats", "192.0.2.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("status after the window = %d, want 200", rec.Code)
+	}
+	if rec := get("/api/stats", "192.0.2.1:1234"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("status with the refilled token spent = %d, want 429", rec.Code)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	s := New(&stubDB{}, nil, 0, nil)
+	s.SetRateLimit(0)
+	handler := s.routes()
+
+	for i := 0; i < 100; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want 200 without a limit", i+1, rec.Code)
+		}
+	}
+}
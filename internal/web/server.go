@@ -1,44 +1,204 @@
 package web
 
 import (
-	"fmt"
 	"io/fs"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
-	"network-monitor/internal/database"
+	"network-monitor/internal/models"
+	"network-monitor/internal/sink/hub"
 )
 
+// BuildInfo identifies the running build
+type BuildInfo struct {
+	Version string
+	Commit  string
+}
+
+// Display holds the dashboard preferences and monitored targets served at
+// /api/config and injected into the index page
+type Display struct {
+	Theme           string        // light, dark or auto (follow the browser)
+	DefaultHours    int           // time range selected when the dashboard loads
+	RefreshInterval time.Duration // how often the dashboard reloads data; 0 disables
+	Targets         []string      // configured ping targets
+	PingInterval    time.Duration // time between pings of each target
+}
+
+// DefaultBind is the address the server listens on until SetBind is called:
+// loopback only, so the dashboard isn't exposed to the network by accident
+const DefaultBind = "127.0.0.1"
+
+// DefaultDisplay is used until SetDisplay is called
+var DefaultDisplay = Display{Theme: "auto", DefaultHours: 24, RefreshInterval: 30 * time.Second}
+
 // Server handles web requests
 type Server struct {
-	db          *database.DB
+	db          models.Database
+	monitor     models.MonitorController
+	bind        string // address to listen on, e.g. 0.0.0.0 for all interfaces
 	port        int
 	staticFiles fs.FS
+	build       BuildInfo
+	corsOrigins []string     // origins allowed to call /api/*; "*" allows any, empty sends no CORS headers
+	basePath    string       // prefix all routes are mounted under, e.g. "/netmon"; empty for the root
+	limiter     *rateLimiter // per-client request limit on the API; nil when unlimited
+	accessLog   *slog.Logger // logger every request is logged to; nil disables the access log
+	display     Display
+	started     time.Time
+	timeout     time.Duration // limit on the database queries behind each API request; 0 for none
+	results     *hub.Hub      // live results relayed by /api/stream; nil when unavailable
+	sigma       float64       // standard deviations above the mean an RTT anomaly needs; 0 disables
+	thresholds  Thresholds    // when heatmap cells count as warnings or bad
+	metrics     http.Handler  // Prometheus metrics served at /metrics; nil answers 404
 }
 
 // New creates a new web server
-func New(db *database.DB, port int, staticFS fs.FS) *Server {
+func New(db models.Database, monitor models.MonitorController, port int, staticFS fs.FS) *Server {
 	return &Server{
 		db:          db,
+		monitor:     monitor,
+		bind:        DefaultBind,
 		port:        port,
 		staticFiles: staticFS,
+		display:     DefaultDisplay,
+		started:     time.Now(),
+		timeout:     DefaultQueryTimeout,
+		sigma:       DefaultAnomalySigma,
+		thresholds:  DefaultThresholds,
+	}
+}
+
+// SetBind sets the address the server listens on, such as 0.0.0.0 for every
+// interface or one interface's address on a multi-homed host
+func (s *Server) SetBind(address string) {
+	s.bind = address
+}
+
+// SetBuildInfo sets the build reported by /api/version
+func (s *Server) SetBuildInfo(info BuildInfo) {
+	s.build = info
+}
+
+// SetCORSOrigins sets the origins allowed to call the API from another site;
+// "*" allows any origin
+func (s *Server) SetCORSOrigins(origins []string) {
+	s.corsOrigins = origins
+}
+
+// SetDisplay sets the dashboard preferences and targets reported by /api/config
+func (s *Server) SetDisplay(display Display) {
+	s.display = display
+}
+
+// SetBasePath mounts the dashboard and API under a URL prefix such as
+// "/netmon", for serving behind a reverse proxy at a subpath. Empty or "/"
+// serves from the root.
+func (s *Server) SetBasePath(prefix string) {
+	s.basePath = strings.TrimRight(prefix, "/")
+}
+
+// SetRateLimit limits each client IP to perSecond requests a second on the
+// API, with short bursts allowed. Zero or less removes the limit.
+func (s *Server) SetRateLimit(perSecond float64) {
+	if perSecond <= 0 {
+		s.limiter = nil
+		return
+	}
+	s.limiter = newRateLimiter(perSecond)
+}
+
+// SetMetrics sets the handler serving Prometheus metrics at /metrics
+func (s *Server) SetMetrics(metrics http.Handler) {
+	s.metrics = metrics
+}
+
+// Listen opens the server's TCP listener on the bind address and port.
+// Connections made once it returns wait for Serve, so the server counts as
+// up from here.
+func (s *Server) Listen() (net.Listener, error) {
+	return net.Listen("tcp", net.JoinHostPort(s.bind, strconv.Itoa(s.port)))
+}
+
+// Serve answers requests on a listener opened by Listen
+func (s *Server) Serve(listener net.Listener) error {
+	log.Printf("Web server listening on %s", listener.Addr())
+	return http.Serve(listener, s.routes())
+}
+
+// handleMetrics handles /metrics requests
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.metrics == nil {
+		s.notFound(w)
+		return
 	}
+	s.metrics.ServeHTTP(w, r)
 }
 
-// Start starts the web server
-func (s *Server) Start() error {
+// routes builds the server's handler
+func (s *Server) routes() http.Handler {
 	mux := http.NewServeMux()
 
 	// API endpoints
 	mux.HandleFunc("/api/recent", s.handleRecent)
 	mux.HandleFunc("/api/stats", s.handleStats)
+	mux.HandleFunc("/api/summary", s.handleSummary)
+	mux.HandleFunc("/api/current", s.handleCurrent)
+	mux.HandleFunc("/api/stream", s.handleStream)
+	mux.HandleFunc("/api/streaks", s.handleStreaks)
+	mux.HandleFunc("/api/errors", s.handleErrors)
+	mux.HandleFunc("/api/debug/output", s.handleDebugOutput)
 	mux.HandleFunc("/api/outages", s.handleOutages)
+	mux.HandleFunc("/api/outages/", s.handleOutageTrace)
+	mux.HandleFunc("/api/outages.ics", s.handleOutagesICS)
+	mux.HandleFunc("/api/sla", s.handleSLA)
+	mux.HandleFunc("/api/baseline", s.handleBaseline)
+	mux.HandleFunc("/api/compare", s.handleCompare)
+	mux.HandleFunc("/api/trends", s.handleTrends)
 	mux.HandleFunc("/api/heatmap", s.handleHeatmap)
+	mux.HandleFunc("/api/thresholds", s.handleThresholds)
 	mux.HandleFunc("/api/patterns", s.handlePatterns)
+	mux.HandleFunc("/api/events", s.handleEvents)
+	mux.HandleFunc("/api/status", s.handleStatus)
+	mux.HandleFunc("/api/pause", s.handlePause)
+	mux.HandleFunc("/api/resume", s.handleResume)
+	mux.HandleFunc("/api/alert/test", s.handleAlertTest)
+	mux.HandleFunc("/api/version", s.handleVersion)
+	mux.HandleFunc("/api/config", s.handleConfig)
+	mux.HandleFunc("/api/targets", s.handleTargets)
 
-	// Static files - serve the provided static file system as webroot
-	mux.Handle("/", http.FileServer(http.FS(s.staticFiles)))
+	// Prometheus metrics
+	mux.HandleFunc("/metrics", s.handleMetrics)
+
+	// Grafana SimpleJSON datasource
+	mux.HandleFunc("/grafana/", s.handleGrafanaRoot)
+	mux.HandleFunc("/grafana/search", s.handleGrafanaSearch)
+	mux.HandleFunc("/grafana/query", s.handleGrafanaQuery)
+
+	// Static files - serve the provided static file system as webroot, with
+	// the server's settings injected into the index page and a not found page
+	// in place of directory listings
+	mux.Handle("/", s.handleIndex(s.handleStatic()))
+
+	handler := s.withRateLimit(s.withCORS(s.withQueryTimeout(mux)))
+	if s.basePath == "" {
+		return s.withAccessLog(handler)
+	}
 
-	log.Printf("Web server starting on port %d", s.port)
-	return http.ListenAndServe(fmt.Sprintf(":%d", s.port), mux)
+	// Only paths under the base path are served; everything else is a 404
+	mounted := http.NewServeMux()
+	mounted.Handle(s.basePath+"/", http.StripPrefix(s.basePath, handler))
+	mounted.HandleFunc(s.basePath, func(w http.ResponseWriter, r *http.Request) {
+		target := s.basePath + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+	return s.withAccessLog(mounted)
 }
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestBasePath(t *testing.T) {
+	db := &stubDB{stats: []models.Stats{{Target: "8.8.8.8", TotalPings: 1, Successful: 1}}}
+	s := New(db, nil, 0, os.DirFS("../../static"))
+	s.SetBasePath("/netmon/")
+	handler := s.routes()
+
+	tests := []struct {
+		name         string
+		path         string
+		wantStatus   int
+		wantLocation string
+		wantBody     string
+	}{
+		{name: "API under base path", path: "/netmon/api/stats", wantStatus: http.StatusOK, wantBody: `"target":"8.8.8.8"`},
+		{name: "index under base path", path: "/netmon/", wantStatus: http.StatusOK, wantBody: `href="/netmon/css/main.css"`},
+		{name: "asset under base path", path: "/netmon/css/main.css", wantStatus: http.StatusOK},
+		{name: "missing trailing slash", path: "/netmon?x=1", wantStatus: http.StatusMovedPermanently, wantLocation: "/netmon/?x=1"},
+		{name: "root API", path: "/api/stats", wantStatus: http.StatusNotFound},
+		{name: "root index", path: "/", wantStatus: http.StatusNotFound},
+		{name: "sibling prefix", path: "/netmonitor/api/stats", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("GET %s status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body does not contain %s:\n%s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestListenUsesBindAddress(t *testing.T) {
+	s := New(nil, nil, 0, nil)
+	s.SetBind("127.0.0.1")
+
+	listener, err := s.Listen()
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	if !addr.IP.Equal(net.IPv4(127, 0, 0, 1)) || addr.Port == 0 {
+		t.Fatalf("listening on %v, want 127.0.0.1 on a free port", addr)
+	}
+	go http.Serve(listener, s.routes())
+
+	conn, err := net.DialTimeout("tcp", addr.String(), time.Second)
+	if err != nil {
+		t.Fatalf("dial %v: %v", addr, err)
+	}
+	conn.Close()
+
+	// The same port on any other local address must be closed
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		t.Fatalf("list interface addresses: %v", err)
+	}
+	for _, a := range addrs {
+		ip, ok := a.(*net.IPNet)
+		if !ok || ip.IP.IsLoopback() || ip.IP.To4() == nil {
+			continue
+		}
+		other := net.JoinHostPort(ip.IP.String(), strconv.Itoa(addr.Port))
+		if conn, err := net.DialTimeout("tcp", other, time.Second); err == nil {
+			conn.Close()
+			t.Errorf("server bound to 127.0.0.1 is reachable at %s", other)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"network-monitor/internal/models"
+)
+
+// Severities a heatmap cell can have, from its failure rate and latency
+const (
+	SeverityGood = "good"
+	SeverityWarn = "warn"
+	SeverityBad  = "bad"
+)
+
+// Thresholds are the failure rate and average latency at which a heatmap cell
+// becomes a warning or bad, served at /api/thresholds. A cell is as severe as
+// the worse of the two; a zero threshold is never crossed.
+type Thresholds struct {
+	WarnRTT  float64 `json:"warn_rtt_ms"`
+	BadRTT   float64 `json:"bad_rtt_ms"`
+	WarnLoss float64 `json:"warn_loss_percent"`
+	BadLoss  float64 `json:"bad_loss_percent"`
+}
+
+// DefaultThresholds is used until SetThresholds is called
+var DefaultThresholds = Thresholds{WarnRTT: 50, BadRTT: 100, WarnLoss: 0.5, BadLoss: 2}
+
+// SetThresholds sets the thresholds heatmap cells are graded against
+func (s *Server) SetThresholds(thresholds Thresholds) {
+	s.thresholds = thresholds
+}
+
+// severity grades a heatmap cell against the thresholds
+func (t Thresholds) severity(point models.HeatmapPoint) string {
+	switch {
+	case exceeds(point.FailureRate, t.BadLoss) || exceeds(point.AvgLatency, t.BadRTT):
+		return SeverityBad
+	case exceeds(point.FailureRate, t.WarnLoss) || exceeds(point.AvgLatency, t.WarnRTT):
+		return SeverityWarn
+	}
+	return SeverityGood
+}
+
+// exceeds reports whether value is above a threshold that is set
+func exceeds(value, threshold float64) bool {
+	return threshold > 0 && value > threshold
+}
+
+// handleThresholds handles /api/thresholds requests
+func (s *Server) handleThresholds(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.thresholds)
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		name       string
+		thresholds Thresholds
+		point      models.HeatmapPoint
+		want       string
+	}{
+		{"quiet", DefaultThresholds, models.HeatmapPoint{AvgLatency: 20}, SeverityGood},
+		{"at the warning RTT", DefaultThresholds, models.HeatmapPoint{AvgLatency: 50}, SeverityGood},
+		{"slow", DefaultThresholds, models.HeatmapPoint{AvgLatency: 60}, SeverityWarn},
+		{"very slow", DefaultThresholds, models.HeatmapPoint{AvgLatency: 150}, SeverityBad},
+		{"some loss", DefaultThresholds, models.HeatmapPoint{AvgLatency: 20, FailureRate: 1}, SeverityWarn},
+		{"heavy loss", DefaultThresholds, models.HeatmapPoint{AvgLatency: 20, FailureRate: 5}, SeverityBad},
+		{"worse of the two", DefaultThresholds, models.HeatmapPoint{AvgLatency: 60, FailureRate: 5}, SeverityBad},
+		{"RTT thresholds disabled", Thresholds{WarnLoss: 0.5, BadLoss: 2}, models.HeatmapPoint{AvgLatency: 500}, SeverityGood},
+		{"only bad loss set", Thresholds{BadLoss: 2}, models.HeatmapPoint{FailureRate: 1}, SeverityGood},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.thresholds.severity(tt.point); got != tt.want {
+				t.Errorf("severity(%+v) = %q, want %q", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+// heatmapDB serves fixed heatmap cells
+type heatmapDB struct {
+	models.Database
+	points []models.HeatmapPoint
+}
+
+func (db heatmapDB) GetHeatmapData(ctx context.Context, from, to time.Time) ([]models.HeatmapPoint, error) {
+	return db.points, nil
+}
+
+func TestHandleHeatmapSeverity(t *testing.T) {
+	s := New(heatmapDB{points: []models.HeatmapPoint{
+		{Hour: 9, Target: "8.8.8.8", AvgLatency: 5},
+		{Hour: 9, Target: "1.1.1.1", AvgLatency: 30},
+	}}, nil, 0, nil)
+	s.SetThresholds(Thresholds{WarnRTT: 10, BadRTT: 100})
+
+	rec := httptest.NewRecorder()
+	s.handleHeatmap(rec, httptest.NewRequest(http.MethodGet, "/api/heatmap", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	var points []models.HeatmapPoint
+	if err := json.NewDecoder(rec.Body).Decode(&points); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := map[string]string{"8.8.8.8": SeverityGood, "1.1.1.1": SeverityWarn}
+	if len(points) != len(want) {
+		t.Fatalf("got %d cells, want %d: %+v", len(points), len(want), points)
+	}
+	for _, p := range points {
+		if p.Severity != want[p.Target] {
+			t.Errorf("%s severity = %q, want %q", p.Target, p.Severity, want[p.Target])
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleThresholds(rec, httptest.NewRequest(http.MethodGet, "/api/thresholds", nil))
+	var thresholds Thresholds
+	if err := json.NewDecoder(rec.Body).Decode(&thresholds); err != nil {
+		t.Fatalf("decode thresholds: %v", err)
+	}
+	if thresholds != (Thresholds{WarnRTT: 10, BadRTT: 100}) {
+		t.Errorf("/api/thresholds = %+v, want the configured thresholds", thresholds)
+	}
+}
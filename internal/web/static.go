@@ -0,0 +1,66 @@
+package web
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// notFoundPage is served for paths that are neither an endpoint nor a static
+// file, styled like the dashboard and linking back to it
+var notFoundPage = template.Must(template.New("404").Parse(`<!DOCTYPE html>
+<html lang="en" data-theme="{{.Theme}}">
+  <head>
+    <meta charset="UTF-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1.0" />
+    <title>Not Found - Network Connectivity Monitor</title>
+    <link rel="stylesheet" href="{{.BasePath}}/css/main.css" />
+  </head>
+  <body>
+    <div class="container">
+      <h1>🌐 Network Connectivity Monitor</h1>
+      <p>There is nothing at this address.</p>
+      <p><a href="{{.BasePath}}/">Back to the dashboard</a></p>
+    </div>
+  </body>
+</html>
+`))
+
+// handleStatic serves the static files. Missing files, and directories
+// without an index.html, get the not found page instead of a bare 404 or a
+// listing of the directory's contents.
+func (s *Server) handleStatic() http.Handler {
+	files := http.FileServer(http.FS(s.staticFiles))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+		info, err := fs.Stat(s.staticFiles, name)
+		if err == nil && info.IsDir() {
+			_, err = fs.Stat(s.staticFiles, path.Join(name, indexPage))
+		}
+		if err != nil {
+			s.notFound(w)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// notFound answers 404 with the not found page
+func (s *Server) notFound(w http.ResponseWriter) {
+	var buf bytes.Buffer
+	if err := notFoundPage.Execute(&buf, s.clientConfig()); err != nil {
+		log.Printf("Failed to render the not found page: %v", err)
+		http.Error(w, "404 page not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	buf.WriteTo(w)
+}
@@ -0,0 +1,52 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticNotFound(t *testing.T) {
+	s := New(nil, nil, 0, fstest.MapFS{
+		"index.html":      {Data: []byte("<html>{{.Theme}}</html>")},
+		"js/app.js":       {Data: []byte("console.log('app')")},
+		"docs/index.html": {Data: []byte("docs")},
+	})
+	s.SetBasePath("/netmon")
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"file", "/netmon/js/app.js", http.StatusOK, "console.log"},
+		{"directory with an index", "/netmon/docs/", http.StatusOK, "docs"},
+		{"directory listing", "/netmon/js/", http.StatusNotFound, "Back to the dashboard"},
+		{"directory without a slash", "/netmon/js", http.StatusNotFound, "Back to the dashboard"},
+		{"unknown path", "/netmon/missing.html", http.StatusNotFound, "Back to the dashboard"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			body := rec.Body.String()
+			if !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.wantBody)
+			}
+			if rec.Code == http.StatusNotFound {
+				if strings.Contains(body, "app.js") {
+					t.Errorf("not found page lists the directory: %q", body)
+				}
+				if !strings.Contains(body, `href="/netmon/"`) {
+					t.Errorf("not found page doesn't link back under the base path: %q", body)
+				}
+			}
+		})
+	}
+}
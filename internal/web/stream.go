@@ -0,0 +1,76 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"network-monitor/internal/sink/hub"
+)
+
+// streamHeartbeat is how often /api/stream sends a comment when no result
+// arrives, so proxies don't close the connection as idle
+const streamHeartbeat = 15 * time.Second
+
+// SetResults sets the hub /api/stream relays live results from. Without one
+// the stream answers 503.
+func (s *Server) SetResults(results *hub.Hub) {
+	s.results = results
+}
+
+// handleStream handles /api/stream requests: a Server-Sent Events feed with
+// one event per new ping result, optionally for a single target, for clients
+// behind proxies that break WebSockets or that only need a live tail
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	if s.results == nil {
+		http.Error(w, "live results are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Subscribe before the headers go out, so every result published once
+	// the client sees the response is delivered
+	results, unsubscribe := s.results.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // stop nginx buffering the events
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	target := r.URL.Query().Get("target")
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case result, ok := <-results:
+			if !ok {
+				return
+			}
+			if target != "" && result.Target != target {
+				continue
+			}
+			data, err := json.Marshal(result)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+	"network-monitor/internal/sink/hub"
+)
+
+func TestHandleStream(t *testing.T) {
+	results := hub.New()
+	defer results.Close()
+	s := New(nil, nil, 0, nil)
+	s.SetResults(results)
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/stream?target=8.8.8.8", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+
+	// The handler subscribed before answering, so these can't be missed
+	now := time.Now()
+	results.Write(models.PingResult{Timestamp: now, Target: "8.8.8.8", Success: true, RTT: 12.5})
+	results.Write(models.PingResult{Timestamp: now, Target: "1.1.1.1", Success: true})
+	results.Write(models.PingResult{Timestamp: now.Add(time.Second), Target: "8.8.8.8"})
+
+	scanner := bufio.NewScanner(resp.Body)
+	var got []models.PingResult
+	for len(got) < 2 && scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var result models.PingResult
+		if err := json.Unmarshal([]byte(data), &result); err != nil {
+			t.Fatalf("decode event %q: %v", data, err)
+		}
+		got = append(got, result)
+	}
+	if len(got) != 2 {
+		t.Fatalf("read %d events before the stream ended: %v", len(got), scanner.Err())
+	}
+	if got[0].Target != "8.8.8.8" || !got[0].Success || got[0].RTT != 12.5 {
+		t.Errorf("first event = %+v, want the successful 8.8.8.8 result", got[0])
+	}
+	if got[1].Target != "8.8.8.8" || got[1].Success {
+		t.Errorf("second event = %+v, want the failed 8.8.8.8 result, skipping 1.1.1.1", got[1])
+	}
+}
+
+func TestHandleStreamUnavailable(t *testing.T) {
+	s := New(nil, nil, 0, nil)
+	rec := httptest.NewRecorder()
+	s.handleStream(rec, httptest.NewRequest(http.MethodGet, "/api/stream", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503 without a hub", rec.Code)
+	}
+}
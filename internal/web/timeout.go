@@ -0,0 +1,39 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultQueryTimeout is how long an API request's database queries may run
+// until SetQueryTimeout is called
+const DefaultQueryTimeout = 30 * time.Second
+
+// SetQueryTimeout limits how long the database queries behind each API and
+// Grafana request may run before they're cancelled. Zero or less removes the
+// limit, leaving queries cancelled only when the client goes away.
+func (s *Server) SetQueryTimeout(timeout time.Duration) {
+	s.timeout = max(timeout, 0)
+}
+
+// withQueryTimeout gives requests to /api/ and /grafana/ a context that is
+// cancelled after the query timeout. Handlers pass the request context to the
+// database, so a slow query is abandoned instead of holding a connection.
+// /api/stream runs no queries and stays open until the client leaves.
+func (s *Server) withQueryTimeout(next http.Handler) http.Handler {
+	if s.timeout == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") && !strings.HasPrefix(r.URL.Path, "/grafana/") || r.URL.Path == "/api/stream" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
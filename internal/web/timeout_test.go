@@ -0,0 +1,78 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// blockingDB answers GetStats only once the query context is done, like a
+// query stuck behind a lock
+type blockingDB struct {
+	models.Database
+	deadline bool
+}
+
+func (db *blockingDB) GetStats(ctx context.Context, from, to time.Time) ([]models.Stats, error) {
+	_, db.deadline = ctx.Deadline()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(5 * time.Second):
+		return nil, nil
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+		path     string
+	}{
+		{"API request", "", "/api/stats"},
+		{"under the base path", "/netmon", "/netmon/api/stats"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &blockingDB{}
+			s := New(db, nil, 0, fstest.MapFS{})
+			s.SetBasePath(tt.basePath)
+			s.SetQueryTimeout(20 * time.Millisecond)
+
+			start := time.Now()
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("request took %v, want it cut off by the query timeout", elapsed)
+			}
+			if !db.deadline {
+				t.Error("query context has no deadline")
+			}
+			if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "deadline exceeded") {
+				t.Errorf("status = %d, body %q, want 500 for the exceeded deadline", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestQueryTimeoutDisabled(t *testing.T) {
+	s := New(&stubDB{}, nil, 0, fstest.MapFS{})
+	s.SetQueryTimeout(0)
+
+	var deadline bool
+	handler := s.withQueryTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, deadline = r.Context().Deadline()
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	if deadline {
+		t.Error("query context has a deadline with the timeout disabled")
+	}
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// errRangeCombination is returned when a request mixes range forms
+var errRangeCombination = errors.New("use only one of hours, days or from/to")
+
+// parseTimeRange reads the period a data endpoint covers from its query
+// parameters, in one of three forms: hours=N or days=N for the period ending
+// now, or from and to as RFC3339 times. Either of from and to may be left out:
+// to defaults to now, and from to defaultSpan before to. Without any of them
+// the period is the last defaultSpan.
+func parseTimeRange(query url.Values, defaultSpan time.Duration, now time.Time) (from, to time.Time, err error) {
+	hours, days := query.Get("hours"), query.Get("days")
+	fromParam, toParam := query.Get("from"), query.Get("to")
+
+	switch {
+	case hours != "" && days != "":
+		return time.Time{}, time.Time{}, errRangeCombination
+	case (hours != "" || days != "") && (fromParam != "" || toParam != ""):
+		return time.Time{}, time.Time{}, errRangeCombination
+	case hours != "":
+		n, err := positiveInt("hours", hours)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return now.Add(-time.Duration(n) * time.Hour), now, nil
+	case days != "":
+		n, err := positiveInt("days", days)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return now.AddDate(0, 0, -n), now, nil
+	}
+
+	to = now
+	if toParam != "" {
+		if to, err = time.Parse(time.RFC3339, toParam); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to must be an RFC3339 time such as %s", now.UTC().Format(time.RFC3339))
+		}
+	}
+	from = to.Add(-defaultSpan)
+	if fromParam != "" {
+		if from, err = time.Parse(time.RFC3339, fromParam); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from must be an RFC3339 time such as %s", now.UTC().Format(time.RFC3339))
+		}
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+// parseDayRange is parseTimeRange for endpoints that count whole days, such
+// as the heatmap. Those cover every day their period touches, so days=N, and
+// the default of defaultDays, start N-1 days before today rather than N×24
+// hours ago, which would touch N+1 days.
+func parseDayRange(query url.Values, defaultDays int, now time.Time) (from, to time.Time, err error) {
+	from, to, err = parseTimeRange(query, time.Duration(defaultDays)*24*time.Hour, now)
+	if err != nil || query.Get("hours") != "" || query.Get("from") != "" || query.Get("to") != "" {
+		return from, to, err
+	}
+	days := defaultDays
+	if query.Get("days") != "" {
+		days, _ = strconv.Atoi(query.Get("days")) // parseTimeRange checked it
+	}
+	return now.AddDate(0, 0, 1-days), now, nil
+}
+
+// positiveInt parses the value of the named parameter as a positive integer
+func positiveInt(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
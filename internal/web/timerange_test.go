@@ -0,0 +1,140 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"network-monitor/internal/database"
+	"network-monitor/internal/models"
+)
+
+// rfc3339 formats t for a from or to query parameter
+func rfc3339(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func TestParseTimeRange(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	const defaultSpan = 24 * time.Hour
+
+	tests := []struct {
+		name     string
+		query    string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  bool
+	}{
+		{name: "default", query: "", wantFrom: now.Add(-defaultSpan), wantTo: now},
+		{name: "hours", query: "hours=6", wantFrom: now.Add(-6 * time.Hour), wantTo: now},
+		{name: "days", query: "days=7", wantFrom: now.AddDate(0, 0, -7), wantTo: now},
+		{name: "from and to", query: "from=2024-04-01T00:00:00Z&to=2024-04-02T06:00:00Z",
+			wantFrom: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 4, 2, 6, 0, 0, 0, time.UTC)},
+		{name: "from with offset", query: "from=2024-05-01T09:00:00%2B03:00",
+			wantFrom: time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC), wantTo: now},
+		{name: "to only", query: "to=2024-04-02T00:00:00Z",
+			wantFrom: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "zero hours", query: "hours=0", wantErr: true},
+		{name: "negative days", query: "days=-1", wantErr: true},
+		{name: "non-numeric hours", query: "hours=all", wantErr: true},
+		{name: "hours and days", query: "hours=1&days=1", wantErr: true},
+		{name: "hours and from", query: "hours=1&from=2024-04-01T00:00:00Z", wantErr: true},
+		{name: "days and to", query: "days=1&to=2024-04-01T00:00:00Z", wantErr: true},
+		{name: "invalid from", query: "from=yesterday", wantErr: true},
+		{name: "date without time", query: "to=2024-04-01", wantErr: true},
+		{name: "from after to", query: "from=2024-04-02T00:00:00Z&to=2024-04-01T00:00:00Z", wantErr: true},
+		{name: "empty range", query: "from=2024-04-01T00:00:00Z&to=2024-04-01T00:00:00Z", wantErr: true},
+		{name: "from in the future", query: "from=2024-05-02T00:00:00Z", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error = %v", tt.query, err)
+			}
+
+			from, to, err := parseTimeRange(query, defaultSpan, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeRange(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+				t.Errorf("parseTimeRange(%q) = %v to %v, want %v to %v", tt.query, from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestParseDayRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		query    string
+		wantFrom time.Time
+	}{
+		{name: "one day is today", now: time.Date(2024, 5, 1, 0, 30, 0, 0, time.UTC), query: "days=1",
+			wantFrom: time.Date(2024, 5, 1, 0, 30, 0, 0, time.UTC)},
+		{name: "seven days just after midnight", now: time.Date(2024, 5, 1, 0, 0, 1, 0, time.UTC), query: "days=7",
+			wantFrom: time.Date(2024, 4, 25, 0, 0, 1, 0, time.UTC)},
+		{name: "seven days just before midnight", now: time.Date(2024, 5, 1, 23, 59, 59, 0, time.UTC), query: "days=7",
+			wantFrom: time.Date(2024, 4, 25, 23, 59, 59, 0, time.UTC)},
+		{name: "default", now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), query: "",
+			wantFrom: time.Date(2024, 4, 2, 12, 0, 0, 0, time.UTC)},
+		{name: "hours are left alone", now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), query: "hours=36",
+			wantFrom: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			from, to, err := parseDayRange(query, 30, tt.now)
+			if err != nil {
+				t.Fatalf("parseDayRange(%q) error = %v", tt.query, err)
+			}
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.now) {
+				t.Errorf("parseDayRange(%q) = %v to %v, want %v to %v", tt.query, from, to, tt.wantFrom, tt.now)
+			}
+		})
+	}
+
+	if _, _, err := parseDayRange(url.Values{"days": {"0"}}, 30, time.Now()); err == nil {
+		t.Error("parseDayRange(days=0) succeeded, want an error")
+	}
+}
+
+func TestHandlePatternsCoversExactDays(t *testing.T) {
+	db, err := database.NewMemory()
+	if err != nil {
+		t.Fatalf("database.NewMemory() error = %v", err)
+	}
+	defer db.Close()
+	// A pattern for each of the last 10 days, today included
+	today := time.Now()
+	for i := 0; i < 10; i++ {
+		date := today.AddDate(0, 0, -i).Format("2006-01-02")
+		if _, err := db.Exec(`INSERT INTO hourly_patterns (date, hour, target, total_pings, failed_pings, avg_rtt_ms, max_rtt_ms, failure_rate)
+            VALUES (?, 9, '8.8.8.8', 60, 0, 10, 12, 0)`, date); err != nil {
+			t.Fatalf("insert pattern for %s: %v", date, err)
+		}
+	}
+	s := New(db, nil, 0, nil)
+
+	for _, days := range []int{1, 7} {
+		rec := httptest.NewRecorder()
+		s.handlePatterns(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/patterns?hour=9&days=%d", days), nil))
+		var patterns []models.PatternDetail
+		if err := json.NewDecoder(rec.Body).Decode(&patterns); err != nil {
+			t.Fatalf("decode days=%d: %v", days, err)
+		}
+		if len(patterns) != days {
+			t.Errorf("days=%d returned %d days of patterns, want %d", days, len(patterns), days)
+		}
+	}
+}
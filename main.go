@@ -2,98 +2,64 @@ package main
 
 import (
 	"embed"
-	"io/fs"
+	"fmt"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
-
-	"network-monitor/internal/config"
-	"network-monitor/internal/database"
-	"network-monitor/internal/monitor"
-	"network-monitor/internal/ping"
-	"network-monitor/internal/web"
+	"strings"
 )
 
 //go:embed static/*
 var staticFiles embed.FS
 
-func main() {
-	// Parse configuration
-	cfg, err := config.ParseFlags()
-	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
-	}
-	if err = cfg.Validate(); err != nil {
-		log.Fatalf("Invalid configuration: %v", err)
-	}
-
-	// Initialize database
-	db, err := database.New(cfg.DatabasePath)
-	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
-	}
-	defer db.Close()
+// Build information, set at build time with
+// -ldflags "-X main.Version=1.2.3 -X main.Commit=abc1234"
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
 
-	// Initialize schema
-	if err := db.InitSchema(); err != nil {
-		log.Fatalf("Failed to initialize database schema: %v", err)
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
 	}
+}
 
-	// Backfill hourly patterns if table is empty (for initial population)
-	if isEmpty, err := db.IsHourlyPatternsEmpty(); err != nil {
-		log.Printf("Warning: Failed to check hourly patterns table: %v", err)
-	} else if isEmpty {
-		log.Println("Hourly patterns table is empty, backfilling from existing ping data...")
-		if err := db.BackfillHourlyPatterns(); err != nil {
-			log.Printf("Warning: Failed to backfill hourly patterns: %v", err)
-		} else {
-			log.Println("Successfully backfilled hourly patterns data")
-		}
+// run dispatches to a subcommand. Without one (or when the first argument is a
+// flag) it defaults to serve, so existing invocations keep working.
+func run(args []string) error {
+	command := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
 
-	// Initialize static file system (embedded for production, filesystem for development)
-	var staticFS fs.FS
-	if cfg.DevMode {
-		// Development mode: serve from filesystem for live editing
-		staticFS = os.DirFS("static")
-		log.Println("Development mode: serving static files from filesystem (live editing enabled)")
-	} else {
-		// Production mode: use embedded files
-		var err error
-		staticFS, err = fs.Sub(staticFiles, "static")
-		if err != nil {
-			log.Fatalf("Failed to create static file system: %v", err)
-		}
-		log.Println("Production mode: serving embedded static files")
+	switch command {
+	case "serve":
+		runServe(args)
+		return nil
+	case "report":
+		return runReport(args)
+	case "backfill":
+		return runBackfill(args)
+	case "import":
+		return runImport(args)
+	case "help":
+		usage()
+		return nil
+	default:
+		usage()
+		return fmt.Errorf("unknown command %q", command)
 	}
+}
 
-	// Initialize components
-	pinger := ping.New()
-	mon := monitor.New(cfg, db, pinger)
-	webServer := web.New(db, cfg.Port, staticFS)
-
-	// Handle shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	go func() {
-		if err := mon.Start(); err != nil {
-			log.Fatalf("Failed to start monitor: %v", err)
-		}
-	}()
-
-	go func() {
-		if err := webServer.Start(); err != nil {
-			log.Fatalf("Failed to start web server: %v", err)
-		}
-	}()
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: %[1]s [command] [flags]
 
-	log.Printf("Monitoring started. Pinging %v every %v", cfg.Targets, cfg.Interval)
-	log.Printf("Web interface available at http://localhost:%d", cfg.Port)
+Commands:
+  serve    Monitor targets and serve the web dashboard (default)
+  report   Generate a report from an existing database
+  backfill Rebuild the heatmap's hourly patterns from all stored results
+  import   Load historical results from a CSV file of timestamp,target,success,rtt_ms
 
-	<-sigChan
-	log.Println("Shutting down...")
-	mon.Stop()
-	mon.Wait()
+Run '%[1]s <command> -h' for command flags.
+`, os.Args[0])
 }
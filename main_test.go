@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"network-monitor/internal/database"
+	"network-monitor/internal/models"
+)
+
+// seedDatabase creates a database with an hour of pings, including a short outage
+func seedDatabase(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "seed.db")
+	db, err := database.New(path, database.DefaultJournalMode)
+	if err != nil {
+		t.Fatalf("database.New() error = %v", err)
+	}
+	defer db.Close()
+	if err := db.InitSchema(); err != nil {
+		t.Fatalf("InitSchema() error = %v", err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 120; i++ {
+		for _, target := range []string{"8.8.8.8", "1.1.1.1"} {
+			result := models.PingResult{
+				Timestamp: start.Add(time.Duration(i) * 30 * time.Second),
+				Target:    target,
+				Success:   !(target == "1.1.1.1" && i >= 50 && i < 55),
+				RTT:       10 + float64(i%7),
+			}
+			if err := db.SaveResult(context.Background(), result); err != nil {
+				t.Fatalf("SaveResult() error = %v", err)
+			}
+		}
+	}
+	return path
+}
+
+func TestRunReportCommand(t *testing.T) {
+	dbPath := seedDatabase(t)
+	out := t.TempDir()
+
+	if err := run([]string{"report", "-db", dbPath, "-out", out, "-hours", "24", "-report-format", "both"}); err != nil {
+		t.Fatalf("report command error = %v", err)
+	}
+
+	reportDirs, err := filepath.Glob(filepath.Join(out, "network_report_*"))
+	if err != nil {
+		t.Fatalf("glob report output: %v", err)
+	}
+
+	var dir, pdf string
+	for _, match := range reportDirs {
+		info, err := os.Stat(match)
+		if err != nil {
+			t.Fatalf("stat %s: %v", match, err)
+		}
+		if info.IsDir() {
+			dir = match
+		} else if filepath.Ext(match) == ".pdf" {
+			pdf = match
+		}
+	}
+	if dir == "" {
+		t.Fatalf("expected a report directory in %s, found %v", out, reportDirs)
+	}
+	if pdf == "" {
+		t.Fatalf("expected a PDF report in %s, found %v", out, reportDirs)
+	}
+
+	for _, name := range []string{"summary.txt", "availability.png", "latency_8_8_8_8.png", "latency_1_1_1_1.png"} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.Size() == 0 {
+			t.Errorf("expected non-empty %s in report directory (err=%v)", name, err)
+		}
+	}
+}
+
+func TestRunReportMissingDatabase(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.db")
+	if err := run([]string{"report", "-db", missing, "-out", t.TempDir()}); err == nil {
+		t.Fatal("expected an error for a missing database")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Error("report command must not create the database")
+	}
+}
+
+func TestRunBackfillCommand(t *testing.T) {
+	dbPath := seedDatabase(t)
+
+	if err := run([]string{"backfill", "-db", dbPath, "-timezone", "UTC"}); err != nil {
+		t.Fatalf("backfill command error = %v", err)
+	}
+
+	db, err := database.OpenReadOnly(dbPath)
+	if err != nil {
+		t.Fatalf("OpenReadOnly() error = %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT target, SUM(total_pings), SUM(failed_pings), COUNT(*) FROM hourly_patterns GROUP BY target ORDER BY target`)
+	if err != nil {
+		t.Fatalf("query hourly_patterns: %v", err)
+	}
+	defer rows.Close()
+
+	type aggregate struct {
+		target               string
+		total, failed, hours int
+	}
+	var got []aggregate
+	for rows.Next() {
+		var a aggregate
+		if err := rows.Scan(&a.target, &a.total, &a.failed, &a.hours); err != nil {
+			t.Fatalf("scan hourly_patterns: %v", err)
+		}
+		got = append(got, a)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("read hourly_patterns: %v", err)
+	}
+
+	// An hour of pings every 30 seconds falls in one or two clock hours
+	if len(got) != 2 {
+		t.Fatalf("hourly_patterns has targets %+v, want 1.1.1.1 and 8.8.8.8", got)
+	}
+	for _, a := range got {
+		wantFailed := 0
+		if a.target == "1.1.1.1" {
+			wantFailed = 5
+		}
+		if a.total != 120 || a.failed != wantFailed || a.hours < 1 || a.hours > 2 {
+			t.Errorf("%s: %d pings, %d failed over %d hours; want 120 pings, %d failed over 1-2 hours", a.target, a.total, a.failed, a.hours, wantFailed)
+		}
+	}
+}
+
+func TestRunImportCommand(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "history.csv")
+	dbPath := filepath.Join(dir, "imported.db")
+	history := `# exported from ping.sh
+target,timestamp,rtt_ms,success
+8.8.8.8,2024-05-01T10:00:00Z,12.5,true
+8.8.8.8,2024-05-01 10:00:30,13.5,1
+8.8.8.8,1714557660,,false
+1.1.1.1,2024-05-01T13:05:00+03:00,9,yes
+1.1.1.1,yesterday,9,true
+1.1.1.1,2024-05-01T10:06:00Z,-1,true
+$(reboot),2024-05-01T10:07:00Z,9,true
+1.1.1.1,2024-05-01T10:08:00Z,9,maybe
+`
+	if err := os.WriteFile(csvPath, []byte(history), 0o644); err != nil {
+		t.Fatalf("write CSV: %v", err)
+	}
+
+	if err := run([]string{"import", "-db", dbPath, "-timezone", "UTC", csvPath}); err != nil {
+		t.Fatalf("import command error = %v", err)
+	}
+
+	db, err := database.OpenReadOnly(dbPath)
+	if err != nil {
+		t.Fatalf("OpenReadOnly() error = %v", err)
+	}
+	defer db.Close()
+
+	results, err := db.GetRange(context.Background(), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetRange() error = %v", err)
+	}
+	want := []models.PingResult{
+		{Timestamp: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Target: "8.8.8.8", Success: true, RTT: 12.5},
+		{Timestamp: time.Date(2024, 5, 1, 10, 0, 30, 0, time.UTC), Target: "8.8.8.8", Success: true, RTT: 13.5},
+		{Timestamp: time.Date(2024, 5, 1, 10, 1, 0, 0, time.UTC), Target: "8.8.8.8"},
+		{Timestamp: time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC), Target: "1.1.1.1", Success: true, RTT: 9},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("imported %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i, w := range want {
+		r := results[i]
+		if !r.Timestamp.Equal(w.Timestamp) || r.Target != w.Target || r.Success != w.Success || r.RTT != w.RTT {
+			t.Errorf("result %d = %+v, want %+v", i, r, w)
+		}
+	}
+
+	var total, failed int
+	if err := db.QueryRow(`SELECT SUM(total_pings), SUM(failed_pings) FROM hourly_patterns WHERE date = '2024-05-01' AND hour = 10`).Scan(&total, &failed); err != nil {
+		t.Fatalf("query hourly_patterns: %v", err)
+	}
+	if total != 4 || failed != 1 {
+		t.Errorf("hourly_patterns has %d pings, %d failed; want 4 and 1", total, failed)
+	}
+}
+
+func TestRunImportRequiresFile(t *testing.T) {
+	if err := run([]string{"import", "-db", filepath.Join(t.TempDir(), "x.db")}); err == nil {
+		t.Fatal("expected an error without a CSV file")
+	}
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	if err := run([]string{"frobnicate"}); err == nil {
+		t.Fatal("expected an error for an unknown command")
+	}
+}
@@ -0,0 +1,203 @@
+// Package client is a Go client for the monitor's JSON API, for programs that
+// want its results, statistics and outages without hand-rolling HTTP calls.
+// It returns the same types the server encodes, exported here under their
+// own names so programs outside this module can use them.
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"network-monitor/internal/models"
+)
+
+// The API's result types. They are aliases of the server's own types, which
+// live in an internal package other modules can't import.
+type (
+	// PingResult is a single ping, as /api/recent and /api/stream return
+	PingResult = models.PingResult
+	// Stats are a target's statistics over a period
+	Stats = models.Stats
+	// Outage is a period a target was down
+	Outage = models.Outage
+	// HeatmapPoint is a target's failure rate and latency at an hour of day
+	HeatmapPoint = models.HeatmapPoint
+)
+
+// maxErrorBody caps how much of an error response is kept in a StatusError
+const maxErrorBody = 4 << 10
+
+// Client calls a monitor's API. Create one with New; it is safe for
+// concurrent use once configured.
+type Client struct {
+	baseURL string // e.g. http://monitor:8080/netmon, without a trailing slash
+	token   string // sent as a bearer token when set
+	http    *http.Client
+}
+
+// StatusError is returned when the API answers with a non-2xx status
+type StatusError struct {
+	StatusCode int
+	Message    string // the response body, which the API uses for the reason
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API returned %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// New creates a client for the monitor at baseURL, such as
+// "http://localhost:8080", including any -base-path it is served under
+func New(baseURL string) *Client {
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), http: http.DefaultClient}
+}
+
+// SetToken sends token as a bearer token with every request, for a monitor
+// behind an authenticating reverse proxy. Empty sends none.
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// SetHTTPClient makes requests with httpClient instead of
+// http.DefaultClient. A client Timeout also cuts off StreamResults, so
+// prefer request contexts for deadlines.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.http = httpClient
+}
+
+// Recent returns the raw results of the last hours, newest first, as
+// /api/recent does
+func (c *Client) Recent(ctx context.Context, hours int) ([]PingResult, error) {
+	var results []PingResult
+	err := c.getJSON(ctx, "/api/recent", period("hours", hours), &results)
+	return results, err
+}
+
+// Stats returns each target's statistics over the last hours
+func (c *Client) Stats(ctx context.Context, hours int) ([]Stats, error) {
+	var stats []Stats
+	err := c.getJSON(ctx, "/api/stats", period("hours", hours), &stats)
+	return stats, err
+}
+
+// Outages returns the outages of the last days, newest first
+func (c *Client) Outages(ctx context.Context, days int) ([]Outage, error) {
+	var outages []Outage
+	err := c.getJSON(ctx, "/api/outages", period("days", days), &outages)
+	return outages, err
+}
+
+// Heatmap returns failure rates and latency by hour of day over the last days
+func (c *Client) Heatmap(ctx context.Context, days int) ([]HeatmapPoint, error) {
+	var points []HeatmapPoint
+	err := c.getJSON(ctx, "/api/heatmap", period("days", days), &points)
+	return points, err
+}
+
+// StreamResults follows /api/stream, calling handle with every new result
+// until ctx is done or the server ends the stream. It returns ctx's error
+// once ctx is done, and nil when the server closed the stream.
+func (c *Client) StreamResults(ctx context.Context, handle func(PingResult)) error {
+	req, err := c.newRequest(ctx, "/api/stream", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Events are data lines ended by a blank line; comments start with ':'
+	scanner := bufio.NewScanner(resp.Body)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(value, " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+		var result PingResult
+		if err := json.Unmarshal([]byte(data.String()), &result); err != nil {
+			return fmt.Errorf("decode streamed result: %w", err)
+		}
+		data.Reset()
+		handle(result)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+// period returns the query selecting the last n hours or days; n <= 0 leaves
+// the endpoint's default
+func period(unit string, n int) url.Values {
+	if n <= 0 {
+		return nil
+	}
+	return url.Values{unit: {strconv.Itoa(n)}}
+}
+
+// getJSON GETs path with query and decodes the JSON response into v
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	req, err := c.newRequest(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// newRequest builds a GET request for path under the base URL
+func (c *Client) newRequest(ctx context.Context, path string, query url.Values) (*http.Request, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// do sends req and returns the response, or a StatusError for a non-2xx one
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	return resp, nil
+}
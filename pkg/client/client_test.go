@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"network-monitor/internal/models"
+)
+
+// cannedAPI serves fixed JSON per path and records the last request
+type cannedAPI struct {
+	responses map[string]string
+	lastQuery string
+	lastAuth  string
+}
+
+func (a *cannedAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.lastQuery = r.URL.RawQuery
+	a.lastAuth = r.Header.Get("Authorization")
+	body, ok := a.responses[r.URL.Path]
+	if !ok {
+		http.Error(w, "hours must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, body)
+}
+
+func TestClientEndpoints(t *testing.T) {
+	api := &cannedAPI{responses: map[string]string{
+		"/netmon/api/recent":  `[{"timestamp":"2024-05-01T12:00:05.120Z","target":"8.8.8.8","success":true,"rtt_ms":12.5,"attempts":1,"anomaly":true}]`,
+		"/netmon/api/stats":   `[{"target":"8.8.8.8","total_pings":100,"successful_pings":98,"avg_rtt":12.1,"max_rtt":40,"min_rtt":9,"packet_loss":2}]`,
+		"/netmon/api/outages": `[{"id":7,"target":"1.1.1.1","start_time":"2024-05-01T10:00:00Z","end_time":"2024-05-01T10:05:00Z","failed_checks":5,"duration":"5m0s","rtt_before_ms":80}]`,
+		"/netmon/api/heatmap": `[{"hour":20,"target":"8.8.8.8","failure_rate":0.5,"avg_latency":15,"max_latency":90,"total_failures":3,"total_pings":600,"days_with_data":7}]`,
+	}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	c := New(server.URL + "/netmon/")
+	c.SetToken("secret")
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		call      func() (any, error)
+		wantQuery string
+		want      any
+	}{
+		{
+			name:      "recent",
+			call:      func() (any, error) { return c.Recent(ctx, 6) },
+			wantQuery: "hours=6",
+			want: []models.PingResult{{
+				Timestamp: time.Date(2024, 5, 1, 12, 0, 5, 120e6, time.UTC),
+				Target:    "8.8.8.8", Success: true, RTT: 12.5, Attempts: 1, Anomaly: true,
+			}},
+		},
+		{
+			name:      "stats",
+			call:      func() (any, error) { return c.Stats(ctx, 24) },
+			wantQuery: "hours=24",
+			want:      []models.Stats{{Target: "8.8.8.8", TotalPings: 100, Successful: 98, AvgRTT: 12.1, MaxRTT: 40, MinRTT: 9, PacketLoss: 2}},
+		},
+		{
+			name:      "outages",
+			call:      func() (any, error) { return c.Outages(ctx, 7) },
+			wantQuery: "days=7",
+			want: []models.Outage{{
+				ID: 7, Target: "1.1.1.1",
+				StartTime: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), EndTime: time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC),
+				FailedChecks: 5, Duration: "5m0s", RTTBefore: 80,
+			}},
+		},
+		{
+			name:      "heatmap with the default period",
+			call:      func() (any, error) { return c.Heatmap(ctx, 0) },
+			wantQuery: "",
+			want:      []models.HeatmapPoint{{Hour: 20, Target: "8.8.8.8", FailureRate: 0.5, AvgLatency: 15, MaxLatency: 90, TotalFailures: 3, TotalPings: 600, DaysWithData: 7}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if api.lastQuery != tt.wantQuery {
+				t.Errorf("query = %q, want %q", api.lastQuery, tt.wantQuery)
+			}
+			if api.lastAuth != "Bearer secret" {
+				t.Errorf("Authorization = %q, want the bearer token", api.lastAuth)
+			}
+		})
+	}
+}
+
+func TestClientStatusError(t *testing.T) {
+	server := httptest.NewServer(&cannedAPI{})
+	defer server.Close()
+
+	_, err := New(server.URL).Stats(context.Background(), 24)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("error = %v, want a StatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusBadRequest || statusErr.Message != "hours must be a positive integer" {
+		t.Errorf("StatusError = %+v", statusErr)
+	}
+}
+
+func TestClientStreamResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/stream" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": heartbeat\n\n")
+		fmt.Fprint(w, "data: {\"timestamp\":\"2024-05-01T12:00:00.000Z\",\"target\":\"8.8.8.8\",\"success\":true,\"rtt_ms\":11}\n\n")
+		fmt.Fprint(w, "data: {\"timestamp\":\"2024-05-01T12:00:01.000Z\",\"target\":\"1.1.1.1\",\"success\":false}\n\n")
+	}))
+	defer server.Close()
+
+	var got []models.PingResult
+	err := New(server.URL).StreamResults(context.Background(), func(result models.PingResult) {
+		got = append(got, result)
+	})
+	if err != nil {
+		t.Fatalf("StreamResults() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Target != "8.8.8.8" || got[0].RTT != 11 || got[1].Target != "1.1.1.1" || got[1].Success {
+		t.Errorf("streamed %+v, want the 8.8.8.8 success then the 1.1.1.1 failure", got)
+	}
+}
+
+func TestClientStreamResultsCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := New(server.URL).StreamResults(ctx, func(models.PingResult) {})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("StreamResults() error = %v, want the context's deadline", err)
+	}
+}
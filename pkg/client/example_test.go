@@ -0,0 +1,48 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+
+	"network-monitor/pkg/client"
+)
+
+// This file is in an external package and imports nothing internal, as a
+// program outside this module would
+
+func ExampleClient_Stats() {
+	// A stand-in for a running monitor
+	monitor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"target":"8.8.8.8","total_pings":100,"successful_pings":98,"avg_rtt":12.1,"packet_loss":2}]`)
+	}))
+	defer monitor.Close()
+
+	stats, err := client.New(monitor.URL).Stats(context.Background(), 24)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, s := range stats {
+		printStats(s)
+	}
+	// Output: 8.8.8.8: 2% loss, 12.1 ms average
+}
+
+// printStats takes the API's type by name
+func printStats(s client.Stats) {
+	fmt.Printf("%s: %g%% loss, %g ms average\n", s.Target, s.PacketLoss, s.AvgRTT)
+}
+
+func ExampleClient_StreamResults() {
+	c := client.New("http://localhost:8080")
+	err := c.StreamResults(context.Background(), func(result client.PingResult) {
+		if !result.Success {
+			fmt.Printf("%s failed: %s\n", result.Target, result.ErrorMessage)
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"network-monitor/internal/config"
+	"network-monitor/internal/database"
+	"network-monitor/internal/models"
+	"network-monitor/internal/report"
+)
+
+// runReport generates a report from an existing database without touching it
+func runReport(args []string) error {
+	cfg, err := config.ParseReportFlags(args)
+	if err != nil {
+		return fmt.Errorf("failed to parse report flags: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid report configuration: %w", err)
+	}
+
+	db, err := openReportDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	generator := report.NewGenerator(db, cfg.OutageThreshold(), cfg.Location())
+	generator.SetSLATerms(report.SLATerms{Target: cfg.SLATarget, MonthlyCost: cfg.MonthlyCost})
+	generator.SetQueryTimeout(cfg.QueryTimeout)
+	if err := generator.Generate(cfg.OutputDir, cfg.Hours, cfg.Format); err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+	return nil
+}
+
+// openReportDatabase opens the configured database without migrating it. SQLite
+// is opened read-only so a report can be generated while the monitor is running.
+func openReportDatabase(cfg config.ReportConfig) (models.Database, error) {
+	if cfg.DBDriver == database.DriverPostgres {
+		db, err := database.NewPostgres(cfg.DBDSN)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+
+	db, err := database.OpenReadOnly(cfg.DatabasePath)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
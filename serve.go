@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"network-monitor/internal/config"
+	"network-monitor/internal/database"
+	"network-monitor/internal/grpc"
+	"network-monitor/internal/models"
+	"network-monitor/internal/monitor"
+	"network-monitor/internal/ping"
+	"network-monitor/internal/sink/hub"
+	"network-monitor/internal/sink/influx"
+	"network-monitor/internal/sink/metrics"
+	"network-monitor/internal/sink/mqtt"
+	"network-monitor/internal/systemd"
+	"network-monitor/internal/web"
+)
+
+// runServe runs the monitor and web dashboard until interrupted
+func runServe(args []string) {
+	// Parse configuration
+	cfg, err := config.ParseFlags(args)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err = cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	log.Printf("network-monitor %s (commit %s, %s)", Version, Commit, runtime.Version())
+
+	// Initialize database and schema, waiting for it if configured to
+	db, err := openDatabase(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	db.SetOutageThreshold(cfg.OutageThreshold())
+	db.SetLocation(cfg.Location())
+	db.SetPreservePadding(cfg.PreservePad)
+	if err := db.SetTargetMeta(context.Background(), cfg.TargetMeta); err != nil {
+		log.Printf("Warning: Failed to store target names and colors: %v", err)
+	}
+
+	// Backfill hourly patterns if table is empty (for initial population)
+	if isEmpty, err := db.IsHourlyPatternsEmpty(context.Background()); err != nil {
+		log.Printf("Warning: Failed to check hourly patterns table: %v", err)
+	} else if isEmpty {
+		log.Println("Hourly patterns table is empty, backfilling from existing ping data...")
+		if err := db.BackfillHourlyPatterns(context.Background()); err != nil {
+			log.Printf("Warning: Failed to backfill hourly patterns: %v", err)
+		} else {
+			log.Println("Successfully backfilled hourly patterns data")
+		}
+	}
+
+	// Initialize static file system (embedded for production, filesystem for development)
+	var staticFS fs.FS
+	if cfg.DevMode {
+		// Development mode: serve from filesystem for live editing
+		staticFS = os.DirFS("static")
+		log.Println("Development mode: serving static files from filesystem (live editing enabled)")
+	} else {
+		// Production mode: use embedded files
+		var err error
+		staticFS, err = fs.Sub(staticFiles, "static")
+		if err != nil {
+			log.Fatalf("Failed to create static file system: %v", err)
+		}
+		log.Println("Production mode: serving embedded static files")
+	}
+
+	// Initialize components
+	icmpPinger, err := ping.New(ping.Config{Binary: cfg.PingBinary, Args: cfg.PingArgs, CaptureOutput: cfg.DebugOutput})
+	if err != nil {
+		log.Fatalf("Failed to configure pinger: %v", err)
+	}
+	if cfg.DebugOutput {
+		log.Println("Storing raw output of failed or unparseable pings (see /api/debug/output)")
+	}
+	pinger := ping.NewRouter(icmpPinger)
+	if cfg.Loopback {
+		addReferenceTargets(&cfg, pinger)
+	}
+
+	// Optional sinks receive every result in addition to SQLite
+	var sinks []models.Sink
+	if cfg.InfluxURL != "" {
+		influxWriter, err := influx.New(influx.Config{
+			URL:      cfg.InfluxURL,
+			Token:    cfg.InfluxToken,
+			Org:      cfg.InfluxOrg,
+			Bucket:   cfg.InfluxBucket,
+			Database: cfg.InfluxDatabase,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure InfluxDB output: %v", err)
+		}
+		defer influxWriter.Close()
+		sinks = append(sinks, influxWriter)
+		log.Printf("Writing results to InfluxDB at %s", cfg.InfluxURL)
+	}
+
+	if cfg.MQTTBroker != "" {
+		publisher, err := mqtt.New(mqtt.Config{
+			Broker:      cfg.MQTTBroker,
+			TopicPrefix: cfg.MQTTTopicPrefix,
+			Username:    cfg.MQTTUsername,
+			Password:    cfg.MQTTPassword,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure MQTT publishing: %v", err)
+		}
+		defer publisher.Close()
+		sinks = append(sinks, publisher)
+		log.Printf("Publishing results to MQTT broker %s under %s/", cfg.MQTTBroker, cfg.MQTTTopicPrefix)
+	}
+
+	// The gRPC API and /api/stream relay live results from a hub the monitor
+	// publishes to
+	results := hub.New()
+	defer results.Close()
+	sinks = append(sinks, results)
+
+	// /metrics serves an RTT histogram per target observed from every result
+	rttHistograms := metrics.New(cfg.RTTBuckets)
+	sinks = append(sinks, rttHistograms)
+
+	var grpcServer *grpc.Server
+	var grpcListener net.Listener
+	if cfg.GRPCPort > 0 {
+		grpcListener, err = net.Listen("tcp", net.JoinHostPort(cfg.Bind, strconv.Itoa(cfg.GRPCPort)))
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+		grpcServer = grpc.New(db, results)
+	}
+
+	mon := monitor.New(cfg, db, pinger, sinks...)
+	webServer := web.New(db, mon, cfg.Port, staticFS)
+	webServer.SetBind(cfg.Bind)
+	webServer.SetBuildInfo(web.BuildInfo{Version: Version, Commit: Commit})
+	webServer.SetBasePath(cfg.BasePath)
+	webServer.SetCORSOrigins(cfg.CORSOrigins)
+	webServer.SetRateLimit(cfg.RateLimit)
+	webServer.SetQueryTimeout(cfg.QueryTimeout)
+	webServer.SetAnomalySigma(cfg.AnomalySigma)
+	webServer.SetThresholds(web.Thresholds{
+		WarnRTT:  cfg.WarnRTT,
+		BadRTT:   cfg.BadRTT,
+		WarnLoss: cfg.WarnLoss,
+		BadLoss:  cfg.BadLoss,
+	})
+	webServer.SetResults(results)
+	webServer.SetMetrics(rttHistograms)
+	if cfg.AccessLog {
+		webServer.SetAccessLog(slog.Default())
+	}
+	webServer.SetDisplay(web.Display{
+		Theme:           cfg.Theme,
+		DefaultHours:    cfg.DefaultHours,
+		RefreshInterval: cfg.Refresh,
+		Targets:         cfg.Targets,
+		PingInterval:    cfg.Interval,
+	})
+
+	// Handle shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	// Toggle pause/resume on SIGUSR1 where the platform supports it
+	pauseChan := make(chan os.Signal, 1)
+	notifyPauseToggle(pauseChan)
+	go func() {
+		for range pauseChan {
+			if err := mon.TogglePause(); err != nil {
+				log.Printf("Failed to toggle pause: %v", err)
+			}
+		}
+	}()
+
+	webListener, err := webServer.Listen()
+	if err != nil {
+		log.Fatalf("Failed to start web server: %v", err)
+	}
+	go func() {
+		if err := webServer.Serve(webListener); err != nil {
+			log.Fatalf("Failed to start web server: %v", err)
+		}
+	}()
+
+	if err := mon.Start(); err != nil {
+		log.Fatalf("Failed to start monitor: %v", err)
+	}
+
+	if grpcServer != nil {
+		go func() {
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				log.Fatalf("Failed to start gRPC server: %v", err)
+			}
+		}()
+		log.Printf("gRPC API available on %s", grpcListener.Addr())
+	}
+
+	log.Printf("Monitoring started. Pinging %v every %v", cfg.Targets, cfg.Interval)
+	log.Printf("Web interface available at http://%s%s/", net.JoinHostPort(dashboardHost(cfg.Bind), strconv.Itoa(cfg.Port)), strings.TrimRight(cfg.BasePath, "/"))
+
+	// Under systemd with Type=notify, dependent units wait for this
+	if sent, err := systemd.Notify(systemd.Ready); err != nil {
+		log.Printf("Warning: Failed to notify systemd of readiness: %v", err)
+	} else if sent {
+		watchdogCtx, stopWatchdog := context.WithCancel(context.Background())
+		defer stopWatchdog()
+		go systemd.RunWatchdog(watchdogCtx, systemd.WatchdogInterval())
+	}
+
+	<-sigChan
+	log.Println("Shutting down...")
+	if _, err := systemd.Notify(systemd.Stopping); err != nil {
+		log.Printf("Warning: Failed to notify systemd of shutdown: %v", err)
+	}
+	mon.Stop()
+	mon.Wait()
+	if grpcServer != nil {
+		grpcServer.Stop()
+	}
+}
+
+// store is a database backend along with the settings serve applies to it
+type store interface {
+	models.Database
+	SetOutageThreshold(threshold models.OutageThreshold)
+	SetLocation(loc *time.Location)
+	SetPreservePadding(padding time.Duration)
+}
+
+// openDatabase opens the configured database backend and applies pending migrations
+func openDatabase(cfg config.Config) (store, error) {
+	if cfg.DBDriver == database.DriverPostgres {
+		db, err := database.OpenPostgres(cfg.DBDSN, cfg.DBOpenRetries, time.Second)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.VacuumOnStart {
+			log.Println("Ignoring -vacuum-on-start: Postgres reclaims space with autovacuum")
+		}
+		if cfg.RecoverCorrupt {
+			log.Println("Ignoring -recover-corrupt: it only applies to SQLite database files")
+		}
+		if cfg.MaxDBSize > 0 {
+			log.Println("Ignoring -max-db-size: the Postgres server manages its own storage")
+		}
+		return db, nil
+	}
+
+	open := database.Open
+	if cfg.RecoverCorrupt {
+		open = database.OpenOrRecover
+	}
+	db, err := open(cfg.DatabasePath, cfg.JournalMode, cfg.DBOpenRetries, time.Second)
+	if errors.Is(err, database.ErrCorrupt) {
+		return nil, fmt.Errorf("%w (start with -recover-corrupt to move it aside and start a fresh database)", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.VacuumOnStart {
+		// Nothing writes yet, so the exclusive lock VACUUM takes stalls no one
+		start := time.Now()
+		if err := db.Vacuum(); err != nil {
+			log.Printf("Warning: Failed to vacuum database: %v", err)
+		} else {
+			log.Printf("Vacuumed database in %v", time.Since(start).Round(time.Millisecond))
+		}
+	}
+	return db, nil
+}
+
+// addReferenceTargets adds loopback and, if it can be detected, the default
+// gateway to the monitored targets under their reserved names
+func addReferenceTargets(cfg *config.Config, pinger *ping.Router) {
+	pinger.SetAlias(models.TargetLoopback, "127.0.0.1")
+	cfg.Targets = append(cfg.Targets, models.TargetLoopback)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	gateway, err := ping.DefaultGateway(ctx)
+	if err != nil {
+		log.Printf("Warning: Not monitoring the default gateway: %v", err)
+		return
+	}
+	pinger.SetAlias(models.TargetGateway, gateway)
+	cfg.Targets = append(cfg.Targets, models.TargetGateway)
+	log.Printf("Monitoring loopback and the default gateway %s as reference targets", gateway)
+}
+
+// dashboardHost is the host to show in the dashboard URL for the bind
+// address; listening on every interface includes localhost
+func dashboardHost(bind string) string {
+	if ip := net.ParseIP(bind); ip != nil && ip.IsUnspecified() {
+		return "localhost"
+	}
+	return bind
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// notifyPauseToggle is a no-op on Windows, which has no SIGUSR1; use the
+// /api/pause and /api/resume endpoints instead
+func notifyPauseToggle(c chan<- os.Signal) {}
@@ -4,21 +4,70 @@ let currentData = [];
 let stats = [];
 let outages = [];
 let heatmapData = [];
+let summary = {}; // at-a-glance view of the last 24 hours, from /api/summary
+let targetMeta = {}; // display name and color by target, from /api/targets
+let thresholds = null; // when heatmap cells are warnings or bad, from /api/thresholds
+
+// Display preferences set by the server's -theme, -default-hours and
+// -refresh-interval flags, plus its targets and version. The server injects
+// them into the page; /api/config is the fallback.
+async function fetchConfig() {
+  const defaults = { theme: "auto", default_hours: 24, refresh_seconds: 30 };
+  if (window.serverConfig && typeof window.serverConfig === "object") {
+    return { ...defaults, ...window.serverConfig };
+  }
+  try {
+    const response = await fetch(apiURL("/api/config"));
+    if (!response.ok) return defaults;
+    return { ...defaults, ...(await response.json()) };
+  } catch (error) {
+    console.error("Error fetching config:", error);
+    return defaults;
+  }
+}
+
+// Target display names and colors from the server's config file. Without
+// them targets show as themselves in the default palette.
+async function fetchTargets() {
+  try {
+    const response = await fetch(apiURL("/api/targets"));
+    if (!response.ok) return;
+    const targets = await response.json();
+    targetMeta = Object.fromEntries(targets.map((t) => [t.target, t]));
+  } catch (error) {
+    console.error("Error fetching targets:", error);
+  }
+}
+
+// The RTT and loss thresholds the server grades heatmap cells against, for
+// the heatmap legend. Without them the legend names the grades only.
+async function fetchThresholds() {
+  try {
+    const response = await fetch(apiURL("/api/thresholds"));
+    if (!response.ok) return;
+    thresholds = await response.json();
+  } catch (error) {
+    console.error("Error fetching thresholds:", error);
+  }
+}
 
 async function fetchData(hours = 24) {
   try {
     const heatmapDays = document.getElementById("heatmapDays").value;
-    const [recentRes, statsRes, outagesRes, heatmapRes] = await Promise.all([
-      fetch(`/api/recent?hours=${hours}`),
-      fetch("/api/stats"),
-      fetch("/api/outages"),
-      fetch(`/api/heatmap?days=${heatmapDays}`),
-    ]);
+    const [recentRes, statsRes, outagesRes, heatmapRes, summaryRes] =
+      await Promise.all([
+        fetch(apiURL(`/api/recent?hours=${hours}&downsample=true`)),
+        fetch(apiURL(`/api/stats?hours=${hours}`)),
+        fetch(apiURL(`/api/outages?hours=${hours}`)),
+        fetch(apiURL(`/api/heatmap?days=${heatmapDays}`)),
+        fetch(apiURL("/api/summary")),
+      ]);
 
     currentData = await recentRes.json();
     stats = await statsRes.json();
     outages = await outagesRes.json();
     heatmapData = await heatmapRes.json();
+    summary = summaryRes.ok ? await summaryRes.json() : {};
 
     // Parse timestamps for currentData
     const parseTime = d3.timeParse("%Y-%m-%dT%H:%M:%S");
@@ -28,7 +77,7 @@ async function fetchData(hours = 24) {
       }
     });
 
-    return { currentData, stats, outages, heatmapData };
+    return { currentData, stats, outages, heatmapData, summary };
   } catch (error) {
     console.error("Error fetching data:", error);
     throw error;
@@ -37,7 +86,7 @@ async function fetchData(hours = 24) {
 
 async function showPatternDetails(hour) {
   try {
-    const response = await fetch(`/api/patterns?hour=${hour}`);
+    const response = await fetch(apiURL(`/api/patterns?hour=${hour}`));
     const patterns = await response.json();
 
     if (!patterns || patterns.length === 0) return;
@@ -74,9 +123,9 @@ async function showPatternDetails(hour) {
       targetPatterns.slice(0, 10).forEach((p) => {
         const rowClass =
           p.failure_rate > 1
-            ? 'style="background: #fee;"'
+            ? 'style="background: var(--row-bad);"'
             : p.failure_rate > 0.5
-            ? 'style="background: #ffc;"'
+            ? 'style="background: var(--row-warn);"'
             : "";
         html += `<tr ${rowClass}>
                         <td>${p.date}</td>
@@ -87,7 +136,7 @@ async function showPatternDetails(hour) {
       });
 
       if (targetPatterns.length > 10) {
-        html += `<tr><td colspan="4" style="text-align: center; color: #999;">
+        html += `<tr><td colspan="4" style="text-align: center; color: var(--text-muted);">
                             ... and ${
                               targetPatterns.length - 10
                             } more days</td></tr>`;
@@ -109,7 +158,7 @@ async function showPatternDetails(hour) {
 function generateReport() {
   // This would trigger server-side report generation
   window.open(
-    `/api/report?hours=${document.getElementById("timeRange").value}`,
+    apiURL(`/api/report?hours=${document.getElementById("timeRange").value}`),
     "_blank"
   );
 }
@@ -1,6 +1,11 @@
 // Chart rendering functions for the network monitor dashboard
 
 const colorScale = d3.scaleOrdinal(d3.schemeCategory10);
+
+// targetColor is a target's configured color, or its color in the default palette
+function targetColor(target) {
+  return (targetMeta[target] && targetMeta[target].color) || colorScale(target);
+}
 const tooltip = d3.select(".tooltip");
 
 function drawLatencyChart() {
@@ -31,7 +36,7 @@ function drawLatencyChart() {
       .attr("y", height / 2)
       .attr("text-anchor", "middle")
       .style("font-size", "14px")
-      .style("fill", "#999")
+      .style("fill", "var(--text-muted)")
       .text("No data available for the selected time range");
     return;
   }
@@ -49,7 +54,7 @@ function drawLatencyChart() {
       .attr("y", height / 2)
       .attr("text-anchor", "middle")
       .style("font-size", "14px")
-      .style("fill", "#999")
+      .style("fill", "var(--text-muted)")
       .text("No latency data available for the selected time range");
     return;
   }
@@ -118,7 +123,7 @@ function drawLatencyChart() {
       .datum(targetData.values)
       .attr("class", "confidence-band")
       .attr("d", area)
-      .style("fill", colorScale(targetData.target))
+      .style("fill", targetColor(targetData.target))
       .style("opacity", 0.15)
       .style("stroke", "none");
   });
@@ -129,23 +134,23 @@ function drawLatencyChart() {
       .datum(targetData.values)
       .attr("class", "line")
       .attr("d", line)
-      .style("stroke", colorScale(targetData.target))
+      .style("stroke", targetColor(targetData.target))
       .style("stroke-width", 2)
       .style("fill", "none");
   });
 
   // Add interactive dots for hover
   validTargets.forEach((targetData) => {
-    g.selectAll(`.dots-${targetData.target.replace(/\./g, "-")}`)
+    g.selectAll(`.dots-${targetData.target.replace(/[^A-Za-z0-9_-]/g, "-")}`)
       .data(targetData.values)
       .enter()
       .append("circle")
-      .attr("class", `dots-${targetData.target.replace(/\./g, "-")}`)
+      .attr("class", `dots-${targetData.target.replace(/[^A-Za-z0-9_-]/g, "-")}`)
       .attr("cx", (d) => x(d.date))
       .attr("cy", (d) => y(d.avg))
       .attr("r", 4)
-      .style("fill", colorScale(targetData.target))
-      .style("stroke", "white")
+      .style("fill", targetColor(targetData.target))
+      .style("stroke", "var(--surface)")
       .style("stroke-width", 2)
       .style("opacity", 0)
       .on("mouseover", function (event, d) {
@@ -155,7 +160,7 @@ function drawLatencyChart() {
         // Show tooltip
         const successPercent = (d.successRate * 100).toFixed(1);
         tooltip.style("opacity", 1).html(
-          `<strong>${targetData.target}</strong><br/>
+          `<strong>${targetLabel(targetData.target)}</strong><br/>
           <strong>Average:</strong> ${d.avg.toFixed(1)} ms<br/>
           <strong>Median:</strong> ${d.median.toFixed(1)} ms<br/>
           <strong>Range:</strong> ${d.min.toFixed(1)} - ${d.max.toFixed(
@@ -214,7 +219,7 @@ function drawLatencyChart() {
 
         if (d0 && d1) {
           const d = xDate - d0.date > d1.date - xDate ? d1 : d0;
-          g.selectAll(`.dots-${targetData.target.replace(/\./g, "-")}`).style(
+          g.selectAll(`.dots-${targetData.target.replace(/[^A-Za-z0-9_-]/g, "-")}`).style(
             "opacity",
             (dot) => (dot === d ? 1 : 0)
           );
@@ -243,7 +248,7 @@ function drawLatencyChart() {
       .append("rect")
       .attr("width", 12)
       .attr("height", 12)
-      .attr("fill", colorScale(targetData.target))
+      .attr("fill", targetColor(targetData.target))
       .attr("rx", 2);
 
     legendRow
@@ -252,7 +257,7 @@ function drawLatencyChart() {
       .attr("y", 9)
       .style("font-size", "12px")
       .style("dominant-baseline", "middle")
-      .text(targetData.target);
+      .text(targetLabel(targetData.target));
 
     // Add stats to legend
     const avgLatency = d3.mean(targetData.values, (d) => d.avg);
@@ -263,7 +268,7 @@ function drawLatencyChart() {
       .attr("x", 18)
       .attr("y", 21)
       .style("font-size", "10px")
-      .style("fill", "#666")
+      .style("fill", "var(--text-secondary)")
       .text(`${avgLatency.toFixed(1)}ms, ${avgSuccess.toFixed(1)}% up`);
 
     // Toggle functionality
@@ -294,7 +299,7 @@ function drawLatencyChart() {
     .attr("y", -5)
     .attr("text-anchor", "end")
     .style("font-size", "11px")
-    .style("fill", "#666")
+    .style("fill", "var(--text-secondary)")
     .text("Click legend items to toggle • Hover for details");
 }
 
@@ -511,14 +516,14 @@ function drawAvailabilityChart() {
         return "#f3f4f6"; // Light gray for no data
       }
     })
-    .attr("stroke", "#fff")
+    .attr("stroke", "var(--surface)")
     .attr("stroke-width", 0.3)
     .style("cursor", "pointer")
     .on("mouseover", function (event, d) {
       const cellData = d.data;
       // Make hovered cell more prominent
       d3.select(this)
-        .attr("stroke", "#333")
+        .attr("stroke", "var(--text)")
         .attr("stroke-width", 1.5);
 
       const successRate = cellData.totalPings > 0
@@ -538,7 +543,7 @@ function drawAvailabilityChart() {
       tooltip
         .style("opacity", 1)
         .html(`
-          <strong>${d.target}</strong><br/>
+          <strong>${targetLabel(d.target)}</strong><br/>
           <strong>Time:</strong> ${timeDisplay}<br/>
           ${cellData.timeSpan > 1 ? `<strong>Duration:</strong> ${cellData.timeSpan} minute${cellData.timeSpan > 1 ? 's' : ''}<br/>` : ""}
           <strong>Status:</strong> ${cellData.hasFailure ? "Has Failures" : "All Success"}<br/>
@@ -556,7 +561,7 @@ function drawAvailabilityChart() {
     .on("mouseout", function (d) {
       // Reset cell appearance
       d3.select(this)
-        .attr("stroke", "#fff")
+        .attr("stroke", "var(--surface)")
         .attr("stroke-width", 0.3);
 
       tooltip.style("opacity", 0);
@@ -612,7 +617,7 @@ function drawAvailabilityChart() {
     .attr("y", gridHeight + 20)
     .attr("text-anchor", "middle")
     .style("font-size", "9px")
-    .style("fill", "#666")
+    .style("fill", "var(--text-secondary)")
     .text(d => d3.timeFormat("%H:%M")(d.time));
 
   // Legend
@@ -700,7 +705,7 @@ function drawAvailabilityChart() {
     .attr("x", 0)
     .attr("y", -20)
     .style("font-size", "10px")
-    .style("fill", "#666")
+    .style("fill", "var(--text-secondary)")
     .text(`${gridData.length} cells in ${actualColumns}×${totalRows} grid • Each square = ${timeUnitText} • Red = any failure, Green = average latency`);
 
   // Grid structure info
@@ -708,6 +713,6 @@ function drawAvailabilityChart() {
     .attr("x", 0)
     .attr("y", -8)
     .style("font-size", "9px")
-    .style("fill", "#888")
+    .style("fill", "var(--text-muted)")
     .text(`${targets.length} targets × ${rowsPerTarget} rows each • Time flows left to right`);
 }
@@ -19,10 +19,10 @@ function updateDashboard() {
         : "status-bad";
 
     card.innerHTML = `
-            <h2>${stat.target}</h2>
+            <h2>${targetLabel(stat.target)}</h2>
             <div class="stat-value ${statusClass}">${uptime}%</div>
             <div class="stat-label">${stat.total_pings} pings</div>
-            <div style="margin-top: 15px; font-size: 14px; color: #666;">
+            <div style="margin-top: 15px; font-size: 14px; color: var(--text-secondary);">
                 <div>Avg RTT: ${stat.avg_rtt?.toFixed(1) || "N/A"} ms</div>
                 <div>Min/Max: ${stat.min_rtt?.toFixed(1) || "N/A"}/${
       stat.max_rtt?.toFixed(1) || "N/A"
@@ -34,13 +34,29 @@ function updateDashboard() {
   });
 }
 
+// Where current failures lie, from the reference targets that
+// -include-loopback monitors. Hidden when nothing is failing or they aren't
+// monitored.
+const faultDomainMessages = {
+  local: "Loopback is down: the fault is on this machine",
+  lan: "The gateway is down: the fault is on the local network or router",
+  internet: "The gateway answers but other targets don't: the fault is beyond the local network",
+};
+
+function updateFaultDomain() {
+  const banner = document.getElementById("faultDomain");
+  const message = faultDomainMessages[summary.fault_domain];
+  banner.hidden = !message;
+  banner.textContent = message || "";
+}
+
 function updateOutagesTable() {
   const tbody = document.querySelector("#outagesTable tbody");
   tbody.innerHTML = "";
 
   if (!outages || outages.length === 0) {
     tbody.innerHTML =
-      '<tr><td colspan="4" style="text-align: center; color: #999;">No outages detected</td></tr>';
+      '<tr><td colspan="4" style="text-align: center; color: var(--text-muted);">No outages detected</td></tr>';
     return;
   }
 
@@ -50,7 +66,7 @@ function updateOutagesTable() {
     const duration = parseDuration(outage.duration);
 
     row.innerHTML = `
-            <td>${outage.target}</td>
+            <td>${targetLabel(outage.target)}</td>
             <td>${startTime.toLocaleString()}</td>
             <td>${duration}</td>
             <td>${outage.failed_checks}</td>
@@ -61,6 +77,7 @@ function updateOutagesTable() {
 function refreshData() {
   const hours = document.getElementById("timeRange").value;
   fetchData(hours).then(() => {
+    updateFaultDomain();
     updateDashboard();
     drawLatencyChart();
     drawAvailabilityChart();
@@ -69,6 +86,20 @@ function refreshData() {
   });
 }
 
+// applyConfig applies the server's display preferences before the first load
+function applyConfig(config) {
+  document.documentElement.dataset.theme = config.theme || "auto";
+
+  const timeRange = document.getElementById("timeRange");
+  const hours = String(config.default_hours || "");
+  if (hours && timeRange.value !== hours) {
+    if (![...timeRange.options].some((option) => option.value === hours)) {
+      timeRange.add(new Option(`Last ${hours} Hours`, hours));
+    }
+    timeRange.value = hours;
+  }
+}
+
 // Event listeners
 function setupEventListeners() {
   document.getElementById("timeRange").addEventListener("change", refreshData);
@@ -48,19 +48,8 @@ function drawHeatmap() {
     .range([0, height])
     .padding(0.05);
 
-  // Color scale for failure rate
-  const colorScale = d3
-    .scaleSequential()
-    .domain([0, 2]) // 0-2% failure rate
-    .interpolator(d3.interpolateRgb("#10b981", "#ef4444"))
-    .clamp(true);
-
-  // Alternative: Color scale for latency
-  const latencyColorScale = d3
-    .scaleSequential()
-    .domain([0, 100]) // 0-100ms latency
-    .interpolator(d3.interpolateRgb("#3b82f6", "#f59e0b"))
-    .clamp(true);
+  // Colors for the severity the server grades each cell with
+  const severityColors = { good: "#10b981", warn: "#f59e0b", bad: "#ef4444" };
 
   // Draw cells
   const cells = g
@@ -73,14 +62,8 @@ function drawHeatmap() {
     .attr("y", (d) => yScale(d.target))
     .attr("width", xScale.bandwidth())
     .attr("height", yScale.bandwidth())
-    .attr("fill", (d) => {
-      // Use failure rate as primary indicator
-      if (d.failure_rate > 0) {
-        return colorScale(d.failure_rate);
-      }
-      // If no failures, show latency
-      return d.avg_latency > 50 ? latencyColorScale(d.avg_latency) : "#10b981";
-    })
+    // The server grades each cell, so every client agrees on the thresholds
+    .attr("fill", (d) => severityColors[d.severity])
     .attr("opacity", (d) => {
       // Make opacity based on data density
       return Math.min(1, 0.3 + (d.days_with_data / 30) * 0.7);
@@ -88,7 +71,7 @@ function drawHeatmap() {
     .on("mouseover", function (event, d) {
       const days = document.getElementById("heatmapDays").value;
       tooltip.style("opacity", 1).html(`
-              <strong>${d.target} - ${d.hour}:00</strong><br/>
+              <strong>${targetLabel(d.target)} - ${d.hour}:00</strong><br/>
               Failure Rate: ${d.failure_rate.toFixed(1)}%<br/>
               Avg Latency: ${d.avg_latency.toFixed(1)} ms<br/>
               Max Latency: ${d.max_latency.toFixed(1)} ms<br/>
@@ -123,7 +106,7 @@ function drawHeatmap() {
 
   // Y-axis (targets)
   g.append("g")
-    .call(d3.axisLeft(yScale))
+    .call(d3.axisLeft(yScale).tickFormat(targetLabel))
     .selectAll("text")
     .attr("class", "heatmap-label");
 
@@ -136,58 +119,38 @@ function drawHeatmap() {
     .style("font-weight", "bold")
     .text("Connectivity Issues by Hour of Day");
 
-  // Color legend
-  const legendWidth = 100;
-  const legendHeight = 20;
-
-  const legendScale = d3.scaleLinear().domain([0, 2]).range([0, legendWidth]);
-
-  const legendAxis = d3
-    .axisBottom(legendScale)
-    .ticks(5)
-    .tickFormat((d) => d + "%");
-
+  // Severity legend, with the thresholds behind each grade
   const legend = svg
     .append("g")
     .attr("class", "heatmap-legend")
     .attr("transform", `translate(${width + margin.left + 20}, ${margin.top})`);
 
-  // Gradient definition
-  const defs = svg.append("defs");
-  const gradient = defs
-    .append("linearGradient")
-    .attr("id", "heatmap-gradient")
-    .attr("x1", "0%")
-    .attr("x2", "100%")
-    .attr("y1", "0%")
-    .attr("y2", "0%");
-
-  const stops = d3.range(0, 1.1, 0.1);
-  stops.forEach((stop) => {
-    gradient
-      .append("stop")
-      .attr("offset", stop * 100 + "%")
-      .attr("stop-color", colorScale(stop * 2));
-  });
-
   legend
-    .append("rect")
-    .attr("width", legendWidth)
-    .attr("height", legendHeight)
-    .style("fill", "url(#heatmap-gradient)");
+    .append("text")
+    .attr("y", -8)
+    .style("font-size", "12px")
+    .text("Severity");
 
-  legend
+  const legendItems = legend
+    .selectAll(".heatmap-legend-item")
+    .data(severityLegend(thresholds))
+    .enter()
     .append("g")
-    .attr("transform", `translate(0,${legendHeight})`)
-    .call(legendAxis);
+    .attr("class", "heatmap-legend-item")
+    .attr("transform", (d, i) => `translate(0, ${i * 22})`);
 
-  legend
+  legendItems
+    .append("rect")
+    .attr("width", 14)
+    .attr("height", 14)
+    .attr("fill", (d) => severityColors[d.severity]);
+
+  legendItems
     .append("text")
-    .attr("x", legendWidth / 2)
-    .attr("y", -5)
-    .attr("text-anchor", "middle")
-    .style("font-size", "12px")
-    .text("Failure Rate");
+    .attr("x", 20)
+    .attr("y", 11)
+    .style("font-size", "11px")
+    .text((d) => d.label);
 
   // Add note about clicking for details
   svg
@@ -195,6 +158,29 @@ function drawHeatmap() {
     .attr("x", margin.left)
     .attr("y", height + margin.top + margin.bottom - 5)
     .style("font-size", "11px")
-    .style("fill", "#999")
+    .style("fill", "var(--text-muted)")
     .text("Click any cell to see detailed daily breakdown for that hour");
 }
+
+// Legend entries for the heatmap's severities, describing what puts a cell in
+// each from the server's thresholds; a threshold of 0 is off
+function severityLegend(t) {
+  if (!t) {
+    return [
+      { severity: "good", label: "Good" },
+      { severity: "warn", label: "Warning" },
+      { severity: "bad", label: "Bad" },
+    ];
+  }
+  const above = (rtt, loss) => {
+    const parts = [];
+    if (rtt > 0) parts.push(`> ${rtt} ms`);
+    if (loss > 0) parts.push(`> ${loss}% loss`);
+    return parts.length > 0 ? parts.join(" or ") : "off";
+  };
+  return [
+    { severity: "good", label: "Good" },
+    { severity: "warn", label: `Warning: ${above(t.warn_rtt_ms, t.warn_loss_percent)}` },
+    { severity: "bad", label: `Bad: ${above(t.bad_rtt_ms, t.bad_loss_percent)}` },
+  ];
+}
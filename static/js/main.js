@@ -1,21 +1,22 @@
 // Main application initialization and coordination
 
 // Initialize the application when DOM is loaded
-document.addEventListener("DOMContentLoaded", function () {
+document.addEventListener("DOMContentLoaded", async function () {
+  // Theme and default time range come from the server's flags
+  const config = await fetchConfig();
+  applyConfig(config);
+  await Promise.all([fetchTargets(), fetchThresholds()]);
+
   // Initial data load
-  fetchData().then(() => {
-    updateDashboard();
-    drawLatencyChart();
-    drawAvailabilityChart();
-    drawHeatmap();
-    updateOutagesTable();
-  });
+  refreshData();
 
   // Set up event listeners
   setupEventListeners();
 
-  // Auto-refresh every 30 seconds
-  setInterval(() => refreshData(), 30000);
+  // Auto-refresh, unless the server disabled it
+  if (config.refresh_seconds > 0) {
+    setInterval(() => refreshData(), config.refresh_seconds * 1000);
+  }
 });
 
 // Global functions that need to be accessible from HTML
@@ -1,5 +1,17 @@
 // Utility functions for the network monitor dashboard
 
+// apiURL prefixes a server path with the -base-path the server was started
+// with, so the dashboard also works behind a proxy at e.g. /netmon/
+function apiURL(path) {
+  return ((window.serverConfig && window.serverConfig.base_path) || "") + path;
+}
+
+// targetLabel is the name a target is shown by: its configured display name,
+// or the target itself
+function targetLabel(target) {
+  return (targetMeta[target] && targetMeta[target].name) || target;
+}
+
 function parseDuration(duration) {
   // Parse Go duration string
   const match = duration.match(/(\d+h)?(\d+m)?(\d+\.?\d*s)?/);